@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/server"
 	"istio.io/istio/galley/pkg/server/settings"
 	istiocmd "istio.io/istio/pkg/cmd"
@@ -159,6 +160,12 @@ func serverCmd() *cobra.Command {
 		"Enable the Fsnotify for watching config source files on the disk and implicit signaling on a config change. Explicit signaling will still be enabled")
 	svr.PersistentFlags().BoolVar(&serverArgs.EnableConfigAnalysis, "enableAnalysis", serverArgs.EnableConfigAnalysis,
 		"Enable config analysis service")
+	svr.PersistentFlags().StringSliceVar(&serverArgs.CustomAnalyzersFiles, "customAnalyzers", serverArgs.CustomAnalyzersFiles,
+		"Comma-separated list of files declaring analyzers to run in addition to the built-in ones")
+	svr.PersistentFlags().StringSliceVar(&serverArgs.CustomTransformsFiles, "customTransforms", serverArgs.CustomTransformsFiles,
+		"Comma-separated list of files declaring transforms of custom resources into Istio config")
+	svr.PersistentFlags().IntVar(&serverArgs.SnapshotHistorySize, "snapshotHistorySize", serverArgs.SnapshotHistorySize,
+		"Number of distributed snapshots kept for each snapshot group and exposed through ControlZ, 0 to disable")
 
 	// validation config
 	svr.PersistentFlags().StringVar(&serverArgs.ValidationArgs.WebhookConfigFile,
@@ -179,6 +186,14 @@ func serverCmd() *cobra.Command {
 		"Name of the validation service running in the same namespace as the deployment")
 	svr.PersistentFlags().StringVar(&serverArgs.ValidationArgs.WebhookName, "webhook-name", "istio-galley",
 		"Name of the k8s validatingwebhookconfiguration")
+	svr.PersistentFlags().BoolVar(&serverArgs.ValidationArgs.EnableAnalysis, "validation-analysis",
+		serverArgs.ValidationArgs.EnableAnalysis,
+		"Analyze the admitted resources against the current config. Requires config analysis to be enabled. "+
+			"The resources which can't be analyzed, e.g. before the config is read, are allowed.")
+	svr.PersistentFlags().StringVar(&serverArgs.ValidationArgs.AnalysisLevel, "validation-analysis-level",
+		serverArgs.ValidationArgs.AnalysisLevel,
+		fmt.Sprintf("Minimum level of the analysis messages rejecting the admitted resources, one of %v.",
+			diag.GetAllLevelStrings()))
 
 	// Hidden, file only flags for validation specific TLS
 	svr.PersistentFlags().StringVar(&serverArgs.ValidationArgs.CertFile, "validation.tls.clientCertificate", "",
@@ -212,8 +227,11 @@ func setupAliases() {
 	viper.RegisterAlias("general.pprofPort", "pprofPort")
 	viper.RegisterAlias("general.enable_profiling", "enableProfiling")
 	viper.RegisterAlias("processing.analysis.enable", "enableAnalysis")
+	viper.RegisterAlias("processing.analysis.customAnalyzers", "customAnalyzers")
 	viper.RegisterAlias("processing.discovery.enable", "enableServiceDiscovery")
 	viper.RegisterAlias("processing.domainSuffix", "domain")
+	viper.RegisterAlias("processing.snapshotHistorySize", "snapshotHistorySize")
+	viper.RegisterAlias("processing.customTransforms", "customTransforms")
 	viper.RegisterAlias("processing.oldprocessor", "useOldProcessor")
 	viper.RegisterAlias("processing.server.enable", "enable-server")
 	viper.RegisterAlias("processing.server.address", "server-address")
@@ -234,4 +252,6 @@ func setupAliases() {
 	viper.RegisterAlias("validation.deploymentName", "deployment-name")
 	viper.RegisterAlias("validation.deploymentNamespace", "deployment-namespace")
 	viper.RegisterAlias("validation.serviceName", "service-name")
+	viper.RegisterAlias("validation.analysis.enable", "validation-analysis")
+	viper.RegisterAlias("validation.analysis.level", "validation-analysis-level")
 }
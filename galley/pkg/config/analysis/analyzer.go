@@ -96,6 +96,11 @@ mainloop:
 	return removedNames
 }
 
+// Analyzers returns the analyzers in this combined analyzer
+func (c *CombinedAnalyzer) Analyzers() []Analyzer {
+	return c.analyzers
+}
+
 // AnalyzerNames returns the names of analyzers in this combined analyzer
 func (c *CombinedAnalyzer) AnalyzerNames() []string {
 	var result []string
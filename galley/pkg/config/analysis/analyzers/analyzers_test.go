@@ -19,14 +19,20 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/gogo/protobuf/types"
 	. "github.com/onsi/gomega"
 
+	"istio.io/api/mesh/v1alpha1"
+
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/annotations"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/deprecation"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/destinationrule"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/serviceentry"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/sidecar"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
@@ -44,6 +50,7 @@ type message struct {
 type testCase struct {
 	name       string
 	inputFiles []string
+	meshConfig *v1alpha1.MeshConfig
 	analyzer   analysis.Analyzer
 	expected   []message
 }
@@ -67,6 +74,27 @@ var testGrid = []testCase{
 			{msg.MisplacedAnnotation, "Deployment fortio-deploy"},
 		},
 	},
+	{
+		name:       "mtlsDestinationRules",
+		inputFiles: []string{"testdata/mtls-destinationrules.yaml"},
+		analyzer:   &auth.MTLSAnalyzer{},
+		expected: []message{
+			{msg.MTLSPolicyConflict, "DestinationRule details.strict"},
+			{msg.MTLSPolicyConflict, "DestinationRule productpage.default"},
+			{msg.MTLSPolicyConflict, "DestinationRule ratings.default"},
+		},
+	},
+	{
+		name:       "mtlsDestinationRulesAutoMtls",
+		inputFiles: []string{"testdata/mtls-destinationrules.yaml"},
+		meshConfig: &v1alpha1.MeshConfig{EnableAutoMtls: &types.BoolValue{Value: true}},
+		analyzer:   &auth.MTLSAnalyzer{},
+		expected: []message{
+			// The details DestinationRule has no TLS settings, so it uses Istio mutual TLS
+			{msg.MTLSPolicyConflict, "DestinationRule productpage.default"},
+			{msg.MTLSPolicyConflict, "DestinationRule ratings.default"},
+		},
+	},
 	{
 		name:       "serviceRoleBindings",
 		inputFiles: []string{"testdata/servicerolebindings.yaml"},
@@ -100,6 +128,23 @@ var testGrid = []testCase{
 			{msg.Deprecated, "ServiceRoleBinding bind-mongodb-viewer.default"},
 		},
 	},
+	{
+		name:       "destinationRuleConflictingHosts",
+		inputFiles: []string{"testdata/destinationrule-conflictinghosts.yaml"},
+		analyzer:   &destinationrule.ConflictingHostsAnalyzer{},
+		expected: []message{
+			{msg.MultipleDestinationRulesForHost, "DestinationRule reviews.default"},
+			{msg.MultipleDestinationRulesForHost, "DestinationRule reviews-fqdn.default"},
+		},
+	},
+	{
+		name:       "destinationRuleSubsets",
+		inputFiles: []string{"testdata/destinationrule-subsets.yaml"},
+		analyzer:   &destinationrule.SubsetSelectorAnalyzer{},
+		expected: []message{
+			{msg.DestinationRuleSubsetSelectsNoPods, "DestinationRule reviews.default"},
+		},
+	},
 	{
 		name:       "gatewayNoWorkload",
 		inputFiles: []string{"testdata/gateway-no-workload.yaml"},
@@ -166,6 +211,23 @@ var testGrid = []testCase{
 			{msg.IstioProxyVersionMismatch, "Pod details-v1-pod-old.enabled-namespace"},
 		},
 	},
+	{
+		name:       "servicePortName",
+		inputFiles: []string{"testdata/service-portname.yaml"},
+		analyzer:   &service.PortNameAnalyzer{},
+		expected: []message{
+			{msg.PortNameIsNotUnderNamingConvention, "Service reviews.default"},
+			{msg.PortNameIsNotUnderNamingConvention, "Service ratings.default"},
+		},
+	},
+	{
+		name:       "serviceEntryKubernetesOverlap",
+		inputFiles: []string{"testdata/serviceentry-kubernetesoverlap.yaml"},
+		analyzer:   &serviceentry.KubernetesServiceOverlapAnalyzer{},
+		expected: []message{
+			{msg.ServiceEntryHostOverlapsKubernetesService, "ServiceEntry reviews.default"},
+		},
+	},
 	{
 		name:       "sidecarDefaultSelector",
 		inputFiles: []string{"testdata/sidecar-default-selector.yaml"},
@@ -258,6 +320,9 @@ func TestAnalyzers(t *testing.T) {
 			}
 
 			sa := local.NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("testCombined", testCase.analyzer), "", cr, true)
+			if testCase.meshConfig != nil {
+				sa.SetMeshConfig(testCase.meshConfig)
+			}
 
 			err := sa.AddFileKubeSource(testCase.inputFiles)
 			if err != nil {
@@ -0,0 +1,150 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/authentication/v1alpha1"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth/mtls"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// defaultMeshPolicyName is the name of the only MeshPolicy taken into account by Pilot.
+const defaultMeshPolicyName = "default"
+
+// MTLSAnalyzer checks that the DestinationRules use Istio mutual TLS for the services on which the authentication
+// policies enforce strict mutual TLS. When auto mutual TLS is enabled in the MeshConfig, Pilot configures Istio mutual
+// TLS for the DestinationRules without TLS settings, so only the explicit TLS settings are checked.
+type MTLSAnalyzer struct{}
+
+var _ analysis.Analyzer = &MTLSAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *MTLSAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "auth.MTLSAnalyzer",
+		Inputs: collection.Names{
+			metadata.IstioAuthenticationV1Alpha1Meshpolicies,
+			metadata.IstioAuthenticationV1Alpha1Policies,
+			metadata.IstioMeshV1Alpha1MeshConfig,
+			metadata.IstioNetworkingV1Alpha3Destinationrules,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *MTLSAnalyzer) Analyze(c analysis.Context) {
+	pc := mtls.NewPolicyChecker()
+
+	autoMTLS := false
+	c.ForEach(metadata.IstioMeshV1Alpha1MeshConfig, func(r *resource.Entry) bool {
+		autoMTLS = r.Item.(*meshconfig.MeshConfig).GetEnableAutoMtls().GetValue()
+		return true
+	})
+
+	c.ForEach(metadata.IstioAuthenticationV1Alpha1Meshpolicies, func(r *resource.Entry) bool {
+		if r.Metadata.Name.String() == defaultMeshPolicyName {
+			pc.AddMeshPolicy(r.Item.(*v1alpha1.Policy))
+		}
+		return true
+	})
+
+	c.ForEach(metadata.IstioAuthenticationV1Alpha1Policies, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		if err := pc.AddPolicy(ns, r.Item.(*v1alpha1.Policy)); err != nil {
+			c.Report(metadata.IstioAuthenticationV1Alpha1Policies, msg.NewInternalError(r, err.Error()))
+		}
+		return true
+	})
+
+	c.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		a.analyzeDestinationRule(r, c, pc, autoMTLS)
+		return true
+	})
+}
+
+func (a *MTLSAnalyzer) analyzeDestinationRule(r *resource.Entry, c analysis.Context, pc *mtls.PolicyChecker, autoMTLS bool) {
+	dr := r.Item.(*v1alpha3.DestinationRule)
+	ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+	fqdn := util.ConvertHostToFQDN(ns, dr.GetHost())
+	if strings.HasPrefix(fqdn, util.Wildcard) {
+		// The policies target services, not wildcard hosts
+		return
+	}
+
+	a.analyzeTrafficPolicy(r, c, pc, autoMTLS, fqdn, dr.GetTrafficPolicy(), nil)
+	for _, ss := range dr.GetSubsets() {
+		// Subsets inherit the TLS settings of the DestinationRule, only check the ones they override
+		tp := ss.GetTrafficPolicy()
+		if tp.GetTls() != nil || len(tp.GetPortLevelSettings()) > 0 {
+			a.analyzeTrafficPolicy(r, c, pc, autoMTLS, fqdn, tp, dr.GetTrafficPolicy())
+		}
+	}
+}
+
+func (a *MTLSAnalyzer) analyzeTrafficPolicy(r *resource.Entry, c analysis.Context, pc *mtls.PolicyChecker, autoMTLS bool,
+	fqdn string, tp, parent *v1alpha3.TrafficPolicy) {
+
+	tls := tp.GetTls()
+	if tls == nil {
+		tls = parent.GetTls()
+	}
+	a.checkTLS(r, c, pc, autoMTLS, mtls.NewTargetService(fqdn), util.Wildcard, tls)
+
+	for _, pls := range tp.GetPortLevelSettings() {
+		number := pls.GetPort().GetNumber()
+		if number == 0 {
+			continue
+		}
+		portTLS := pls.GetTls()
+		if portTLS == nil {
+			portTLS = tls
+		}
+		a.checkTLS(r, c, pc, autoMTLS, mtls.NewTargetServiceWithPortNumber(fqdn, number), fmt.Sprintf("%d", number), portTLS)
+	}
+}
+
+func (a *MTLSAnalyzer) checkTLS(r *resource.Entry, c analysis.Context, pc *mtls.PolicyChecker, autoMTLS bool,
+	ts mtls.TargetService, port string, tls *v1alpha3.TLSSettings) {
+
+	// A missing TLS setting means Istio mutual TLS with auto mutual TLS, otherwise plain text, i.e. DISABLE
+	if tls == nil && autoMTLS {
+		return
+	}
+	mode := tls.GetMode()
+	if mode == v1alpha3.TLSSettings_ISTIO_MUTUAL {
+		return
+	}
+
+	enforced, err := pc.IsServiceMTLSEnforced(ts)
+	if err != nil {
+		// Not a service of the cluster, the policies don't apply to it
+		return
+	}
+	if enforced {
+		c.Report(metadata.IstioNetworkingV1Alpha3Destinationrules, msg.NewMTLSPolicyConflict(r, mode.String(), ts.FQDN(), port))
+	}
+}
@@ -17,8 +17,9 @@ package mtls
 import (
 	"fmt"
 
+	"istio.io/api/authentication/v1alpha1"
+
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
-	"istio.io/istio/security/proto/authentication/v1alpha1"
 )
 
 // TargetService is a simple struct type for representing a service
@@ -20,9 +20,9 @@ import (
 
 	"github.com/ghodss/yaml"
 
-	"github.com/golang/protobuf/jsonpb"
+	"github.com/gogo/protobuf/jsonpb"
 
-	"istio.io/istio/security/proto/authentication/v1alpha1"
+	"istio.io/api/authentication/v1alpha1"
 )
 
 func TestMTLSPolicyChecker_singleResource(t *testing.T) {
@@ -0,0 +1,381 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package declarative provides analyzers declared in YAML files and loaded at runtime, so that users can check their
+// own conventions without changing the compiled-in analyzers. An analyzer reports a message for each resource of its
+// input collections for which its CEL (https://github.com/google/cel-spec) condition evaluates to true:
+//
+//	analyzers:
+//	- name: acme.VirtualServiceTimeouts
+//	  inputs:
+//	  - istio/networking/v1alpha3/virtualservices
+//	  condition: 'spec.http.exists(r, !has(r.timeout))'
+//	  message:
+//	    code: ACME0001
+//	    level: Warn
+//	    template: 'VirtualService %s must set a timeout on all HTTP routes'
+//	    args: ['name']
+//
+// The condition and args expressions can use the following variables:
+//   - name: the name of the resource
+//   - namespace: the namespace of the resource
+//   - metadata: the name, namespace, labels and annotations of the resource
+//   - spec: the content of the resource, using the same field names as in the resource YAML
+package declarative
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/hashicorp/go-multierror"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// reservedCodePrefix is the prefix of the codes of the built-in messages.
+const reservedCodePrefix = "IST"
+
+// File is the content of a file declaring analyzers.
+type File struct {
+	Analyzers []*Spec `json:"analyzers"`
+}
+
+// Spec declares an analyzer.
+type Spec struct {
+	// Name of the analyzer, must be unique.
+	Name string `json:"name"`
+
+	// Inputs are the names of the analyzed collections, e.g. "istio/networking/v1alpha3/virtualservices".
+	Inputs []string `json:"inputs"`
+
+	// Condition is a CEL expression evaluating to true if the message must be reported for the resource.
+	Condition string `json:"condition"`
+
+	// Message is the reported message.
+	Message MessageSpec `json:"message"`
+}
+
+// MessageSpec declares the message reported by an analyzer.
+type MessageSpec struct {
+	// Code of the message, e.g. "ACME0001". The "IST" prefix is reserved for the built-in messages.
+	Code string `json:"code"`
+
+	// Level of the message, one of "Error", "Warn" or "Info".
+	Level string `json:"level"`
+
+	// Template of the message text, formatted with the args, e.g. "VirtualService %s must set a timeout".
+	Template string `json:"template"`
+
+	// Args are CEL expressions, evaluated for the resource to format the template.
+	Args []string `json:"args,omitempty"`
+}
+
+// Analyzer is an analyzer declared by a Spec.
+type Analyzer struct {
+	spec      *Spec
+	inputs    collection.Names
+	msgType   *diag.MessageType
+	condition cel.Program
+	args      []cel.Program
+}
+
+var _ analysis.Analyzer = &Analyzer{}
+
+// Load returns the analyzers declared in the given files.
+func Load(files ...string) ([]analysis.Analyzer, error) {
+	var result []analysis.Analyzer
+	names := make(map[string]bool)
+	for _, file := range files {
+		by, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		analyzers, err := Parse(by)
+		if err != nil {
+			return nil, fmt.Errorf("invalid analyzers file %q: %v", file, err)
+		}
+		for _, a := range analyzers {
+			if names[a.Metadata().Name] {
+				return nil, fmt.Errorf("invalid analyzers file %q: duplicate analyzer %q", file, a.Metadata().Name)
+			}
+			names[a.Metadata().Name] = true
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// Parse returns the analyzers declared in the given YAML text.
+func Parse(yamlText []byte) ([]analysis.Analyzer, error) {
+	var f File
+	if err := yaml.Unmarshal(yamlText, &f); err != nil {
+		return nil, err
+	}
+
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewIdent("name", decls.String, nil),
+		decls.NewIdent("namespace", decls.String, nil),
+		decls.NewIdent("metadata", decls.NewMapType(decls.String, decls.Dyn), nil),
+		decls.NewIdent("spec", decls.Dyn, nil)))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []analysis.Analyzer
+	var errs error
+	names := make(map[string]bool)
+	for i, s := range f.Analyzers {
+		if s == nil {
+			errs = multierror.Append(errs, fmt.Errorf("analyzer %d: must not be empty", i))
+			continue
+		}
+		if names[s.Name] {
+			errs = multierror.Append(errs, fmt.Errorf("analyzer %q: duplicate name", s.Name))
+			continue
+		}
+		names[s.Name] = true
+
+		a, err := newAnalyzer(env, s)
+		if err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, fmt.Sprintf("analyzer %q:", s.Name)))
+			continue
+		}
+		result = append(result, a)
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+	return result, nil
+}
+
+func newAnalyzer(env cel.Env, s *Spec) (*Analyzer, error) {
+	if s.Name == "" {
+		return nil, fmt.Errorf("name must be set")
+	}
+
+	a := &Analyzer{spec: s}
+
+	if len(s.Inputs) == 0 {
+		return nil, fmt.Errorf("at least one input must be set")
+	}
+	cols := metadata.MustGet().AllCollections()
+	for _, in := range s.Inputs {
+		if _, found := cols.Lookup(in); !found {
+			return nil, fmt.Errorf("unknown input collection %q", in)
+		}
+		a.inputs = append(a.inputs, collection.NewName(in))
+	}
+
+	level, ok := diag.GetUppercaseStringToLevelMap()[strings.ToUpper(s.Message.Level)]
+	if !ok {
+		return nil, fmt.Errorf("invalid message level %q, please choose from: %v", s.Message.Level, diag.GetAllLevelStrings())
+	}
+	if s.Message.Code == "" {
+		return nil, fmt.Errorf("message code must be set")
+	}
+	if strings.HasPrefix(s.Message.Code, reservedCodePrefix) {
+		return nil, fmt.Errorf("message code %q must not start with the reserved %q prefix", s.Message.Code, reservedCodePrefix)
+	}
+	if s.Message.Template == "" {
+		return nil, fmt.Errorf("message template must be set")
+	}
+	a.msgType = diag.NewMessageType(level, s.Message.Code, s.Message.Template)
+
+	var err error
+	if a.condition, err = compile(env, s.Condition, true); err != nil {
+		return nil, fmt.Errorf("invalid condition: %v", err)
+	}
+	for _, arg := range s.Message.Args {
+		p, err := compile(env, arg, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message arg %q: %v", arg, err)
+		}
+		a.args = append(a.args, p)
+	}
+
+	return a, nil
+}
+
+// compile compiles the expression, checking that it evaluates to a bool if requested.
+func compile(env cel.Env, expr string, isBool bool) (cel.Program, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("expression must be set")
+	}
+	ast, iss := env.Parse(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	checked, iss := env.Check(ast)
+	if iss != nil && iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if t := checked.ResultType(); isBool && t.GetPrimitive() != exprpb.Type_BOOL && t.GetDyn() == nil {
+		return nil, fmt.Errorf("expression must evaluate to a bool, not %v", t)
+	}
+	return env.Program(checked)
+}
+
+// Metadata implements analysis.Analyzer
+func (a *Analyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   a.spec.Name,
+		Inputs: a.inputs,
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *Analyzer) Analyze(c analysis.Context) {
+	for _, col := range a.inputs {
+		c.ForEach(col, func(r *resource.Entry) bool {
+			a.analyzeResource(c, col, r)
+			return !c.Canceled()
+		})
+	}
+}
+
+func (a *Analyzer) analyzeResource(c analysis.Context, col collection.Name, r *resource.Entry) {
+	vars, err := variables(r)
+	if err != nil {
+		scope.Analysis.Warnf("Analyzer %s: unable to convert %v(%v): %v", a.spec.Name, col, r.Metadata.Name, err)
+		return
+	}
+
+	out, _, err := a.condition.Eval(vars)
+	if err != nil {
+		scope.Analysis.Debugf("Analyzer %s: unable to evaluate the condition for %v(%v): %v",
+			a.spec.Name, col, r.Metadata.Name, err)
+		return
+	}
+	if report, ok := out.Value().(bool); !ok || !report {
+		return
+	}
+
+	args := make([]interface{}, 0, len(a.args))
+	for _, p := range a.args {
+		v, _, err := p.Eval(vars)
+		if err != nil {
+			args = append(args, fmt.Sprintf("<%v>", err))
+			continue
+		}
+		args = append(args, v.Value())
+	}
+
+	c.Report(col, diag.NewMessage(a.msgType, r.Origin, args...))
+}
+
+// variables returns the variables of the expressions evaluated for the resource.
+func variables(r *resource.Entry) (map[string]interface{}, error) {
+	ns, name := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+	spec, err := toUnstructured(r.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]interface{}, len(r.Metadata.Labels))
+	for k, v := range r.Metadata.Labels {
+		labels[k] = v
+	}
+	annotations := make(map[string]interface{}, len(r.Metadata.Annotations))
+	for k, v := range r.Metadata.Annotations {
+		annotations[k] = v
+	}
+
+	return map[string]interface{}{
+		"name":      name,
+		"namespace": ns,
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   ns,
+			"labels":      labels,
+			"annotations": annotations,
+		},
+		"spec": spec,
+	}, nil
+}
+
+// toUnstructured converts the item of a resource to its JSON-style unstructured form.
+func toUnstructured(item proto.Message) (interface{}, error) {
+	if item == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	var by []byte
+	var err error
+	if isKubeType(item) {
+		// The built-in Kubernetes types have their own JSON encoding, matching their YAML form.
+		by, err = json.Marshal(item)
+	} else {
+		var s string
+		s, err = (&jsonpb.Marshaler{}).MarshalToString(item)
+		by = []byte(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(by))
+	d.UseNumber()
+	var out interface{}
+	if err = d.Decode(&out); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(out), nil
+}
+
+func isKubeType(item interface{}) bool {
+	t := reflect.TypeOf(item)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.HasPrefix(t.PkgPath(), "k8s.io/")
+}
+
+// normalizeNumbers converts the JSON numbers to int64, or float64 if they are not integers, so that they can be
+// compared with the CEL int literals.
+func normalizeNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeNumbers(e)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package declarative
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+)
+
+func TestAnalyze(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	analyzers, err := Load("testdata/analyzers.yaml")
+	g.Expect(err).To(BeNil())
+	g.Expect(analyzers).To(HaveLen(3))
+	g.Expect(analyzers[0].Metadata().Name).To(Equal("acme.VirtualServiceTimeouts"))
+	g.Expect(analyzers[0].Metadata().Inputs).To(ConsistOf(metadata.IstioNetworkingV1Alpha3Virtualservices))
+
+	sa := local.NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("testCombined", analyzers...), "", nil, true)
+	g.Expect(sa.AddFileKubeSource([]string{"testdata/resources.yaml"})).To(Succeed())
+
+	result, err := sa.Analyze(make(chan struct{}))
+	g.Expect(err).To(BeNil())
+
+	var actual []string
+	for _, m := range result.Messages {
+		actual = append(actual, m.String())
+	}
+	g.Expect(actual).To(ConsistOf(
+		"Warn [ACME0001](VirtualService without-timeouts.default) "+
+			"VirtualService without-timeouts must set a timeout on all HTTP routes",
+		"Error [ACME0002](Gateway wildcard.prod) Gateway wildcard in prod must not use a wildcard host",
+		"Info [ACME0003](Service high-port.default) Service uses port above 9000",
+	))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	valid := `
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts)'
+  message: {code: ACME0001, level: Warn, template: 'Message'}
+`
+	cases := map[string]string{
+		"no name": `
+analyzers:
+- inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts)'
+  message: {code: ACME0001, level: Warn, template: 'Message'}
+`,
+		"duplicate name": `
+analyzers:
+- name: a` + valid + `
+- name: a` + valid,
+		"unknown input": `
+analyzers:
+- name: a
+  inputs: [istio/unknown]
+  condition: 'has(spec.hosts)'
+  message: {code: ACME0001, level: Warn, template: 'Message'}
+`,
+		"invalid level": `
+analyzers:
+- name: a
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts)'
+  message: {code: ACME0001, level: Fatal, template: 'Message'}
+`,
+		"reserved code": `
+analyzers:
+- name: a
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts)'
+  message: {code: IST0101, level: Warn, template: 'Message'}
+`,
+		"no template": `
+analyzers:
+- name: a
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts)'
+  message: {code: ACME0001, level: Warn}
+`,
+		"syntax error": `
+analyzers:
+- name: a
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts'
+  message: {code: ACME0001, level: Warn, template: 'Message'}
+`,
+		"not a bool": `
+analyzers:
+- name: a
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'name'
+  message: {code: ACME0001, level: Warn, template: 'Message'}
+`,
+		"unknown variable": `
+analyzers:
+- name: a
+  inputs: [istio/networking/v1alpha3/virtualservices]
+  condition: 'has(spec.hosts)'
+  message: {code: ACME0001, level: Warn, template: 'Message %s', args: [kind]}
+`,
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			_, err := Parse([]byte(c))
+			g.Expect(err).NotTo(BeNil())
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := Load("testdata/nonexistent.yaml")
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = Load("testdata/analyzers.yaml", "testdata/analyzers.yaml")
+	g.Expect(err).To(MatchError(ContainSubstring("duplicate analyzer")))
+}
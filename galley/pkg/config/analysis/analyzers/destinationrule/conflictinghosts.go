@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// ConflictingHostsAnalyzer checks if multiple DestinationRules of a namespace define the same host. Only one of them
+// is applied by Pilot.
+type ConflictingHostsAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictingHostsAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *ConflictingHostsAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "destinationrule.ConflictingHostsAnalyzer",
+		Inputs: collection.Names{
+			metadata.IstioNetworkingV1Alpha3Destinationrules,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ConflictingHostsAnalyzer) Analyze(c analysis.Context) {
+	// Pilot looks up the DestinationRules of a host namespace by namespace, so the conflicts are within a namespace
+	hostsDestinationRules := map[util.ScopedFqdn][]*resource.Entry{}
+	c.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		dr := r.Item.(*v1alpha3.DestinationRule)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+		scopedFqdn := util.NewScopedFqdn(ns, ns, dr.GetHost())
+		hostsDestinationRules[scopedFqdn] = append(hostsDestinationRules[scopedFqdn], r)
+		return true
+	})
+
+	for scopedFqdn, drList := range hostsDestinationRules {
+		if len(drList) < 2 {
+			continue
+		}
+		_, fqdn := scopedFqdn.GetScopeAndFqdn()
+		drNames := combineResourceEntryNames(drList)
+		for _, r := range drList {
+			c.Report(metadata.IstioNetworkingV1Alpha3Destinationrules, msg.NewMultipleDestinationRulesForHost(r, drNames, fqdn))
+		}
+	}
+}
+
+func combineResourceEntryNames(rList []*resource.Entry) string {
+	names := make([]string, 0, len(rList))
+	for _, r := range rList {
+		names = append(names, r.Metadata.Name.String())
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	v1 "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// SubsetSelectorAnalyzer checks that the subsets of the DestinationRules select pods of their host service.
+type SubsetSelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &SubsetSelectorAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *SubsetSelectorAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "destinationrule.SubsetSelectorAnalyzer",
+		Inputs: collection.Names{
+			metadata.IstioNetworkingV1Alpha3Destinationrules,
+			metadata.K8SCoreV1Pods,
+			metadata.K8SCoreV1Services,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *SubsetSelectorAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		a.analyzeDestinationRule(r, c)
+		return true
+	})
+}
+
+func (a *SubsetSelectorAnalyzer) analyzeDestinationRule(r *resource.Entry, c analysis.Context) {
+	dr := r.Item.(*v1alpha3.DestinationRule)
+	if len(dr.GetSubsets()) == 0 {
+		return
+	}
+
+	ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+	svcName := util.GetResourceNameFromHost(ns, dr.GetHost())
+	rSvc := c.Find(metadata.K8SCoreV1Services, svcName)
+	if rSvc == nil {
+		// Not a Kubernetes service, e.g. a ServiceEntry host: the workloads are unknown
+		return
+	}
+	svc := rSvc.Item.(*v1.ServiceSpec)
+	if len(svc.Selector) == 0 {
+		// The endpoints of the service are managed outside of Kubernetes
+		return
+	}
+
+	svcNamespace, _ := svcName.InterpretAsNamespaceAndName()
+	var podLabels []k8s_labels.Set
+	svcSelector := k8s_labels.SelectorFromSet(svc.Selector)
+	c.ForEach(metadata.K8SCoreV1Pods, func(rPod *resource.Entry) bool {
+		podNamespace, _ := rPod.Metadata.Name.InterpretAsNamespaceAndName()
+		if podNamespace != svcNamespace {
+			return true // Services only select pods in their namespace
+		}
+		labels := k8s_labels.Set(rPod.Item.(*v1.Pod).ObjectMeta.Labels)
+		if svcSelector.Matches(labels) {
+			podLabels = append(podLabels, labels)
+		}
+		return true
+	})
+
+	if len(podLabels) == 0 {
+		// Without any pod of the service, e.g. when analyzing files only, every subset would be reported
+		return
+	}
+
+	for _, ss := range dr.GetSubsets() {
+		if len(ss.GetLabels()) == 0 {
+			continue
+		}
+		ssSelector := k8s_labels.SelectorFromSet(ss.GetLabels())
+		found := false
+		for _, labels := range podLabels {
+			if ssSelector.Matches(labels) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Report(metadata.IstioNetworkingV1Alpha3Destinationrules,
+				msg.NewDestinationRuleSubsetSelectsNoPods(r, ss.GetName(), dr.GetHost(), ssSelector.String()))
+		}
+	}
+}
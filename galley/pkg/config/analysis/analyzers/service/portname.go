@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+// PortNameAnalyzer checks that the ports of the services are named after their protocol, e.g. "http-web". Pilot
+// sniffs the protocol of the other ports if protocol sniffing is enabled, otherwise it handles their traffic as
+// plain TCP. The analyzer doesn't know the settings of Pilot, so the message describes both cases.
+type PortNameAnalyzer struct{}
+
+var (
+	// The services of these namespaces are not part of the mesh
+	ignoredNamespaces = map[string]bool{
+		"kube-system": true,
+		"kube-public": true,
+	}
+
+	_ analysis.Analyzer = &PortNameAnalyzer{}
+)
+
+// Metadata implements Analyzer
+func (a *PortNameAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "service.PortNameAnalyzer",
+		Inputs: collection.Names{
+			metadata.K8SCoreV1Services,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *PortNameAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		if ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName(); ignoredNamespaces[ns] {
+			return true
+		}
+
+		svc := r.Item.(*v1.ServiceSpec)
+		for _, port := range svc.Ports {
+			if configKube.ConvertProtocol(port.Port, port.Name, port.Protocol) != protocol.Unsupported {
+				continue
+			}
+			suggestedName := strings.ToLower(string(protocol.HTTP))
+			if port.Name != "" {
+				suggestedName += "-" + port.Name
+			}
+			c.Report(metadata.K8SCoreV1Services,
+				msg.NewPortNameIsNotUnderNamingConvention(r, port.Name, int(port.Port), suggestedName))
+		}
+		return true
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// KubernetesServiceOverlapAnalyzer checks if the hosts of the ServiceEntries are also the hosts of Kubernetes services.
+// The behavior is undefined if they overlap.
+type KubernetesServiceOverlapAnalyzer struct{}
+
+var _ analysis.Analyzer = &KubernetesServiceOverlapAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *KubernetesServiceOverlapAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "serviceentry.KubernetesServiceOverlapAnalyzer",
+		Inputs: collection.Names{
+			metadata.IstioNetworkingV1Alpha3Serviceentries,
+			metadata.K8SCoreV1Services,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *KubernetesServiceOverlapAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(metadata.IstioNetworkingV1Alpha3Serviceentries, func(r *resource.Entry) bool {
+		se := r.Item.(*v1alpha3.ServiceEntry)
+
+		for _, h := range se.GetHosts() {
+			// The ServiceEntry hosts are not relative to its namespace, only FQDNs of services can overlap
+			svcName := util.GetResourceNameFromHost("", h)
+			if ns, _ := svcName.InterpretAsNamespaceAndName(); ns == "" {
+				continue
+			}
+			if c.Find(metadata.K8SCoreV1Services, svcName) != nil {
+				c.Report(metadata.IstioNetworkingV1Alpha3Serviceentries,
+					msg.NewServiceEntryHostOverlapsKubernetesService(r, h, svcName.String()))
+			}
+		}
+		return true
+	})
+}
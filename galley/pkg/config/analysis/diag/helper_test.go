@@ -14,6 +14,10 @@
 
 package diag
 
+import (
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
 type testOrigin string
 
 func (o testOrigin) FriendlyName() string {
@@ -23,3 +27,22 @@ func (o testOrigin) FriendlyName() string {
 func (o testOrigin) Namespace() string {
 	return ""
 }
+
+func (o testOrigin) Reference() resource.Reference {
+	return nil
+}
+
+type testReferencedOrigin struct {
+	testOrigin
+	ref string
+}
+
+func (o testReferencedOrigin) Reference() resource.Reference {
+	return testReference(o.ref)
+}
+
+type testReference string
+
+func (r testReference) String() string {
+	return string(r)
+}
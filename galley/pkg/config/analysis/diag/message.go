@@ -60,6 +60,9 @@ func (m *Message) Unstructured(includeOrigin bool) map[string]interface{} {
 	result["level"] = m.Type.Level().String()
 	if includeOrigin && m.Origin != nil {
 		result["origin"] = m.Origin.FriendlyName()
+		if r := m.Origin.Reference(); r != nil {
+			result["reference"] = r.String()
+		}
 	}
 	result["message"] = fmt.Sprintf(m.Type.Template(), m.Parameters...)
 
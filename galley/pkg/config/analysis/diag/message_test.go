@@ -48,5 +48,11 @@ func TestMessage_Unstructured(t *testing.T) {
 	m = NewMessage(mt, testOrigin("toppings/cheese"), "Feta")
 
 	g.Expect(m.Unstructured(true)).To((HaveKey("origin")))
+	g.Expect(m.Unstructured(true)).To(Not(HaveKey("reference")))
 	g.Expect(m.Unstructured(false)).To(Not(HaveKey("origin")))
+
+	m = NewMessage(mt, testReferencedOrigin{testOrigin: "toppings/cheese", ref: "pizza.yaml:3"}, "Feta")
+
+	g.Expect(m.Unstructured(true)).To(HaveKeyWithValue("reference", "pizza.yaml:3"))
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("reference")))
 }
@@ -51,3 +51,31 @@ func (ms *Messages) SortedCopy() Messages {
 	newMs.Sort()
 	return newMs
 }
+
+// Diff returns the messages of after that are not in before, and the messages of before that are not in after.
+// The messages are compared by their String() form, so that the same message about a resource read from another
+// source, e.g. a file instead of the cluster, is the same message.
+func Diff(before, after Messages) (added, removed Messages) {
+	counts := make(map[string]int)
+	for _, m := range before {
+		counts[m.String()]++
+	}
+	for _, m := range after {
+		s := m.String()
+		if counts[s] > 0 {
+			counts[s]--
+			continue
+		}
+		added = append(added, m)
+	}
+
+	// What is left in counts is only in before
+	for _, m := range before {
+		s := m.String()
+		if counts[s] > 0 {
+			counts[s]--
+			removed = append(removed, m)
+		}
+	}
+	return added, removed
+}
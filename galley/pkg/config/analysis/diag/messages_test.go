@@ -107,3 +107,21 @@ func TestMessages_SortedCopy(t *testing.T) {
 	g.Expect(msgs).To(Equal(sameMsgs))
 	g.Expect(newMsgs).To(Equal(expectedMsgs))
 }
+
+func TestDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mt := NewMessageType(Error, "B1", "Template: %q")
+	kept := NewMessage(mt, testOrigin("A"), "A")
+	fixed := NewMessage(mt, testOrigin("B"), "B")
+	introduced := NewMessage(mt, testOrigin("C"), "C")
+
+	// The same message is reported twice before, and once after the change
+	added, removed := Diff(Messages{kept, fixed, kept}, Messages{introduced, kept})
+	g.Expect(added).To(Equal(Messages{introduced}))
+	g.Expect(removed).To(Equal(Messages{kept, fixed}))
+
+	added, removed = Diff(nil, nil)
+	g.Expect(added).To(BeEmpty())
+	g.Expect(removed).To(BeEmpty())
+}
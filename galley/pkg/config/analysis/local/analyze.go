@@ -18,9 +18,13 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"sync"
 
+	"github.com/ghodss/yaml"
 	"github.com/hashicorp/go-multierror"
 
+	"istio.io/api/mesh/v1alpha1"
+
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/event"
@@ -60,13 +64,25 @@ type SourceAnalyzer struct {
 
 	// Hook function called when a collection is used in analysis
 	collectionReporter snapshotter.CollectionReporterFn
+
+	// Suppressions of messages, in addition to the ones of the resource annotations
+	suppressions analysis.Suppressions
+
+	// Mesh configuration used by the analysis, the default one if nil
+	meshConfig *v1alpha1.MeshConfig
 }
 
 // AnalysisResult represents the returnable results of an analysis execution
 type AnalysisResult struct {
-	Messages          diag.Messages
-	SkippedAnalyzers  []string
-	ExecutedAnalyzers []string
+	Messages           diag.Messages
+	SuppressedMessages diag.Messages
+	SkippedAnalyzers   []string
+	ExecutedAnalyzers  []string
+}
+
+// SuppressionsFile is the content of a file listing suppressions of messages
+type SuppressionsFile struct {
+	Suppressions analysis.Suppressions `json:"suppressions"`
 }
 
 // NewSourceAnalyzer creates a new SourceAnalyzer with no sources. Use the Add*Source methods to add sources in ascending precedence order,
@@ -105,7 +121,11 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 	var result AnalysisResult
 
 	meshsrc := meshcfg.NewInmemory()
-	meshsrc.Set(meshcfg.Default())
+	if sa.meshConfig != nil {
+		meshsrc.Set(sa.meshConfig)
+	} else {
+		meshsrc.Set(meshcfg.Default())
+	}
 
 	if len(sa.sources) == 0 {
 		return result, fmt.Errorf("at least one file and/or Kubernetes source must be provided")
@@ -121,6 +141,9 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 	result.ExecutedAnalyzers = sa.analyzer.AnalyzerNames()
 
 	updater := &snapshotter.InMemoryStatusUpdater{}
+	// Suppressed messages are reported before the other messages of the same analysis
+	var suppressedMu sync.Mutex
+	var suppressed diag.Messages
 	distributorSettings := snapshotter.AnalyzingDistributorSettings{
 		StatusUpdater:      updater,
 		Analyzer:           sa.analyzer,
@@ -129,6 +152,12 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 		TriggerSnapshot:    metadata.LocalAnalysis,
 		CollectionReporter: sa.collectionReporter,
 		AnalysisNamespaces: namespaces,
+		Suppressions:       sa.suppressions,
+		SuppressionReporter: func(m diag.Messages) {
+			suppressedMu.Lock()
+			suppressed = m
+			suppressedMu.Unlock()
+		},
 	}
 	distributor := snapshotter.NewAnalyzingDistributor(distributorSettings)
 
@@ -150,12 +179,49 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 	scope.Analysis.Debugf("Waiting for analysis messages to be available...")
 	if updater.WaitForReport(cancel) {
 		result.Messages = updater.Get()
+		suppressedMu.Lock()
+		result.SuppressedMessages = suppressed
+		suppressedMu.Unlock()
 		return result, nil
 	}
 
 	return result, errors.New("cancelled")
 }
 
+// SetSuppressions sets the suppressions of messages, in addition to the ones of the annotations of the resources
+func (sa *SourceAnalyzer) SetSuppressions(s analysis.Suppressions) {
+	sa.suppressions = s
+}
+
+// SetMeshConfig sets the mesh configuration used by the analysis, instead of the default one
+func (sa *SourceAnalyzer) SetMeshConfig(m *v1alpha1.MeshConfig) {
+	sa.meshConfig = m
+}
+
+// ReadSuppressionsFile reads the suppressions of messages from the given YAML file
+func ReadSuppressionsFile(file string) (analysis.Suppressions, error) {
+	by, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var f SuppressionsFile
+	if err = yaml.Unmarshal(by, &f); err != nil {
+		return nil, fmt.Errorf("error parsing suppressions file %q: %v", file, err)
+	}
+
+	var errs error
+	for _, s := range f.Suppressions {
+		if err = s.Validate(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	if errs != nil {
+		return nil, fmt.Errorf("invalid suppressions file %q: %v", file, errs)
+	}
+	return f.Suppressions, nil
+}
+
 // AddFileKubeSource adds a source based on the specified k8s yaml files to the current SourceAnalyzer
 func (sa *SourceAnalyzer) AddFileKubeSource(files []string) error {
 	src := inmemory.NewKubeSource(sa.kubeResources)
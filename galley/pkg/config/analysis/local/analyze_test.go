@@ -124,6 +124,65 @@ func TestFilterOutputByNamespace(t *testing.T) {
 	g.Expect(result.Messages).To(ConsistOf(msg1))
 }
 
+func TestSuppressions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cancel := make(chan struct{})
+
+	r1 := createTestResource("ns1", "resource", "v1")
+	r2 := createTestResource("ns2", "resource", "v1")
+	msg1 := msg.NewInternalError(r1, "msg")
+	msg2 := msg.NewInternalError(r2, "msg")
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			ctx.Report(data.Collection1, msg1)
+			ctx.Report(data.Collection1, msg2)
+		},
+	}
+
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("a", a), "", nil, false)
+	sa.SetSuppressions(analysis.Suppressions{{Code: msg.InternalError.Code(), ResourceName: "*.ns2"}})
+	err := sa.AddFileKubeSource([]string{})
+	g.Expect(err).To(BeNil())
+
+	result, err := sa.Analyze(cancel)
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Messages).To(ConsistOf(msg1))
+	g.Expect(result.SuppressedMessages).To(ConsistOf(msg2))
+}
+
+func TestReadSuppressionsFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tmpfile := tempFileFromString(t, `
+suppressions:
+- code: IST0101
+  resource: "VirtualService *.default"
+- code: IST0102
+  resource: "Namespace *"
+`)
+	defer func() { _ = os.Remove(tmpfile.Name()) }()
+
+	s, err := ReadSuppressionsFile(tmpfile.Name())
+	g.Expect(err).To(BeNil())
+	g.Expect(s).To(Equal(analysis.Suppressions{
+		{Code: "IST0101", ResourceName: "VirtualService *.default"},
+		{Code: "IST0102", ResourceName: "Namespace *"},
+	}))
+
+	invalid := tempFileFromString(t, `
+suppressions:
+- resource: "Namespace *"
+`)
+	defer func() { _ = os.Remove(invalid.Name()) }()
+
+	_, err = ReadSuppressionsFile(invalid.Name())
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = ReadSuppressionsFile("nonexistent-file.yaml")
+	g.Expect(err).NotTo(BeNil())
+}
+
 func TestAddRunningKubeSource(t *testing.T) {
 	g := NewGomegaWithT(t)
 
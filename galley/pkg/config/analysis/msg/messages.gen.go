@@ -60,6 +60,26 @@ var (
 	// MultipleSidecarsWithoutWorkloadSelectors defines a diag.MessageType for message "MultipleSidecarsWithoutWorkloadSelectors".
 	// Description: More than one sidecar resource in a namespace has no workload selector
 	MultipleSidecarsWithoutWorkloadSelectors = diag.NewMessageType(diag.Error, "IST0111", "The Sidecars %v in namespace %q have no workload selector, which can lead to undefined behavior.")
+
+	// MTLSPolicyConflict defines a diag.MessageType for message "MTLSPolicyConflict".
+	// Description: A DestinationRule does not use Istio mutual TLS for a host on which an authentication policy requires strict mutual TLS.
+	MTLSPolicyConflict = diag.NewMessageType(diag.Error, "IST0112", "The DestinationRule uses TLS mode %s for host %s (port %s), but strict mTLS is required for it by the authentication policies. Traffic to the host will fail unless the mode is ISTIO_MUTUAL.")
+
+	// DestinationRuleSubsetSelectsNoPods defines a diag.MessageType for message "DestinationRuleSubsetSelectsNoPods".
+	// Description: A DestinationRule subset selects none of the pods of its host service.
+	DestinationRuleSubsetSelectsNoPods = diag.NewMessageType(diag.Warning, "IST0113", "The subset %s of host %s selects no pods (labels %s)")
+
+	// MultipleDestinationRulesForHost defines a diag.MessageType for message "MultipleDestinationRulesForHost".
+	// Description: More than one DestinationRule is defined for the same host.
+	MultipleDestinationRulesForHost = diag.NewMessageType(diag.Error, "IST0114", "The DestinationRules %s define the same host %s, only one of them will be applied.")
+
+	// ServiceEntryHostOverlapsKubernetesService defines a diag.MessageType for message "ServiceEntryHostOverlapsKubernetesService".
+	// Description: A ServiceEntry host is also the host of a Kubernetes service.
+	ServiceEntryHostOverlapsKubernetesService = diag.NewMessageType(diag.Warning, "IST0115", "The host %s is also the host of the Kubernetes service %s, which can lead to undefined behavior.")
+
+	// PortNameIsNotUnderNamingConvention defines a diag.MessageType for message "PortNameIsNotUnderNamingConvention".
+	// Description: A service port name does not start with a protocol prefix, so its protocol is sniffed or its traffic is handled as TCP.
+	PortNameIsNotUnderNamingConvention = diag.NewMessageType(diag.Info, "IST0116", "The port %s (%d) has no protocol prefix, so its protocol is sniffed if protocol sniffing is enabled in Pilot, otherwise its traffic is handled as plain TCP. Name it <protocol>[-<suffix>], e.g. %s, to select the protocol.")
 )
 
 // NewInternalError returns a new diag.Message based on InternalError.
@@ -189,6 +209,59 @@ func NewMultipleSidecarsWithoutWorkloadSelectors(entry *resource.Entry, confliti
 	)
 }
 
+// NewMTLSPolicyConflict returns a new diag.Message based on MTLSPolicyConflict.
+func NewMTLSPolicyConflict(entry *resource.Entry, tlsMode string, host string, port string) diag.Message {
+	return diag.NewMessage(
+		MTLSPolicyConflict,
+		originOrNil(entry),
+		tlsMode,
+		host,
+		port,
+	)
+}
+
+// NewDestinationRuleSubsetSelectsNoPods returns a new diag.Message based on DestinationRuleSubsetSelectsNoPods.
+func NewDestinationRuleSubsetSelectsNoPods(entry *resource.Entry, subset string, host string, labels string) diag.Message {
+	return diag.NewMessage(
+		DestinationRuleSubsetSelectsNoPods,
+		originOrNil(entry),
+		subset,
+		host,
+		labels,
+	)
+}
+
+// NewMultipleDestinationRulesForHost returns a new diag.Message based on MultipleDestinationRulesForHost.
+func NewMultipleDestinationRulesForHost(entry *resource.Entry, destinationRules string, host string) diag.Message {
+	return diag.NewMessage(
+		MultipleDestinationRulesForHost,
+		originOrNil(entry),
+		destinationRules,
+		host,
+	)
+}
+
+// NewServiceEntryHostOverlapsKubernetesService returns a new diag.Message based on ServiceEntryHostOverlapsKubernetesService.
+func NewServiceEntryHostOverlapsKubernetesService(entry *resource.Entry, host string, service string) diag.Message {
+	return diag.NewMessage(
+		ServiceEntryHostOverlapsKubernetesService,
+		originOrNil(entry),
+		host,
+		service,
+	)
+}
+
+// NewPortNameIsNotUnderNamingConvention returns a new diag.Message based on PortNameIsNotUnderNamingConvention.
+func NewPortNameIsNotUnderNamingConvention(entry *resource.Entry, portName string, port int, suggestedName string) diag.Message {
+	return diag.NewMessage(
+		PortNameIsNotUnderNamingConvention,
+		originOrNil(entry),
+		portName,
+		port,
+		suggestedName,
+	)
+}
+
 func originOrNil(e *resource.Entry) resource.Origin {
 	var o resource.Origin
 	if e != nil {
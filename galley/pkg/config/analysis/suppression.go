@@ -0,0 +1,90 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// SuppressAnnotation is the annotation of a resource listing the comma separated codes of the messages that are
+// suppressed for the resource, e.g. "IST0101,IST0102".
+const SuppressAnnotation = "galley.istio.io/analyze-suppress"
+
+// Suppression suppresses the messages with a code for the resources matching a name pattern.
+type Suppression struct {
+	// Code of the suppressed messages, e.g. "IST0101".
+	Code string `json:"code"`
+
+	// ResourceName is a glob pattern of the friendly names of the resources, e.g. "VirtualService *.default".
+	ResourceName string `json:"resource"`
+}
+
+// ParseSuppression parses a suppression in the "<code>=<resource name pattern>" form.
+func ParseSuppression(s string) (Suppression, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Suppression{}, fmt.Errorf("invalid suppression %q, expected <code>=<resource name pattern>", s)
+	}
+	sup := Suppression{Code: parts[0], ResourceName: parts[1]}
+	return sup, sup.Validate()
+}
+
+// Validate returns an error if the suppression is not valid.
+func (s Suppression) Validate() error {
+	if s.Code == "" {
+		return fmt.Errorf("suppression code must be set")
+	}
+	if _, err := path.Match(s.ResourceName, ""); err != nil {
+		return fmt.Errorf("invalid suppression resource name pattern %q: %v", s.ResourceName, err)
+	}
+	return nil
+}
+
+// Matches returns true if the message is suppressed.
+func (s Suppression) Matches(m diag.Message) bool {
+	if m.Type.Code() != s.Code || m.Origin == nil {
+		return false
+	}
+	ok, _ := path.Match(s.ResourceName, m.Origin.FriendlyName())
+	return ok
+}
+
+// Suppressions is a list of suppressions.
+type Suppressions []Suppression
+
+// IsSuppressed returns true if the message about the given resource is suppressed, either by one of the suppressions
+// or by the SuppressAnnotation of the resource. The resource may be nil.
+func (s Suppressions) IsSuppressed(m diag.Message, r *resource.Entry) bool {
+	for _, sup := range s {
+		if sup.Matches(m) {
+			return true
+		}
+	}
+
+	if r == nil {
+		return false
+	}
+	for _, code := range strings.Split(r.Metadata.Annotations[SuppressAnnotation], ",") {
+		if strings.TrimSpace(code) == m.Type.Code() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+type testOrigin string
+
+func (o testOrigin) FriendlyName() string          { return string(o) }
+func (o testOrigin) Namespace() string             { return "" }
+func (o testOrigin) Reference() resource.Reference { return nil }
+
+var (
+	testMessageType  = diag.NewMessageType(diag.Error, "IST0101", "Referenced %s not found")
+	testMessageType2 = diag.NewMessageType(diag.Warning, "IST0102", "Namespace %s not injected")
+)
+
+func TestParseSuppression(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, err := ParseSuppression("IST0101=VirtualService *.default")
+	g.Expect(err).To(BeNil())
+	g.Expect(s).To(Equal(Suppression{Code: "IST0101", ResourceName: "VirtualService *.default"}))
+
+	for _, invalid := range []string{"", "IST0101", "IST0101=", "=Namespace default", "IST0101=[a"} {
+		_, err = ParseSuppression(invalid)
+		g.Expect(err).NotTo(BeNil(), invalid)
+	}
+}
+
+func TestSuppression_Matches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := Suppression{Code: "IST0101", ResourceName: "VirtualService *.default"}
+
+	g.Expect(s.Matches(diag.NewMessage(testMessageType, testOrigin("VirtualService reviews.default"), "x"))).To(BeTrue())
+	g.Expect(s.Matches(diag.NewMessage(testMessageType, testOrigin("VirtualService reviews.prod"), "x"))).To(BeFalse())
+	g.Expect(s.Matches(diag.NewMessage(testMessageType2, testOrigin("VirtualService reviews.default"), "x"))).To(BeFalse())
+	g.Expect(s.Matches(diag.NewMessage(testMessageType, nil, "x"))).To(BeFalse())
+}
+
+func TestSuppressions_IsSuppressed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := Suppressions{{Code: "IST0101", ResourceName: "Gateway *"}}
+	r := &resource.Entry{
+		Metadata: resource.Metadata{
+			Annotations: map[string]string{SuppressAnnotation: "IST0001, IST0102"},
+		},
+	}
+
+	m1 := diag.NewMessage(testMessageType, testOrigin("Gateway gw.default"), "x")
+	m2 := diag.NewMessage(testMessageType2, testOrigin("Namespace default"), "x")
+	m3 := diag.NewMessage(testMessageType, testOrigin("Namespace default"), "x")
+
+	g.Expect(s.IsSuppressed(m1, nil)).To(BeTrue())
+	g.Expect(s.IsSuppressed(m2, nil)).To(BeFalse())
+	g.Expect(s.IsSuppressed(m2, r)).To(BeTrue())
+	g.Expect(s.IsSuppressed(m3, r)).To(BeFalse())
+	g.Expect(Suppressions(nil).IsSuppressed(m2, &resource.Entry{})).To(BeFalse())
+}
@@ -166,6 +166,10 @@ collections:
     proto: "istio.security.v1beta1.AuthorizationPolicy"
     protoPackage: "istio.io/api/security/v1beta1"
 
+  - name: "istio/telemetry/v1alpha1/accesslogpolicies"
+    proto: "istio.telemetry.v1alpha1.AccessLogPolicy"
+    protoPackage: "istio.io/istio/pkg/config/accesslog/v1alpha1"
+
   ### K8s collections ###
 
   # Built-in K8s collections
@@ -288,6 +292,10 @@ collections:
     proto: "istio.security.v1beta1.AuthorizationPolicy"
     protoPackage: "istio.io/api/security/v1beta1"
 
+  - name: "k8s/telemetry.istio.io/v1alpha1/accesslogpolicies"
+    proto: "istio.telemetry.v1alpha1.AccessLogPolicy"
+    protoPackage: "istio.io/istio/pkg/config/accesslog/v1alpha1"
+
     # Keep Legacy Mixer CRD related collections separate, as these will be gone soon.
   - name: "k8s/config.istio.io/v1alpha2/apikeys"
     proto: "google.protobuf.Struct"
@@ -568,6 +576,7 @@ snapshots:
       - "istio/rbac/v1alpha1/servicerolebindings"
       - "istio/rbac/v1alpha1/serviceroles"
       - "istio/security/v1beta1/authorizationpolicies"
+      - "istio/telemetry/v1alpha1/accesslogpolicies"
       - "k8s/core/v1/namespaces"
       - "k8s/core/v1/services"
 
@@ -614,6 +623,8 @@ snapshots:
   - name: "localAnalysis"
     strategy: immediate
     collections:
+      - "istio/authentication/v1alpha1/meshpolicies"
+      - "istio/authentication/v1alpha1/policies"
       - "istio/rbac/v1alpha1/servicerolebindings"
       - "istio/rbac/v1alpha1/serviceroles"
       - "istio/mesh/v1alpha1/MeshConfig"
@@ -777,6 +788,12 @@ sources:
       group: "security.istio.io"
       version: "v1beta1"
 
+    - collection: "k8s/telemetry.istio.io/v1alpha1/accesslogpolicies"
+      kind: "AccessLogPolicy"
+      plural: "accesslogpolicies"
+      group: "telemetry.istio.io"
+      version: "v1alpha1"
+
     - collection: "k8s/config.istio.io/v1alpha2/rules"
       kind: "rule"
       plural: "rules"
@@ -1031,6 +1048,7 @@ transforms:
       "k8s/rbac.istio.io/v1alpha1/clusterrbacconfigs": "istio/rbac/v1alpha1/clusterrbacconfigs"
       "k8s/rbac.istio.io/v1alpha1/serviceroles": "istio/rbac/v1alpha1/serviceroles"
       "k8s/security.istio.io/v1beta1/authorizationpolicies": "istio/security/v1beta1/authorizationpolicies"
+      "k8s/telemetry.istio.io/v1alpha1/accesslogpolicies": "istio/telemetry/v1alpha1/accesslogpolicies"
       "k8s/core/v1/namespaces": "k8s/core/v1/namespaces"
       "k8s/core/v1/services": "k8s/core/v1/services"
       "k8s/core/v1/pods": "k8s/core/v1/pods"
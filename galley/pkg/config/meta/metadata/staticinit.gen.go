@@ -30,6 +30,9 @@ import (
 	// Register protos in "istio.io/api/security/v1beta1"
 	_ "istio.io/api/security/v1beta1"
 
+	// Register protos in "istio.io/istio/pkg/config/accesslog/v1alpha1"
+	_ "istio.io/istio/pkg/config/accesslog/v1alpha1"
+
 	// Register protos in "k8s.io/api/apps/v1"
 	_ "k8s.io/api/apps/v1"
 
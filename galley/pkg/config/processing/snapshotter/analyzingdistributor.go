@@ -15,6 +15,7 @@
 package snapshotter
 
 import (
+	"fmt"
 	"sync"
 
 	"istio.io/istio/galley/pkg/config/analysis"
@@ -28,8 +29,12 @@ import (
 // CollectionReporterFn is a hook function called whenever a collection is accessed through the AnalyzingDistributor's context
 type CollectionReporterFn func(collection.Name)
 
+// SuppressionReporterFn is a hook function called with the suppressed messages of each analysis
+type SuppressionReporterFn func(diag.Messages)
+
 // AnalyzingDistributor is an snapshotter. Distributor implementation that will perform analysis on a snapshot before
-// publishing. It will update the CRD status with the analysis results.
+// publishing. It will update the CRD status with the analysis results. Only the analyzers whose input collections
+// changed since the previous analysis are run again, the messages of the others are reused.
 type AnalyzingDistributor struct {
 	s AnalyzingDistributorSettings
 
@@ -38,6 +43,17 @@ type AnalyzingDistributor struct {
 
 	snapshotsMu   sync.RWMutex
 	lastSnapshots map[string]*Snapshot
+
+	// State of the last complete analysis, to only rerun the analyzers whose input collections changed since.
+	incrementalMu sync.Mutex
+	lastEntries   map[collection.Name]map[resource.Name]*resource.Entry
+	lastResults   []*analyzerResult
+}
+
+// analyzerResult is the output of an analyzer, cached until its input collections change.
+type analyzerResult struct {
+	messages   diag.Messages
+	suppressed diag.Messages
 }
 
 var _ Distributor = &AnalyzingDistributor{}
@@ -66,6 +82,12 @@ type AnalyzingDistributorSettings struct {
 
 	// Namespaces that should be analyzed
 	AnalysisNamespaces []string
+
+	// Suppressions of messages, in addition to the ones of the analysis.SuppressAnnotation of the resources.
+	Suppressions analysis.Suppressions
+
+	// An optional hook that will be called with the suppressed messages of each analysis.
+	SuppressionReporter SuppressionReporterFn
 }
 
 // NewAnalyzingDistributor returns a new instance of AnalyzingDistributor.
@@ -74,6 +96,9 @@ func NewAnalyzingDistributor(s AnalyzingDistributorSettings) *AnalyzingDistribut
 	if s.CollectionReporter == nil {
 		s.CollectionReporter = func(collection.Name) {}
 	}
+	if s.SuppressionReporter == nil {
+		s.SuppressionReporter = func(diag.Messages) {}
+	}
 
 	return &AnalyzingDistributor{
 		s:             s,
@@ -134,30 +159,18 @@ func (d *AnalyzingDistributor) analyzeAndDistribute(cancelCh chan struct{}, name
 		sn:                 d.getCombinedSnapshot(),
 		cancelCh:           cancelCh,
 		collectionReporter: d.s.CollectionReporter,
+		suppressions:       d.s.Suppressions,
 	}
 
 	scope.Analysis.Debugf("Beginning analyzing the current snapshot")
-	d.s.Analyzer.Analyze(ctx)
+	completed := d.analyze(ctx)
 	scope.Analysis.Debugf("Finished analyzing the current snapshot, found messages: %v", ctx.messages)
 
-	// Only keep messages for resources in namespaces we want to analyze
-	// If the message doesn't have an origin (meaning we can't determine the namespace) fail open and keep it
-	// If no such limit is specified, keep them all.
-	var msgs diag.Messages
-	if len(namespaces) == 0 {
-		msgs = ctx.messages
-	} else {
-		for _, m := range ctx.messages {
-			if m.Origin != nil {
-				if _, ok := namespaces[m.Origin.Namespace()]; !ok {
-					continue
-				}
-			}
-			msgs = append(msgs, m)
-		}
-	}
+	msgs := filterMessages(ctx.messages, namespaces)
 
-	if !ctx.Canceled() {
+	if completed {
+		suppressed := filterMessages(ctx.suppressed, namespaces)
+		d.s.SuppressionReporter(suppressed.SortedCopy())
 		d.s.StatusUpdater.Update(msgs.SortedCopy())
 	}
 
@@ -165,6 +178,187 @@ func (d *AnalyzingDistributor) analyzeAndDistribute(cancelCh chan struct{}, name
 	d.s.Distributor.Distribute(name, s)
 }
 
+// analyze runs the analyzers whose input collections changed since the last complete analysis, and merges their
+// messages in the context with the cached messages of the other analyzers. The analyzers that don't declare their
+// inputs are always run. It returns false if the analysis was canceled, in which case the cache is left untouched.
+func (d *AnalyzingDistributor) analyze(ctx *context) bool {
+	d.incrementalMu.Lock()
+	defer d.incrementalMu.Unlock()
+
+	entries := snapshotEntries(ctx.sn)
+	changed := changedCollections(d.lastEntries, entries)
+
+	analyzers := d.s.Analyzer.Analyzers()
+	if len(d.lastResults) != len(analyzers) {
+		d.lastResults = make([]*analyzerResult, len(analyzers))
+	}
+
+	results := make([]*analyzerResult, len(analyzers))
+	rerun := 0
+	for i, a := range analyzers {
+		if last := d.lastResults[i]; last != nil && !inputsChanged(a, changed) {
+			results[i] = last
+			continue
+		}
+
+		if ctx.Canceled() {
+			scope.Analysis.Debugf("Analyzer %q has been cancelled...", a.Metadata().Name)
+			return false
+		}
+		scope.Analysis.Debugf("Started analyzer %q...", a.Metadata().Name)
+		ctx.messages, ctx.suppressed = nil, nil
+		a.Analyze(ctx)
+		results[i] = &analyzerResult{messages: ctx.messages, suppressed: ctx.suppressed}
+		rerun++
+		scope.Analysis.Debugf("Completed analyzer %q...", a.Metadata().Name)
+	}
+	if ctx.Canceled() {
+		return false
+	}
+	scope.Analysis.Debugf("Ran %d of %d analyzers, the inputs of the others didn't change", rerun, len(analyzers))
+
+	ctx.messages, ctx.suppressed = nil, nil
+	for _, r := range results {
+		ctx.messages = append(ctx.messages, r.messages...)
+		ctx.suppressed = append(ctx.suppressed, r.suppressed...)
+	}
+
+	d.lastEntries = entries
+	d.lastResults = results
+	return true
+}
+
+// AnalyzeResource analyzes the last snapshots with the given resource added to the collection, or replacing the
+// resource with the same name. Only the analyzers reading the collection are run, and only the messages reported for
+// the resource are returned. It fails if no snapshot has been distributed yet.
+func (d *AnalyzingDistributor) AnalyzeResource(col collection.Name, r *resource.Entry) (diag.Messages, error) {
+	d.snapshotsMu.RLock()
+	empty := len(d.lastSnapshots) == 0
+	d.snapshotsMu.RUnlock()
+	if empty {
+		return nil, fmt.Errorf("no snapshot has been distributed yet")
+	}
+
+	ctx := &context{
+		sn:                 overlay(d.getCombinedSnapshot(), col, r),
+		cancelCh:           make(chan struct{}),
+		collectionReporter: d.s.CollectionReporter,
+		suppressions:       d.s.Suppressions,
+	}
+	for _, a := range d.s.Analyzer.Analyzers() {
+		if !inputsChanged(a, map[collection.Name]struct{}{col: {}}) {
+			continue
+		}
+		a.Analyze(ctx)
+	}
+
+	var result diag.Messages
+	for _, m := range ctx.messages {
+		if m.Origin != nil && m.Origin == r.Origin {
+			result = append(result, m)
+		}
+	}
+	return result.SortedCopy(), nil
+}
+
+// overlay returns a copy of the snapshot with the resource set in the collection.
+func overlay(sn *Snapshot, col collection.Name, r *resource.Entry) *Snapshot {
+	var collections []*coll.Instance
+	found := false
+	for _, n := range sn.set.Names() {
+		c := sn.set.Collection(n)
+		if n == col {
+			c = c.Clone()
+			c.Set(r)
+			found = true
+		}
+		collections = append(collections, c)
+	}
+	if !found {
+		c := coll.New(col)
+		c.Set(r)
+		collections = append(collections, c)
+	}
+
+	return &Snapshot{set: coll.NewSetFromCollections(collections)}
+}
+
+// snapshotEntries returns the entries of each collection of the snapshot, by name.
+func snapshotEntries(sn *Snapshot) map[collection.Name]map[resource.Name]*resource.Entry {
+	result := make(map[collection.Name]map[resource.Name]*resource.Entry)
+	for _, n := range sn.set.Names() {
+		entries := make(map[resource.Name]*resource.Entry)
+		sn.set.Collection(n).ForEach(func(r *resource.Entry) bool {
+			entries[r.Metadata.Name] = r
+			return true
+		})
+		result[n] = entries
+	}
+	return result
+}
+
+// changedCollections returns the collections whose entries differ. The entries are immutable, so an updated resource
+// is a different entry.
+func changedCollections(old, current map[collection.Name]map[resource.Name]*resource.Entry) map[collection.Name]struct{} {
+	result := make(map[collection.Name]struct{})
+	for n, entries := range current {
+		if !sameEntries(old[n], entries) {
+			result[n] = struct{}{}
+		}
+	}
+	for n := range old {
+		if _, ok := current[n]; !ok {
+			result[n] = struct{}{}
+		}
+	}
+	return result
+}
+
+func sameEntries(a, b map[resource.Name]*resource.Entry) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for name, r := range b {
+		if a[name] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func inputsChanged(a analysis.Analyzer, changed map[collection.Name]struct{}) bool {
+	inputs := a.Metadata().Inputs
+	if len(inputs) == 0 {
+		return true
+	}
+	for _, in := range inputs {
+		if _, ok := changed[in]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterMessages only keeps messages for resources in namespaces we want to analyze.
+// If the message doesn't have an origin (meaning we can't determine the namespace) fail open and keep it
+// If no such limit is specified, keep them all.
+func filterMessages(messages diag.Messages, namespaces map[string]struct{}) diag.Messages {
+	if len(namespaces) == 0 {
+		return messages
+	}
+
+	var msgs diag.Messages
+	for _, m := range messages {
+		if m.Origin != nil {
+			if _, ok := namespaces[m.Origin.Namespace()]; !ok {
+				continue
+			}
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
 // getCombinedSnapshot creates a new snapshot from the last snapshots of each snapshot group
 // Important assumption: the collections in each snapshot don't overlap.
 func (d *AnalyzingDistributor) getCombinedSnapshot() *Snapshot {
@@ -188,16 +382,47 @@ type context struct {
 	sn                 *Snapshot
 	cancelCh           chan struct{}
 	messages           diag.Messages
+	suppressed         diag.Messages
 	collectionReporter CollectionReporterFn
+	suppressions       analysis.Suppressions
+
+	// Entries of the collections by origin, lazily indexed to find the resources of the reported messages.
+	entriesByOrigin map[collection.Name]map[resource.Origin]*resource.Entry
 }
 
 var _ analysis.Context = &context{}
 
 // Report implements analysis.Context
 func (c *context) Report(col collection.Name, m diag.Message) {
+	if c.suppressions.IsSuppressed(m, c.findByOrigin(col, m.Origin)) {
+		c.suppressed.Add(m)
+		return
+	}
 	c.messages.Add(m)
 }
 
+func (c *context) findByOrigin(col collection.Name, o resource.Origin) *resource.Entry {
+	if o == nil {
+		return nil
+	}
+
+	if c.entriesByOrigin == nil {
+		c.entriesByOrigin = make(map[collection.Name]map[resource.Origin]*resource.Entry)
+	}
+	entries, ok := c.entriesByOrigin[col]
+	if !ok {
+		entries = make(map[resource.Origin]*resource.Entry)
+		c.sn.ForEach(col, func(r *resource.Entry) bool {
+			if r.Origin != nil {
+				entries[r.Origin] = r
+			}
+			return true
+		})
+		c.entriesByOrigin[col] = entries
+	}
+	return entries[o]
+}
+
 // Find implements analysis.Context
 func (c *context) Find(col collection.Name, name resource.Name) *resource.Entry {
 	c.collectionReporter(col)
@@ -44,6 +44,7 @@ type analyzerMock struct {
 	analyzeCalls       []*Snapshot
 	collectionToAccess collection.Name
 	entriesToReport    []*resource.Entry
+	inputs             collection.Names
 }
 
 // Analyze implements Analyzer
@@ -63,7 +64,7 @@ func (a *analyzerMock) Analyze(c analysis.Context) {
 func (a *analyzerMock) Metadata() analysis.Metadata {
 	return analysis.Metadata{
 		Name:   "",
-		Inputs: collection.Names{},
+		Inputs: a.inputs,
 	}
 }
 
@@ -205,6 +206,186 @@ func TestAnalyzeSortsMessages(t *testing.T) {
 	g.Expect(u.messages[1].Origin).To(Equal(o1))
 }
 
+func TestAnalyzeSuppressesMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{}
+	annotated := &resource.Entry{
+		Metadata: resource.Metadata{
+			Name:        resource.NewName("includedNamespace", "r1"),
+			Annotations: map[string]string{analysis.SuppressAnnotation: "IST0002, IST0001"},
+		},
+		Origin: &rt.Origin{
+			Collection: data.Collection1,
+			Kind:       "Kind1",
+			Name:       resource.NewName("includedNamespace", "r1"),
+		},
+	}
+	byName := &resource.Entry{
+		Origin: &rt.Origin{
+			Collection: data.Collection1,
+			Kind:       "Kind1",
+			Name:       resource.NewName("includedNamespace", "r2"),
+		},
+	}
+	reported := &resource.Entry{
+		Origin: &rt.Origin{
+			Collection: data.Collection1,
+			Kind:       "Kind1",
+			Name:       resource.NewName("includedNamespace", "r3"),
+		},
+	}
+	a := &analyzerMock{
+		collectionToAccess: data.Collection1,
+		entriesToReport:    []*resource.Entry{annotated, byName, reported},
+	}
+	d := NewInMemoryDistributor()
+
+	var suppressed diag.Messages
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       d,
+		AnalysisSnapshots: []string{metadata.Default},
+		TriggerSnapshot:   metadata.Default,
+		Suppressions: analysis.Suppressions{
+			{Code: msg.InternalError.Code(), ResourceName: "Kind1 r2.*"},
+		},
+		SuppressionReporter: func(m diag.Messages) { suppressed = m },
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	c := coll.New(data.Collection1)
+	c.Set(annotated)
+	sDefault := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c})}
+
+	ad.Distribute(metadata.Default, sDefault)
+
+	g.Eventually(func() diag.Messages { return u.messages }).Should(HaveLen(1))
+	g.Expect(u.messages[0].Origin).To(Equal(reported.Origin))
+	g.Expect(suppressed).To(HaveLen(2))
+	g.Expect(suppressed[0].Origin).To(Equal(annotated.Origin))
+	g.Expect(suppressed[1].Origin).To(Equal(byName.Origin))
+}
+
+func TestAnalyzeOnlyRerunsAnalyzersWithChangedInputs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r1 := &resource.Entry{
+		Origin: &rt.Origin{Collection: data.Collection1, Name: resource.NewName("ns", "r1")},
+	}
+	r2 := &resource.Entry{
+		Metadata: resource.Metadata{Name: resource.NewName("ns", "r2")},
+		Origin:   &rt.Origin{Collection: data.Collection2, Name: resource.NewName("ns", "r2")},
+	}
+	a1 := &analyzerMock{
+		collectionToAccess: data.Collection1,
+		entriesToReport:    []*resource.Entry{r1},
+		inputs:             collection.Names{data.Collection1},
+	}
+	a2 := &analyzerMock{
+		collectionToAccess: data.Collection2,
+		entriesToReport:    []*resource.Entry{r2},
+		inputs:             collection.Names{data.Collection2},
+	}
+	// Doesn't declare its inputs, so it always reruns
+	a3 := &analyzerMock{
+		collectionToAccess: data.Collection1,
+	}
+
+	u := &updaterMock{}
+	d := NewInMemoryDistributor()
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a1, a2, a3),
+		Distributor:       d,
+		AnalysisSnapshots: []string{metadata.Default},
+		TriggerSnapshot:   metadata.Default,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	c1 := coll.New(data.Collection1)
+	c1.Set(r1)
+	c2 := coll.New(data.Collection2)
+	s1 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1.Clone(), c2.Clone()})}
+
+	ad.Distribute(metadata.Default, s1)
+	g.Eventually(func() snapshot.Snapshot { return d.GetSnapshot(metadata.Default) }).Should(Equal(s1))
+	g.Expect(a1.analyzeCalls).To(HaveLen(1))
+	g.Expect(a2.analyzeCalls).To(HaveLen(1))
+	g.Expect(a3.analyzeCalls).To(HaveLen(1))
+	g.Expect(u.messages).To(HaveLen(2))
+
+	// Only collection2 changes
+	c2.Set(r2)
+	s2 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1.Clone(), c2.Clone()})}
+
+	ad.Distribute(metadata.Default, s2)
+	g.Eventually(func() snapshot.Snapshot { return d.GetSnapshot(metadata.Default) }).Should(Equal(s2))
+	g.Expect(a1.analyzeCalls).To(HaveLen(1))
+	g.Expect(a2.analyzeCalls).To(HaveLen(2))
+	g.Expect(a3.analyzeCalls).To(HaveLen(2))
+
+	// The message of a1 is kept from the previous analysis
+	g.Expect(u.messages).To(HaveLen(2))
+	g.Expect(u.messages[0].Origin).To(Equal(r1.Origin))
+	g.Expect(u.messages[1].Origin).To(Equal(r2.Origin))
+}
+
+func TestAnalyzeResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	existing := &resource.Entry{
+		Metadata: resource.Metadata{Name: resource.NewName("ns", "existing")},
+		Origin:   &rt.Origin{Collection: data.Collection1, Name: resource.NewName("ns", "existing")},
+	}
+	admitted := &resource.Entry{
+		Metadata: resource.Metadata{Name: resource.NewName("ns", "admitted")},
+		Origin:   &rt.Origin{Collection: data.Collection1, Name: resource.NewName("ns", "admitted")},
+	}
+	a1 := &analyzerMock{
+		collectionToAccess: data.Collection1,
+		entriesToReport:    []*resource.Entry{existing, admitted},
+		inputs:             collection.Names{data.Collection1},
+	}
+	a2 := &analyzerMock{
+		collectionToAccess: data.Collection2,
+		entriesToReport:    []*resource.Entry{admitted},
+		inputs:             collection.Names{data.Collection2},
+	}
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     &updaterMock{},
+		Analyzer:          analysis.Combine("testCombined", a1, a2),
+		Distributor:       NewInMemoryDistributor(),
+		AnalysisSnapshots: []string{metadata.Default},
+		TriggerSnapshot:   metadata.SyntheticServiceEntry,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	_, err := ad.AnalyzeResource(data.Collection1, admitted)
+	g.Expect(err).NotTo(BeNil())
+
+	c1 := coll.New(data.Collection1)
+	c1.Set(existing)
+	sDefault := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1})}
+	ad.Distribute(metadata.Default, sDefault)
+
+	msgs, err := ad.AnalyzeResource(data.Collection1, admitted)
+	g.Expect(err).To(BeNil())
+
+	// Only the messages of the admitted resource, by the analyzers reading its collection
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Origin).To(Equal(admitted.Origin))
+	g.Expect(a1.analyzeCalls).To(HaveLen(1))
+	g.Expect(a2.analyzeCalls).To(BeEmpty())
+
+	// The resource is overlaid on a copy of the snapshot
+	g.Expect(a1.analyzeCalls[0].Find(data.Collection1, admitted.Metadata.Name)).To(Equal(admitted))
+	g.Expect(a1.analyzeCalls[0].Find(data.Collection1, existing.Metadata.Name)).To(Equal(existing))
+	g.Expect(sDefault.Find(data.Collection1, admitted.Metadata.Name)).To(BeNil())
+}
+
 func getTestSnapshot(names ...string) *Snapshot {
 	c := make([]*coll.Instance, 0)
 	for _, name := range names {
@@ -0,0 +1,352 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// History is a Distributor that keeps the last distributed snapshots of each group, before distributing them to
+// another Distributor. The snapshots of a group are numbered from 1, in the order of their distribution.
+type History struct {
+	size int
+	d    Distributor
+	now  func() time.Time
+
+	mu     sync.RWMutex
+	groups map[string]*groupHistory
+}
+
+var _ Distributor = &History{}
+
+// HistoryInfo describes a snapshot of the history.
+type HistoryInfo struct {
+	// Version of the snapshot in the history of its group.
+	Version int64 `json:"version"`
+
+	// Time of the distribution of the snapshot.
+	Time time.Time `json:"time"`
+
+	// Resources is the number of resources in the snapshot.
+	Resources int `json:"resources"`
+
+	// Changes is the number of resources added, updated or removed since the previous snapshot.
+	Changes int `json:"changes"`
+
+	// MCPVersions are the versions of the collections of the snapshot sent to the MCP clients, e.g.
+	// "istio/networking/v1alpha3/gateways/3", by collection.
+	MCPVersions map[string]string `json:"mcpVersions"`
+}
+
+// ResourceKey identifies a version of a resource in a snapshot.
+type ResourceKey struct {
+	Collection string `json:"collection"`
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+}
+
+// SnapshotDiff is the difference between two snapshots of a group.
+type SnapshotDiff struct {
+	From    int64         `json:"from"`
+	To      int64         `json:"to"`
+	Added   []ResourceKey `json:"added"`
+	Updated []ResourceKey `json:"updated"`
+	Removed []ResourceKey `json:"removed"`
+}
+
+type groupHistory struct {
+	lastVersion int64
+
+	// The entries of the last snapshot, to diff the next one with.
+	lastEntries map[entryKey]*resource.Entry
+
+	// The retained snapshots, oldest first.
+	entries []*historyEntry
+}
+
+type historyEntry struct {
+	info     HistoryInfo
+	snapshot *Snapshot
+}
+
+// NewHistory returns a new History keeping the last size snapshots of each group, and distributing them to d.
+func NewHistory(size int, d Distributor) *History {
+	return &History{
+		size:   size,
+		d:      d,
+		now:    time.Now,
+		groups: make(map[string]*groupHistory),
+	}
+}
+
+// Distribute implements Distributor
+func (h *History) Distribute(name string, s *Snapshot) {
+	h.record(name, s)
+	h.d.Distribute(name, s)
+}
+
+func (h *History) record(name string, s *Snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.groups[name]
+	if !ok {
+		g = &groupHistory{}
+		h.groups[name] = g
+	}
+
+	entries := snapshotKeys(s)
+	diff := diffEntries(g.lastEntries, entries)
+	g.lastEntries = entries
+
+	g.lastVersion++
+	g.entries = append(g.entries, &historyEntry{
+		info: HistoryInfo{
+			Version:     g.lastVersion,
+			Time:        h.now(),
+			Resources:   len(entries),
+			Changes:     len(diff.Added) + len(diff.Updated) + len(diff.Removed),
+			MCPVersions: mcpVersions(s),
+		},
+		snapshot: s,
+	})
+	if len(g.entries) > h.size {
+		g.entries = g.entries[len(g.entries)-h.size:]
+	}
+}
+
+// Groups returns the names of the groups of the history.
+func (h *History) Groups() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make([]string, 0, len(h.groups))
+	for name := range h.groups {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// List returns the retained snapshots of the group, oldest first.
+func (h *History) List(group string) []HistoryInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	g, ok := h.groups[group]
+	if !ok {
+		return nil
+	}
+	result := make([]HistoryInfo, 0, len(g.entries))
+	for _, e := range g.entries {
+		result = append(result, e.info)
+	}
+	return result
+}
+
+// Get returns the snapshot of the group with the given version, if it is still retained.
+func (h *History) Get(group string, version int64) (*Snapshot, HistoryInfo, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	e := h.find(group, version)
+	if e == nil {
+		return nil, HistoryInfo{}, false
+	}
+	return e.snapshot, e.info, true
+}
+
+// Diff returns the difference between two retained snapshots of the group.
+func (h *History) Diff(group string, from, to int64) (*SnapshotDiff, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	f := h.find(group, from)
+	if f == nil {
+		return nil, fmt.Errorf("snapshot %s/%d not found", group, from)
+	}
+	t := h.find(group, to)
+	if t == nil {
+		return nil, fmt.Errorf("snapshot %s/%d not found", group, to)
+	}
+
+	diff := diffSnapshots(f.snapshot, t.snapshot)
+	diff.From = from
+	diff.To = to
+	return diff, nil
+}
+
+// FirstContaining returns the first retained snapshot of the group containing the resource. If version is not
+// empty, the first one containing this version of the resource is returned.
+func (h *History) FirstContaining(group string, col collection.Name, name resource.Name, version string) (HistoryInfo, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	g, ok := h.groups[group]
+	if !ok {
+		return HistoryInfo{}, false
+	}
+	for _, e := range g.entries {
+		r := e.snapshot.Find(col, name)
+		if r != nil && (version == "" || string(r.Metadata.Version) == version) {
+			return e.info, true
+		}
+	}
+	return HistoryInfo{}, false
+}
+
+// FirstWithMCPVersion returns the first retained snapshot of the group distributing the MCP version of a collection,
+// e.g. the version acknowledged by an MCP client. The version is prefixed by its collection, as returned by
+// Snapshot.Version.
+func (h *History) FirstWithMCPVersion(group, version string) (HistoryInfo, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	i := strings.LastIndex(version, "/")
+	if i < 0 {
+		return HistoryInfo{}, false
+	}
+	col := version[:i]
+
+	g, ok := h.groups[group]
+	if !ok {
+		return HistoryInfo{}, false
+	}
+	for _, e := range g.entries {
+		if e.info.MCPVersions[col] == version {
+			return e.info, true
+		}
+	}
+	return HistoryInfo{}, false
+}
+
+func (h *History) find(group string, version int64) *historyEntry {
+	g, ok := h.groups[group]
+	if !ok {
+		return nil
+	}
+	for _, e := range g.entries {
+		if e.info.Version == version {
+			return e
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys of the resources of the snapshot, sorted by collection and name.
+func Keys(s *Snapshot) []ResourceKey {
+	entries := snapshotKeys(s)
+	result := make([]ResourceKey, 0, len(entries))
+	for k, r := range entries {
+		result = append(result, newResourceKey(k.col, r))
+	}
+	sortKeys(result)
+	return result
+}
+
+// mcpVersions returns the MCP versions of the collections of the snapshot.
+func mcpVersions(s *Snapshot) map[string]string {
+	result := make(map[string]string)
+	if s == nil {
+		return result
+	}
+	for _, col := range s.Collections() {
+		result[col] = s.Version(col)
+	}
+	return result
+}
+
+type entryKey struct {
+	col  collection.Name
+	name resource.Name
+}
+
+func snapshotKeys(s *Snapshot) map[entryKey]*resource.Entry {
+	result := make(map[entryKey]*resource.Entry)
+	if s == nil {
+		return result
+	}
+	for _, col := range s.set.Names() {
+		s.set.Collection(col).ForEach(func(r *resource.Entry) bool {
+			result[entryKey{col: col, name: r.Metadata.Name}] = r
+			return true
+		})
+	}
+	return result
+}
+
+// diffSnapshots returns the resources added, updated and removed from the snapshot a to b. A nil snapshot is empty.
+func diffSnapshots(a, b *Snapshot) *SnapshotDiff {
+	return diffEntries(snapshotKeys(a), snapshotKeys(b))
+}
+
+func diffEntries(before, after map[entryKey]*resource.Entry) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		Added:   []ResourceKey{},
+		Updated: []ResourceKey{},
+		Removed: []ResourceKey{},
+	}
+	for k, r := range after {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, newResourceKey(k.col, r))
+		case isUpdated(old, r):
+			diff.Updated = append(diff.Updated, newResourceKey(k.col, r))
+		}
+	}
+	for k, r := range before {
+		if _, ok := after[k]; !ok {
+			diff.Removed = append(diff.Removed, newResourceKey(k.col, r))
+		}
+	}
+
+	sortKeys(diff.Added)
+	sortKeys(diff.Updated)
+	sortKeys(diff.Removed)
+	return diff
+}
+
+// isUpdated compares the versions of the resources, or the entries themselves if they have no version.
+func isUpdated(old, r *resource.Entry) bool {
+	if old.Metadata.Version == "" && r.Metadata.Version == "" {
+		return old != r
+	}
+	return old.Metadata.Version != r.Metadata.Version
+}
+
+func newResourceKey(col collection.Name, r *resource.Entry) ResourceKey {
+	return ResourceKey{
+		Collection: col.String(),
+		Name:       r.Metadata.Name.String(),
+		Version:    string(r.Metadata.Version),
+	}
+}
+
+func sortKeys(keys []ResourceKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Collection != keys[j].Collection {
+			return keys[i].Collection < keys[j].Collection
+		}
+		return keys[i].Name < keys[j].Name
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	coll "istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/testing/data"
+)
+
+func newHistorySnapshot(entries ...*resource.Entry) *Snapshot {
+	c := coll.New(data.Collection1)
+	for _, e := range entries {
+		c.Set(e)
+	}
+	return &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c})}
+}
+
+func TestHistoryDistributes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d := NewInMemoryDistributor()
+	h := NewHistory(2, d)
+
+	s := newHistorySnapshot(data.EntryN1I1V1)
+	h.Distribute(metadata.Default, s)
+
+	g.Expect(d.GetSnapshot(metadata.Default)).To(Equal(s))
+	g.Expect(h.Groups()).To(Equal([]string{metadata.Default}))
+}
+
+func TestHistoryKeepsLastSnapshots(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	h := NewHistory(2, NewInMemoryDistributor())
+
+	s1 := newHistorySnapshot(data.EntryN1I1V1)
+	s2 := newHistorySnapshot(data.EntryN1I1V2, data.EntryN2I2V1)
+	s3 := newHistorySnapshot(data.EntryN2I2V1)
+	h.Distribute(metadata.Default, s1)
+	h.Distribute(metadata.Default, s2)
+	h.Distribute(metadata.Default, s3)
+
+	infos := h.List(metadata.Default)
+	g.Expect(infos).To(HaveLen(2))
+	g.Expect(infos[0].Version).To(Equal(int64(2)))
+	g.Expect(infos[0].Resources).To(Equal(2))
+	g.Expect(infos[0].Changes).To(Equal(2))
+	g.Expect(infos[1].Version).To(Equal(int64(3)))
+	g.Expect(infos[1].Resources).To(Equal(1))
+	g.Expect(infos[1].Changes).To(Equal(1))
+
+	_, _, ok := h.Get(metadata.Default, 1)
+	g.Expect(ok).To(BeFalse())
+
+	s, info, ok := h.Get(metadata.Default, 2)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(s).To(Equal(s2))
+	g.Expect(info.Version).To(Equal(int64(2)))
+
+	g.Expect(h.List("unknown")).To(BeEmpty())
+}
+
+func TestHistoryDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	h := NewHistory(5, NewInMemoryDistributor())
+	h.Distribute(metadata.Default, newHistorySnapshot(data.EntryN1I1V1, data.EntryN2I2V1))
+	h.Distribute(metadata.Default, newHistorySnapshot(data.EntryN1I1V2, data.EntryN3I3V1))
+
+	diff, err := h.Diff(metadata.Default, 1, 2)
+	g.Expect(err).To(BeNil())
+	g.Expect(diff).To(Equal(&SnapshotDiff{
+		From: 1,
+		To:   2,
+		Added: []ResourceKey{
+			{Collection: data.Collection1.String(), Name: "n3/i3", Version: "v1"},
+		},
+		Updated: []ResourceKey{
+			{Collection: data.Collection1.String(), Name: "n1/i1", Version: "v2"},
+		},
+		Removed: []ResourceKey{
+			{Collection: data.Collection1.String(), Name: "n2/i2", Version: "v1"},
+		},
+	}))
+
+	_, err = h.Diff(metadata.Default, 1, 3)
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestHistoryFirstContaining(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	h := NewHistory(5, NewInMemoryDistributor())
+	h.Distribute(metadata.Default, newHistorySnapshot())
+	h.Distribute(metadata.Default, newHistorySnapshot(data.EntryN1I1V1))
+	h.Distribute(metadata.Default, newHistorySnapshot(data.EntryN1I1V2))
+
+	name := data.EntryN1I1V1.Metadata.Name
+
+	info, ok := h.FirstContaining(metadata.Default, data.Collection1, name, "")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(info.Version).To(Equal(int64(2)))
+
+	info, ok = h.FirstContaining(metadata.Default, data.Collection1, name, "v2")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(info.Version).To(Equal(int64(3)))
+
+	_, ok = h.FirstContaining(metadata.Default, data.Collection1, name, "v3")
+	g.Expect(ok).To(BeFalse())
+
+	_, ok = h.FirstContaining(metadata.Default, data.Collection2, name, "")
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestHistoryFirstWithMCPVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	h := NewHistory(5, NewInMemoryDistributor())
+	c := coll.New(data.Collection1)
+	c.Set(data.EntryN1I1V1)
+	distribute := func() {
+		h.Distribute(metadata.Default, &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c.Clone()})})
+	}
+	distribute()
+	distribute()
+	c.Set(data.EntryN1I1V2)
+	distribute()
+
+	infos := h.List(metadata.Default)
+	g.Expect(infos).To(HaveLen(3))
+	version1 := data.Collection1.String() + "/1"
+	version2 := data.Collection1.String() + "/2"
+	g.Expect(infos[0].MCPVersions).To(Equal(map[string]string{data.Collection1.String(): version1}))
+	g.Expect(infos[2].MCPVersions).To(Equal(map[string]string{data.Collection1.String(): version2}))
+
+	// The version is first distributed by the first snapshot having it.
+	info, ok := h.FirstWithMCPVersion(metadata.Default, version1)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(info.Version).To(Equal(int64(1)))
+
+	info, ok = h.FirstWithMCPVersion(metadata.Default, version2)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(info.Version).To(Equal(int64(3)))
+
+	_, ok = h.FirstWithMCPVersion(metadata.Default, data.Collection1.String()+"/3")
+	g.Expect(ok).To(BeFalse())
+	_, ok = h.FirstWithMCPVersion(metadata.Default, data.Collection2.String()+"/1")
+	g.Expect(ok).To(BeFalse())
+	_, ok = h.FirstWithMCPVersion(metadata.Default, "invalid")
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestKeys(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	keys := Keys(newHistorySnapshot(data.EntryN2I2V1, data.EntryN1I1V1))
+	g.Expect(keys).To(Equal([]ResourceKey{
+		{Collection: data.Collection1.String(), Name: "n1/i1", Version: "v1"},
+		{Collection: data.Collection1.String(), Name: "n2/i2", Version: "v1"},
+	}))
+	g.Expect(Keys(nil)).To(BeEmpty())
+}
@@ -0,0 +1,241 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package historyz
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"istio.io/pkg/ctrlz/fw"
+
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// SnapshotHistory defines the expected interface for producing historyz data. It is implemented by
+// snapshotter.History.
+type SnapshotHistory interface {
+	Groups() []string
+	List(group string) []snapshotter.HistoryInfo
+	Get(group string, version int64) (*snapshotter.Snapshot, snapshotter.HistoryInfo, bool)
+	Diff(group string, from, to int64) (*snapshotter.SnapshotDiff, error)
+	FirstContaining(group string, col collection.Name, name resource.Name, version string) (snapshotter.HistoryInfo, bool)
+	FirstWithMCPVersion(group, version string) (snapshotter.HistoryInfo, bool)
+}
+
+var _ SnapshotHistory = &snapshotter.History{}
+
+// historyzTopic is a fw.Topic implementation that exposes the history of the snapshots distributed by Galley.
+type historyzTopic struct {
+	tmpl *template.Template
+
+	history SnapshotHistory
+}
+
+var _ fw.Topic = &historyzTopic{}
+
+// CreateTopic creates and returns a historyz topic for the snapshot history. It does not do any registration.
+func CreateTopic(history SnapshotHistory) fw.Topic {
+	return &historyzTopic{
+		history: history,
+	}
+}
+
+// Title is implementation of Topic.Title.
+func (c *historyzTopic) Title() string {
+	return "Config History"
+}
+
+// Prefix is implementation of Topic.Prefix.
+func (c *historyzTopic) Prefix() string {
+	return "history"
+}
+
+type data struct {
+	Groups    []string
+	Group     string
+	Snapshots []snapshotter.HistoryInfo
+}
+
+type snapshotData struct {
+	Group     string
+	Info      snapshotter.HistoryInfo
+	Resources []snapshotter.ResourceKey
+}
+
+type errorData struct {
+	Error string
+}
+
+// Activate is implementation of Topic.Activate.
+func (c *historyzTopic) Activate(context fw.TopicContext) {
+	l := template.Must(context.Layout().Clone())
+	c.tmpl = template.Must(l.Parse(historyTemplate))
+
+	_ = context.HTMLRouter().StrictSlash(true).NewRoute().Path("/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fw.RenderHTML(w, c.tmpl, c.collectData(req.URL.Query().Get("group")))
+	})
+
+	_ = context.JSONRouter().StrictSlash(true).NewRoute().Methods("GET").Path("/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fw.RenderJSON(w, http.StatusOK, c.collectData(req.URL.Query().Get("group")))
+	})
+
+	_ = context.JSONRouter().StrictSlash(true).NewRoute().Methods("GET").Path("/snapshot").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		group := req.URL.Query().Get("group")
+		version, err := parseVersion(req, "version")
+		if err != nil {
+			renderError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		s, info, ok := c.history.Get(group, version)
+		if !ok {
+			renderError(w, http.StatusNotFound, fmt.Errorf("snapshot %s/%d not found", group, version))
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, &snapshotData{
+			Group:     group,
+			Info:      info,
+			Resources: snapshotter.Keys(s),
+		})
+	})
+
+	_ = context.JSONRouter().StrictSlash(true).NewRoute().Methods("GET").Path("/diff").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		from, err := parseVersion(req, "from")
+		if err != nil {
+			renderError(w, http.StatusBadRequest, err)
+			return
+		}
+		to, err := parseVersion(req, "to")
+		if err != nil {
+			renderError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		diff, err := c.history.Diff(req.URL.Query().Get("group"), from, to)
+		if err != nil {
+			renderError(w, http.StatusNotFound, err)
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, diff)
+	})
+
+	_ = context.JSONRouter().StrictSlash(true).NewRoute().Methods("GET").Path("/first").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		group := q.Get("group")
+		col := q.Get("collection")
+		name := q.Get("name")
+		if col == "" || name == "" {
+			renderError(w, http.StatusBadRequest, fmt.Errorf("collection and name are required"))
+			return
+		}
+
+		rName, err := resource.NewFullName(name)
+		if err != nil {
+			renderError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		info, ok := c.history.FirstContaining(group, collection.NewName(col), rName, q.Get("version"))
+		if !ok {
+			renderError(w, http.StatusNotFound, fmt.Errorf("no snapshot of %s contains %s/%s", group, col, name))
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, info)
+	})
+
+	_ = context.JSONRouter().StrictSlash(true).NewRoute().Methods("GET").Path("/mcp").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		group := q.Get("group")
+		version := q.Get("version")
+		if version == "" {
+			renderError(w, http.StatusBadRequest, fmt.Errorf("version is required"))
+			return
+		}
+
+		info, ok := c.history.FirstWithMCPVersion(group, version)
+		if !ok {
+			renderError(w, http.StatusNotFound, fmt.Errorf("no snapshot of %s has the MCP version %s", group, version))
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, info)
+	})
+}
+
+func (c *historyzTopic) collectData(group string) *data {
+	return &data{
+		Groups:    c.history.Groups(),
+		Group:     group,
+		Snapshots: c.history.List(group),
+	}
+}
+
+func parseVersion(req *http.Request, key string) (int64, error) {
+	v := req.URL.Query().Get(key)
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", key, v, err)
+	}
+	return version, nil
+}
+
+func renderError(w http.ResponseWriter, statusCode int, err error) {
+	fw.RenderJSON(w, statusCode, &errorData{Error: err.Error()})
+}
+
+const historyTemplate = `{{ define "content" }}
+
+    <p>
+        The last snapshots distributed by Galley, for each group of snapshots, with the MCP versions of their
+        collections. The resources of a snapshot, the difference between two snapshots, the first snapshot
+        containing a resource and the first snapshot with an MCP version are available in JSON.
+    </p>
+
+    <ul>
+    {{ range $value := .Groups }}
+        <li><a href="?group={{$value}}">{{$value}}</a></li>
+    {{end}}
+    </ul>
+
+    {{ if .Group }}
+    <table>
+        <thead>
+            <tr>
+                <th>Version</th>
+                <th>Time</th>
+                <th>Resources</th>
+                <th>Changes</th>
+                <th>MCP Versions</th>
+            </tr>
+        </thead>
+        <tbody>
+        {{ range $value := .Snapshots }}
+            <tr>
+                <td>{{$value.Version}}</td>
+                <td>{{$value.Time}}</td>
+                <td>{{$value.Resources}}</td>
+                <td>{{$value.Changes}}</td>
+                <td>{{ range $version := $value.MCPVersions }}{{$version}}<br>{{end}}</td>
+            </tr>
+        {{end}}
+        </tbody>
+    </table>
+    {{end}}
+
+{{ end }}
+`
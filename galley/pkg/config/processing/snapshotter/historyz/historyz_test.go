@@ -0,0 +1,225 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package historyz
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/gomega"
+
+	"istio.io/pkg/ctrlz/fw"
+
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+type historyMock struct {
+	infos []snapshotter.HistoryInfo
+	first map[string]snapshotter.HistoryInfo
+}
+
+var _ SnapshotHistory = &historyMock{}
+
+func (h *historyMock) Groups() []string {
+	return []string{"default"}
+}
+
+func (h *historyMock) List(group string) []snapshotter.HistoryInfo {
+	if group != "default" {
+		return nil
+	}
+	return h.infos
+}
+
+func (h *historyMock) Get(group string, version int64) (*snapshotter.Snapshot, snapshotter.HistoryInfo, bool) {
+	for _, info := range h.List(group) {
+		if info.Version == version {
+			return nil, info, true
+		}
+	}
+	return nil, snapshotter.HistoryInfo{}, false
+}
+
+func (h *historyMock) Diff(group string, from, to int64) (*snapshotter.SnapshotDiff, error) {
+	if _, _, ok := h.Get(group, from); !ok {
+		return nil, fmt.Errorf("snapshot %s/%d not found", group, from)
+	}
+	if _, _, ok := h.Get(group, to); !ok {
+		return nil, fmt.Errorf("snapshot %s/%d not found", group, to)
+	}
+	return &snapshotter.SnapshotDiff{
+		From:    from,
+		To:      to,
+		Added:   []snapshotter.ResourceKey{{Collection: "c", Name: "ns/a", Version: "v1"}},
+		Updated: []snapshotter.ResourceKey{},
+		Removed: []snapshotter.ResourceKey{},
+	}, nil
+}
+
+func (h *historyMock) FirstContaining(group string, col collection.Name, name resource.Name,
+	version string) (snapshotter.HistoryInfo, bool) {
+	info, ok := h.first[fmt.Sprintf("%s/%s/%s/%s", group, col, name, version)]
+	return info, ok
+}
+
+func (h *historyMock) FirstWithMCPVersion(group, version string) (snapshotter.HistoryInfo, bool) {
+	for _, info := range h.List(group) {
+		for _, v := range info.MCPVersions {
+			if v == version {
+				return info, true
+			}
+		}
+	}
+	return snapshotter.HistoryInfo{}, false
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	h := &historyMock{
+		infos: []snapshotter.HistoryInfo{
+			{Version: 3, Resources: 2, Changes: 2, MCPVersions: map[string]string{"c": "c/2"}},
+			{Version: 4, Resources: 1, Changes: 1, MCPVersions: map[string]string{"c": "c/3"}},
+		},
+		first: map[string]snapshotter.HistoryInfo{
+			"default/c/ns/a/": {Version: 3},
+		},
+	}
+
+	layout := template.Must(template.New("main").Parse(`{{ template "content" . }}`))
+	htmlRouter := mux.NewRouter()
+	jsonRouter := mux.NewRouter()
+	CreateTopic(h).Activate(fw.NewContext(htmlRouter, jsonRouter, layout))
+
+	r := mux.NewRouter()
+	r.PathPrefix("/historyj").Handler(http.StripPrefix("/historyj", jsonRouter))
+	r.PathPrefix("/history").Handler(http.StripPrefix("/history", htmlRouter))
+	return httptest.NewServer(r)
+}
+
+func get(t *testing.T, url string, out interface{}) int {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Should have unmarshalled json: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestTopic(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	topic := CreateTopic(&historyMock{})
+	g.Expect(topic.Title()).To(Equal("Config History"))
+	g.Expect(topic.Prefix()).To(Equal("history"))
+}
+
+func TestHistoryJ(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+
+	var d data
+	g.Expect(get(t, s.URL+"/historyj/?group=default", &d)).To(Equal(http.StatusOK))
+	g.Expect(d.Groups).To(Equal([]string{"default"}))
+	g.Expect(d.Snapshots).To(HaveLen(2))
+	g.Expect(d.Snapshots[1].Version).To(Equal(int64(4)))
+}
+
+func TestHistoryJSnapshot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+
+	var d snapshotData
+	g.Expect(get(t, s.URL+"/historyj/snapshot?group=default&version=3", &d)).To(Equal(http.StatusOK))
+	g.Expect(d.Info.Version).To(Equal(int64(3)))
+	g.Expect(d.Resources).To(BeEmpty())
+
+	g.Expect(get(t, s.URL+"/historyj/snapshot?group=default&version=1", nil)).To(Equal(http.StatusNotFound))
+	g.Expect(get(t, s.URL+"/historyj/snapshot?group=default&version=x", nil)).To(Equal(http.StatusBadRequest))
+}
+
+func TestHistoryJDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+
+	var d snapshotter.SnapshotDiff
+	g.Expect(get(t, s.URL+"/historyj/diff?group=default&from=3&to=4", &d)).To(Equal(http.StatusOK))
+	g.Expect(d.From).To(Equal(int64(3)))
+	g.Expect(d.To).To(Equal(int64(4)))
+	g.Expect(d.Added).To(HaveLen(1))
+
+	var e errorData
+	g.Expect(get(t, s.URL+"/historyj/diff?group=default&from=1&to=4", &e)).To(Equal(http.StatusNotFound))
+	g.Expect(e.Error).To(ContainSubstring("default/1"))
+}
+
+func TestHistoryJFirst(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+
+	var info snapshotter.HistoryInfo
+	g.Expect(get(t, s.URL+"/historyj/first?group=default&collection=c&name=ns/a", &info)).To(Equal(http.StatusOK))
+	g.Expect(info.Version).To(Equal(int64(3)))
+
+	g.Expect(get(t, s.URL+"/historyj/first?group=default&collection=c&name=ns/b", nil)).To(Equal(http.StatusNotFound))
+	g.Expect(get(t, s.URL+"/historyj/first?group=default&collection=c", nil)).To(Equal(http.StatusBadRequest))
+}
+
+func TestHistoryJMCP(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+
+	var info snapshotter.HistoryInfo
+	g.Expect(get(t, s.URL+"/historyj/mcp?group=default&version=c/3", &info)).To(Equal(http.StatusOK))
+	g.Expect(info.Version).To(Equal(int64(4)))
+	g.Expect(info.MCPVersions).To(Equal(map[string]string{"c": "c/3"}))
+
+	g.Expect(get(t, s.URL+"/historyj/mcp?group=default&version=c/1", nil)).To(Equal(http.StatusNotFound))
+	g.Expect(get(t, s.URL+"/historyj/mcp?group=default", nil)).To(Equal(http.StatusBadRequest))
+}
+
+func TestHistoryHTML(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+
+	g.Expect(get(t, s.URL+"/history/?group=default", nil)).To(Equal(http.StatusOK))
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package custom provides transforms of third-party custom resources into Istio config, which Galley then serves
+// like native config. Transforms are either declared in YAML files and loaded at runtime (see Parse), or implemented
+// in Go and registered from the init function of their package:
+//
+//	func init() {
+//		custom.Register(custom.Transform{
+//			Name:      "acme.RouteToVirtualService",
+//			Resources: schema.KubeResources{routes},
+//			Provider:  transformer.NewSimpleTransformerProvider(routes.Collection.Name, virtualServices, handleFn),
+//		})
+//	}
+//
+// The registering package must then be linked into Galley, e.g. with a blank import.
+//
+// The transformed resources share their collections with the native resources, so their names are prefixed with the
+// transform name (see OutputName) to never replace a native resource or the output of another transform.
+package custom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"istio.io/istio/galley/pkg/config/meta/schema"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/transformer"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+const (
+	// The proto of the custom resources, which are not known to Galley.
+	structProto        = "google.protobuf.Struct"
+	structProtoPackage = "github.com/gogo/protobuf/types"
+)
+
+// Transform is a transform of custom resources into Istio config.
+type Transform struct {
+	// Name of the transform, must be unique. Its lowercase form must be a DNS-1123 subdomain, e.g.
+	// "acme.RouteToVirtualService".
+	Name string
+
+	// Resources are the Kubernetes resources read by the transform, which are not part of the Galley metadata.
+	Resources schema.KubeResources
+
+	// Provider of the transformer.
+	Provider transformer.Provider
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Transform)
+)
+
+// Register makes a transform available to Galley. It panics if the transform is invalid, or if a transform with the
+// same name is already registered.
+func Register(t Transform) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if err := validateName(t.Name); err != nil {
+		panic(fmt.Sprintf("custom.Register: %v", err))
+	}
+	if _, found := registry[t.Name]; found {
+		panic(fmt.Sprintf("custom.Register: transform %q is already registered", t.Name))
+	}
+	registry[t.Name] = t
+}
+
+// OutputName returns the name of the output resource of the named transform for the input resource with the given
+// name. It is prefixed with the lowercase transform name, e.g. "ns/acme.routetovirtualservice.reviews", so that it
+// doesn't collide with the names of the native resources and of the outputs of the other transforms. The transforms
+// must name their outputs with it.
+func OutputName(transform string, input resource.Name) resource.Name {
+	ns, name := input.InterpretAsNamespaceAndName()
+	return resource.NewName(ns, strings.ToLower(transform)+"."+name)
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("transform name must be set")
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.ToLower(name)); len(errs) > 0 {
+		return fmt.Errorf("invalid transform name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Registered returns the registered transforms, sorted by name.
+func Registered() []Transform {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	result := make([]Transform, 0, len(registry))
+	for _, t := range registry {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// NewKubeResource returns the Kubernetes resource of a custom resource definition. Its collection is named
+// "k8s/<group>/<version>/<plural>" and its resources are read as google.protobuf.Struct.
+func NewKubeResource(group, version, kind, plural string, clusterScoped bool) (schema.KubeResource, error) {
+	if group == "" || version == "" || kind == "" || plural == "" {
+		return schema.KubeResource{}, fmt.Errorf("group, version, kind and plural must be set")
+	}
+
+	spec, err := collection.NewSpec(fmt.Sprintf("k8s/%s/%s/%s", group, version, plural), structProtoPackage, structProto)
+	if err != nil {
+		return schema.KubeResource{}, err
+	}
+
+	return schema.KubeResource{
+		Collection:    spec,
+		Group:         group,
+		Version:       version,
+		Kind:          kind,
+		Plural:        plural,
+		ClusterScoped: clusterScoped,
+	}, nil
+}
+
+// Providers returns the transformer providers of the transforms.
+func Providers(transforms []Transform) transformer.Providers {
+	result := make(transformer.Providers, 0, len(transforms))
+	for _, t := range transforms {
+		result = append(result, t.Provider)
+	}
+	return result
+}
+
+// KubeResources returns the Kubernetes resources read by the transforms, without duplicates. It fails if the
+// resources conflict with each other, or with the known ones.
+func KubeResources(known schema.KubeResources, transforms []Transform) (schema.KubeResources, error) {
+	var result schema.KubeResources
+	cols := make(map[collection.Name]schema.KubeResource)
+	for _, r := range known {
+		cols[r.Collection.Name] = r
+	}
+
+	for _, t := range transforms {
+		for _, r := range t.Resources {
+			if existing, found := cols[r.Collection.Name]; found {
+				if existing != r {
+					return nil, fmt.Errorf("transform %q: conflicting definitions of the resource %s", t.Name, r.Collection.Name)
+				}
+				continue
+			}
+			if _, found := known.Find(r.Group, r.Kind); found {
+				return nil, fmt.Errorf("transform %q: resource %s/%s is already known", t.Name, r.Group, r.Kind)
+			}
+			cols[r.Collection.Name] = r
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
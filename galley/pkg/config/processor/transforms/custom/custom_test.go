@@ -0,0 +1,143 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/transformer"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+)
+
+func resetRegistry() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = make(map[string]Transform)
+}
+
+func newTestTransform(name string) Transform {
+	return Transform{
+		Name: name,
+		Provider: transformer.NewSimpleTransformerProvider(basicmeta.Collection1, basicmeta.Collection2,
+			func(e event.Event, h event.Handler) {}),
+	}
+}
+
+func TestRegister(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer resetRegistry()
+
+	Register(newTestTransform("b"))
+	Register(newTestTransform("a"))
+
+	transforms := Registered()
+	g.Expect(transforms).To(HaveLen(2))
+	g.Expect(transforms[0].Name).To(Equal("a"))
+	g.Expect(transforms[1].Name).To(Equal("b"))
+
+	providers := Providers(transforms)
+	g.Expect(providers).To(HaveLen(2))
+	g.Expect(providers[0].Outputs()).To(Equal(collection.Names{basicmeta.Collection2}))
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer resetRegistry()
+
+	Register(newTestTransform("a"))
+	g.Expect(func() { Register(newTestTransform("a")) }).To(Panic())
+}
+
+func TestRegister_NoName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer resetRegistry()
+
+	g.Expect(func() { Register(newTestTransform("")) }).To(Panic())
+}
+
+func TestRegister_InvalidName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer resetRegistry()
+
+	g.Expect(func() { Register(newTestTransform("acme/Route")) }).To(Panic())
+}
+
+func TestOutputName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(OutputName("acme.RouteToVirtualService", resource.NewName("ns", "reviews"))).To(
+		Equal(resource.NewName("ns", "acme.routetovirtualservice.reviews")))
+	g.Expect(OutputName("acme.PoolToGateway", resource.NewName("", "pool"))).To(
+		Equal(resource.NewName("", "acme.pooltogateway.pool")))
+}
+
+func TestNewKubeResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r, err := NewKubeResource("networking.acme.io", "v1", "Route", "routes", false)
+	g.Expect(err).To(BeNil())
+	g.Expect(r.Collection.Name).To(Equal(collection.NewName("k8s/networking.acme.io/v1/routes")))
+	g.Expect(r.Collection.MessageName).To(Equal("google.protobuf.Struct"))
+	g.Expect(r.Kind).To(Equal("Route"))
+	g.Expect(r.Plural).To(Equal("routes"))
+
+	_, err = NewKubeResource("networking.acme.io", "v1", "Route", "", false)
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = NewKubeResource("networking.acme.io", "v1", "Route", "my-routes", false)
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestKubeResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	known := metadata.MustGet().KubeSource().Resources()
+	routes, _ := NewKubeResource("networking.acme.io", "v1", "Route", "routes", false)
+	pools, _ := NewKubeResource("networking.acme.io", "v1", "Pool", "pools", false)
+
+	resources, err := KubeResources(known, []Transform{
+		{Name: "a", Resources: schema.KubeResources{routes}},
+		{Name: "b", Resources: schema.KubeResources{routes, pools}},
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(resources).To(Equal(schema.KubeResources{routes, pools}))
+}
+
+func TestKubeResources_Conflicts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	known := metadata.MustGet().KubeSource().Resources()
+	routes, _ := NewKubeResource("networking.acme.io", "v1", "Route", "routes", false)
+	clusterRoutes, _ := NewKubeResource("networking.acme.io", "v1", "Route", "routes", true)
+	virtualServices, _ := NewKubeResource("networking.istio.io", "v1alpha3", "VirtualService", "virtualservices", false)
+
+	_, err := KubeResources(known, []Transform{
+		{Name: "a", Resources: schema.KubeResources{routes}},
+		{Name: "b", Resources: schema.KubeResources{clusterRoutes}},
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("conflicting definitions")))
+
+	_, err = KubeResources(known, []Transform{
+		{Name: "a", Resources: schema.KubeResources{virtualServices}},
+	})
+	g.Expect(err).NotTo(BeNil())
+}
@@ -0,0 +1,434 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/transformer"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// File is the content of a file declaring transforms.
+//
+//	transforms:
+//	- name: acme.RouteToVirtualService
+//	  input:
+//	    group: networking.acme.io
+//	    version: v1
+//	    kind: Route
+//	    plural: routes
+//	  output: istio/networking/v1alpha3/virtualservices
+//	  mappings:
+//	  - from: spec.hostname
+//	    to: hosts[0]
+//	  - from: spec.service
+//	    to: http[0].route[0].destination.host
+//	  - value: 5s
+//	    to: http[0].timeout
+//
+// Each custom resource is transformed into a resource of the output collection named after the transform and the
+// custom resource (see OutputName), e.g. "acme.routetovirtualservice.reviews", so that it doesn't replace a native
+// resource of the same name. The mappings
+// copy the values at their "from" paths, or their constant values, to their "to" paths. The "from" paths start with
+// "metadata" (name, namespace, labels and annotations) or "spec", and the "to" paths are relative to the output
+// resource content. Both use the field names of the resource YAML.
+type File struct {
+	Transforms []*Spec `json:"transforms"`
+}
+
+// Spec declares a transform.
+type Spec struct {
+	// Name of the transform, must be unique.
+	Name string `json:"name"`
+
+	// Input is the custom resource definition of the transformed resources.
+	Input InputSpec `json:"input"`
+
+	// Output is the name of the collection of the transformed resources, e.g.
+	// "istio/networking/v1alpha3/virtualservices".
+	Output string `json:"output"`
+
+	// Mappings of the fields of the input resources to the fields of the output ones.
+	Mappings []MappingSpec `json:"mappings"`
+}
+
+// InputSpec declares the custom resource definition of the input of a transform.
+type InputSpec struct {
+	Group         string `json:"group"`
+	Version       string `json:"version"`
+	Kind          string `json:"kind"`
+	Plural        string `json:"plural"`
+	ClusterScoped bool   `json:"clusterScoped,omitempty"`
+}
+
+// MappingSpec declares a field mapping.
+type MappingSpec struct {
+	// From is the path of the input field, e.g. "spec.hostname". The mapping is ignored if the field is not set.
+	From string `json:"from,omitempty"`
+
+	// Value is the constant value of the output field, if From is not set.
+	Value interface{} `json:"value,omitempty"`
+
+	// To is the path of the output field, e.g. "http[0].route[0].destination.host".
+	To string `json:"to"`
+}
+
+// Load returns the transforms declared in the given files.
+func Load(files ...string) ([]Transform, error) {
+	var result []Transform
+	names := make(map[string]bool)
+	for _, file := range files {
+		by, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		transforms, err := Parse(by)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transforms file %q: %v", file, err)
+		}
+		for _, t := range transforms {
+			if names[t.Name] {
+				return nil, fmt.Errorf("invalid transforms file %q: duplicate transform %q", file, t.Name)
+			}
+			names[t.Name] = true
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// Parse returns the transforms declared in the given YAML text.
+func Parse(yamlText []byte) ([]Transform, error) {
+	var f File
+	if err := yaml.Unmarshal(yamlText, &f); err != nil {
+		return nil, err
+	}
+
+	var result []Transform
+	var errs error
+	names := make(map[string]bool)
+	for i, s := range f.Transforms {
+		if s == nil {
+			errs = multierror.Append(errs, fmt.Errorf("transform %d: must not be empty", i))
+			continue
+		}
+		if names[s.Name] {
+			errs = multierror.Append(errs, fmt.Errorf("transform %q: duplicate name", s.Name))
+			continue
+		}
+		names[s.Name] = true
+
+		t, err := newTransform(s)
+		if err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, fmt.Sprintf("transform %q:", s.Name)))
+			continue
+		}
+		result = append(result, t)
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+	return result, nil
+}
+
+type mapping struct {
+	from  []segment
+	value interface{}
+	to    []segment
+}
+
+// segment of a field path. The index is -1 if the segment is not a list element.
+type segment struct {
+	field string
+	index int
+}
+
+var segmentRegex = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)(\[([0-9]+)\])?$`)
+
+func newTransform(s *Spec) (Transform, error) {
+	if err := validateName(s.Name); err != nil {
+		return Transform{}, err
+	}
+
+	in, err := NewKubeResource(s.Input.Group, s.Input.Version, s.Input.Kind, s.Input.Plural, s.Input.ClusterScoped)
+	if err != nil {
+		return Transform{}, fmt.Errorf("invalid input: %v", err)
+	}
+
+	out, found := metadata.MustGet().AllCollections().Lookup(s.Output)
+	if !found {
+		return Transform{}, fmt.Errorf("unknown output collection %q", s.Output)
+	}
+
+	if len(s.Mappings) == 0 {
+		return Transform{}, fmt.Errorf("at least one mapping must be set")
+	}
+	var mappings []mapping
+	for _, ms := range s.Mappings {
+		m, err := newMapping(ms)
+		if err != nil {
+			return Transform{}, err
+		}
+		mappings = append(mappings, m)
+	}
+
+	handleFn := func(e event.Event, h event.Handler) {
+		handle(s.Name, out, mappings, e, h)
+	}
+	return Transform{
+		Name:      s.Name,
+		Resources: schema.KubeResources{in},
+		Provider:  transformer.NewSimpleTransformerProvider(in.Collection.Name, out.Name, handleFn),
+	}, nil
+}
+
+func newMapping(s MappingSpec) (mapping, error) {
+	var m mapping
+	var err error
+
+	if m.to, err = parsePath(s.To); err != nil {
+		return mapping{}, fmt.Errorf("invalid mapping to %q: %v", s.To, err)
+	}
+
+	switch {
+	case s.From != "" && s.Value != nil:
+		return mapping{}, fmt.Errorf("invalid mapping to %q: only one of from and value must be set", s.To)
+	case s.From != "":
+		if m.from, err = parsePath(s.From); err != nil {
+			return mapping{}, fmt.Errorf("invalid mapping from %q: %v", s.From, err)
+		}
+		if f := m.from[0].field; (f != "metadata" && f != "spec") || m.from[0].index >= 0 {
+			return mapping{}, fmt.Errorf("invalid mapping from %q: must start with metadata or spec", s.From)
+		}
+	case s.Value != nil:
+		m.value = s.Value
+	default:
+		return mapping{}, fmt.Errorf("invalid mapping to %q: one of from and value must be set", s.To)
+	}
+
+	return m, nil
+}
+
+func parsePath(path string) ([]segment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path must be set")
+	}
+
+	var result []segment
+	for _, p := range strings.Split(path, ".") {
+		match := segmentRegex.FindStringSubmatch(p)
+		if match == nil {
+			return nil, fmt.Errorf("invalid path segment %q", p)
+		}
+		s := segment{field: match[1], index: -1}
+		if match[3] != "" {
+			s.index, _ = strconv.Atoi(match[3])
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func handle(name string, out collection.Spec, mappings []mapping, e event.Event, h event.Handler) {
+	switch e.Kind {
+	case event.Added, event.Updated:
+		r, err := transform(name, out, mappings, e.Entry)
+		if err != nil {
+			scope.Processing.Errorf("Transform %s: unable to transform %v: %v", name, e.Entry.Metadata.Name, err)
+			if e.Kind == event.Added {
+				return
+			}
+			// Do not keep serving the output of a previous version of the resource
+			h.Handle(event.DeleteFor(out.Name, OutputName(name, e.Entry.Metadata.Name), e.Entry.Metadata.Version))
+			return
+		}
+		e = e.WithSource(out.Name)
+		e.Entry = r
+		h.Handle(e)
+
+	case event.Deleted:
+		h.Handle(event.DeleteFor(out.Name, OutputName(name, e.Entry.Metadata.Name), e.Entry.Metadata.Version))
+
+	default:
+		panic(fmt.Errorf("custom.handle: unexpected event: %v", e))
+	}
+}
+
+// transform returns the output resource of the input one.
+func transform(transformName string, out collection.Spec, mappings []mapping, r *resource.Entry) (*resource.Entry, error) {
+	in, err := inputObject(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj interface{} = map[string]interface{}{}
+	for _, m := range mappings {
+		v := m.value
+		if m.from != nil {
+			var found bool
+			if v, found = get(in, m.from); !found {
+				continue
+			}
+		}
+		// The constant values are shared by all the resources, and the next mappings may update the value
+		if obj, err = set(obj, m.to, deepCopy(v)); err != nil {
+			return nil, err
+		}
+	}
+
+	by, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	item := out.NewProtoInstance()
+	if err = jsonpb.UnmarshalString(string(by), item); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", out.Name, err)
+	}
+
+	metadata := r.Metadata.Clone()
+	metadata.Name = OutputName(transformName, r.Metadata.Name)
+	return &resource.Entry{
+		Metadata: metadata,
+		Item:     item,
+		Origin:   r.Origin,
+	}, nil
+}
+
+// inputObject returns the JSON-style form of the metadata and spec of the input resource.
+func inputObject(r *resource.Entry) (map[string]interface{}, error) {
+	var spec interface{} = map[string]interface{}{}
+	if r.Item != nil {
+		s, err := (&jsonpb.Marshaler{}).MarshalToString(r.Item)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(s), &spec); err != nil {
+			return nil, err
+		}
+	}
+
+	ns, name := r.Metadata.Name.InterpretAsNamespaceAndName()
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   ns,
+			"labels":      stringMap(r.Metadata.Labels),
+			"annotations": stringMap(r.Metadata.Annotations),
+		},
+		"spec": spec,
+	}, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			result[k] = deepCopy(e)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(t))
+		for i, e := range t {
+			result[i] = deepCopy(e)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func stringMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// get returns the value at the path in the object.
+func get(obj interface{}, path []segment) (interface{}, bool) {
+	for _, s := range path {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if obj, ok = m[s.field]; !ok {
+			return nil, false
+		}
+		if s.index >= 0 {
+			l, ok := obj.([]interface{})
+			if !ok || s.index >= len(l) {
+				return nil, false
+			}
+			obj = l[s.index]
+		}
+	}
+	return obj, true
+}
+
+// set sets the value at the path in the object, creating the missing objects and list elements, and returns the
+// updated object.
+func set(obj interface{}, path []segment, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	s := path[0]
+
+	if obj == nil {
+		obj = map[string]interface{}{}
+	}
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to set the field %q of a %T", s.field, obj)
+	}
+
+	var err error
+	if s.index < 0 {
+		m[s.field], err = set(m[s.field], path[1:], v)
+		return m, err
+	}
+
+	var l []interface{}
+	if existing, found := m[s.field]; found {
+		if l, ok = existing.([]interface{}); !ok {
+			return nil, fmt.Errorf("unable to set the element %d of the %T field %q", s.index, existing, s.field)
+		}
+	}
+	for len(l) <= s.index {
+		l = append(l, nil)
+	}
+	if l[s.index], err = set(l[s.index], path[1:], v); err != nil {
+		return nil, err
+	}
+	m[s.field] = l
+	return m, nil
+}
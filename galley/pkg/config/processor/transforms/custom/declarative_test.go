@@ -0,0 +1,368 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/testing/fixtures"
+)
+
+var routes = collection.NewName("k8s/networking.acme.io/v1/routes")
+
+func newRoute(t *testing.T, name, version, spec string) *resource.Entry {
+	t.Helper()
+
+	s := &types.Struct{}
+	if err := jsonpb.UnmarshalString(spec, s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return &resource.Entry{
+		Metadata: resource.Metadata{
+			Name:    resource.NewName("ns", name),
+			Version: resource.Version(version),
+			Labels:  map[string]string{"app": "reviews"},
+		},
+		Item: s,
+	}
+}
+
+func setup(g *GomegaWithT, yamlText string) (event.Transformer, *fixtures.Source, *fixtures.Accumulator) {
+	transforms, err := Parse([]byte(yamlText))
+	g.Expect(err).To(BeNil())
+	g.Expect(transforms).To(HaveLen(1))
+
+	xform := Providers(transforms).Create(processing.ProcessorOptions{})[0]
+	src := &fixtures.Source{}
+	acc := &fixtures.Accumulator{}
+	src.Dispatch(xform)
+	xform.DispatchFor(xform.Outputs()[0], acc)
+
+	return xform, src, acc
+}
+
+const routeTransform = `
+transforms:
+- name: acme.RouteToVirtualService
+  input:
+    group: networking.acme.io
+    version: v1
+    kind: Route
+    plural: routes
+  output: istio/networking/v1alpha3/virtualservices
+  mappings:
+  - from: spec.hostname
+    to: hosts[0]
+  - from: metadata.labels.app
+    to: hosts[1]
+  - from: spec.service
+    to: http[0].route[0].destination.host
+  - from: spec.port
+    to: http[0].route[0].destination.port.number
+  - value: 5s
+    to: http[0].timeout
+`
+
+func TestLoad(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	transforms, err := Load("testdata/transforms.yaml")
+	g.Expect(err).To(BeNil())
+	g.Expect(transforms).To(HaveLen(2))
+
+	g.Expect(transforms[0].Name).To(Equal("acme.RouteToVirtualService"))
+	g.Expect(transforms[0].Resources).To(HaveLen(1))
+	g.Expect(transforms[0].Resources[0].Collection.Name).To(Equal(routes))
+	g.Expect(transforms[0].Provider.Inputs()).To(Equal(collection.Names{routes}))
+	g.Expect(transforms[0].Provider.Outputs()).To(Equal(collection.Names{metadata.IstioNetworkingV1Alpha3Virtualservices}))
+
+	g.Expect(transforms[1].Name).To(Equal("acme.PoolToDestinationRule"))
+	g.Expect(transforms[1].Provider.Outputs()).To(Equal(collection.Names{metadata.IstioNetworkingV1Alpha3Destinationrules}))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := Load("testdata/missing.yaml")
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = Load("testdata/transforms.yaml", "testdata/transforms.yaml")
+	g.Expect(err).To(MatchError(ContainSubstring("duplicate transform")))
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "no name",
+			yaml: `
+transforms:
+- input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{value: a, to: "hosts[0]"}]`,
+			err: "name must be set",
+		},
+		{
+			name: "invalid name",
+			yaml: `
+transforms:
+- name: acme/Route
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{value: a, to: "hosts[0]"}]`,
+			err: "invalid transform name",
+		},
+		{
+			name: "duplicate",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{value: a, to: "hosts[0]"}]
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{value: a, to: "hosts[0]"}]`,
+			err: "duplicate name",
+		},
+		{
+			name: "invalid input",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{value: a, to: "hosts[0]"}]`,
+			err: "invalid input",
+		},
+		{
+			name: "unknown output",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/unknown
+  mappings: [{value: a, to: "hosts[0]"}]`,
+			err: "unknown output collection",
+		},
+		{
+			name: "no mappings",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices`,
+			err: "at least one mapping",
+		},
+		{
+			name: "invalid to",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{value: a, to: "hosts[a]"}]`,
+			err: "invalid path segment",
+		},
+		{
+			name: "invalid from",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{from: status.host, to: "hosts[0]"}]`,
+			err: "must start with metadata or spec",
+		},
+		{
+			name: "from and value",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{from: spec.host, value: a, to: "hosts[0]"}]`,
+			err: "only one of from and value",
+		},
+		{
+			name: "no from or value",
+			yaml: `
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/virtualservices
+  mappings: [{to: "hosts[0]"}]`,
+			err: "one of from and value must be set",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			g := NewGomegaWithT(tt)
+
+			_, err := Parse([]byte(c.yaml))
+			g.Expect(err).To(MatchError(ContainSubstring(c.err)))
+		})
+	}
+}
+
+func TestTransform_AddUpdateDelete(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	xform, src, acc := setup(g, routeTransform)
+	xform.Start()
+	defer xform.Stop()
+
+	r1 := newRoute(t, "reviews", "v1", `{"hostname": "reviews.acme.io", "service": "reviews", "port": 9080}`)
+	r2 := newRoute(t, "reviews", "v2", `{"hostname": "reviews.acme.io", "service": "reviews-v2"}`)
+
+	src.Handlers.Handle(event.FullSyncFor(routes))
+	src.Handlers.Handle(event.AddFor(routes, r1))
+	src.Handlers.Handle(event.UpdateFor(routes, r2))
+	src.Handlers.Handle(event.DeleteForResource(routes, r2))
+
+	// The outputs are named after the transform, so they don't replace the native VirtualService "ns/reviews"
+	outputName := resource.NewName("ns", "acme.routetovirtualservice.reviews")
+	vs1 := &resource.Entry{
+		Metadata: r1.Metadata.Clone(),
+		Item: &v1alpha3.VirtualService{
+			Hosts: []string{"reviews.acme.io", "reviews"},
+			Http: []*v1alpha3.HTTPRoute{{
+				Route: []*v1alpha3.HTTPRouteDestination{{
+					Destination: &v1alpha3.Destination{
+						Host: "reviews",
+						Port: &v1alpha3.PortSelector{Number: 9080},
+					},
+				}},
+				Timeout: types.DurationProto(5000000000),
+			}},
+		},
+	}
+	vs1.Metadata.Name = outputName
+	vs2 := &resource.Entry{
+		Metadata: r2.Metadata.Clone(),
+		Item: &v1alpha3.VirtualService{
+			Hosts: []string{"reviews.acme.io", "reviews"},
+			Http: []*v1alpha3.HTTPRoute{{
+				Route: []*v1alpha3.HTTPRouteDestination{{
+					Destination: &v1alpha3.Destination{
+						Host: "reviews-v2",
+					},
+				}},
+				Timeout: types.DurationProto(5000000000),
+			}},
+		},
+	}
+	vs2.Metadata.Name = outputName
+
+	g.Eventually(acc.Events).Should(ConsistOf(
+		event.FullSyncFor(metadata.IstioNetworkingV1Alpha3Virtualservices),
+		event.AddFor(metadata.IstioNetworkingV1Alpha3Virtualservices, vs1),
+		event.UpdateFor(metadata.IstioNetworkingV1Alpha3Virtualservices, vs2),
+		event.DeleteFor(metadata.IstioNetworkingV1Alpha3Virtualservices, outputName, r2.Metadata.Version),
+	))
+}
+
+func TestTransform_InvalidOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	xform, src, acc := setup(g, routeTransform)
+	xform.Start()
+	defer xform.Stop()
+
+	// The port is not a number
+	r1 := newRoute(t, "reviews", "v1", `{"hostname": "reviews.acme.io", "port": "http"}`)
+	r2 := newRoute(t, "reviews", "v2", `{"hostname": "reviews.acme.io", "port": "http"}`)
+
+	src.Handlers.Handle(event.FullSyncFor(routes))
+	src.Handlers.Handle(event.AddFor(routes, r1))
+	src.Handlers.Handle(event.UpdateFor(routes, r2))
+
+	// The invalid resource is not added, and the output of its previous version is deleted
+	g.Eventually(acc.Events).Should(ConsistOf(
+		event.FullSyncFor(metadata.IstioNetworkingV1Alpha3Virtualservices),
+		event.DeleteFor(metadata.IstioNetworkingV1Alpha3Virtualservices,
+			resource.NewName("ns", "acme.routetovirtualservice.reviews"), r2.Metadata.Version),
+	))
+}
+
+func TestSet_Conflict(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	to, err := parsePath("hosts[0]")
+	g.Expect(err).To(BeNil())
+	conflicting, err := parsePath("hosts.name")
+	g.Expect(err).To(BeNil())
+
+	obj, err := set(nil, to, "a")
+	g.Expect(err).To(BeNil())
+	g.Expect(obj).To(Equal(map[string]interface{}{"hosts": []interface{}{"a"}}))
+
+	_, err = set(obj, conflicting, "b")
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestConstantValuesAreNotShared(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	transforms, err := Parse([]byte(`
+transforms:
+- name: a
+  input: {group: acme.io, version: v1, kind: Route, plural: routes}
+  output: istio/networking/v1alpha3/destinationrules
+  mappings:
+  - value: {loadBalancer: {simple: ROUND_ROBIN}}
+    to: trafficPolicy
+  - from: spec.service
+    to: host
+  - from: spec.maxConnections
+    to: trafficPolicy.connectionPool.tcp.maxConnections
+`))
+	g.Expect(err).To(BeNil())
+
+	xform := Providers(transforms).Create(processing.ProcessorOptions{})[0]
+	src := &fixtures.Source{}
+	acc := &fixtures.Accumulator{}
+	src.Dispatch(xform)
+	xform.DispatchFor(xform.Outputs()[0], acc)
+	xform.Start()
+	defer xform.Stop()
+
+	src.Handlers.Handle(event.FullSyncFor(collection.NewName("k8s/acme.io/v1/routes")))
+	src.Handlers.Handle(event.AddFor(collection.NewName("k8s/acme.io/v1/routes"),
+		newRoute(t, "a", "v1", `{"service": "a", "maxConnections": 10}`)))
+	src.Handlers.Handle(event.AddFor(collection.NewName("k8s/acme.io/v1/routes"),
+		newRoute(t, "b", "v1", `{"service": "b"}`)))
+
+	g.Eventually(acc.Events).Should(HaveLen(3))
+	dr := acc.Events()[2].Entry.Item.(*v1alpha3.DestinationRule)
+	g.Expect(dr.Host).To(Equal("b"))
+	g.Expect(dr.TrafficPolicy.ConnectionPool).To(BeNil())
+}
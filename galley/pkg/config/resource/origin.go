@@ -19,4 +19,13 @@ type Origin interface {
 	FriendlyName() string
 
 	Namespace() string
+
+	// Reference returns the reference of the resource in its source, e.g. its position in a file. It returns nil if
+	// the source doesn't have such a reference.
+	Reference() Reference
+}
+
+// Reference provides more information about the location of a resource in its source.
+type Reference interface {
+	String() string
 }
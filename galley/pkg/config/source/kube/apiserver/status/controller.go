@@ -15,10 +15,14 @@
 package status
 
 import (
+	"context"
 	"sync"
+	"time"
 
+	"golang.org/x/time/rate"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/meta/schema"
@@ -28,6 +32,18 @@ import (
 	"istio.io/istio/galley/pkg/config/source/kube/rt"
 )
 
+const (
+	// defaultQPS is the default rate of the writes of the controller to the API Server.
+	defaultQPS = 10
+
+	// defaultBurst is the default number of writes of the controller allowed to exceed the rate.
+	defaultBurst = 20
+
+	// defaultEventRefreshPeriod is the default period of the refresh of the events. The API Server deletes the
+	// events after their TTL, one hour by default, the events are written again before that.
+	defaultEventRefreshPeriod = 20 * time.Minute
+)
+
 // Controller is the interface for a status controller. It is mainly used to separate implementation from
 // interface, so that code can be tested separately.
 type Controller interface {
@@ -38,6 +54,7 @@ type Controller interface {
 }
 
 // ControllerImpl keeps track of status information for a given K8s style collection and continuously reconciles.
+// Built-in resources have no status field for the messages, so the messages are reported as events instead.
 type ControllerImpl struct {
 	// Protects the top-level start/stop state of the controller
 	mu sync.Mutex
@@ -48,8 +65,26 @@ type ControllerImpl struct {
 	// Wait group for synchronizing the exit of the background go routine.
 	wg sync.WaitGroup
 
+	// Cancels the pending writes of the background go routine.
+	cancel context.CancelFunc
+
 	// Subfield of status that this controller manages
 	subfield string
+
+	// Limits the rate of the status updates and events written to the API Server.
+	limiter *rate.Limiter
+
+	// Period of the refresh of the events reported for built-in resources.
+	eventRefreshPeriod time.Duration
+}
+
+// target is a resource type whose status is updated by the controller.
+type target struct {
+	resource schema.KubeResource
+	iface    dynamic.NamespaceableResourceInterface
+
+	// builtIn resources have no status field for the messages, they are reported as events instead.
+	builtIn bool
 }
 
 var _ Controller = &ControllerImpl{}
@@ -57,7 +92,9 @@ var _ Controller = &ControllerImpl{}
 // NewController returns a new instance of controller.
 func NewController(subfield string) *ControllerImpl {
 	return &ControllerImpl{
-		subfield: subfield,
+		subfield:           subfield,
+		limiter:            rate.NewLimiter(defaultQPS, defaultBurst),
+		eventRefreshPeriod: defaultEventRefreshPeriod,
 	}
 }
 
@@ -71,7 +108,8 @@ func (c *ControllerImpl) Start(p *rt.Provider, resources []schema.KubeResource)
 	}
 	c.state = newState()
 
-	ifaces := make(map[collection.Name]dynamic.NamespaceableResourceInterface)
+	targets := make(map[collection.Name]target)
+	builtIns := make(map[collection.Name]struct{})
 	for _, r := range resources {
 		if r.Disabled {
 			continue
@@ -81,11 +119,33 @@ func (c *ControllerImpl) Start(p *rt.Provider, resources []schema.KubeResource)
 		if err != nil {
 			scope.Source.Errorf("Unable to create a dynamic resource interface for resource %v", r.CanonicalResourceName())
 		}
-		ifaces[r.Collection.Name] = iface
+		builtIn := p.GetAdapter(r).IsBuiltIn()
+		if builtIn {
+			builtIns[r.Collection.Name] = struct{}{}
+		}
+		targets[r.Collection.Name] = target{resource: r, iface: iface, builtIn: builtIn}
+	}
+
+	var events corev1.EventsGetter
+	if len(builtIns) > 0 {
+		cl, err := p.KubeClient()
+		if err != nil {
+			scope.Source.Errorf("Unable to create a client for the events of built-in resources: %v", err)
+		} else {
+			events = cl.CoreV1()
+		}
 	}
 
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+
 	c.wg.Add(1)
-	go run(c.state, c.subfield, ifaces, &c.wg)
+	go run(ctx, c.state, c.subfield, targets, events, c.eventRefreshPeriod, c.limiter, &c.wg)
+
+	if events != nil {
+		c.wg.Add(1)
+		go refreshEvents(ctx, c.state, builtIns, c.eventRefreshPeriod, &c.wg)
+	}
 }
 
 // Stop the controller
@@ -94,6 +154,7 @@ func (c *ControllerImpl) Stop() {
 	defer c.mu.Unlock()
 	if c.state != nil {
 		c.state.quiesceWork()
+		c.cancel()
 		c.wg.Wait()
 		c.state = nil
 	}
@@ -138,7 +199,26 @@ func (c *ControllerImpl) Report(messages diag.Messages) {
 	c.state.applyMessages(msgs)
 }
 
-func run(state *state, subfield string, ifaces map[collection.Name]dynamic.NamespaceableResourceInterface, wg *sync.WaitGroup) {
+// refreshEvents periodically reconciles the events of the built-in resources, so that the events of the messages
+// still reported are written again before the API Server deletes them.
+func refreshEvents(ctx context.Context, state *state, cols map[collection.Name]struct{}, period time.Duration,
+	wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			state.resetObserved(cols)
+		}
+	}
+}
+
+func run(ctx context.Context, state *state, subfield string, targets map[collection.Name]target,
+	events corev1.EventsGetter, eventRefreshPeriod time.Duration, limiter *rate.Limiter, wg *sync.WaitGroup) {
 mainloop:
 	for {
 		st, ok := state.dequeueWork()
@@ -146,14 +226,19 @@ mainloop:
 			break mainloop
 		}
 
-		iface := ifaces[st.key.col]
-		if iface == nil {
+		t := targets[st.key.col]
+		if t.iface == nil || (t.builtIn && events == nil) {
 			scope.Source.Errorf("No updater available for diagnostic message(s) for '%v/%v'.", st.key.col, st.key.res)
 			continue
 		}
 
+		// Throttle the writes to the API Server. This only fails when the controller is stopped.
+		if err := limiter.Wait(ctx); err != nil {
+			break mainloop
+		}
+
 		ns, n := st.key.res.InterpretAsNamespaceAndName()
-		u, err := iface.Namespace(ns).Get(n, metav1.GetOptions{ResourceVersion: string(st.observedVersion)})
+		u, err := t.iface.Namespace(ns).Get(n, metav1.GetOptions{ResourceVersion: string(st.observedVersion)})
 		if err != nil {
 			scope.Source.Errorf("Unable to read the resource while trying to update status: %v(%v): %v",
 				st.key.col, st.key.res, err)
@@ -168,6 +253,17 @@ mainloop:
 			continue mainloop
 		}
 
+		if t.builtIn {
+			if err = reconcileEvents(events, t.resource, subfield, u, st.desiredStatus, eventRefreshPeriod); err != nil {
+				scope.Source.Errorf("Unable to update events of Resource %v(%v): %v", st.key.col, st.key.res, err)
+				continue mainloop
+			}
+			// Built-in resources don't report their status through watch events, so the events written are
+			// recorded as observed. This stops repeated writes of the same messages until the next refresh.
+			state.setObserved(st.key.col, st.key.res, resource.Version(u.GetResourceVersion()), st.desiredStatus)
+			continue mainloop
+		}
+
 		// Get the map of status objects. If it doesn't already exist, create it.
 		statusObj, ok := u.Object["status"]
 		if !ok {
@@ -191,7 +287,7 @@ mainloop:
 			}
 		}
 
-		_, err = iface.Namespace(ns).UpdateStatus(u, metav1.UpdateOptions{})
+		_, err = t.iface.Namespace(ns).UpdateStatus(u, metav1.UpdateOptions{})
 		if err != nil {
 			// TODO: Reinsert work? It probably makes sense to reinsert (with a delay), in case of a transient failure.
 			scope.Source.Errorf("Unable to update status of Resource %v(%v): %v", st.key.col, st.key.res, err)
@@ -17,16 +17,23 @@ package status
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/schema"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
 	"istio.io/istio/galley/pkg/config/resource"
 	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/basicmeta"
@@ -356,6 +363,223 @@ func TestBasicReconcilation_VersionMismatch(t *testing.T) {
 	g.Consistently(cl.Actions).Should(HaveLen(1))
 }
 
+func TestEvents_BuiltIn(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewController(subfield)
+	k, _, kcl := setupBuiltInClient()
+
+	e := resource.Entry{
+		Origin: &rt.Origin{
+			Collection: serviceCollection,
+			Name:       resource.NewName("bar", "foo"),
+			Version:    resource.Version("v1"),
+		},
+	}
+
+	c.Start(rt.NewProvider(k, 0), []schema.KubeResource{serviceResource})
+	defer c.Stop()
+
+	m := msg.NewInternalError(&e, "foo")
+	c.Report(diag.Messages{m})
+
+	listEvents := func() []v1.Event {
+		l, err := kcl.CoreV1().Events("bar").List(metav1.ListOptions{})
+		g.Expect(err).To(BeNil())
+		return l.Items
+	}
+	g.Eventually(listEvents).Should(HaveLen(1))
+
+	ev := listEvents()[0]
+	g.Expect(ev.Labels).To(HaveKeyWithValue(eventLabel, subfield))
+	g.Expect(ev.Reason).To(Equal(m.Type.Code()))
+	g.Expect(ev.Type).To(Equal(v1.EventTypeWarning))
+	g.Expect(ev.Message).To(Equal(m.Unstructured(false)["message"]))
+	g.Expect(ev.InvolvedObject.Kind).To(Equal("Service"))
+	g.Expect(ev.InvolvedObject.APIVersion).To(Equal("v1"))
+	g.Expect(ev.InvolvedObject.Name).To(Equal("foo"))
+	g.Expect(ev.InvolvedObject.Namespace).To(Equal("bar"))
+
+	// Reporting the same messages again doesn't write anything.
+	writes := countEventWrites(kcl)
+	c.Report(diag.Messages{m})
+	g.Consistently(func() int { return countEventWrites(kcl) }).Should(Equal(writes))
+
+	// The event is deleted once the message is cleared.
+	c.Report(diag.Messages{})
+	g.Eventually(listEvents).Should(BeEmpty())
+}
+
+func TestEvents_KeepsOtherEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewController(subfield)
+	k, _, kcl := setupBuiltInClient()
+
+	other := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo.other",
+			Namespace: "bar",
+			Labels:    map[string]string{eventLabel: "otherMessages"},
+		},
+		InvolvedObject: v1.ObjectReference{Kind: "Service", Name: "foo", Namespace: "bar"},
+	}
+	_, err := kcl.CoreV1().Events("bar").Create(other)
+	g.Expect(err).To(BeNil())
+
+	e := resource.Entry{
+		Origin: &rt.Origin{
+			Collection: serviceCollection,
+			Name:       resource.NewName("bar", "foo"),
+			Version:    resource.Version("v1"),
+		},
+	}
+
+	c.Start(rt.NewProvider(k, 0), []schema.KubeResource{serviceResource})
+	defer c.Stop()
+
+	c.Report(diag.Messages{msg.NewInternalError(&e, "foo")})
+	g.Eventually(func() int { return countEventWrites(kcl) }).Should(Equal(2))
+	c.Report(diag.Messages{})
+	g.Eventually(func() int { return countEventWrites(kcl) }).Should(Equal(3))
+	l, err := kcl.CoreV1().Events("bar").List(metav1.ListOptions{})
+	g.Expect(err).To(BeNil())
+	g.Expect(l.Items).To(ConsistOf(*other))
+}
+
+func TestEvents_Refresh(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewController(subfield)
+	c.eventRefreshPeriod = 100 * time.Millisecond
+	k, _, kcl := setupBuiltInClient()
+
+	e := resource.Entry{
+		Origin: &rt.Origin{
+			Collection: serviceCollection,
+			Name:       resource.NewName("bar", "foo"),
+			Version:    resource.Version("v1"),
+		},
+	}
+
+	c.Start(rt.NewProvider(k, 0), []schema.KubeResource{serviceResource})
+	defer c.Stop()
+
+	c.Report(diag.Messages{msg.NewInternalError(&e, "foo")})
+
+	getCount := func() int32 {
+		l, err := kcl.CoreV1().Events("bar").List(metav1.ListOptions{})
+		g.Expect(err).To(BeNil())
+		if len(l.Items) != 1 {
+			return 0
+		}
+		return l.Items[0].Count
+	}
+	g.Eventually(getCount).Should(Equal(int32(1)))
+
+	// The event is written again with an incremented count while the message is reported, so that it doesn't
+	// expire.
+	g.Eventually(getCount).Should(BeNumerically(">", 1))
+}
+
+func TestEvents_ListsOnlyResourceEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewController(subfield)
+	k, _, kcl := setupBuiltInClient()
+
+	e := resource.Entry{
+		Origin: &rt.Origin{
+			Collection: serviceCollection,
+			Name:       resource.NewName("bar", "foo"),
+			Version:    resource.Version("v1"),
+		},
+	}
+
+	c.Start(rt.NewProvider(k, 0), []schema.KubeResource{serviceResource})
+	defer c.Stop()
+
+	c.Report(diag.Messages{msg.NewInternalError(&e, "foo")})
+	g.Eventually(func() int { return countEventWrites(kcl) }).Should(Equal(1))
+
+	var lists []k8stesting.ListActionImpl
+	for _, a := range kcl.Actions() {
+		if l, ok := a.(k8stesting.ListActionImpl); ok && l.GetResource().Resource == "events" {
+			lists = append(lists, l)
+		}
+	}
+	g.Expect(lists).NotTo(BeEmpty())
+	for _, l := range lists {
+		restrictions := l.GetListRestrictions()
+		g.Expect(restrictions.Labels.String()).To(Equal(eventLabel + "=" + subfield))
+		g.Expect(restrictions.Fields.String()).To(Equal(
+			"involvedObject.kind=Service,involvedObject.name=foo,involvedObject.namespace=bar"))
+	}
+}
+
+func TestEventName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n1 := eventName("foo", "Service", "IST0001", "m1")
+	g.Expect(n1).To(HavePrefix("foo."))
+	g.Expect(eventName("foo", "Service", "IST0001", "m1")).To(Equal(n1))
+	g.Expect(eventName("foo", "Service", "IST0001", "m2")).NotTo(Equal(n1))
+	g.Expect(eventName("foo", "Pod", "IST0001", "m1")).NotTo(Equal(n1))
+}
+
+var (
+	serviceCollection = collection.NewName("k8s/core/v1/services")
+
+	serviceResource = schema.KubeResource{
+		Collection: collection.Spec{Name: serviceCollection},
+		Version:    "v1",
+		Kind:       "Service",
+		Plural:     "services",
+	}
+)
+
+// builtInKube returns a fake kubernetes client, as the mock one doesn't support events.
+type builtInKube struct {
+	*mock.Kube
+	client kubernetes.Interface
+}
+
+func (k *builtInKube) KubeClient() (kubernetes.Interface, error) {
+	return k.client, nil
+}
+
+func setupBuiltInClient() (*builtInKube, *fake.FakeDynamicClient, *kubeFake.Clientset) {
+	k, cl := setupClient()
+
+	svc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":            "foo",
+				"namespace":       "bar",
+				"resourceVersion": "v1",
+			},
+		},
+	}
+	cl.ReactionChain = nil
+	cl.AddReactor("get", "services", func(action k8stesting.Action) (
+		handled bool, ret k8sRuntime.Object, err error) {
+		return true, svc, nil
+	})
+
+	kcl := kubeFake.NewSimpleClientset()
+	return &builtInKube{Kube: k, client: kcl}, cl, kcl
+}
+
+func countEventWrites(cl *kubeFake.Clientset) int {
+	n := 0
+	for _, a := range cl.Actions() {
+		if a.GetVerb() == "create" || a.GetVerb() == "update" || a.GetVerb() == "delete" {
+			n++
+		}
+	}
+	return n
+}
+
 func setupClient() (*mock.Kube, *fake.FakeDynamicClient) {
 	k := mock.NewKube()
 
@@ -0,0 +1,169 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/meta/schema"
+)
+
+const (
+	// eventLabel is the label of the events created by the controller. Its value is the subfield of the controller,
+	// so that multiple controllers don't step on each other's events.
+	eventLabel = "galley.istio.io/analysis"
+
+	// eventComponent is the reported source component of the events.
+	eventComponent = "galley"
+
+	// eventNamespace is the namespace of the events of cluster-scoped resources.
+	eventNamespace = metav1.NamespaceDefault
+)
+
+// reconcileEvents makes the events of the given built-in resource match the desired status, a list of messages as
+// produced by toStatusValue. Built-in resources have no status field that could host the messages, so each message is
+// reported as an event instead. Event names are derived from the message, so a message is reported only once for a
+// resource, and the events of the messages that are no longer desired are deleted. The events of the messages still
+// desired that were last written before the refresh period are written again, incrementing their count, so that
+// the API Server doesn't delete them once their TTL expires.
+func reconcileEvents(events corev1.EventsGetter, r schema.KubeResource, subfield string,
+	u *unstructured.Unstructured, desired interface{}, refreshPeriod time.Duration) error {
+
+	ns := u.GetNamespace()
+	if ns == "" {
+		ns = eventNamespace
+	}
+
+	desiredEvents := make(map[string]*v1.Event)
+	msgs, _ := desired.([]interface{})
+	for _, m := range msgs {
+		if m, ok := m.(map[string]interface{}); ok {
+			e := newEvent(r, subfield, u, ns, m)
+			desiredEvents[e.Name] = e
+		}
+	}
+
+	// Only list the events of the resource, rather than all the events of the controller in the namespace.
+	existing, err := events.Events(ns).List(eventListOptions(r, subfield, u))
+	if err != nil {
+		return err
+	}
+
+	for _, e := range existing.Items {
+		// The field selector is not honored by every client, e.g. the fake one.
+		if e.InvolvedObject.Kind != r.Kind || e.InvolvedObject.Name != u.GetName() {
+			continue
+		}
+		if _, ok := desiredEvents[e.Name]; ok {
+			// The message is already reported.
+			delete(desiredEvents, e.Name)
+			if time.Since(e.LastTimestamp.Time) < refreshPeriod {
+				continue
+			}
+			e.Count++
+			e.LastTimestamp = metav1.Now()
+			if _, err = events.Events(ns).Update(&e); err != nil && !kubeErrors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		if err = events.Events(ns).Delete(e.Name, &metav1.DeleteOptions{}); err != nil && !kubeErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for _, e := range desiredEvents {
+		if _, err = events.Events(ns).Create(e); err != nil && !kubeErrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eventListOptions selects the events of the controller subfield whose involved object is the given resource.
+func eventListOptions(r schema.KubeResource, subfield string, u *unstructured.Unstructured) metav1.ListOptions {
+	involved := fields.Set{
+		"involvedObject.kind": r.Kind,
+		"involvedObject.name": u.GetName(),
+	}
+	if u.GetNamespace() != "" {
+		involved["involvedObject.namespace"] = u.GetNamespace()
+	}
+	return metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{eventLabel: subfield}).String(),
+		FieldSelector: fields.SelectorFromSet(involved).String(),
+	}
+}
+
+func newEvent(r schema.KubeResource, subfield string, u *unstructured.Unstructured, ns string,
+	m map[string]interface{}) *v1.Event {
+
+	code, _ := m["code"].(string)
+	level, _ := m["level"].(string)
+	message, _ := m["message"].(string)
+
+	eventType := v1.EventTypeWarning
+	if level == diag.Info.String() {
+		eventType = v1.EventTypeNormal
+	}
+
+	apiVersion := r.Version
+	if r.Group != "" {
+		apiVersion = r.Group + "/" + r.Version
+	}
+
+	now := metav1.Now()
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      eventName(u.GetName(), r.Kind, code, message),
+			Namespace: ns,
+			Labels:    map[string]string{eventLabel: subfield},
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion:      apiVersion,
+			Kind:            r.Kind,
+			Namespace:       u.GetNamespace(),
+			Name:            u.GetName(),
+			UID:             u.GetUID(),
+			ResourceVersion: u.GetResourceVersion(),
+		},
+		Reason:         code,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: eventComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+}
+
+// eventName returns a stable name for the event of a message about a resource.
+func eventName(name, kind, code, message string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(kind + "/" + code + "/" + message))
+	return fmt.Sprintf("%s.%x", name, h.Sum64())
+}
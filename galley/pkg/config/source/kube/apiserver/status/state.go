@@ -118,6 +118,21 @@ func (s *state) setObserved(col collection.Name, res resource.Name, version reso
 	s.mu.Unlock()
 }
 
+// resetObserved forgets the last observed status of the resources of the given collections, so that their desired
+// status is reconciled again.
+func (s *state) resetObserved(cols map[collection.Name]struct{}) {
+	s.mu.Lock()
+	for k, st := range s.states {
+		if _, ok := cols[k.col]; !ok || st.desiredStatus == nil {
+			continue
+		}
+		if st.setObserved(st.observedVersion, nil) && s.reconcile {
+			s.enqueueWork(st)
+		}
+	}
+	s.mu.Unlock()
+}
+
 // Apply the given set of messages to their target resources.
 func (s *state) applyMessages(messages Messages) {
 	s.mu.Lock()
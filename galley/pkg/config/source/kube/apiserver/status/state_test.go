@@ -23,6 +23,7 @@ import (
 
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
 	"istio.io/istio/galley/pkg/config/resource"
 	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/data"
@@ -288,3 +289,38 @@ func TestState_ClearMessages_AgainstAppliedEmptyState(t *testing.T) {
 
 	g.Expect(s.hasWork()).To(BeFalse())
 }
+
+func TestState_ResetObserved(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newState()
+
+	res := *data.EntryN1I1V1
+	res.Origin = &rt.Origin{
+		Collection: data.Collection1,
+		Kind:       "k1",
+		Name:       res.Metadata.Name,
+		Version:    res.Metadata.Version,
+	}
+
+	ms := msg.NewInternalError(&res, "t")
+	msgs := NewMessageSet()
+	msgs.Add(res.Origin.(*rt.Origin), ms)
+	s.applyMessages(msgs)
+
+	st, ok := s.dequeueWork()
+	g.Expect(ok).To(BeTrue())
+	s.setObserved(data.Collection1, res.Metadata.Name, res.Metadata.Version, st.desiredStatus)
+	g.Expect(s.hasWork()).To(BeFalse())
+
+	// Other collections are not reconciled again.
+	s.resetObserved(map[collection.Name]struct{}{data.Collection2: {}})
+	g.Expect(s.hasWork()).To(BeFalse())
+
+	s.resetObserved(map[collection.Name]struct{}{data.Collection1: {}})
+	g.Expect(s.hasWork()).To(BeTrue())
+	st, ok = s.dequeueWork()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(st.observedStatus).To(BeNil())
+	g.Expect(st.desiredStatus).To(Equal(toStatusValue(diag.Messages{ms})))
+}
@@ -196,7 +196,9 @@ func (s *KubeSource) RemoveContent(name string) {
 func (s *KubeSource) parseContent(r schema.KubeResources, name, yamlText string) ([]kubeResource, error) {
 	var resources []kubeResource
 	var errs error
-	for i, chunk := range kubeyaml.Split([]byte(yamlText)) {
+	chunks, lines := kubeyaml.SplitWithLines([]byte(yamlText))
+	for i, chunk := range chunks {
+		pos := chunkPosition(name, chunk, lines[i])
 		chunk = bytes.TrimSpace(chunk)
 		r, err := s.parseChunk(r, chunk)
 		if err != nil {
@@ -206,11 +208,26 @@ func (s *KubeSource) parseContent(r schema.KubeResources, name, yamlText string)
 			errs = multierror.Append(errs, e)
 			continue
 		}
+		r.entry.Origin.(*rt.Origin).Ref = pos
 		resources = append(resources, r)
 	}
 	return resources, errs
 }
 
+// chunkPosition returns the position of the first non-space character of the chunk starting at the given line. The
+// position is the one of the whole resource, the positions of its fields are not recorded.
+func chunkPosition(name string, chunk []byte, line int) *rt.Position {
+	leading := chunk[:len(chunk)-len(bytes.TrimLeft(chunk, " \t\r\n"))]
+	column := 1
+	if i := bytes.LastIndexByte(leading, '\n'); i >= 0 {
+		line += bytes.Count(leading, []byte("\n"))
+		column += len(leading) - i - 1
+	} else {
+		column += len(leading)
+	}
+	return &rt.Position{Filename: name, Line: line, Column: column}
+}
+
 func (s *KubeSource) parseChunk(r schema.KubeResources, yamlChunk []byte) (kubeResource, error) {
 	// Convert to JSON
 	jsonChunk, err := yaml.YAMLToJSON(yamlChunk)
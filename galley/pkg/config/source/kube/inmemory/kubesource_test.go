@@ -21,6 +21,7 @@ import (
 
 	"istio.io/istio/galley/pkg/config/event"
 	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/basicmeta"
 	"istio.io/istio/galley/pkg/config/testing/data"
 	"istio.io/istio/galley/pkg/config/testing/fixtures"
@@ -114,6 +115,23 @@ func TestKubeSource_ApplyContent_Unchanged0Add1(t *testing.T) {
 	g.Expect(events[5].Entry.Metadata.Name).To(Equal(data.EntryN1I1V1.Metadata.Name))
 }
 
+func TestKubeSource_ApplyContent_Position(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := setupKubeSource()
+	s.Start()
+	defer s.Stop()
+
+	err := s.ApplyContent("foo", kubeyaml.JoinString(data.YamlN1I1V1, data.YamlN2I2V1))
+	g.Expect(err).To(BeNil())
+
+	actual := s.Get(data.Collection1).AllSorted()
+	g.Expect(actual).To(HaveLen(2))
+	g.Expect(actual[0].Origin.Reference()).To(Equal(&rt.Position{Filename: "foo", Line: 2, Column: 1}))
+	g.Expect(actual[1].Origin.Reference()).To(Equal(&rt.Position{Filename: "foo", Line: 11, Column: 1}))
+	g.Expect(actual[1].Origin.Reference().String()).To(Equal("foo:11:1"))
+}
+
 func TestKubeSource_RemoveContent(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -29,10 +29,28 @@ type Origin struct {
 	Kind       string
 	Name       resource.Name
 	Version    resource.Version
+
+	// Ref is the reference of the resource in its source, if known.
+	Ref resource.Reference
 }
 
 var _ resource.Origin = &Origin{}
 
+// Position is the position of a resource in a file, i.e. the start of its document. It doesn't locate the fields
+// of the resource.
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+var _ resource.Reference = &Position{}
+
+// String implements resource.Reference
+func (p *Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
 // FriendlyName implements resource.Origin
 func (o *Origin) FriendlyName() string {
 	parts := strings.Split(o.Name.String(), "/")
@@ -54,3 +72,8 @@ func (o *Origin) Namespace() string {
 	ns, _ := o.Name.InterpretAsNamespaceAndName()
 	return ns
 }
+
+// Reference implements resource.Origin
+func (o *Origin) Reference() resource.Reference {
+	return o.Ref
+}
@@ -22,6 +22,7 @@ import (
 	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 
 	"istio.io/istio/galley/pkg/config/meta/schema"
 	"istio.io/istio/galley/pkg/config/source/kube"
@@ -88,6 +89,14 @@ func (p *Provider) sharedInformerFactory() (informers.SharedInformerFactory, err
 	return p.informers, nil
 }
 
+// KubeClient returns the kubernetes.Interface of the provider.
+func (p *Provider) KubeClient() (kubernetes.Interface, error) {
+	if p.interfaces == nil {
+		return nil, errors.New("client interfaces was not initialized")
+	}
+	return p.interfaces.KubeClient()
+}
+
 // GetDynamicResourceInterface returns a dynamic.NamespaceableResourceInterface for the given resource.
 func (p *Provider) GetDynamicResourceInterface(r schema.KubeResource) (dynamic.NamespaceableResourceInterface, error) {
 	p.mu.Lock()
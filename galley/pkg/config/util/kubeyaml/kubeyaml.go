@@ -35,6 +35,24 @@ func Split(yamlText []byte) [][]byte {
 	return result
 }
 
+// SplitWithLines splits the given yaml doc if it's multipart document, like Split. It also returns the line number,
+// starting at 1, of the beginning of each part in the doc.
+func SplitWithLines(yamlText []byte) ([][]byte, []int) {
+	parts := bytes.Split(yamlText, []byte(yamlSeparator))
+	var result [][]byte
+	var lines []int
+	line := 1
+	for _, p := range parts {
+		if len(p) != 0 {
+			result = append(result, p)
+			lines = append(lines, line)
+		}
+		// Account for the new line of the separator.
+		line += bytes.Count(p, []byte("\n")) + 1
+	}
+	return result, lines
+}
+
 // SplitString splits the given yaml doc if it's multipart document.
 func SplitString(yamlText string) []string {
 	parts := strings.Split(yamlText, yamlSeparator)
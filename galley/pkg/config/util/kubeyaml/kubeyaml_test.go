@@ -82,6 +82,27 @@ func TestSplit(t *testing.T) {
 	}
 }
 
+func TestSplitWithLines(t *testing.T) {
+	for i, c := range splitCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			actual, lines := SplitWithLines([]byte(c.merged))
+
+			var exp [][]byte
+			for _, e := range c.split {
+				exp = append(exp, []byte(e))
+			}
+			g.Expect(actual).To(Equal(exp))
+			g.Expect(lines).To(HaveLen(len(exp)))
+		})
+	}
+
+	g := NewGomegaWithT(t)
+	_, lines := SplitWithLines([]byte("a: b\n---\n---\nc: d\ne: f\n---\ng: h\n"))
+	g.Expect(lines).To(Equal([]int{1, 4, 7}))
+}
+
 func TestSplitString(t *testing.T) {
 	for i, c := range splitCases {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
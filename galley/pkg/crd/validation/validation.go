@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 	"time"
 
 	"k8s.io/client-go/kubernetes"
@@ -30,6 +31,7 @@ import (
 	"istio.io/pkg/log"
 	"istio.io/pkg/probe"
 
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	mixervalidate "istio.io/istio/mixer/pkg/validate"
 	"istio.io/istio/pkg/config/schemas"
 	"istio.io/istio/pkg/kube"
@@ -198,6 +200,12 @@ func (p *WebhookParameters) Validate() error {
 		if err := validatePort(int(p.Port)); err != nil {
 			errs = multierror.Append(errs, err)
 		}
+		if p.EnableAnalysis {
+			if _, ok := diag.GetUppercaseStringToLevelMap()[strings.ToUpper(p.AnalysisLevel)]; !ok {
+				errs = multierror.Append(errs, fmt.Errorf("invalid analysis level %q, please choose from: %v",
+					p.AnalysisLevel, diag.GetAllLevelStrings()))
+			}
+		}
 	}
 
 	return errs.ErrorOrNil()
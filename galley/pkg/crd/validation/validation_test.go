@@ -75,6 +75,13 @@ func TestValidate(t *testing.T) {
 			wrapFunc:      func(args *WebhookParameters) { args.Port = 100000 },
 			expectedError: "port number 100000 must be in the range 1..65535",
 		},
+		"invalid analysis level": {
+			wrapFunc: func(args *WebhookParameters) {
+				args.EnableAnalysis = true
+				args.AnalysisLevel = "fatal"
+			},
+			expectedError: `invalid analysis level "fatal"`,
+		},
 	}
 
 	for name, scenario := range scenarios {
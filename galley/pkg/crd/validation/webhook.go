@@ -23,6 +23,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	mixerCrd "istio.io/istio/mixer/pkg/config/crd"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/pilot/pkg/config/kube/crd"
@@ -69,8 +71,23 @@ const (
 	retryUpdateAfterFailureTimeout = time.Second
 
 	httpsHandlerReadyPath = "/ready"
+
+	// analysisAuditAnnotation is the audit annotation of the analysis messages of the allowed resources.
+	analysisAuditAnnotation = "analysis"
 )
 
+// admissionReviewResponse is an admission review with the warnings of the response, which the API server returns to
+// the client since Kubernetes 1.19, e.g. printed by kubectl. The previous versions ignore them.
+type admissionReviewResponse struct {
+	Response *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionResponse struct {
+	*admissionv1beta1.AdmissionResponse
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
 // WebhookParameters contains the configuration for the Istio Pilot validation
 // admission controller.
 type WebhookParameters struct {
@@ -124,6 +141,27 @@ type WebhookParameters struct {
 
 	// Enable reconcile validatingwebhookconfiguration
 	EnableReconcileWebhookConfiguration bool
+
+	// EnableAnalysis enables the analysis of the admitted Pilot resources against the current config of Galley. The
+	// analysis fails open: the resources are allowed if they can't be analyzed, e.g. before Galley has read the current
+	// config.
+	EnableAnalysis bool
+
+	// AnalysisLevel is the level of the analysis messages rejecting the admitted resources, one of "Error", "Warn"
+	// or "Info". The messages of a lower level are returned as warnings of the allowed resources, which kubectl
+	// prints with Kubernetes 1.19 and later, and as an audit annotation.
+	AnalysisLevel string
+
+	// ResourceAnalyzer analyzes the admitted resources, if EnableAnalysis is set.
+	ResourceAnalyzer ResourceAnalyzer
+}
+
+// ResourceAnalyzer analyzes an admitted resource against the current config, to find the problems it would cause in
+// combination with the other resources, e.g. a VirtualService referencing a non-existent gateway.
+type ResourceAnalyzer interface {
+	// AnalyzeResource returns the messages reported for the resource, given in its JSON form. The namespace of the
+	// admission request is used if the resource doesn't specify one.
+	AnalyzeResource(raw []byte, namespace string) (diag.Messages, error)
 }
 
 type createInformerEndpointSource func(cl clientset.Interface, namespace, name string) cache.ListerWatcher
@@ -154,6 +192,8 @@ func (p *WebhookParameters) String() string {
 	fmt.Fprintf(buf, "ServiceName: %s\n", p.ServiceName)
 	fmt.Fprintf(buf, "EnableValidation: %v\n", p.EnableValidation)
 	fmt.Fprintf(buf, "EnableReconcileWebhookConfiguration: %v\n", p.EnableReconcileWebhookConfiguration)
+	fmt.Fprintf(buf, "EnableAnalysis: %v\n", p.EnableAnalysis)
+	fmt.Fprintf(buf, "AnalysisLevel: %s\n", p.AnalysisLevel)
 
 	return buf.String()
 }
@@ -171,6 +211,8 @@ func DefaultArgs() *WebhookParameters {
 		WebhookName:                         "istio-galley",
 		EnableValidation:                    true,
 		EnableReconcileWebhookConfiguration: true,
+		EnableAnalysis:                      false,
+		AnalysisLevel:                       diag.Error.String(),
 	}
 }
 
@@ -188,6 +230,10 @@ type Webhook struct {
 	// mixer
 	validator store.BackendValidator
 
+	// analysis of the pilot resources, nil if disabled
+	analyzer      ResourceAnalyzer
+	analysisLevel diag.Level
+
 	server                        *http.Server
 	clientset                     clientset.Interface
 	deploymentAndServiceNamespace string
@@ -280,6 +326,15 @@ func NewWebhook(p WebhookParameters) (*Webhook, error) {
 		createInformerEndpointSource:  defaultCreateInformerEndpointSource,
 	}
 
+	if p.EnableAnalysis && p.ResourceAnalyzer != nil {
+		level, ok := diag.GetUppercaseStringToLevelMap()[strings.ToUpper(p.AnalysisLevel)]
+		if !ok {
+			return nil, fmt.Errorf("invalid analysis level %q, please choose from: %v", p.AnalysisLevel, diag.GetAllLevelStrings())
+		}
+		wh.analyzer = p.ResourceAnalyzer
+		wh.analysisLevel = level
+	}
+
 	// mtls disabled because apiserver webhook cert usage is still TBD.
 	wh.server.TLSConfig = &tls.Config{GetCertificate: wh.getCert}
 	h := http.NewServeMux()
@@ -382,12 +437,16 @@ func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
 		reviewResponse = admit(ar.Request)
 	}
 
-	response := admissionv1beta1.AdmissionReview{}
+	response := admissionReviewResponse{}
 	if reviewResponse != nil {
-		response.Response = reviewResponse
+		response.Response = &admissionResponse{AdmissionResponse: reviewResponse}
 		if ar.Request != nil {
 			response.Response.UID = ar.Request.UID
 		}
+		// The analysis messages of the allowed resources are otherwise only visible in the audit logs
+		if msgs, ok := reviewResponse.AuditAnnotations[analysisAuditAnnotation]; ok && reviewResponse.Allowed {
+			response.Response.Warnings = strings.Split(msgs, "\n")
+		}
 	}
 
 	resp, err := json.Marshal(response)
@@ -455,10 +514,50 @@ func (wh *Webhook) admitPilot(request *admissionv1beta1.AdmissionRequest) *admis
 		return toAdmissionResponse(err)
 	}
 
+	if wh.analyzer != nil {
+		return wh.analyze(request)
+	}
+
 	reportValidationPass(request)
 	return &admissionv1beta1.AdmissionResponse{Allowed: true}
 }
 
+// analyze analyzes the valid admitted resource against the current config. The resource is rejected if the analysis
+// reports messages at or above the analysis level, and allowed with the other messages otherwise, returned as
+// warnings by serve. The analysis fails open: the resource is allowed if it can't be analyzed, e.g. before Galley has
+// read the current config, since it is already validated and the analysis only finds problems with the other config.
+func (wh *Webhook) analyze(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	msgs, err := wh.analyzer.AnalyzeResource(request.Object.Raw, request.Namespace)
+	if err != nil {
+		scope.Warnf("unable to analyze %s resource %s/%s: %v", request.Kind.Kind, request.Namespace, request.Name, err)
+		reportValidationPass(request)
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	var rejecting []string
+	var all []string
+	for _, m := range msgs {
+		all = append(all, m.String())
+		if m.Type.Level().IsWorseThanOrEqualTo(wh.analysisLevel) {
+			rejecting = append(rejecting, m.String())
+		}
+	}
+
+	if len(rejecting) > 0 {
+		scope.Infof("configuration analysis failed: %v", rejecting)
+		reportValidationFailed(request, reasonAnalysisFailed)
+		return toAdmissionResponse(fmt.Errorf("configuration analysis failed:\n%s", strings.Join(rejecting, "\n")))
+	}
+
+	reportValidationPass(request)
+	response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+	if len(all) > 0 {
+		response.Result = &v1.Status{Message: fmt.Sprintf("configuration analysis messages:\n%s", strings.Join(all, "\n"))}
+		response.AuditAnnotations = map[string]string{analysisAuditAnnotation: strings.Join(all, "\n")}
+	}
+	return response
+}
+
 func (wh *Webhook) admitMixer(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
 	ev := &store.BackendEvent{
 		Key: store.Key{
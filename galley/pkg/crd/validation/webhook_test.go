@@ -25,7 +25,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,6 +44,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	fcache "k8s.io/client-go/tools/cache/testing"
 
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/pilot/pkg/config/kube/crd"
 	"istio.io/istio/pilot/pkg/model"
@@ -349,6 +352,93 @@ func TestAdmitPilot(t *testing.T) {
 	}
 }
 
+type fakeResourceAnalyzer struct {
+	msgs diag.Messages
+	err  error
+}
+
+func (a *fakeResourceAnalyzer) AnalyzeResource([]byte, string) (diag.Messages, error) {
+	return a.msgs, a.err
+}
+
+func TestAdmitPilot_Analysis(t *testing.T) {
+	valid := makePilotConfig(t, 0, true, false)
+
+	wh, cancel := createTestWebhook(t, dummyClient, createFakeEndpointsSource(), dummyConfig)
+	defer cancel()
+
+	warning := diag.NewMessage(diag.NewMessageType(diag.Warning, "IST9998", "warning"), nil)
+	failure := diag.NewMessage(diag.NewMessageType(diag.Error, "IST9999", "failure"), nil)
+
+	cases := []struct {
+		name     string
+		analyzer *fakeResourceAnalyzer
+		level    diag.Level
+		allowed  bool
+		message  string
+	}{
+		{
+			name:     "no messages",
+			analyzer: &fakeResourceAnalyzer{},
+			level:    diag.Error,
+			allowed:  true,
+		},
+		{
+			name:     "message below level",
+			analyzer: &fakeResourceAnalyzer{msgs: diag.Messages{warning}},
+			level:    diag.Error,
+			allowed:  true,
+			message:  "IST9998",
+		},
+		{
+			name:     "message at level",
+			analyzer: &fakeResourceAnalyzer{msgs: diag.Messages{warning, failure}},
+			level:    diag.Error,
+			allowed:  false,
+			message:  "IST9999",
+		},
+		{
+			name:     "message above level",
+			analyzer: &fakeResourceAnalyzer{msgs: diag.Messages{failure}},
+			level:    diag.Warning,
+			allowed:  false,
+			message:  "IST9999",
+		},
+		{
+			name:     "analysis error",
+			analyzer: &fakeResourceAnalyzer{err: errors.New("not started")},
+			level:    diag.Error,
+			allowed:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("[%d] %s", i, c.name), func(t *testing.T) {
+			wh.analyzer = c.analyzer
+			wh.analysisLevel = c.level
+			defer func() { wh.analyzer = nil }()
+
+			got := wh.admitPilot(&admissionv1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Kind: "mock"},
+				Object:    runtime.RawExtension{Raw: valid},
+				Operation: admissionv1beta1.Create,
+			})
+			if got.Allowed != c.allowed {
+				t.Fatalf("got %v want %v", got.Allowed, c.allowed)
+			}
+			if c.message == "" {
+				if got.Result != nil {
+					t.Fatalf("got unexpected result %v", got.Result)
+				}
+				return
+			}
+			if got.Result == nil || !strings.Contains(got.Result.Message, c.message) {
+				t.Fatalf("got result %v, want message containing %q", got.Result, c.message)
+			}
+		})
+	}
+}
+
 func makeMixerConfig(t *testing.T, i int, includeBogusKey bool) []byte {
 	t.Helper()
 	uns := &unstructured.Unstructured{}
@@ -645,6 +735,32 @@ func TestServe(t *testing.T) {
 	}
 }
 
+func TestServe_AnalysisWarnings(t *testing.T) {
+	req := httptest.NewRequest("POST", "http://validator", bytes.NewReader(makeTestReview(t, true)))
+	req.Header.Add("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	serve(w, req, func(*admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed:          true,
+			AuditAnnotations: map[string]string{analysisAuditAnnotation: "first\nsecond"},
+		}
+	})
+
+	var got struct {
+		Response struct {
+			Allowed  bool     `json:"allowed"`
+			Warnings []string `json:"warnings"`
+		} `json:"response"`
+	}
+	if err := json.NewDecoder(w.Result().Body).Decode(&got); err != nil {
+		t.Fatalf("could not decode response body: %v", err)
+	}
+	if !got.Response.Allowed || !reflect.DeepEqual(got.Response.Warnings, []string{"first", "second"}) {
+		t.Fatalf("got response %+v, want allowed with the analysis warnings", got.Response)
+	}
+}
+
 func checkCert(t *testing.T, whc *Webhook, cert, key []byte) bool {
 	t.Helper()
 	actual := whc.cert
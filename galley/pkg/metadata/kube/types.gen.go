@@ -892,6 +892,21 @@ func init() {
 
 	versions = make([]string, 0)
 
+	versions = append(versions, "v1alpha1")
+
+	b.Add(schema.ResourceSpec{
+		Kind:      "AccessLogPolicy",
+		ListKind:  "AccessLogPolicyList",
+		Singular:  "accesslogpolicy",
+		Plural:    "accesslogpolicies",
+		Versions:  versions,
+		Group:     "telemetry.istio.io",
+		Target:    metadata.Types.Get("istio/telemetry/v1alpha1/accesslogpolicies"),
+		Converter: converter.Get("identity"),
+	})
+
+	versions = make([]string, 0)
+
 	versions = append(versions, "v1")
 
 	b.Add(schema.ResourceSpec{
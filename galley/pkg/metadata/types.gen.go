@@ -29,6 +29,9 @@ import (
 	// Register protos in "istio.io/api/security/v1beta1"
 	_ "istio.io/api/security/v1beta1"
 
+	// Register protos in "istio.io/istio/pkg/config/accesslog/v1alpha1"
+	_ "istio.io/istio/pkg/config/accesslog/v1alpha1"
+
 	// Register protos in "k8s.io/api/core/v1"
 	_ "k8s.io/api/core/v1"
 
@@ -208,6 +211,9 @@ var (
 	// istio/security/v1beta1/authorizationpolicies metadata
 	IstioSecurityV1beta1Authorizationpolicies resource.Info
 
+	// istio/telemetry/v1alpha1/accesslogpolicies metadata
+	IstioTelemetryV1alpha1Accesslogpolicies resource.Info
+
 	// k8s/core/v1/endpoints metadata
 	K8sCoreV1Endpoints resource.Info
 
@@ -395,6 +401,9 @@ func init() {
 	IstioSecurityV1beta1Authorizationpolicies = b.Register(
 		"istio/security/v1beta1/authorizationpolicies",
 		"type.googleapis.com/istio.security.v1beta1.AuthorizationPolicy")
+	IstioTelemetryV1alpha1Accesslogpolicies = b.Register(
+		"istio/telemetry/v1alpha1/accesslogpolicies",
+		"type.googleapis.com/istio.telemetry.v1alpha1.AccessLogPolicy")
 	K8sCoreV1Endpoints = b.Register(
 		"k8s/core/v1/endpoints",
 		"type.googleapis.com/k8s.io.api.core.v1.Endpoints")
@@ -0,0 +1,92 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/crd/validation"
+)
+
+// resourceAnalyzer analyzes the resources admitted by the validation webhook against the current snapshot of the
+// analyzing distributor, which is only created once the processing component is started.
+type resourceAnalyzer struct {
+	mu          sync.RWMutex
+	distributor *snapshotter.AnalyzingDistributor
+}
+
+var _ validation.ResourceAnalyzer = &resourceAnalyzer{}
+
+func (a *resourceAnalyzer) setDistributor(d *snapshotter.AnalyzingDistributor) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.distributor = d
+}
+
+// AnalyzeResource implements validation.ResourceAnalyzer
+func (a *resourceAnalyzer) AnalyzeResource(raw []byte, namespace string) (diag.Messages, error) {
+	a.mu.RLock()
+	d := a.distributor
+	a.mu.RUnlock()
+	if d == nil {
+		return nil, errors.New("config analysis is not started")
+	}
+
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	gv, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources unknown to Galley, or not part of the Istio config, are not analyzed.
+	m := metadata.MustGet()
+	kr, found := m.KubeSource().Resources().Find(gv.Group, typeMeta.Kind)
+	if !found {
+		return nil, nil
+	}
+	col, found := m.DirectTransformSettings().Mapping()[kr.Collection.Name]
+	if !found {
+		return nil, nil
+	}
+
+	adapter := rt.DefaultProvider().GetAdapter(kr)
+	obj, err := adapter.ParseJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s resource: %v", kr.Kind, err)
+	}
+	objMeta := adapter.ExtractObject(obj)
+	if objMeta.GetNamespace() == "" && !kr.ClusterScoped {
+		objMeta.SetNamespace(namespace)
+	}
+	item, err := adapter.ExtractResource(obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract %s resource: %v", kr.Kind, err)
+	}
+
+	return d.AnalyzeResource(col, rt.ToResourceEntry(objMeta, &kr, item))
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+)
+
+const admittedVirtualService = `{
+  "apiVersion": "networking.istio.io/v1alpha3",
+  "kind": "VirtualService",
+  "metadata": {"name": "reviews"},
+  "spec": {"hosts": ["reviews"], "gateways": ["bogus"]}
+}`
+
+func newTestResourceAnalyzer() *resourceAnalyzer {
+	a := &resourceAnalyzer{}
+	a.setDistributor(snapshotter.NewAnalyzingDistributor(snapshotter.AnalyzingDistributorSettings{
+		Analyzer:    analysis.Combine("all"),
+		Distributor: snapshotter.NewInMemoryDistributor(),
+	}))
+	return a
+}
+
+func TestResourceAnalyzer_NotStarted(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &resourceAnalyzer{}
+	_, err := a.AnalyzeResource([]byte(admittedVirtualService), "default")
+	g.Expect(err).To(MatchError(ContainSubstring("not started")))
+}
+
+func TestResourceAnalyzer_UnknownKind(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := newTestResourceAnalyzer()
+	msgs, err := a.AnalyzeResource([]byte(`{"apiVersion": "acme.io/v1", "kind": "Route"}`), "default")
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(BeEmpty())
+}
+
+func TestResourceAnalyzer_InvalidResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := newTestResourceAnalyzer()
+	_, err := a.AnalyzeResource([]byte(`{"apiVersion": "networking.istio.io/v1alpha3"`), "default")
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestResourceAnalyzer_NoSnapshot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := newTestResourceAnalyzer()
+	_, err := a.AnalyzeResource([]byte(admittedVirtualService), "default")
+	g.Expect(err).To(MatchError(ContainSubstring("no snapshot")))
+}
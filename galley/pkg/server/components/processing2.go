@@ -32,20 +32,25 @@ import (
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
 
+	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/declarative"
 	"istio.io/istio/galley/pkg/config/event"
 	"istio.io/istio/galley/pkg/config/meta/metadata"
 	"istio.io/istio/galley/pkg/config/meta/schema"
 	"istio.io/istio/galley/pkg/config/meta/schema/collection"
 	"istio.io/istio/galley/pkg/config/processing"
 	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter/historyz"
 	"istio.io/istio/galley/pkg/config/processor"
 	"istio.io/istio/galley/pkg/config/processor/groups"
 	"istio.io/istio/galley/pkg/config/processor/transforms"
+	"istio.io/istio/galley/pkg/config/processor/transforms/custom"
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver/status"
 	"istio.io/istio/galley/pkg/config/util/kuberesource"
+	"istio.io/istio/galley/pkg/crd/validation"
 	"istio.io/istio/galley/pkg/server/process"
 	"istio.io/istio/galley/pkg/server/settings"
 	configz "istio.io/istio/pkg/mcp/configz/server"
@@ -67,6 +72,10 @@ type Processing2 struct {
 	mcpCache     *snapshot.Cache
 	configzTopic fw.Topic
 
+	history       *snapshotter.History
+	historyzTopic fw.Topic
+	analyzer      *resourceAnalyzer
+
 	k kube.Interfaces
 
 	serveWG       sync.WaitGroup
@@ -85,11 +94,19 @@ var _ process.Component = &Processing2{}
 // NewProcessing2 returns a new processing component.
 func NewProcessing2(a *settings.Args) *Processing2 {
 	mcpCache := snapshot.New(groups.IndexFunction)
-	return &Processing2{
+	p := &Processing2{
 		args:         a,
 		mcpCache:     mcpCache,
 		configzTopic: configz.CreateTopic(mcpCache),
+		analyzer:     &resourceAnalyzer{},
+	}
+
+	if a.SnapshotHistorySize > 0 {
+		p.history = snapshotter.NewHistory(a.SnapshotHistorySize, snapshotter.NewMCPDistributor(mcpCache))
+		p.historyzTopic = historyz.CreateTopic(p.history)
 	}
+
+	return p
 }
 
 // Start implements process.Component
@@ -106,12 +123,25 @@ func (p *Processing2) Start() (err error) {
 
 	transformProviders := transforms.Providers(m)
 
+	// Add the transforms of custom resources, which read resources unknown to the metadata
+	var customTransforms []custom.Transform
+	if customTransforms, err = custom.Load(p.args.CustomTransformsFiles...); err != nil {
+		return
+	}
+	customTransforms = append(customTransforms, custom.Registered()...)
+	transformProviders = append(transformProviders, custom.Providers(customTransforms)...)
+	var customResources schema.KubeResources
+	if customResources, err = custom.KubeResources(m.KubeSource().Resources(), customTransforms); err != nil {
+		return
+	}
+
 	// Disable any unnecessary resources, including resources not in configured snapshots
 	var colsInSnapshots collection.Names
 	for _, c := range m.AllCollectionsInSnapshots(p.args.Snapshots) {
 		colsInSnapshots = append(colsInSnapshots, collection.NewName(c))
 	}
-	kubeResources := kuberesource.DisableExcludedKubeResources(m.KubeSource().Resources(), transformProviders,
+	resources := append(m.KubeSource().Resources(), customResources...)
+	kubeResources := kuberesource.DisableExcludedKubeResources(resources, transformProviders,
 		colsInSnapshots, p.args.ExcludedResourceKinds, p.args.EnableServiceDiscovery)
 
 	if src, updater, err = p.createSourceAndStatusUpdater(kubeResources); err != nil {
@@ -119,9 +149,16 @@ func (p *Processing2) Start() (err error) {
 	}
 
 	var distributor snapshotter.Distributor = snapshotter.NewMCPDistributor(p.mcpCache)
+	if p.history != nil {
+		distributor = p.history
+	}
 
 	if p.args.EnableConfigAnalysis {
-		combinedAnalyzer := analyzers.AllCombined()
+		var custom []analysis.Analyzer
+		if custom, err = declarative.Load(p.args.CustomAnalyzersFiles...); err != nil {
+			return
+		}
+		combinedAnalyzer := analysis.Combine("all", append(analyzers.All(), custom...)...)
 		combinedAnalyzer.RemoveDisabled(kubeResources.DisabledCollections(), transformProviders)
 
 		settings := snapshotter.AnalyzingDistributorSettings{
@@ -132,7 +169,9 @@ func (p *Processing2) Start() (err error) {
 			TriggerSnapshot:   p.args.TriggerSnapshot,
 		}
 
-		distributor = snapshotter.NewAnalyzingDistributor(settings)
+		analyzingDistributor := snapshotter.NewAnalyzingDistributor(settings)
+		p.analyzer.setDistributor(analyzingDistributor)
+		distributor = analyzingDistributor
 	}
 
 	processorSettings := processor.Settings{
@@ -256,6 +295,17 @@ func (p *Processing2) ConfigZTopic() fw.Topic {
 	return p.configzTopic
 }
 
+// HistoryZTopic returns the topic exposing the history of the distributed snapshots, or nil if it is disabled.
+func (p *Processing2) HistoryZTopic() fw.Topic {
+	return p.historyzTopic
+}
+
+// ResourceAnalyzer returns the analyzer of the resources admitted by the validation webhook. It analyzes them against
+// the current config, once the component is started with config analysis enabled.
+func (p *Processing2) ResourceAnalyzer() validation.ResourceAnalyzer {
+	return p.analyzer
+}
+
 func (p *Processing2) getServerGrpcOptions() []grpc.ServerOption {
 	var grpcOptions []grpc.ServerOption
 	grpcOptions = append(grpcOptions,
@@ -60,6 +60,12 @@ func New(a *settings.Args) *Server {
 			s.host.Add(s.p2)
 			t := s.p2.ConfigZTopic()
 			topics = append(topics, t)
+			if t = s.p2.HistoryZTopic(); t != nil {
+				topics = append(topics, t)
+			}
+			if a.ValidationArgs != nil && a.ValidationArgs.EnableAnalysis && a.EnableConfigAnalysis {
+				a.ValidationArgs.ResourceAnalyzer = s.p2.ResourceAnalyzer()
+			}
 		}
 	}
 
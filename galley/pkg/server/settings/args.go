@@ -122,6 +122,17 @@ type Args struct { // nolint:maligned
 	// Enable Config Analysis service, that will analyze and update CRD status. UseOldProcessor must be set to false.
 	EnableConfigAnalysis bool
 
+	// CustomAnalyzersFiles are the files declaring the analyzers run in addition to the built-in ones.
+	CustomAnalyzersFiles []string
+
+	// CustomTransformsFiles are the files declaring transforms of custom resources into Istio config, in addition to
+	// the built-in and registered ones.
+	CustomTransformsFiles []string
+
+	// SnapshotHistorySize is the number of distributed snapshots kept for each snapshot group, and exposed through
+	// ControlZ. The history is disabled if it is 0.
+	SnapshotHistorySize int
+
 	// DisableResourceReadyCheck disables the CRD readiness check. This
 	// allows Galley to start when not all supported CRD are
 	// registered with the kube-apiserver.
@@ -178,6 +189,7 @@ func DefaultArgs() *Args {
 		UseOldProcessor:             false,
 		WatchConfigFiles:            false,
 		EnableConfigAnalysis:        false,
+		SnapshotHistorySize:         20,
 		Liveness: probe.Options{
 			Path:           defaultLivenessProbeFilePath,
 			UpdateInterval: defaultProbeCheckInterval,
@@ -215,6 +227,9 @@ func (a *Args) String() string {
 	_, _ = fmt.Fprintf(buf, "DomainSuffix: %s\n", a.DomainSuffix)
 	_, _ = fmt.Fprintf(buf, "DisableResourceReadyCheck: %v\n", a.DisableResourceReadyCheck)
 	_, _ = fmt.Fprintf(buf, "ExcludedResourceKinds: %v\n", a.ExcludedResourceKinds)
+	_, _ = fmt.Fprintf(buf, "CustomAnalyzersFiles: %v\n", a.CustomAnalyzersFiles)
+	_, _ = fmt.Fprintf(buf, "CustomTransformsFiles: %v\n", a.CustomTransformsFiles)
+	_, _ = fmt.Fprintf(buf, "SnapshotHistorySize: %d\n", a.SnapshotHistorySize)
 	_, _ = fmt.Fprintf(buf, "SinkAddress: %v\n", a.SinkAddress)
 	_, _ = fmt.Fprintf(buf, "SinkAuthMode: %v\n", a.SinkAuthMode)
 	_, _ = fmt.Fprintf(buf, "SinkMeta: %v\n", a.SinkMeta)
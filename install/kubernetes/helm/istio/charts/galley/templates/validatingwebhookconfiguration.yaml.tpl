@@ -48,6 +48,15 @@ webhooks:
         - "*"
         resources:
         - "*"
+      - operations:
+        - CREATE
+        - UPDATE
+        apiGroups:
+        - telemetry.istio.io
+        apiVersions:
+        - "*"
+        resources:
+        - "*"
       - operations:
         - CREATE
         - UPDATE
@@ -15,20 +15,27 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/ghodss/yaml"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 
 	"istio.io/pkg/env"
 
+	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/declarative"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/local"
 	"istio.io/istio/galley/pkg/config/meta/metadata"
 	cfgKube "istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/pkg/kube"
 )
 
@@ -36,6 +43,14 @@ type AnalyzerFoundIssuesError struct{}
 
 const (
 	FoundIssueString = "Analyzer found issues."
+
+	// Output formats of the analysis messages
+	logOutput   = "log"
+	yamlOutput  = "yaml"
+	sarifOutput = "sarif"
+
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json"
 )
 
 func (f AnalyzerFoundIssuesError) Error() string {
@@ -43,11 +58,16 @@ func (f AnalyzerFoundIssuesError) Error() string {
 }
 
 var (
-	useKube      bool
-	useDiscovery string
-	failureLevel = messageThreshold{diag.Warning} // messages at least this level will generate an error exit code
-	outputLevel  = messageThreshold{diag.Info}    // messages at least this level will be included in the output
-	colorize     bool
+	useKube         bool
+	useDiscovery    string
+	failureLevel    = messageThreshold{diag.Warning} // messages at least this level will generate an error exit code
+	outputLevel     = messageThreshold{diag.Info}    // messages at least this level will be included in the output
+	colorize        bool
+	msgOutputFormat string
+	suppress        []string
+	suppressFile    string
+	customAnalyzers []string
+	diffMode        bool
 
 	termEnvVar = env.RegisterStringVar("TERM", "", "Specifies terminal type.  Use 'dumb' to suppress color output")
 
@@ -80,8 +100,27 @@ istioctl experimental analyze -d true a.yaml b.yaml services.yaml
 
 # Analyze the current live cluster, overriding service discovery to disabled
 istioctl experimental analyze -k -d false
+
+# Analyze yaml files, printing the messages in the SARIF format for code scanning tools
+istioctl experimental analyze -o sarif a.yaml b.yaml
+
+# Analyze yaml files, suppressing the IST0102 messages of the "frod" namespace
+istioctl experimental analyze --suppress "IST0102=Namespace frod" a.yaml b.yaml
+
+# Analyze yaml files, also running the analyzers declared in acme-analyzers.yaml
+istioctl experimental analyze --custom-analyzers acme-analyzers.yaml a.yaml b.yaml
+
+# Only print the messages that applying the yaml files to the current live cluster would introduce or fix
+istioctl experimental analyze -k --diff a.yaml b.yaml
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputFormat(msgOutputFormat); err != nil {
+				return err
+			}
+			if diffMode && (!useKube || len(args) == 0) {
+				return errors.New("--diff requires --use-kube and at least one file")
+			}
+
 			files, err := gatherFiles(args)
 			if err != nil {
 				return err
@@ -126,54 +165,79 @@ istioctl experimental analyze -k -d false
 				selectedNamespace = defaultNamespace
 			}
 
-			sa := local.NewSourceAnalyzer(metadata.MustGet(), analyzers.AllCombined(), selectedNamespace, nil, sd)
-
-			// If we're using kube, use that as a base source.
-			if k != nil {
-				sa.AddRunningKubeSource(k)
-			}
-
-			// If files are provided, treat them (collectively) as a source.
-			if len(files) > 0 {
-				if err = sa.AddFileKubeSource(files); err != nil {
-					// Partial success is possible, so don't return early, but do print.
-					// TODO(https://github.com/istio/istio/issues/17862): If we had any such errors, we should return a nonzero exit code
-					fmt.Fprintf(cmd.ErrOrStderr(), "Error(s) reading files: %v", err)
-				}
+			suppressions, err := analysisSuppressions()
+			if err != nil {
+				return err
 			}
 
-			result, err := sa.Analyze(cancel)
+			custom, err := declarative.Load(customAnalyzers...)
 			if err != nil {
 				return err
 			}
 
-			// Maybe output details about which analyzers ran
-			if verbose {
-				if len(result.SkippedAnalyzers) > 0 {
-					fmt.Fprintln(cmd.ErrOrStderr(), "Skipped analyzers:")
-					for _, a := range result.SkippedAnalyzers {
-						fmt.Fprintln(cmd.ErrOrStderr(), "\t", a)
-					}
+			combined := analysis.Combine("all", append(analyzers.All(), custom...)...)
+
+			// analyze runs the analysis of the live cluster, if used, combined with the given files.
+			analyze := func(files []string) (local.AnalysisResult, error) {
+				sa := local.NewSourceAnalyzer(metadata.MustGet(), combined, selectedNamespace, nil, sd)
+				sa.SetSuppressions(suppressions)
+
+				// If we're using kube, use that as a base source.
+				if k != nil {
+					sa.AddRunningKubeSource(k)
 				}
-				if len(result.ExecutedAnalyzers) > 0 {
-					fmt.Fprintln(cmd.ErrOrStderr(), "Executed analyzers:")
-					for _, a := range result.ExecutedAnalyzers {
-						fmt.Fprintln(cmd.ErrOrStderr(), "\t", a)
+
+				// If files are provided, treat them (collectively) as a source.
+				if len(files) > 0 {
+					if err := sa.AddFileKubeSource(files); err != nil {
+						// Partial success is possible, so don't return early, but do print.
+						// TODO(https://github.com/istio/istio/issues/17862): If we had any such errors, we should return a nonzero exit code
+						fmt.Fprintf(cmd.ErrOrStderr(), "Error(s) reading files: %v", err)
 					}
 				}
-				fmt.Fprintln(cmd.ErrOrStderr())
+
+				return sa.Analyze(cancel)
 			}
 
-			// Filter outputMessages by specified level
-			var outputMessages diag.Messages
-			for _, m := range result.Messages {
-				if m.Type.Level().IsWorseThanOrEqualTo(outputLevel.Level) {
-					outputMessages = append(outputMessages, m)
+			if diffMode {
+				// Analyze the live cluster alone first, to know the messages that are not caused by the files
+				before, err := analyze(nil)
+				if err != nil {
+					return err
 				}
+				after, err := analyze(files)
+				if err != nil {
+					return err
+				}
+				printVerbose(cmd.ErrOrStderr(), after)
+
+				introduced, fixed := diag.Diff(before.Messages, after.Messages)
+				if err = printDiff(cmd.OutOrStdout(), cmd.ErrOrStderr(), filterByOutputLevel(introduced),
+					filterByOutputLevel(fixed), msgOutputFormat); err != nil {
+					return err
+				}
+
+				// Only the messages introduced by the files can fail the change
+				return errorIfMessagesExceedThreshold(introduced)
+			}
+
+			result, err := analyze(files)
+			if err != nil {
+				return err
 			}
 
+			// Maybe output details about which analyzers ran
+			printVerbose(cmd.ErrOrStderr(), result)
+
+			// Filter outputMessages by specified level
+			outputMessages := filterByOutputLevel(result.Messages)
+
 			// Print validation message output, or a line indicating that none were found
-			if len(outputMessages) == 0 {
+			if msgOutputFormat != logOutput {
+				if err = printMessages(cmd.OutOrStdout(), outputMessages, msgOutputFormat); err != nil {
+					return err
+				}
+			} else if len(outputMessages) == 0 {
 				fmt.Fprintln(cmd.ErrOrStderr(), "\u2714 No validation issues found.")
 			} else {
 				for _, m := range outputMessages {
@@ -200,9 +264,78 @@ istioctl experimental analyze -k -d false
 		fmt.Sprintf("The severity level of analysis at which to set a non-zero exit code. Valid values: %v", diag.GetAllLevelStrings()))
 	analysisCmd.PersistentFlags().Var(&outputLevel, "output-threshold",
 		fmt.Sprintf("The severity level of analysis at which to display messages. Valid values: %v", diag.GetAllLevelStrings()))
+	analysisCmd.PersistentFlags().StringVarP(&msgOutputFormat, "output", "o", logOutput,
+		fmt.Sprintf("Output format: one of %v. The file positions of the messages are the start of the resource "+
+			"the message is about, not of the field at fault", outputFormats))
+	analysisCmd.PersistentFlags().StringArrayVarP(&suppress, "suppress", "S", []string{},
+		"Suppress the messages matching '<code>=<resource name pattern>', e.g. 'IST0102=Namespace frod'. "+
+			"The pattern is a glob of the resource names as printed in the messages. Can be repeated. "+
+			"Messages can also be suppressed with the "+analysis.SuppressAnnotation+" annotation of the resources.")
+	analysisCmd.PersistentFlags().StringVar(&suppressFile, "suppressions-file", "",
+		"YAML file listing suppressions as 'code' and 'resource' name pattern pairs, under 'suppressions'")
+	analysisCmd.PersistentFlags().StringArrayVar(&customAnalyzers, "custom-analyzers", []string{},
+		"YAML file declaring analyzers to run in addition to the built-in ones, under 'analyzers'. Can be repeated.")
+	analysisCmd.PersistentFlags().BoolVar(&diffMode, "diff", false,
+		"Analyze the live cluster both without and with the files, and only print the messages that the files would "+
+			"introduce or fix. The exit code only depends on the introduced messages. Requires --use-kube.")
 	return analysisCmd
 }
 
+func analysisSuppressions() (analysis.Suppressions, error) {
+	var result analysis.Suppressions
+	if suppressFile != "" {
+		s, err := local.ReadSuppressionsFile(suppressFile)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s...)
+	}
+	for _, v := range suppress {
+		s, err := analysis.ParseSuppression(v)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func printVerbose(w io.Writer, result local.AnalysisResult) {
+	if !verbose {
+		return
+	}
+	if len(result.SkippedAnalyzers) > 0 {
+		fmt.Fprintln(w, "Skipped analyzers:")
+		for _, a := range result.SkippedAnalyzers {
+			fmt.Fprintln(w, "\t", a)
+		}
+	}
+	if len(result.ExecutedAnalyzers) > 0 {
+		fmt.Fprintln(w, "Executed analyzers:")
+		for _, a := range result.ExecutedAnalyzers {
+			fmt.Fprintln(w, "\t", a)
+		}
+	}
+	if len(result.SuppressedMessages) > 0 {
+		fmt.Fprintf(w, "Suppressed messages: %d\n", len(result.SuppressedMessages))
+		for _, m := range result.SuppressedMessages {
+			fmt.Fprintln(w, "\t", renderMessage(m))
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// filterByOutputLevel only keeps the messages at least at the output threshold level.
+func filterByOutputLevel(msgs diag.Messages) diag.Messages {
+	var result diag.Messages
+	for _, m := range msgs {
+		if m.Type.Level().IsWorseThanOrEqualTo(outputLevel.Level) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
 func gatherFiles(args []string) ([]string, error) {
 	var result []string
 	for _, a := range args {
@@ -251,12 +384,214 @@ func colorSuffix() string {
 func renderMessage(m diag.Message) string {
 	origin := ""
 	if m.Origin != nil {
-		origin = " (" + m.Origin.FriendlyName() + ")"
+		origin = " (" + m.Origin.FriendlyName()
+		if r := m.Origin.Reference(); r != nil {
+			origin += " " + r.String()
+		}
+		origin += ")"
 	}
 	return fmt.Sprintf(
 		"%s%v%s [%v]%s %s", colorPrefix(m), m.Type.Level(), colorSuffix(), m.Type.Code(), origin, fmt.Sprintf(m.Type.Template(), m.Parameters...))
 }
 
+var outputFormats = []string{logOutput, jsonOutput, yamlOutput, sarifOutput}
+
+func validateOutputFormat(format string) error {
+	for _, f := range outputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q not a valid output format, please choose from: %v", format, outputFormats)
+}
+
+// printMessages prints the messages in a machine-readable format.
+func printMessages(w io.Writer, msgs diag.Messages, format string) error {
+	if format == sarifOutput {
+		return printStructured(w, toSARIF(msgs), format)
+	}
+	return printStructured(w, unstructuredMessages(msgs), format)
+}
+
+// printDiff prints the messages introduced and fixed by a change. In the SARIF format, only the introduced messages
+// are printed, as results of the analysis.
+func printDiff(w, errW io.Writer, introduced, fixed diag.Messages, format string) error {
+	switch format {
+	case logOutput:
+		if len(introduced) == 0 && len(fixed) == 0 {
+			fmt.Fprintln(errW, "\u2714 The changes introduce or fix no validation issues.")
+			return nil
+		}
+		if len(introduced) > 0 {
+			fmt.Fprintln(w, "Introduced by the changes:")
+			for _, m := range introduced {
+				fmt.Fprintln(w, "+", renderMessage(m))
+			}
+		}
+		if len(fixed) > 0 {
+			fmt.Fprintln(w, "Fixed by the changes:")
+			for _, m := range fixed {
+				fmt.Fprintln(w, "-", renderMessage(m))
+			}
+		}
+		return nil
+	case sarifOutput:
+		return printStructured(w, toSARIF(introduced), format)
+	default:
+		return printStructured(w, map[string]interface{}{
+			"introduced": unstructuredMessages(introduced),
+			"fixed":      unstructuredMessages(fixed),
+		}, format)
+	}
+}
+
+func unstructuredMessages(msgs diag.Messages) []interface{} {
+	result := make([]interface{}, 0, len(msgs))
+	for _, m := range msgs {
+		result = append(result, m.Unstructured(true))
+	}
+	return result
+}
+
+// printStructured prints the output as JSON, or YAML if requested.
+func printStructured(w io.Writer, out interface{}, format string) error {
+	by, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == yamlOutput {
+		if by, err = yaml.JSONToYAML(by); err != nil {
+			return err
+		}
+	} else {
+		by = append(by, '\n')
+	}
+	_, err = w.Write(by)
+	return err
+}
+
+// sarifLog is the subset of a SARIF log (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) used to
+// report the analysis messages to code scanning tools.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion is the start of the resource document in the file, the position of the field at fault is unknown.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+var sarifLevels = map[diag.Level]string{
+	diag.Info:    "note",
+	diag.Warning: "warning",
+	diag.Error:   "error",
+}
+
+func toSARIF(msgs diag.Messages) *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "istioctl analyze",
+				InformationURI: "https://istio.io",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, m := range msgs {
+		code := m.Type.Code()
+		if !rules[code] {
+			rules[code] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               code,
+				ShortDescription: sarifMessage{Text: m.Type.Template()},
+			})
+		}
+
+		result := sarifResult{
+			RuleID:  code,
+			Level:   sarifLevels[m.Type.Level()],
+			Message: sarifMessage{Text: fmt.Sprintf(m.Type.Template(), m.Parameters...)},
+		}
+		if m.Origin != nil {
+			loc := sarifLocation{
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: m.Origin.FriendlyName()}},
+			}
+			if p, ok := m.Origin.Reference().(*rt.Position); ok {
+				loc.PhysicalLocation = &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: p.Filename},
+					Region:           sarifRegion{StartLine: p.Line, StartColumn: p.Column},
+				}
+				loc.Message = &sarifMessage{Text: fmt.Sprintf("Start of the %s resource, the region doesn't "+
+					"locate the field at fault.", m.Origin.FriendlyName())}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return &sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}
+}
+
 func istioctlColorDefault(cmd *cobra.Command) bool {
 	if strings.EqualFold(termEnvVar.Get(), "dumb") {
 		return false
@@ -15,9 +15,16 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
 
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 
 	. "github.com/onsi/gomega"
 )
@@ -63,3 +70,159 @@ func TestNoErrorIfMessageLevelsBelowThreshold(t *testing.T) {
 
 	g.Expect(err).To(BeNil())
 }
+
+func TestPrintMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msgs := testOutputMessages()
+
+	var b bytes.Buffer
+	g.Expect(printMessages(&b, msgs, jsonOutput)).To(Succeed())
+	var actual []map[string]interface{}
+	g.Expect(json.Unmarshal(b.Bytes(), &actual)).To(Succeed())
+	g.Expect(actual).To(Equal([]map[string]interface{}{
+		{
+			"code":      "B1",
+			"level":     "Error",
+			"message":   `Template: "foo"`,
+			"origin":    "VirtualService a.default",
+			"reference": "a.yaml:3:1",
+		},
+		{
+			"code":    "A1",
+			"level":   "Info",
+			"message": `Template: "bar"`,
+		},
+	}))
+
+	b.Reset()
+	g.Expect(printMessages(&b, msgs, yamlOutput)).To(Succeed())
+	var actualYAML []map[string]interface{}
+	g.Expect(yaml.Unmarshal(b.Bytes(), &actualYAML)).To(Succeed())
+	g.Expect(actualYAML).To(Equal(actual))
+
+	b.Reset()
+	g.Expect(printMessages(&b, nil, jsonOutput)).To(Succeed())
+	g.Expect(b.String()).To(Equal("[]\n"))
+}
+
+func TestPrintMessages_SARIF(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var b bytes.Buffer
+	g.Expect(printMessages(&b, testOutputMessages(), sarifOutput)).To(Succeed())
+
+	var actual sarifLog
+	g.Expect(json.Unmarshal(b.Bytes(), &actual)).To(Succeed())
+	g.Expect(actual.Version).To(Equal(sarifVersion))
+	g.Expect(actual.Runs).To(HaveLen(1))
+
+	run := actual.Runs[0]
+	g.Expect(run.Tool.Driver.Rules).To(ConsistOf(
+		sarifRule{ID: "B1", ShortDescription: sarifMessage{Text: "Template: %q"}},
+		sarifRule{ID: "A1", ShortDescription: sarifMessage{Text: "Template: %q"}}))
+	g.Expect(run.Results).To(Equal([]sarifResult{
+		{
+			RuleID:  "B1",
+			Level:   "error",
+			Message: sarifMessage{Text: `Template: "foo"`},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "a.yaml"},
+					Region:           sarifRegion{StartLine: 3, StartColumn: 1},
+				},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "VirtualService a.default"}},
+				Message: &sarifMessage{
+					Text: "Start of the VirtualService a.default resource, the region doesn't locate the field at fault.",
+				},
+			}},
+		},
+		{
+			RuleID:  "A1",
+			Level:   "note",
+			Message: sarifMessage{Text: `Template: "bar"`},
+		},
+	}))
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, f := range []string{logOutput, jsonOutput, yamlOutput, sarifOutput} {
+		g.Expect(validateOutputFormat(f)).To(Succeed())
+	}
+	g.Expect(validateOutputFormat("xml")).NotTo(Succeed())
+}
+
+func testOutputMessages() diag.Messages {
+	o := &rt.Origin{
+		Kind: "VirtualService",
+		Name: resource.NewName("default", "a"),
+		Ref:  &rt.Position{Filename: "a.yaml", Line: 3, Column: 1},
+	}
+	return diag.Messages{
+		diag.NewMessage(diag.NewMessageType(diag.Error, "B1", "Template: %q"), o, "foo"),
+		diag.NewMessage(diag.NewMessageType(diag.Info, "A1", "Template: %q"), nil, "bar"),
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msgs := testOutputMessages()
+	introduced, fixed := msgs[:1], msgs[1:]
+
+	var b, errB bytes.Buffer
+	g.Expect(printDiff(&b, &errB, introduced, fixed, logOutput)).To(Succeed())
+	g.Expect(b.String()).To(Equal("Introduced by the changes:\n" +
+		"+ Error [B1] (VirtualService a.default a.yaml:3:1) Template: \"foo\"\n" +
+		"Fixed by the changes:\n" +
+		"- Info [A1] Template: \"bar\"\n"))
+
+	b.Reset()
+	g.Expect(printDiff(&b, &errB, nil, nil, logOutput)).To(Succeed())
+	g.Expect(b.String()).To(BeEmpty())
+	g.Expect(errB.String()).To(ContainSubstring("no validation issues"))
+
+	b.Reset()
+	g.Expect(printDiff(&b, &errB, introduced, fixed, jsonOutput)).To(Succeed())
+	var actual map[string][]map[string]interface{}
+	g.Expect(json.Unmarshal(b.Bytes(), &actual)).To(Succeed())
+	g.Expect(actual["introduced"]).To(HaveLen(1))
+	g.Expect(actual["introduced"][0]["code"]).To(Equal("B1"))
+	g.Expect(actual["fixed"]).To(HaveLen(1))
+	g.Expect(actual["fixed"][0]["code"]).To(Equal("A1"))
+
+	b.Reset()
+	g.Expect(printDiff(&b, &errB, introduced, fixed, sarifOutput)).To(Succeed())
+	var log sarifLog
+	g.Expect(json.Unmarshal(b.Bytes(), &log)).To(Succeed())
+	g.Expect(log.Runs[0].Results).To(HaveLen(1))
+	g.Expect(log.Runs[0].Results[0].RuleID).To(Equal("B1"))
+}
+
+func TestAnalysisSuppressions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func() {
+		suppress = []string{}
+		suppressFile = ""
+	}()
+
+	suppress = []string{"IST0102=Namespace frod", "IST0101=VirtualService *"}
+	s, err := analysisSuppressions()
+	g.Expect(err).To(BeNil())
+	g.Expect(s).To(Equal(analysis.Suppressions{
+		{Code: "IST0102", ResourceName: "Namespace frod"},
+		{Code: "IST0101", ResourceName: "VirtualService *"},
+	}))
+
+	suppress = []string{"IST0102"}
+	_, err = analysisSuppressions()
+	g.Expect(err).NotTo(BeNil())
+
+	suppress = nil
+	suppressFile = "nonexistent-file.yaml"
+	_, err = analysisSuppressions()
+	g.Expect(err).NotTo(BeNil())
+}
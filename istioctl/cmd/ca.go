@@ -0,0 +1,294 @@
+// Copyright 2019 Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	securitycmd "istio.io/istio/security/pkg/cmd"
+	"istio.io/istio/security/pkg/pki/rollover"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type rolloverStartOptions struct {
+	// The files of the new CA key and certificates. A self-signed root is generated if none is set.
+	signingCertFile string
+	signingKeyFile  string
+	certChainFile   string
+	rootCertFile    string
+	// The organization and TTL of the generated self-signed root.
+	org string
+	ttl time.Duration
+	// Whether the rollover advances automatically once a phase has propagated.
+	auto bool
+}
+
+// material returns the new CA key and certificates, read from the files or generated.
+func (opts *rolloverStartOptions) material() (*rollover.Material, error) {
+	if opts.signingCertFile == "" && opts.signingKeyFile == "" && opts.certChainFile == "" && opts.rootCertFile == "" {
+		cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+			TTL:          opts.ttl,
+			Org:          opts.org,
+			IsCA:         true,
+			IsSelfSigned: true,
+			RSAKeySize:   2048,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the self-signed root: %v", err)
+		}
+		return &rollover.Material{SigningCert: cert, SigningKey: key, RootCert: cert}, nil
+	}
+	if opts.signingCertFile == "" || opts.signingKeyFile == "" || opts.rootCertFile == "" {
+		return nil, fmt.Errorf("--signing-cert, --signing-key and --root-cert are required to plug in a CA certificate")
+	}
+
+	m := &rollover.Material{}
+	for _, f := range []struct {
+		path string
+		out  *[]byte
+	}{
+		{opts.signingCertFile, &m.SigningCert},
+		{opts.signingKeyFile, &m.SigningKey},
+		{opts.certChainFile, &m.CertChain},
+		{opts.rootCertFile, &m.RootCert},
+	} {
+		if f.path == "" {
+			continue
+		}
+		b, err := ioutil.ReadFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		*f.out = b
+	}
+	return m, nil
+}
+
+func caCmd() *cobra.Command {
+	caCmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the Istio certificate authority",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.HelpFunc()(cmd, args)
+			if len(args) != 0 {
+				return fmt.Errorf("unknown subcommand %q", args[0])
+			}
+			return nil
+		},
+	}
+	caCmd.AddCommand(rolloverCmd())
+	return caCmd
+}
+
+func rolloverCmd() *cobra.Command {
+	rolloverCmd := &cobra.Command{
+		Use:   "rollover",
+		Short: "Roll the root certificate of Citadel over without breaking the mTLS connections",
+		Long: `istioctl experimental ca rollover drives a rollover of the root certificate of Citadel, in phases:
+
+  DistributeCombinedBundle  the new root is added to the trust bundle, Citadel still issues from the old root
+  IssueFromNewRoot          Citadel issues the workload certificates from the new root
+  RetireOldRoot             the old root is removed from the trust bundle
+  Completed                 the rollover is over
+
+Citadel reports the propagation of each phase to the workloads, and a phase only advances once it has propagated,
+either automatically or with 'istioctl experimental ca rollover advance'.
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.HelpFunc()(cmd, args)
+			if len(args) != 0 {
+				return fmt.Errorf("unknown subcommand %q", args[0])
+			}
+			return nil
+		},
+	}
+	rolloverCmd.AddCommand(rolloverStartCmd())
+	rolloverCmd.AddCommand(rolloverStatusCmd())
+	rolloverCmd.AddCommand(rolloverAdvanceCmd())
+	rolloverCmd.AddCommand(rolloverAbortCmd())
+	rolloverCmd.AddCommand(rolloverFinishCmd())
+	return rolloverCmd
+}
+
+func rolloverStore() (*rollover.Store, error) {
+	client, err := interfaceFactory(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return rollover.NewStore(client.CoreV1(), istioNamespace), nil
+}
+
+func rolloverStartCmd() *cobra.Command {
+	opts := &rolloverStartOptions{}
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a root rollover to a new CA certificate",
+		Long: `istioctl experimental ca rollover start introduces a new root in the trust bundle of the mesh.
+The new CA key and certificates are read from the given files, as for a plugged-in CA, or a self-signed root is
+generated if no file is given.
+`,
+		Example: `# Roll over to a newly generated self-signed root, advancing automatically
+istioctl experimental ca rollover start --auto
+
+# Roll over to a plugged-in CA certificate
+istioctl experimental ca rollover start --signing-cert ca-cert.pem --signing-key ca-key.pem \
+  --cert-chain cert-chain.pem --root-cert root-cert.pem`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			m, err := opts.material()
+			if err != nil {
+				return err
+			}
+			store, err := rolloverStore()
+			if err != nil {
+				return err
+			}
+			state, err := store.Start(m, opts.auto)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "Root rollover started in phase %s\n", state.Phase)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.signingCertFile, "signing-cert", "", "Path to the new CA signing certificate file.")
+	cmd.PersistentFlags().StringVar(&opts.signingKeyFile, "signing-key", "", "Path to the new CA signing key file.")
+	cmd.PersistentFlags().StringVar(&opts.certChainFile, "cert-chain", "", "Path to the new certificate chain file.")
+	cmd.PersistentFlags().StringVar(&opts.rootCertFile, "root-cert", "", "Path to the new root certificate file.")
+	cmd.PersistentFlags().StringVar(&opts.org, "org", "cluster.local",
+		"Organization of the generated self-signed root certificate.")
+	cmd.PersistentFlags().DurationVar(&opts.ttl, "ttl", securitycmd.DefaultSelfSignedCACertTTL,
+		"TTL of the generated self-signed root certificate.")
+	cmd.PersistentFlags().BoolVar(&opts.auto, "auto", false,
+		"Advance to the next phase automatically once the current phase has propagated.")
+	return cmd
+}
+
+func rolloverStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Display the phase of the root rollover and its propagation",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := rolloverStore()
+			if err != nil {
+				return err
+			}
+			state, err := store.Get()
+			if err != nil {
+				return err
+			}
+			printRolloverState(c.OutOrStdout(), state)
+			return nil
+		},
+	}
+}
+
+func printRolloverState(w io.Writer, state *rollover.State) {
+	if state == nil {
+		fmt.Fprintln(w, "No root rollover in progress")
+		return
+	}
+	fmt.Fprintf(w, "Phase:      %s (since %s)\n", state.Phase, state.LastTransitionTime.Format(time.RFC3339))
+	fmt.Fprintf(w, "Automatic:  %v\n", state.Auto)
+	if state.Phase == rollover.PhaseCompleted {
+		return
+	}
+	if state.Status.ObservedTime.IsZero() {
+		fmt.Fprintln(w, "Propagated: not observed by Citadel yet")
+		return
+	}
+	fmt.Fprintf(w, "Propagated: %v (%d/%d workload secrets up-to-date, observed at %s)\n", state.Status.Propagated,
+		state.Status.Updated, state.Status.Total, state.Status.ObservedTime.Format(time.RFC3339))
+	if len(state.Status.Pending) > 0 {
+		fmt.Fprintf(w, "Pending:    %s\n", strings.Join(state.Status.Pending, ", "))
+	}
+	if state.Status.Message != "" {
+		fmt.Fprintf(w, "Message:    %s\n", state.Status.Message)
+	}
+}
+
+func rolloverAdvanceCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "advance",
+		Short: "Advance the root rollover to its next phase",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := rolloverStore()
+			if err != nil {
+				return err
+			}
+			state, err := store.Advance(force)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "Root rollover advanced to phase %s\n", state.Phase)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().BoolVar(&force, "force", false,
+		"Advance even though the current phase has not propagated to every workload.")
+	return cmd
+}
+
+func rolloverAbortCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "abort",
+		Short: "Abort a root rollover which has not started issuing from the new root",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := rolloverStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Abort(); err != nil {
+				return err
+			}
+			fmt.Fprintln(c.OutOrStdout(), "Root rollover aborted, Citadel restores the previous trust bundle")
+			return nil
+		},
+	}
+}
+
+func rolloverFinishCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "finish",
+		Short: "Remove a completed root rollover",
+		Long: `istioctl experimental ca rollover finish removes a completed root rollover.
+For a plugged-in CA certificate, the cacerts secret must hold the new CA key and certificates first: Citadel keeps
+using the key and certificates of the rollover until it is finished.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := rolloverStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Finish(); err != nil {
+				return err
+			}
+			fmt.Fprintln(c.OutOrStdout(), "Root rollover finished")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/rollover"
+)
+
+const rolloverTestdata = "../../security/pkg/pki/testdata/multilevelpki/"
+
+func TestCARollover(t *testing.T) {
+	// The commands share the same cluster.
+	client := fake.NewSimpleClientset()
+	interfaceFactory = func(_ string) (kubernetes.Interface, error) {
+		return client, nil
+	}
+	istioNamespace = "istio-system"
+
+	cases := []struct {
+		description       string
+		args              string
+		expectedException bool
+		expectedOutput    string
+	}{
+		{
+			description:    "no rollover",
+			args:           "experimental ca rollover status",
+			expectedOutput: "No root rollover in progress\n",
+		},
+		{
+			description:       "incomplete plugged-in certificate",
+			args:              "experimental ca rollover start --signing-cert " + rolloverTestdata + "int-cert.pem",
+			expectedException: true,
+			expectedOutput: "Error: --signing-cert, --signing-key and --root-cert are required to plug in a " +
+				"CA certificate\n",
+		},
+		{
+			description: "start",
+			args: "experimental ca rollover start --signing-cert " + rolloverTestdata + "int-cert.pem --signing-key " +
+				rolloverTestdata + "int-key.pem --cert-chain " + rolloverTestdata + "int-cert-chain.pem --root-cert " +
+				rolloverTestdata + "root-cert.pem",
+			expectedOutput: "Root rollover started in phase DistributeCombinedBundle\n",
+		},
+		{
+			description:       "second start",
+			args:              "experimental ca rollover start",
+			expectedException: true,
+			expectedOutput:    "Error: a rollover is already in phase DistributeCombinedBundle\n",
+		},
+		{
+			description:       "advance before the propagation",
+			args:              "experimental ca rollover advance",
+			expectedException: true,
+			expectedOutput:    "Error: phase DistributeCombinedBundle has not propagated yet (0/0 workloads up-to-date)\n",
+		},
+		{
+			description:    "forced advance",
+			args:           "experimental ca rollover advance --force",
+			expectedOutput: "Root rollover advanced to phase IssueFromNewRoot\n",
+		},
+		{
+			description:       "abort once issuing from the new root",
+			args:              "experimental ca rollover abort",
+			expectedException: true,
+			expectedOutput: "Error: cannot abort the rollover in phase IssueFromNewRoot, certificates may already " +
+				"be issued from the new root\n",
+		},
+		{
+			description:       "finish before completion",
+			args:              "experimental ca rollover finish",
+			expectedException: true,
+			expectedOutput:    "Error: cannot finish the rollover in phase IssueFromNewRoot\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			verifyCAOutput(t, c.args, c.expectedException, c.expectedOutput)
+		})
+	}
+
+	// The status reports the propagation observed by Citadel.
+	store := rollover.NewStore(client.CoreV1(), istioNamespace)
+	if _, err := store.UpdateStatus(rollover.PhaseIssuing, rollover.Status{
+		Updated:      1,
+		Total:        2,
+		Pending:      []string{"default/istio.default"},
+		ObservedTime: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	rootCmd := GetRootCmd(strings.Split("experimental ca rollover status", " "))
+	rootCmd.SetOutput(&out)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Phase:      IssueFromNewRoot", "Automatic:  false",
+		"(1/2 workload secrets up-to-date", "Pending:    default/istio.default"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the status, got\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCARolloverAbort(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	interfaceFactory = func(_ string) (kubernetes.Interface, error) {
+		return client, nil
+	}
+	istioNamespace = "istio-system"
+
+	verifyCAOutput(t, "experimental ca rollover start --auto", false,
+		"Root rollover started in phase DistributeCombinedBundle\n")
+	verifyCAOutput(t, "experimental ca rollover status", false, "")
+	verifyCAOutput(t, "experimental ca rollover abort", false,
+		"Root rollover aborted, Citadel restores the previous trust bundle\n")
+	verifyCAOutput(t, "experimental ca rollover status", false, "No root rollover in progress\n")
+}
+
+func verifyCAOutput(t *testing.T, args string, expectedException bool, expectedOutput string) {
+	t.Helper()
+
+	var out bytes.Buffer
+	rootCmd := GetRootCmd(strings.Split(args, " "))
+	rootCmd.SetOutput(&out)
+
+	fErr := rootCmd.Execute()
+	output := out.String()
+
+	if expectedException {
+		if fErr == nil {
+			t.Fatalf("Wanted an exception, didn't get one, output was %q", output)
+		}
+	} else if fErr != nil {
+		t.Fatalf("Unwanted exception: %v", fErr)
+	}
+
+	if expectedOutput != "" && expectedOutput != output {
+		t.Fatalf("Unexpected output for 'istioctl %s'\n got: %q\nwant: %q", args, output, expectedOutput)
+	}
+}
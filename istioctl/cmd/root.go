@@ -151,6 +151,8 @@ debug and diagnose their Istio mesh.
 	experimentalCmd.AddCommand(removeFromMeshCmd())
 	experimentalCmd.AddCommand(Analyze())
 	experimentalCmd.AddCommand(waitCmd())
+	experimentalCmd.AddCommand(sidecarRecommendCmd())
+	experimentalCmd.AddCommand(caCmd())
 
 	postInstallCmd.AddCommand(Webhook())
 	experimentalCmd.AddCommand(postInstallCmd)
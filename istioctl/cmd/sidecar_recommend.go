@@ -0,0 +1,246 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/istioctl/pkg/sidecar"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	istiolabels "istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+// defaultSidecarRecommendDomainSuffix is the domain suffix of the Kubernetes services if neither --domain-suffix
+// nor the trust domain of the mesh config is set.
+const defaultSidecarRecommendDomainSuffix = "cluster.local"
+
+// sidecarRecommendConfigTypes are the types of the Istio configs the xDS config of a sidecar is generated from.
+var sidecarRecommendConfigTypes = []string{
+	schemas.VirtualService.Type,
+	schemas.DestinationRule.Type,
+	schemas.ServiceEntry.Type,
+	schemas.Sidecar.Type,
+}
+
+func sidecarRecommendCmd() *cobra.Command {
+	var (
+		metricsFile  string
+		perWorkload  bool
+		includes     []string
+		domainSuffix string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sidecar-recommend [<pod-name>[.<namespace>]]",
+		Short: "Recommend minimal Sidecar resources from the observed traffic",
+		Long: `
+Recommends minimal Sidecar resources from the services the workloads depend on.
+
+The dependencies are read either from a file with the Istio standard metrics in the Prometheus
+text format (e.g. the output of the Prometheus federation endpoint), or from the Envoy cluster
+stats of a pod. The generated Sidecar resources only allow egress to the observed services and
+to the hosts given by --include.
+
+When a pod is given, a Sidecar is generated for its workload only, selected by its app label, as
+the stats of one pod don't tell the dependencies of the other workloads of its namespace. The
+expected config size reduction of its proxy is estimated by generating its xDS config from the
+services and the Istio configs of the cluster before and after applying the recommended Sidecar,
+and printed to stderr.
+
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		Example: `
+# Recommend a Sidecar per namespace from the metrics exported by Prometheus
+curl -s 'http://prometheus:9090/federate?match[]=istio_requests_total' > metrics.txt
+istioctl experimental sidecar-recommend --metrics-file metrics.txt
+
+# Recommend a Sidecar per workload from the metrics exported by Prometheus
+istioctl experimental sidecar-recommend --metrics-file metrics.txt --per-workload
+
+# Recommend a Sidecar for the workload of a pod from its Envoy stats and estimate the reduction
+istioctl experimental sidecar-recommend productpage-v1-7d4c8f8b4-abcde.default`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 0) == (metricsFile == "") {
+				cmd.Println(cmd.UsageString())
+				return errors.New("either a pod or --metrics-file must be specified")
+			}
+			if len(args) > 1 {
+				cmd.Println(cmd.UsageString())
+				return errors.New("at most one pod can be specified")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if metricsFile != "" {
+				scope := sidecar.ScopeNamespace
+				if perWorkload {
+					scope = sidecar.ScopeWorkload
+				}
+				f, err := os.Open(metricsFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close() // nolint: errcheck
+				deps, err := sidecar.ParsePrometheus(f)
+				if err != nil {
+					return err
+				}
+				writeYAMLOutput(schemas.Istio, sidecar.Generate(deps, scope, includes), cmd.OutOrStdout())
+				return nil
+			}
+
+			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
+			return recommendForPod(cmd, podName, ns, includes, domainSuffix)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&metricsFile, "metrics-file", "",
+		"File with the Istio standard metrics in the Prometheus text format")
+	cmd.PersistentFlags().BoolVar(&perWorkload, "per-workload", false,
+		"Generate a Sidecar per workload selected by the app label instead of per namespace, always done for a pod")
+	cmd.PersistentFlags().StringSliceVar(&includes, "include", sidecar.DefaultIncludes,
+		"Egress hosts in the namespace/dnsName format always allowed by the generated Sidecar")
+	cmd.PersistentFlags().StringVar(&domainSuffix, "domain-suffix", "",
+		"Domain suffix of the Kubernetes services, defaults to the trust domain of the mesh config or "+
+			defaultSidecarRecommendDomainSuffix)
+
+	return cmd
+}
+
+func recommendForPod(cmd *cobra.Command, podName, ns string, includes []string, domainSuffix string) error {
+	client, err := interfaceFactory(kubeconfig)
+	if err != nil {
+		return err
+	}
+	pod, err := client.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s.%s: %v", podName, ns, err)
+	}
+	kubeClient, err := clientExecFactory(kubeconfig, configContext)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %v", err)
+	}
+	stats, err := kubeClient.EnvoyDo(podName, ns, "GET", "stats", nil)
+	if err != nil {
+		return fmt.Errorf("failed to execute command on sidecar: %v", err)
+	}
+	cfg, err := recommendPodSidecar(pod, stats, includes)
+	if err != nil {
+		return err
+	}
+	writeYAMLOutput(schemas.Istio, []model.Config{cfg}, cmd.OutOrStdout())
+
+	mesh, domainSuffix, err := readSidecarRecommendMesh(client, domainSuffix)
+	if err != nil {
+		return err
+	}
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		IPAddresses:     []string{pod.Status.PodIP},
+		ID:              pod.Name + "." + ns,
+		DNSDomain:       ns + ".svc." + domainSuffix,
+		ConfigNamespace: ns,
+		Metadata:        &model.NodeMetadata{},
+		WorkloadLabels:  istiolabels.Collection{pod.Labels},
+	}
+	return printReduction(cmd, mesh, proxy, cfg)
+}
+
+// recommendPodSidecar generates the Sidecar of the workload of a pod from its Envoy stats. The Sidecar always
+// selects the workload by its app label, the stats of a single pod can't stand for its whole namespace.
+func recommendPodSidecar(pod *v1.Pod, stats []byte, includes []string) (model.Config, error) {
+	app := pod.Labels["app"]
+	if app == "" {
+		return model.Config{}, fmt.Errorf("pod %s.%s has no app label, can't generate a Sidecar for its workload",
+			pod.Name, pod.Namespace)
+	}
+	deps, err := sidecar.ParseEnvoyStats(bytes.NewReader(stats), app, pod.Namespace)
+	if err != nil {
+		return model.Config{}, err
+	}
+	if configs := sidecar.Generate(deps, sidecar.ScopeWorkload, includes); len(configs) > 0 {
+		return configs[0], nil
+	}
+	// No dependency observed, only allow the included hosts.
+	return sidecar.NewSidecar(pod.Namespace, app, includes), nil
+}
+
+// readSidecarRecommendMesh reads the services, the Istio configs and the mesh config of the cluster. It also
+// returns the domain suffix the services are converted with, the trust domain of the mesh config if not given.
+func readSidecarRecommendMesh(client kubernetes.Interface, domainSuffix string) (*sidecar.Mesh, string, error) {
+	meshConfig, err := getMeshConfigFromConfigMap(kubeconfig, "sidecar-recommend")
+	if err != nil {
+		return nil, "", err
+	}
+	if domainSuffix == "" {
+		domainSuffix = sidecarRecommendDomainSuffix(meshConfig)
+	}
+
+	services, err := client.CoreV1().Services(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list services: %v", err)
+	}
+	mesh := &sidecar.Mesh{MeshConfig: meshConfig}
+	for _, svc := range services.Items {
+		mesh.Services = append(mesh.Services, kube.ConvertService(svc, domainSuffix, ""))
+	}
+
+	configClient, err := clientFactory()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, typ := range sidecarRecommendConfigTypes {
+		configs, err := configClient.List(typ, metav1.NamespaceAll)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list %s: %v", typ, err)
+		}
+		mesh.Configs = append(mesh.Configs, configs...)
+	}
+	return mesh, domainSuffix, nil
+}
+
+// sidecarRecommendDomainSuffix returns the trust domain of the mesh config, which is the domain suffix of the
+// services unless the cluster uses another trust domain, or the default domain suffix if not set.
+func sidecarRecommendDomainSuffix(meshConfig *meshconfig.MeshConfig) string {
+	if meshConfig != nil && meshConfig.TrustDomain != "" {
+		return meshConfig.TrustDomain
+	}
+	return defaultSidecarRecommendDomainSuffix
+}
+
+func printReduction(cmd *cobra.Command, mesh *sidecar.Mesh, proxy *model.Proxy, cfg model.Config) error {
+	r, err := sidecar.EstimateReduction(mesh, proxy, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to estimate the config size reduction: %v", err)
+	}
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Estimated config size reduction: %.1f%% (%d -> %d bytes), "+
+		"listeners: %d -> %d, clusters: %d -> %d, virtual hosts: %d -> %d\n", r.Percent(), r.Before.Bytes,
+		r.After.Bytes, r.Before.Listeners, r.After.Listeners, r.Before.Clusters, r.After.Clusters,
+		r.Before.VirtualHosts, r.After.VirtualHosts)
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/test/util"
+)
+
+func TestRecommendPodSidecar(t *testing.T) {
+	stats := util.ReadFile("../pkg/sidecar/testdata/stats.txt", t)
+	pod := func(labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "productpage-v1-abcde", Namespace: "default", Labels: labels}}
+	}
+
+	cases := []struct {
+		name      string
+		pod       *v1.Pod
+		stats     []byte
+		wantHosts []string
+		wantErr   bool
+	}{
+		{
+			name:      "observed dependencies",
+			pod:       pod(map[string]string{"app": "productpage"}),
+			stats:     stats,
+			wantHosts: []string{"*/mongodb.external.com", "default/reviews.default.svc.cluster.local", "istio-system/*"},
+		},
+		{
+			name:      "no dependency",
+			pod:       pod(map[string]string{"app": "productpage"}),
+			wantHosts: []string{"istio-system/*"},
+		},
+		{
+			name:    "no app label",
+			pod:     pod(nil),
+			stats:   stats,
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := recommendPodSidecar(c.pod, c.stats, []string{"istio-system/*"})
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Name != "productpage" || cfg.Namespace != "default" {
+				t.Errorf("got Sidecar %s.%s, want productpage.default", cfg.Name, cfg.Namespace)
+			}
+			spec := cfg.Spec.(*networking.Sidecar)
+			// A single pod must never produce a Sidecar for the whole namespace.
+			if spec.WorkloadSelector == nil || spec.WorkloadSelector.Labels["app"] != "productpage" {
+				t.Errorf("got workload selector %v, want app=productpage", spec.WorkloadSelector)
+			}
+			if got := spec.Egress[0].Hosts; !reflect.DeepEqual(got, c.wantHosts) {
+				t.Errorf("got egress hosts %v, want %v", got, c.wantHosts)
+			}
+		})
+	}
+}
+
+func TestSidecarRecommendDomainSuffix(t *testing.T) {
+	if got := sidecarRecommendDomainSuffix(&meshconfig.MeshConfig{}); got != "cluster.local" {
+		t.Errorf("got domain suffix %s, want cluster.local", got)
+	}
+	if got := sidecarRecommendDomainSuffix(&meshconfig.MeshConfig{TrustDomain: "example.org"}); got != "example.org" {
+		t.Errorf("got domain suffix %s, want example.org", got)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sidecar recommends minimal Sidecar resources from the dependencies observed in the
+// mesh telemetry or in the Envoy cluster stats, and estimates the config size reduction.
+package sidecar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/expfmt"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+)
+
+const (
+	unknown = "unknown"
+
+	requestsTotal       = "istio_requests_total"
+	tcpConnectionsTotal = "istio_tcp_connections_opened_total"
+
+	sourceApp                   = "source_app"
+	sourceWorkloadNamespace     = "source_workload_namespace"
+	destinationService          = "destination_service"
+	destinationServiceNamespace = "destination_service_namespace"
+
+	clusterStatPrefix = "cluster."
+)
+
+// clusterStatSuffixes are the Envoy cluster stats indicating the cluster was used.
+var clusterStatSuffixes = []string{".upstream_rq_total", ".upstream_cx_total"}
+
+// Dependency is a service called by a source workload.
+type Dependency struct {
+	// SourceApp is the app label of the source workload.
+	SourceApp string
+	// SourceNamespace is the namespace of the source workload.
+	SourceNamespace string
+	// Destination is the host name of the destination service.
+	Destination host.Name
+	// DestinationNamespace is the namespace of the destination service, empty if unknown.
+	DestinationNamespace string
+}
+
+// ParsePrometheus parses the dependencies from the Istio standard metrics in the Prometheus text
+// exposition format, e.g. the output of the Prometheus federation endpoint or of the Mixer
+// Prometheus adapter. Only the samples with a non zero value are considered.
+func ParsePrometheus(r io.Reader) ([]Dependency, error) {
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %v", err)
+	}
+
+	seen := map[Dependency]bool{}
+	for _, name := range []string{requestsTotal, tcpConnectionsTotal} {
+		family, ok := families[name]
+		if !ok {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			value := m.GetCounter().GetValue()
+			if value == 0 {
+				value = m.GetUntyped().GetValue()
+			}
+			if value == 0 {
+				continue
+			}
+
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			d := Dependency{
+				SourceApp:            labels[sourceApp],
+				SourceNamespace:      labels[sourceWorkloadNamespace],
+				Destination:          host.Name(labels[destinationService]),
+				DestinationNamespace: labels[destinationServiceNamespace],
+			}
+			if d.SourceNamespace == "" || d.SourceNamespace == unknown ||
+				d.Destination == "" || d.Destination == unknown {
+				continue
+			}
+			if d.SourceApp == unknown {
+				d.SourceApp = ""
+			}
+			if d.DestinationNamespace == unknown {
+				d.DestinationNamespace = ""
+			}
+			seen[d] = true
+		}
+	}
+	return sortDependencies(seen), nil
+}
+
+// ParseEnvoyStats parses the dependencies from the Envoy stats of a proxy, e.g. the output of the
+// Envoy admin "/stats" endpoint. The outbound clusters with requests or connections are considered.
+func ParseEnvoyStats(r io.Reader, app, namespace string) ([]Dependency, error) {
+	seen := map[Dependency]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, clusterStatPrefix) {
+			continue
+		}
+		sep := strings.LastIndex(line, ": ")
+		if sep < 0 {
+			continue
+		}
+		stat, value := line[:sep], line[sep+2:]
+		if v, err := strconv.ParseUint(value, 10, 64); err != nil || v == 0 {
+			continue
+		}
+		for _, suffix := range clusterStatSuffixes {
+			if !strings.HasSuffix(stat, suffix) {
+				continue
+			}
+			cluster := strings.TrimSuffix(strings.TrimPrefix(stat, clusterStatPrefix), suffix)
+			direction, _, hostname, _ := model.ParseSubsetKey(cluster)
+			if direction != model.TrafficDirectionOutbound || hostname == "" {
+				continue
+			}
+			seen[Dependency{
+				SourceApp:            app,
+				SourceNamespace:      namespace,
+				Destination:          hostname,
+				DestinationNamespace: hostNamespace(hostname),
+			}] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats: %v", err)
+	}
+	return sortDependencies(seen), nil
+}
+
+// hostNamespace returns the namespace of a Kubernetes service host name in the form of
+// <name>.<namespace>.svc.<domain>, or empty for other hosts.
+func hostNamespace(h host.Name) string {
+	parts := strings.Split(string(h), ".")
+	if len(parts) >= 3 && parts[2] == "svc" {
+		return parts[1]
+	}
+	return ""
+}
+
+func sortDependencies(seen map[Dependency]bool) []Dependency {
+	out := make([]Dependency, 0, len(seen))
+	for d := range seen {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.SourceNamespace != b.SourceNamespace {
+			return a.SourceNamespace < b.SourceNamespace
+		}
+		if a.SourceApp != b.SourceApp {
+			return a.SourceApp < b.SourceApp
+		}
+		return a.Destination < b.Destination
+	})
+	return out
+}
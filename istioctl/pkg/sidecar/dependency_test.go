@@ -0,0 +1,70 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrometheus(t *testing.T) {
+	f, err := os.Open("testdata/metrics.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() // nolint: errcheck
+
+	got, err := ParsePrometheus(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Dependency{
+		{SourceApp: "ratings", SourceNamespace: "backend", Destination: "mongodb.external.com"},
+		{SourceApp: "productpage", SourceNamespace: "default", Destination: "details.default.svc.cluster.local", DestinationNamespace: "default"},
+		{SourceApp: "productpage", SourceNamespace: "default", Destination: "reviews.default.svc.cluster.local", DestinationNamespace: "default"},
+		{SourceApp: "reviews", SourceNamespace: "default", Destination: "ratings.backend.svc.cluster.local", DestinationNamespace: "backend"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestParsePrometheus_Invalid(t *testing.T) {
+	if _, err := ParsePrometheus(strings.NewReader("istio_requests_total{ 1\n")); err == nil {
+		t.Errorf("want error for invalid metrics")
+	}
+}
+
+func TestParseEnvoyStats(t *testing.T) {
+	f, err := os.Open("testdata/stats.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() // nolint: errcheck
+
+	got, err := ParseEnvoyStats(f, "productpage", "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Dependency{
+		{SourceApp: "productpage", SourceNamespace: "default", Destination: "mongodb.external.com"},
+		{SourceApp: "productpage", SourceNamespace: "default", Destination: "reviews.default.svc.cluster.local", DestinationNamespace: "default"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"fmt"
+	"sort"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+const (
+	// DefaultName is the name of the namespace wide Sidecar.
+	DefaultName = "default"
+
+	appLabel = "app"
+)
+
+// DefaultIncludes are the egress hosts always added to the generated Sidecar, the control plane
+// and telemetry services in the Istio system namespace.
+var DefaultIncludes = []string{"istio-system/*"}
+
+// Scope decides how the dependencies are grouped into Sidecar resources.
+type Scope int
+
+const (
+	// ScopeNamespace generates a single Sidecar per namespace for all workloads in it.
+	ScopeNamespace Scope = iota
+	// ScopeWorkload generates a Sidecar per workload, selected by the app label.
+	ScopeWorkload
+)
+
+// Generate returns the minimal Sidecar resources allowing the given dependencies. The includes are
+// additional egress hosts in the "namespace/dnsName" format added to every Sidecar.
+func Generate(deps []Dependency, scope Scope, includes []string) []model.Config {
+	type key struct {
+		namespace string
+		app       string
+	}
+	hosts := map[key]map[string]bool{}
+	for _, d := range deps {
+		k := key{namespace: d.SourceNamespace}
+		if scope == ScopeWorkload {
+			if d.SourceApp == "" {
+				// Can't select the workload without the app label.
+				continue
+			}
+			k.app = d.SourceApp
+		}
+		if hosts[k] == nil {
+			hosts[k] = map[string]bool{}
+		}
+		hosts[k][egressHost(d)] = true
+	}
+
+	out := make([]model.Config, 0, len(hosts))
+	for k, h := range hosts {
+		for _, include := range includes {
+			h[include] = true
+		}
+		egressHosts := make([]string, 0, len(h))
+		for eh := range h {
+			egressHosts = append(egressHosts, eh)
+		}
+		sort.Strings(egressHosts)
+
+		out = append(out, NewSidecar(k.namespace, k.app, egressHosts))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// NewSidecar returns a Sidecar resource in the namespace allowing the egress hosts. The Sidecar applies to
+// the workloads with the given app label, or to the whole namespace if app is empty.
+func NewSidecar(namespace, app string, egressHosts []string) model.Config {
+	spec := &networking.Sidecar{
+		Egress: []*networking.IstioEgressListener{
+			{
+				Hosts: egressHosts,
+			},
+		},
+	}
+	name := DefaultName
+	if app != "" {
+		name = app
+		spec.WorkloadSelector = &networking.WorkloadSelector{
+			Labels: map[string]string{appLabel: app},
+		}
+	}
+	return model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      schemas.Sidecar.Type,
+			Group:     schemas.Sidecar.Group,
+			Version:   schemas.Sidecar.Version,
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// egressHost returns the egress host of the dependency in the "namespace/dnsName" format.
+func egressHost(d Dependency) string {
+	ns := d.DestinationNamespace
+	if ns == "" {
+		ns = "*"
+	}
+	return fmt.Sprintf("%s/%s", ns, d.Destination)
+}
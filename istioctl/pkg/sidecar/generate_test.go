@@ -0,0 +1,117 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"reflect"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pkg/config/schemas"
+)
+
+var testDependencies = []Dependency{
+	{SourceApp: "ratings", SourceNamespace: "backend", Destination: "mongodb.external.com"},
+	{SourceApp: "productpage", SourceNamespace: "default", Destination: "details.default.svc.cluster.local", DestinationNamespace: "default"},
+	{SourceApp: "productpage", SourceNamespace: "default", Destination: "reviews.default.svc.cluster.local", DestinationNamespace: "default"},
+	{SourceApp: "reviews", SourceNamespace: "default", Destination: "ratings.backend.svc.cluster.local", DestinationNamespace: "backend"},
+	{SourceNamespace: "default", Destination: "unlabeled.default.svc.cluster.local", DestinationNamespace: "default"},
+}
+
+func TestGenerate(t *testing.T) {
+	type sidecar struct {
+		namespace string
+		name      string
+		selector  map[string]string
+		hosts     []string
+	}
+	cases := []struct {
+		name     string
+		scope    Scope
+		includes []string
+		want     []sidecar
+	}{
+		{
+			name:     "per namespace",
+			scope:    ScopeNamespace,
+			includes: DefaultIncludes,
+			want: []sidecar{
+				{
+					namespace: "backend",
+					name:      DefaultName,
+					hosts:     []string{"*/mongodb.external.com", "istio-system/*"},
+				},
+				{
+					namespace: "default",
+					name:      DefaultName,
+					hosts: []string{
+						"backend/ratings.backend.svc.cluster.local",
+						"default/details.default.svc.cluster.local",
+						"default/reviews.default.svc.cluster.local",
+						"default/unlabeled.default.svc.cluster.local",
+						"istio-system/*",
+					},
+				},
+			},
+		},
+		{
+			name:  "per workload",
+			scope: ScopeWorkload,
+			want: []sidecar{
+				{
+					namespace: "backend",
+					name:      "ratings",
+					selector:  map[string]string{"app": "ratings"},
+					hosts:     []string{"*/mongodb.external.com"},
+				},
+				{
+					namespace: "default",
+					name:      "productpage",
+					selector:  map[string]string{"app": "productpage"},
+					hosts:     []string{"default/details.default.svc.cluster.local", "default/reviews.default.svc.cluster.local"},
+				},
+				{
+					namespace: "default",
+					name:      "reviews",
+					selector:  map[string]string{"app": "reviews"},
+					hosts:     []string{"backend/ratings.backend.svc.cluster.local"},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			configs := Generate(testDependencies, c.scope, c.includes)
+			if len(configs) != len(c.want) {
+				t.Fatalf("got %d sidecars but want %d: %v", len(configs), len(c.want), configs)
+			}
+			for i, want := range c.want {
+				cfg := configs[i]
+				if cfg.Type != schemas.Sidecar.Type || cfg.Namespace != want.namespace || cfg.Name != want.name {
+					t.Errorf("got sidecar %s %s/%s but want %s/%s", cfg.Type, cfg.Namespace, cfg.Name, want.namespace, want.name)
+				}
+				spec := cfg.Spec.(*networking.Sidecar)
+				if !reflect.DeepEqual(spec.GetWorkloadSelector().GetLabels(), want.selector) {
+					t.Errorf("%s/%s: got selector %v but want %v", cfg.Namespace, cfg.Name, spec.GetWorkloadSelector(), want.selector)
+				}
+				if got := spec.Egress[0].Hosts; !reflect.DeepEqual(got, want.hosts) {
+					t.Errorf("%s/%s: got hosts %v but want %v", cfg.Namespace, cfg.Name, got, want.hosts)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"fmt"
+	"sort"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/conversion"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pilot/pkg/serviceregistry/external"
+	memregistry "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+// Size is the size of the xDS config of a proxy.
+type Size struct {
+	Listeners    int
+	Clusters     int
+	VirtualHosts int
+	Bytes        int
+}
+
+// Reduction is the estimated xDS config size of a proxy before and after applying a Sidecar.
+type Reduction struct {
+	Before Size
+	After  Size
+}
+
+// Percent returns the reduction of the config size in bytes in percent.
+func (r *Reduction) Percent() float64 {
+	if r.Before.Bytes == 0 {
+		return 0
+	}
+	return float64(r.Before.Bytes-r.After.Bytes) * 100 / float64(r.Before.Bytes)
+}
+
+// Mesh is the state of the mesh the xDS config of a proxy is generated from.
+type Mesh struct {
+	// Services are the services of the Kubernetes registry. The services of the ServiceEntries in the configs are
+	// added to them.
+	Services []*model.Service
+
+	// Configs are the Istio networking configs, e.g. the VirtualServices, DestinationRules, ServiceEntries and
+	// Sidecars, of all the namespaces.
+	Configs []model.Config
+
+	// MeshConfig is the mesh config.
+	MeshConfig *meshconfig.MeshConfig
+}
+
+// EstimateReduction estimates the config size reduction of the sidecar proxy if the Sidecar is applied, by
+// generating its listeners, clusters and routes with the Pilot config generator before and after adding the
+// Sidecar to the mesh configs. The Sidecar replaces the existing Sidecars of its namespace.
+//
+// The service instances of the proxy are not known, the inbound listeners and clusters are not generated. They
+// are the same before and after applying a Sidecar without ingress listener.
+func EstimateReduction(mesh *Mesh, proxy *model.Proxy, sidecar model.Config) (*Reduction, error) {
+	before, err := generateSize(mesh, mesh.Configs, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	after := []model.Config{sidecar}
+	for _, c := range mesh.Configs {
+		if c.Type == schemas.Sidecar.Type && c.Namespace == sidecar.Namespace {
+			continue
+		}
+		after = append(after, c)
+	}
+	afterSize, err := generateSize(mesh, after, proxy)
+	if err != nil {
+		return nil, err
+	}
+	return &Reduction{Before: *before, After: *afterSize}, nil
+}
+
+// generateSize returns the size of the xDS config generated for the proxy from the services and the configs.
+func generateSize(mesh *Mesh, configs []model.Config, proxy *model.Proxy) (*Size, error) {
+	store := model.MakeIstioStore(memory.Make(schemas.Istio))
+	for _, c := range configs {
+		// The resource version is set by the store.
+		c.ResourceVersion = ""
+		if _, err := store.Create(c); err != nil {
+			return nil, fmt.Errorf("failed to add %s %s/%s: %v", c.Type, c.Namespace, c.Name, err)
+		}
+	}
+	services := make(map[host.Name]*model.Service, len(mesh.Services))
+	for _, svc := range mesh.Services {
+		services[svc.Hostname] = svc
+	}
+	// The ServiceEntries are converted into services the same way as Pilot does, with the ServiceEntry registry
+	// over the config store.
+	serviceEntryStore := external.NewServiceDiscovery(nil, store)
+	discovery := aggregate.NewController()
+	discovery.AddRegistry(aggregate.Registry{
+		Name:             serviceregistry.KubernetesRegistry,
+		ServiceDiscovery: memregistry.NewDiscovery(services, 0),
+		Controller:       staticController{},
+	})
+	discovery.AddRegistry(aggregate.Registry{
+		Name:             "ServiceEntries",
+		ServiceDiscovery: serviceEntryStore,
+		Controller:       serviceEntryStore,
+	})
+	env := &model.Environment{
+		ServiceDiscovery: discovery,
+		IstioConfigStore: store,
+		Mesh:             mesh.MeshConfig,
+		MeshNetworks:     &meshconfig.MeshNetworks{},
+	}
+	push := model.NewPushContext()
+	if err := push.InitContext(env, nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize the push context: %v", err)
+	}
+	env.PushContext = push
+
+	node := *proxy
+	node.SetSidecarScope(push)
+
+	// The plugins add the same filters before and after applying the Sidecar, none is needed to compare sizes.
+	configgen := core.NewConfigGenerator(nil)
+	listeners := configgen.BuildListeners(env, &node, push)
+	clusters := configgen.BuildClusters(env, &node, push)
+	routes := configgen.BuildHTTPRoutes(env, &node, push, routeNames(listeners))
+
+	size := &Size{
+		Listeners: len(listeners),
+		Clusters:  len(clusters),
+	}
+	for _, l := range listeners {
+		size.Bytes += proto.Size(l)
+	}
+	for _, c := range clusters {
+		size.Bytes += proto.Size(c)
+	}
+	for _, rc := range routes {
+		size.VirtualHosts += len(rc.GetVirtualHosts())
+		size.Bytes += proto.Size(rc)
+	}
+	return size, nil
+}
+
+// staticController is the controller of the services of the mesh, which don't change during the estimation.
+type staticController struct{}
+
+func (staticController) AppendServiceHandler(func(*model.Service, model.Event)) error {
+	return nil
+}
+
+func (staticController) AppendInstanceHandler(func(*model.ServiceInstance, model.Event)) error {
+	return nil
+}
+
+func (staticController) Run(<-chan struct{}) {}
+
+// routeNames returns the sorted names of the RDS route configs used by the HTTP connection managers of the
+// listeners.
+func routeNames(listeners []*xdsapi.Listener) []string {
+	names := map[string]struct{}{}
+	for _, l := range listeners {
+		for _, fc := range l.GetFilterChains() {
+			for _, f := range fc.GetFilters() {
+				if f.GetName() != wellknown.HTTPConnectionManager {
+					continue
+				}
+				hcm := &http_conn.HttpConnectionManager{}
+				var err error
+				switch c := f.GetConfigType().(type) {
+				case *listener.Filter_TypedConfig:
+					err = ptypes.UnmarshalAny(c.TypedConfig, hcm)
+				case *listener.Filter_Config:
+					err = conversion.StructToMessage(c.Config, hcm)
+				}
+				if err != nil || hcm.GetRds() == nil {
+					continue
+				}
+				names[hcm.GetRds().GetRouteConfigName()] = struct{}{}
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
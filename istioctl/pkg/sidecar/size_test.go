@@ -0,0 +1,137 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	memregistry "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+func TestEstimateReduction(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	m := &Mesh{
+		Services: []*model.Service{
+			memregistry.MakeService(host.Name("reviews.default.svc.cluster.local"), "10.10.0.1"),
+			memregistry.MakeService(host.Name("ratings.default.svc.cluster.local"), "10.10.0.2"),
+			memregistry.MakeService(host.Name("mysql.backend.svc.cluster.local"), "10.10.0.3"),
+			memregistry.MakeService(host.Name("redis.backend.svc.cluster.local"), "10.10.0.4"),
+		},
+		Configs:    []model.Config{NewSidecar("default", "", []string{"*/*"})},
+		MeshConfig: &meshConfig,
+	}
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		IPAddresses:     []string{"10.20.0.1"},
+		ID:              "productpage.default",
+		DNSDomain:       "default.svc.cluster.local",
+		ConfigNamespace: "default",
+		Metadata:        &model.NodeMetadata{},
+		WorkloadLabels:  labels.Collection{{"app": "productpage"}},
+	}
+
+	r, err := EstimateReduction(m, proxy, NewSidecar("default", "", []string{"./*"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.After.Clusters >= r.Before.Clusters {
+		t.Errorf("want fewer clusters after applying the Sidecar, got %d -> %d", r.Before.Clusters, r.After.Clusters)
+	}
+	if r.After.VirtualHosts >= r.Before.VirtualHosts {
+		t.Errorf("want fewer virtual hosts after applying the Sidecar, got %d -> %d", r.Before.VirtualHosts, r.After.VirtualHosts)
+	}
+	if r.Percent() <= 0 {
+		t.Errorf("want a positive reduction, got %v", r.Percent())
+	}
+
+	// The Sidecar allowing all the hosts replaces the existing one, the config is unchanged.
+	r, err = EstimateReduction(m, proxy, NewSidecar("default", "", []string{"*/*"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Before != r.After {
+		t.Errorf("want unchanged config, got %+v -> %+v", r.Before, r.After)
+	}
+}
+
+func TestEstimateReductionServiceEntries(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	m := &Mesh{
+		Services: []*model.Service{
+			memregistry.MakeService(host.Name("reviews.default.svc.cluster.local"), "10.10.0.1"),
+		},
+		Configs:    []model.Config{NewSidecar("default", "", []string{"*/*"})},
+		MeshConfig: &meshConfig,
+	}
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		IPAddresses:     []string{"10.20.0.1"},
+		ID:              "productpage.default",
+		DNSDomain:       "default.svc.cluster.local",
+		ConfigNamespace: "default",
+		Metadata:        &model.NodeMetadata{},
+		WorkloadLabels:  labels.Collection{{"app": "productpage"}},
+	}
+	withoutServiceEntry, err := EstimateReduction(m, proxy, NewSidecar("default", "", []string{"./*"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Configs = append(m.Configs, model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      schemas.ServiceEntry.Type,
+			Group:     schemas.ServiceEntry.Group,
+			Version:   schemas.ServiceEntry.Version,
+			Name:      "external-api",
+			Namespace: "backend",
+		},
+		Spec: &networking.ServiceEntry{
+			Hosts:      []string{"api.example.com"},
+			Ports:      []*networking.Port{{Number: 443, Name: "tls", Protocol: "TLS"}},
+			Location:   networking.ServiceEntry_MESH_EXTERNAL,
+			Resolution: networking.ServiceEntry_DNS,
+		},
+	})
+	r, err := EstimateReduction(m, proxy, NewSidecar("default", "", []string{"./*"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The ServiceEntry adds a cluster before applying the Sidecar, which only imports the namespace of the proxy.
+	if r.Before.Clusters != withoutServiceEntry.Before.Clusters+1 {
+		t.Errorf("want the cluster of the ServiceEntry before applying the Sidecar, got %d clusters, want %d",
+			r.Before.Clusters, withoutServiceEntry.Before.Clusters+1)
+	}
+	if r.After != withoutServiceEntry.After {
+		t.Errorf("want the ServiceEntry out of the config after applying the Sidecar, got %+v, want %+v",
+			r.After, withoutServiceEntry.After)
+	}
+}
+
+func TestReductionPercent(t *testing.T) {
+	r := &Reduction{Before: Size{Bytes: 200}, After: Size{Bytes: 50}}
+	if got := r.Percent(); got != 75 {
+		t.Errorf("got %v but want 75", got)
+	}
+	if got := (&Reduction{}).Percent(); got != 0 {
+		t.Errorf("got %v but want 0", got)
+	}
+}
@@ -15,13 +15,19 @@
 package cmd
 
 import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/hashicorp/go-multierror"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/security/pkg/pki/federation"
+	"istio.io/istio/security/pkg/pki/revocation"
 )
 
 // ReadMeshConfig gets mesh configuration from a config file
@@ -41,3 +47,48 @@ func ReadMeshNetworksConfig(filename string) (*meshconfig.MeshNetworks, error) {
 	}
 	return mesh.LoadMeshNetworksConfig(string(yaml))
 }
+
+// ReadExtAuthzConfig gets the external authorization providers from a config file
+func ReadExtAuthzConfig(filename string) (*model.ExtAuthzConfig, error) {
+	yaml, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, multierror.Prefix(err, "cannot read external authorization config file")
+	}
+	return model.LoadExtAuthzConfig(string(yaml))
+}
+
+// ReadRevocationDenyList gets the certificate revocation deny list from a config file
+func ReadRevocationDenyList(filename string) (*revocation.DenyList, error) {
+	yaml, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, multierror.Prefix(err, "cannot read revocation deny list file")
+	}
+	return revocation.Parse(yaml)
+}
+
+// ReadRevocationCRL gets the PEM certificate revocation lists from a file, one per trusted root
+func ReadRevocationCRL(filename string) ([]byte, error) {
+	crl, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, rest := pem.Decode(crl)
+	if block == nil {
+		return nil, fmt.Errorf("invalid certificate revocation list: no PEM data")
+	}
+	for ; block != nil; block, rest = pem.Decode(rest) {
+		if _, err := x509.ParseDERCRL(block.Bytes); err != nil {
+			return nil, multierror.Prefix(err, "invalid certificate revocation list")
+		}
+	}
+	return crl, nil
+}
+
+// ReadFederationConfig gets the SPIFFE federation configuration from a config file
+func ReadFederationConfig(filename string) (*federation.Config, error) {
+	yaml, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, multierror.Prefix(err, "cannot read federation config file")
+	}
+	return federation.LoadConfig(yaml)
+}
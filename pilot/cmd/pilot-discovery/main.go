@@ -108,6 +108,14 @@ func init() {
 		fmt.Sprintf("File name for Istio mesh configuration. If not specified, a default mesh will be used."))
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.NetworksConfigFile, "networksConfig", "/etc/istio/config/meshNetworks",
 		fmt.Sprintf("File name for Istio mesh networks configuration. If not specified, a default mesh networks will be used."))
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.ExtAuthzConfigFile, "extAuthzConfig", "/etc/istio/config/extAuthz",
+		"File name for the external authorization providers an AuthorizationPolicy can delegate to. If not specified, no provider is configured.")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.RevocationDenyListFile, "revocationDenyList", "",
+		"File name for the certificate revocation deny list. If not specified, no peer will be denied.")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.RevocationCRLFile, "revocationCRL", "",
+		"File name for the certificate revocation list published by Citadel. If not specified, no certificate will be revoked.")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.FederationConfigFile, "federationConfig", "",
+		"File name for the SPIFFE federation configuration, also given to the node agents for the inbound connections. If not specified, only the local trust domain will be trusted.")
 	discoveryCmd.PersistentFlags().StringVarP(&serverArgs.Namespace, "namespace", "n", "",
 		"Select a namespace where the controller resides. If not set, uses ${POD_NAMESPACE} environment variable")
 	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Plugins, "plugins", bootstrap.DefaultPlugins,
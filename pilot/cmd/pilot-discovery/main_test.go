@@ -0,0 +1,50 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"istio.io/istio/pilot/pkg/bootstrap"
+	"istio.io/istio/pkg/test/env"
+)
+
+// TestDiscoveryDefaultArgs starts Pilot with the default value of every flag, except the ones
+// pointing to the environment: the registries, the config source and the listening addresses.
+// The optional configuration files missing in a default install must not prevent Pilot to start.
+func TestDiscoveryDefaultArgs(t *testing.T) {
+	if err := discoveryCmd.ParseFlags([]string{
+		"--registries=Mock",
+		"--configDir=" + env.IstioSrc + "/tests/testdata/config",
+		"--httpAddr=127.0.0.1:0",
+		"--grpcAddr=127.0.0.1:0",
+		"--secureGrpcAddr=",
+		"--monitoringAddr=127.0.0.1:0",
+		"--ctrlz_port=0",
+	}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	bootstrap.PilotCertDir = env.IstioSrc + "/tests/testdata/certs/pilot"
+
+	s, err := bootstrap.NewServer(serverArgs)
+	if err != nil {
+		t.Fatalf("failed to create discovery service: %v", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := s.Start(stop); err != nil {
+		t.Fatalf("failed to start discovery service: %v", err)
+	}
+}
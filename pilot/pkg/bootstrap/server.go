@@ -15,6 +15,7 @@
 package bootstrap
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -82,11 +83,15 @@ import (
 	"istio.io/istio/pkg/mcp/creds"
 	"istio.io/istio/pkg/mcp/monitoring"
 	"istio.io/istio/pkg/mcp/sink"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/pki/federation"
+	"istio.io/istio/security/pkg/pki/revocation"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -211,6 +216,10 @@ type PilotArgs struct {
 	Service                  ServiceArgs
 	MeshConfig               *meshconfig.MeshConfig
 	NetworksConfigFile       string
+	ExtAuthzConfigFile       string
+	RevocationDenyListFile   string
+	RevocationCRLFile        string
+	FederationConfigFile     string
 	CtrlZOptions             *ctrlz.Options
 	Plugins                  []string
 	MCPMaxMessageSize        int
@@ -257,6 +266,10 @@ type Server struct {
 
 	mesh             *meshconfig.MeshConfig
 	meshNetworks     *meshconfig.MeshNetworks
+	extAuthz         *model.ExtAuthzConfig
+	denyList         *revocation.DenyList
+	revocationCRL    []byte
+	federation       *federation.Store
 	configController model.ConfigStoreCache
 
 	kubeClient            kubernetes.Interface
@@ -312,6 +325,13 @@ func NewServer(args PilotArgs) (*Server, error) {
 	if err := s.initMeshNetworks(&args); err != nil {
 		return nil, fmt.Errorf("mesh networks: %v", err)
 	}
+	s.initExtAuthz(&args)
+	if err := s.initRevocationDenyList(&args); err != nil {
+		return nil, fmt.Errorf("revocation deny list: %v", err)
+	}
+	if err := s.initFederation(&args); err != nil {
+		return nil, fmt.Errorf("federation: %v", err)
+	}
 	// Certificate controller is created before MCP
 	// controller in case MCP server pod waits to mount a certificate
 	// to be provisioned by the certificate controller.
@@ -494,6 +514,156 @@ func (s *Server) initMesh(args *PilotArgs) error {
 	return nil
 }
 
+// initExtAuthz loads the external authorization providers from the file provided in the args and add a watcher
+// for changes in this file. No provider is configured until the file exists and is valid, the policies delegating
+// to an unknown provider deny all the requests.
+func (s *Server) initExtAuthz(args *PilotArgs) {
+	if args.ExtAuthzConfigFile == "" {
+		log.Info("external authorization providers not provided")
+		return
+	}
+	if _, err := os.Stat(args.ExtAuthzConfigFile); os.IsNotExist(err) {
+		log.Infof("external authorization providers file %q not found", args.ExtAuthzConfigFile)
+	} else if extAuthz, err := cmd.ReadExtAuthzConfig(args.ExtAuthzConfigFile); err != nil {
+		log.Errorf("failed to read external authorization providers from %q, no provider is configured: %v",
+			args.ExtAuthzConfigFile, err)
+	} else {
+		log.Infof("external authorization providers %s", spew.Sdump(extAuthz))
+		s.extAuthz = extAuthz
+	}
+
+	// Watch the external authorization providers file for changes and reload if it got modified
+	s.addFileWatcher(args.ExtAuthzConfigFile, func() {
+		extAuthz, err := cmd.ReadExtAuthzConfig(args.ExtAuthzConfigFile)
+		if err != nil {
+			log.Warnf("failed to read external authorization providers from %q, keeping the previous ones: %v",
+				args.ExtAuthzConfigFile, err)
+			return
+		}
+		if !reflect.DeepEqual(extAuthz, s.extAuthz) {
+			log.Infof("external authorization providers updated to: %s", spew.Sdump(extAuthz))
+			s.extAuthz = extAuthz
+			if s.EnvoyXdsServer != nil {
+				s.EnvoyXdsServer.Env.ExtAuthz = extAuthz
+				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+			}
+		}
+	})
+}
+
+// initRevocationDenyList loads the certificate revocation deny list and revocation list from the files
+// provided in the args and add watchers for changes in these files.
+func (s *Server) initRevocationDenyList(args *PilotArgs) error {
+	if args.RevocationDenyListFile == "" {
+		log.Info("revocation deny list not provided")
+	} else {
+		denyList, err := cmd.ReadRevocationDenyList(args.RevocationDenyListFile)
+		if err != nil {
+			return fmt.Errorf("failed to read revocation deny list from %q: %v", args.RevocationDenyListFile, err)
+		}
+		log.Infof("revocation deny list %s", spew.Sdump(denyList))
+		s.denyList = denyList
+
+		// Watch the revocation deny list file for changes and reload if it got modified
+		s.addFileWatcher(args.RevocationDenyListFile, func() {
+			// Reload the config file
+			denyList, err := cmd.ReadRevocationDenyList(args.RevocationDenyListFile)
+			if err != nil {
+				log.Warnf("failed to read revocation deny list from %q: %v", args.RevocationDenyListFile, err)
+				return
+			}
+			if !reflect.DeepEqual(denyList, s.denyList) {
+				log.Infof("revocation deny list file updated to: %s", spew.Sdump(denyList))
+				s.denyList = denyList
+				if s.EnvoyXdsServer != nil {
+					s.EnvoyXdsServer.Env.RevokedIdentities = denyList.Identities
+					s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+				}
+			}
+		})
+	}
+
+	if args.RevocationCRLFile == "" {
+		log.Info("certificate revocation list not provided")
+		return nil
+	}
+
+	// The revocation list is published by Citadel, it is missing until Citadel first publishes it.
+	crl, err := cmd.ReadRevocationCRL(args.RevocationCRLFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read certificate revocation list from %q: %v", args.RevocationCRLFile, err)
+	}
+	s.revocationCRL = crl
+
+	// Watch the revocation list file for changes and reload if it got modified
+	s.addFileWatcher(args.RevocationCRLFile, func() {
+		crl, err := cmd.ReadRevocationCRL(args.RevocationCRLFile)
+		if err != nil {
+			log.Warnf("failed to read certificate revocation list from %q: %v", args.RevocationCRLFile, err)
+			return
+		}
+		if !bytes.Equal(crl, s.revocationCRL) {
+			log.Info("certificate revocation list file updated")
+			s.revocationCRL = crl
+			if s.EnvoyXdsServer != nil {
+				s.EnvoyXdsServer.Env.RevocationCRL = crl
+				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+			}
+		}
+	})
+
+	return nil
+}
+
+// initFederation loads the SPIFFE federation configuration from the file provided in the args, and
+// periodically refreshes the trust bundles of the federated trust domains from their sources. Changes to the
+// configuration file itself require a restart. A configuration or trust bundle failing to load is an error,
+// rather than silently federating with fewer trust domains than configured.
+// The outbound connections to a federated trust domain trust its roots alone. The inbound connections trust the
+// ROOTCA_FEDERATED resource of the node agents, which must be given the same configuration to serve the roots of
+// the federated trust domains along with the local root. The revoked serial numbers can't be enforced on the
+// inbound connections then, since Envoy requires a revocation list issued by every trusted root.
+func (s *Server) initFederation(args *PilotArgs) error {
+	if args.FederationConfigFile == "" {
+		log.Info("federation configuration not provided")
+		return nil
+	}
+
+	config, err := cmd.ReadFederationConfig(args.FederationConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read federation configuration from %q: %v", args.FederationConfigFile, err)
+	}
+	for _, td := range config.TrustDomains {
+		if td.Name == spiffe.GetTrustDomain() {
+			return fmt.Errorf("the local trust domain %s can't be federated", td.Name)
+		}
+	}
+	log.Infof("federation configuration %s", spew.Sdump(config))
+	if args.RevocationCRLFile != "" {
+		log.Warn("the revoked certificates are not rejected by the inbound connections of the federated mesh")
+	}
+
+	var core corev1.CoreV1Interface
+	if s.kubeClient != nil {
+		core = s.kubeClient.CoreV1()
+	}
+	s.federation = federation.NewStore(config, core)
+	if _, err := s.federation.Refresh(); err != nil {
+		return fmt.Errorf("failed to load the trust bundles of the federated trust domains: %v", err)
+	}
+
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go s.federation.Run(federation.DefaultRefreshInterval, stop, func() {
+			if s.EnvoyXdsServer != nil {
+				s.EnvoyXdsServer.Env.FederatedRoots = s.federation.RootCertsPEM()
+				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+			}
+		})
+		return nil
+	})
+	return nil
+}
+
 // initMeshNetworks loads the mesh networks configuration from the file provided
 // in the args and add a watcher for changes in this file.
 func (s *Server) initMeshNetworks(args *PilotArgs) error { //nolint: unparam
@@ -1044,10 +1214,16 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 	environment := &model.Environment{
 		Mesh:             s.mesh,
 		MeshNetworks:     s.meshNetworks,
+		ExtAuthz:         s.extAuthz,
+		RevocationCRL:    s.revocationCRL,
+		FederatedRoots:   s.federation.RootCertsPEM(),
 		IstioConfigStore: s.istioConfigStore,
 		ServiceDiscovery: s.ServiceController,
 		PushContext:      model.NewPushContext(),
 	}
+	if s.denyList != nil {
+		environment.RevokedIdentities = s.denyList.Identities
+	}
 
 	s.EnvoyXdsServer = envoyv2.NewDiscoveryServer(environment,
 		istio_networking.NewConfigGenerator(args.Plugins),
@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"istio.io/istio/pkg/config/accesslog/v1alpha1"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+// AccessLogPolicy is an AccessLogPolicy resource with its spec.
+type AccessLogPolicy struct {
+	// Name of the policy, used in the Envoy runtime key of the sampling filter.
+	Name string
+
+	// Namespace of the policy.
+	Namespace string
+
+	// Spec of the policy.
+	Spec *v1alpha1.AccessLogPolicy
+}
+
+// AccessLogPolicies stores the access log policies in the cluster.
+type AccessLogPolicies struct {
+	// namespaceToPolicies maps a namespace to its policies, sorted by creation time and name.
+	namespaceToPolicies map[string][]*AccessLogPolicy
+
+	// rootNamespace is the namespace of the policies applying to all the namespaces.
+	rootNamespace string
+}
+
+// GetAccessLogPolicies returns the access log policies in the cluster.
+func GetAccessLogPolicies(env *Environment) (*AccessLogPolicies, error) {
+	configs, err := env.List(schemas.AccessLogPolicy.Type, NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	sortConfigByCreationTime(configs)
+
+	policies := &AccessLogPolicies{
+		namespaceToPolicies: map[string][]*AccessLogPolicy{},
+		rootNamespace:       env.Mesh.GetRootNamespace(),
+	}
+	for _, config := range configs {
+		policy, ok := config.Spec.(*v1alpha1.AccessLogPolicy)
+		if !ok {
+			log.Warnf("skipped access log policy %s/%s: unexpected spec type %T", config.Namespace, config.Name, config.Spec)
+			continue
+		}
+		policies.namespaceToPolicies[config.Namespace] = append(policies.namespaceToPolicies[config.Namespace],
+			&AccessLogPolicy{Name: config.Name, Namespace: config.Namespace, Spec: policy})
+	}
+	return policies, nil
+}
+
+// PoliciesForWorkload returns the policies selecting the workload with the given labels in the namespace. The
+// policies of the root namespace come first.
+func (p *AccessLogPolicies) PoliciesForWorkload(namespace string, workloadLabels labels.Collection) []*AccessLogPolicy {
+	if p == nil {
+		return nil
+	}
+	namespaces := []string{namespace}
+	if p.rootNamespace != "" && p.rootNamespace != namespace {
+		namespaces = []string{p.rootNamespace, namespace}
+	}
+	var out []*AccessLogPolicy
+	for _, ns := range namespaces {
+		for _, policy := range p.namespaceToPolicies[ns] {
+			if len(policy.Spec.Selector) > 0 && !workloadLabels.IsSupersetOf(policy.Spec.Selector) {
+				continue
+			}
+			out = append(out, policy)
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/accesslog/v1alpha1"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+func TestGetAccessLogPolicies(t *testing.T) {
+	policies := []struct {
+		name      string
+		namespace string
+		spec      string
+	}{
+		{name: "mesh", namespace: "istio-system", spec: `{"sinks": [{"file": "/dev/stdout"}]}`},
+		{name: "foo", namespace: "foo", spec: `{"sinks": [{"file": "/dev/stdout"}]}`},
+		{
+			name:      "foo-app",
+			namespace: "foo",
+			spec: `{
+				"selector": {"app": "a"},
+				"filter": {"minDuration": "100ms"},
+				"sinks": [{"grpc": {}}]
+			}`,
+		},
+		{name: "bar", namespace: "bar", spec: `{"sinks": [{"file": "/dev/stdout"}]}`},
+	}
+
+	store := model.MakeIstioStore(memory.Make(schemas.Istio))
+	for _, p := range policies {
+		spec := &v1alpha1.AccessLogPolicy{}
+		if err := jsonpb.UnmarshalString(p.spec, spec); err != nil {
+			t.Fatalf("failed to parse policy %s: %v", p.name, err)
+		}
+		if _, err := store.Create(model.Config{
+			ConfigMeta: model.ConfigMeta{
+				Type:      schemas.AccessLogPolicy.Type,
+				Group:     schemas.AccessLogPolicy.Group,
+				Version:   schemas.AccessLogPolicy.Version,
+				Name:      p.name,
+				Namespace: p.namespace,
+			},
+			Spec: spec,
+		}); err != nil {
+			t.Fatalf("failed to create policy %s: %v", p.name, err)
+		}
+	}
+	m := mesh.DefaultMeshConfig()
+	env := &model.Environment{Mesh: &m, IstioConfigStore: store}
+
+	got, err := model.GetAccessLogPolicies(env)
+	if err != nil {
+		t.Fatalf("failed to get access log policies: %v", err)
+	}
+
+	cases := []struct {
+		namespace string
+		labels    labels.Collection
+		want      []string
+	}{
+		{namespace: "foo", labels: labels.Collection{{"app": "a", "version": "v1"}}, want: []string{"mesh", "foo", "foo-app"}},
+		{namespace: "foo", labels: labels.Collection{{"app": "b"}}, want: []string{"mesh", "foo"}},
+		{namespace: "bar", want: []string{"mesh", "bar"}},
+		{namespace: "baz", want: []string{"mesh"}},
+		{namespace: "istio-system", want: []string{"mesh"}},
+	}
+	for _, tc := range cases {
+		var names []string
+		for _, p := range got.PoliciesForWorkload(tc.namespace, tc.labels) {
+			names = append(names, p.Namespace+"/"+p.Name)
+		}
+		var want []string
+		for _, name := range tc.want {
+			if name == "mesh" {
+				want = append(want, "istio-system/mesh")
+			} else {
+				want = append(want, tc.namespace+"/"+name)
+			}
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("%s %v: got %v but want %v", tc.namespace, tc.labels, names, want)
+		}
+	}
+
+	app := got.PoliciesForWorkload("foo", labels.Collection{{"app": "a"}})[2]
+	if app.Spec.Encoding != v1alpha1.TEXT {
+		t.Errorf("got encoding %s but want TEXT", app.Spec.Encoding)
+	}
+	if d, _ := types.DurationFromProto(app.Spec.Filter.GetMinDuration()); d != 100*time.Millisecond {
+		t.Errorf("got min duration %v but want 100ms", d)
+	}
+
+	var nilPolicies *model.AccessLogPolicies
+	if got := nilPolicies.PoliciesForWorkload("foo", nil); got != nil {
+		t.Errorf("want no policy for nil policies but got %v", got)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -57,6 +58,32 @@ type Environment struct {
 	// routable L3 network. A single routable L3 network can have one or more
 	// service registries.
 	MeshNetworks *meshconfig.MeshNetworks
+
+	// ExtAuthz (loaded from the external authorization config file) provides the external
+	// authorization services that could be selected by the AuthorizationPolicy.
+	ExtAuthz *ExtAuthzConfig
+
+	// RevokedIdentities (loaded from the revocation deny list config map) are the identities whose
+	// connections and requests are denied by the workloads.
+	RevokedIdentities []string
+
+	// RevocationCRL (published by Citadel in a config map) is the PEM certificate revocation list of
+	// the revoked serial numbers, checked by the workloads when validating the certificates of their peers.
+	RevocationCRL []byte
+
+	// FederatedRoots are the PEM root certificates of the foreign trust domains federated with the mesh,
+	// by trust domain, whose workloads are trusted by the proxies.
+	FederatedRoots map[string][]byte
+}
+
+// FederatedTrustDomains returns the sorted names of the trust domains federated with the mesh.
+func (e *Environment) FederatedTrustDomains() []string {
+	names := make([]string, 0, len(e.FederatedRoots))
+	for name := range e.FederatedRoots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Proxy contains information about an specific instance of a proxy (envoy sidecar, gateway,
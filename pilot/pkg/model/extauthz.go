@@ -0,0 +1,165 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/config/validation"
+)
+
+// ExtAuthzProtocol is the protocol used to talk to an external authorization service.
+type ExtAuthzProtocol string
+
+const (
+	// ExtAuthzProtocolGRPC uses the Envoy external authorization gRPC API.
+	ExtAuthzProtocolGRPC ExtAuthzProtocol = "GRPC"
+
+	// ExtAuthzProtocolHTTP uses raw HTTP requests to the external authorization service.
+	ExtAuthzProtocolHTTP ExtAuthzProtocol = "HTTP"
+
+	// DefaultExtAuthzTimeout is the timeout used for the external authorization check if not specified.
+	DefaultExtAuthzTimeout = 200 * time.Millisecond
+)
+
+// ExtAuthzProvider describes an external authorization service that could be selected by
+// the AuthorizationPolicy to delegate the authorization decision.
+type ExtAuthzProvider struct {
+	// Name of the provider, referred by the AuthorizationPolicy.
+	Name string `json:"name"`
+
+	// Service is the fully qualified host name of the external authorization service in the mesh,
+	// e.g. "ext-authz.foo.svc.cluster.local".
+	Service string `json:"service"`
+
+	// Port is the port of the external authorization service.
+	Port int `json:"port"`
+
+	// Protocol is either GRPC or HTTP, defaults to GRPC.
+	Protocol ExtAuthzProtocol `json:"protocol,omitempty"`
+
+	// Timeout of the check request, e.g. "500ms". Defaults to DefaultExtAuthzTimeout.
+	Timeout string `json:"timeout,omitempty"`
+
+	// FailOpen allows the request if the external authorization service is unavailable or
+	// returns an error. By default the request is denied.
+	FailOpen bool `json:"failOpen,omitempty"`
+
+	// PathPrefix is prepended to the path of the check request, only used with HTTP protocol.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// IncludeHeaders is the list of request headers sent to the HTTP external authorization service
+	// in addition to the default headers (Host, Method, Path, Content-Length and Authorization).
+	IncludeHeaders []string `json:"includeHeaders,omitempty"`
+
+	timeout time.Duration
+}
+
+// GetTimeout returns the timeout of the check request.
+func (p *ExtAuthzProvider) GetTimeout() time.Duration {
+	if p.timeout == 0 {
+		return DefaultExtAuthzTimeout
+	}
+	return p.timeout
+}
+
+// ExtAuthzConfig is the collection of the external authorization providers in the mesh, loaded from the
+// external authorization config file of Pilot.
+type ExtAuthzConfig struct {
+	Providers []*ExtAuthzProvider `json:"providers"`
+}
+
+// Provider returns the provider with the given name, or nil if not found.
+func (c *ExtAuthzConfig) Provider(name string) *ExtAuthzProvider {
+	if c == nil {
+		return nil
+	}
+	for _, p := range c.Providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// LoadExtAuthzConfig returns a new ExtAuthzConfig decoded and validated from the input YAML. Unknown fields are
+// rejected.
+func LoadExtAuthzConfig(in string) (*ExtAuthzConfig, error) {
+	out := &ExtAuthzConfig{}
+	js, err := yaml.YAMLToJSON([]byte(in))
+	if err != nil {
+		return nil, multierror.Prefix(err, "failed to parse external authorization config.")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return nil, multierror.Prefix(err, "failed to parse external authorization config.")
+	}
+
+	var errs error
+	names := map[string]bool{}
+	for i, p := range out.Providers {
+		if p == nil {
+			errs = multierror.Append(errs, fmt.Errorf("provider %d: must not be empty", i))
+			continue
+		}
+		if p.Name == "" {
+			errs = multierror.Append(errs, fmt.Errorf("provider %d: name must be set", i))
+		} else if names[p.Name] {
+			errs = multierror.Append(errs, fmt.Errorf("provider %q: duplicate name", p.Name))
+		}
+		names[p.Name] = true
+
+		if p.Service == "" {
+			errs = multierror.Append(errs, fmt.Errorf("provider %q: service must be set", p.Name))
+		} else if err := validation.ValidateFQDN(p.Service); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("provider %q: invalid service: %v", p.Name, err))
+		}
+		if p.Port <= 0 || p.Port > 65535 {
+			errs = multierror.Append(errs, fmt.Errorf("provider %q: invalid port %d", p.Name, p.Port))
+		}
+
+		switch p.Protocol {
+		case "":
+			p.Protocol = ExtAuthzProtocolGRPC
+		case ExtAuthzProtocolGRPC, ExtAuthzProtocolHTTP:
+		default:
+			errs = multierror.Append(errs, fmt.Errorf("provider %q: unsupported protocol %q", p.Name, p.Protocol))
+		}
+		if p.Protocol == ExtAuthzProtocolGRPC && (p.PathPrefix != "" || len(p.IncludeHeaders) > 0) {
+			errs = multierror.Append(errs,
+				fmt.Errorf("provider %q: pathPrefix and includeHeaders are only supported with HTTP protocol", p.Name))
+		}
+
+		if p.Timeout != "" {
+			timeout, err := time.ParseDuration(p.Timeout)
+			if err != nil || timeout <= 0 {
+				errs = multierror.Append(errs, fmt.Errorf("provider %q: invalid timeout %q", p.Name, p.Timeout))
+			}
+			p.timeout = timeout
+		}
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+	return out, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func TestLoadExtAuthzConfig(t *testing.T) {
+	cases := []struct {
+		name        string
+		in          string
+		wantErr     bool
+		wantTimeout map[string]time.Duration
+		wantProto   map[string]model.ExtAuthzProtocol
+	}{
+		{
+			name: "empty",
+			in:   "",
+		},
+		{
+			name: "valid",
+			in: `
+providers:
+- name: grpc
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+  timeout: 1s
+  failOpen: true
+- name: http
+  service: ext-authz.foo.svc.cluster.local
+  port: 8000
+  protocol: HTTP
+  pathPrefix: /check
+  includeHeaders: ["x-user"]
+`,
+			wantTimeout: map[string]time.Duration{
+				"grpc": time.Second,
+				"http": model.DefaultExtAuthzTimeout,
+			},
+			wantProto: map[string]model.ExtAuthzProtocol{
+				"grpc": model.ExtAuthzProtocolGRPC,
+				"http": model.ExtAuthzProtocolHTTP,
+			},
+		},
+		{
+			name: "duplicate name",
+			in: `
+providers:
+- name: a
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+- name: a
+  service: ext-authz.foo.svc.cluster.local
+  port: 9001
+`,
+			wantErr: true,
+		},
+		{
+			name: "missing service and port",
+			in: `
+providers:
+- name: a
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid protocol",
+			in: `
+providers:
+- name: a
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+  protocol: TCP
+`,
+			wantErr: true,
+		},
+		{
+			name: "path prefix with grpc",
+			in: `
+providers:
+- name: a
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+  pathPrefix: /check
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			in: `
+providers:
+- name: a
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+  failClosed: true
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid timeout",
+			in: `
+providers:
+- name: a
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+  timeout: soon
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := model.LoadExtAuthzConfig(c.in)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error but got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, want := range c.wantTimeout {
+				if p := got.Provider(name); p == nil || p.GetTimeout() != want {
+					t.Errorf("provider %s: got timeout of %v but want %v", name, p, want)
+				}
+			}
+			for name, want := range c.wantProto {
+				if p := got.Provider(name); p == nil || p.Protocol != want {
+					t.Errorf("provider %s: got protocol of %v but want %v", name, p, want)
+				}
+			}
+			if got.Provider("not-found") != nil {
+				t.Errorf("want nil for unknown provider")
+			}
+		})
+	}
+}
@@ -96,6 +96,9 @@ type PushContext struct {
 	// are no authorization policies in the cluster.
 	AuthzPolicies *AuthorizationPolicies `json:"-"`
 
+	// AccessLogPolicies stores the existing access log policies in the cluster.
+	AccessLogPolicies *AccessLogPolicies `json:"-"`
+
 	// Env has a pointer to the shared environment used to create the snapshot.
 	Env *Environment `json:"-"`
 
@@ -750,6 +753,10 @@ func (ps *PushContext) createNewContext(env *Environment) error {
 		return err
 	}
 
+	if err := ps.initAccessLogPolicies(env); err != nil {
+		return err
+	}
+
 	if err := ps.initEnvoyFilters(env); err != nil {
 		return err
 	}
@@ -771,7 +778,7 @@ func (ps *PushContext) updateContext(
 	pushReq *PushRequest) error {
 
 	var servicesChanged, virtualServicesChanged, destinationRulesChanged, gatewayChanged,
-		authnChanged, authzChanged, envoyFiltersChanged, sidecarsChanged bool
+		authnChanged, authzChanged, accessLogChanged, envoyFiltersChanged, sidecarsChanged bool
 
 	for k := range pushReq.ConfigTypesUpdated {
 		switch k {
@@ -793,6 +800,8 @@ func (ps *PushContext) updateContext(
 			authzChanged = true
 		case schemas.AuthenticationPolicy.Type, schemas.AuthenticationMeshPolicy.Type:
 			authnChanged = true
+		case schemas.AccessLogPolicy.Type:
+			accessLogChanged = true
 		}
 	}
 
@@ -844,6 +853,14 @@ func (ps *PushContext) updateContext(
 		ps.AuthzPolicies = oldPushContext.AuthzPolicies
 	}
 
+	if accessLogChanged {
+		if err := ps.initAccessLogPolicies(env); err != nil {
+			return err
+		}
+	} else {
+		ps.AccessLogPolicies = oldPushContext.AccessLogPolicies
+	}
+
 	if envoyFiltersChanged {
 		if err := ps.initEnvoyFilters(env); err != nil {
 			return err
@@ -1359,6 +1376,12 @@ func (ps *PushContext) initAuthorizationPolicies(env *Environment) error {
 	return nil
 }
 
+func (ps *PushContext) initAccessLogPolicies(env *Environment) error {
+	var err error
+	ps.AccessLogPolicies, err = GetAccessLogPolicies(env)
+	return err
+}
+
 // pre computes envoy filters per namespace
 func (ps *PushContext) initEnvoyFilters(env *Environment) error {
 	envoyFilterConfigs, err := env.List(schemas.EnvoyFilter.Type, NamespaceAll)
@@ -0,0 +1,282 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"fmt"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	accesslogconfig "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/protobuf/proto"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/security/authz/model/matcher"
+	accesslogpolicy "istio.io/istio/pkg/config/accesslog"
+	accesslogv1alpha1 "istio.io/istio/pkg/config/accesslog/v1alpha1"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/pkg/log"
+)
+
+const (
+	// tcpGRPCAccessLog is the name of the Envoy TCP gRPC access log sink.
+	tcpGRPCAccessLog = "envoy.tcp_grpc_access_log"
+
+	// accessLogRuntimeKey is the Envoy runtime key of a filter of an access log policy, allowing to
+	// override the filter value at runtime, e.g. "access_log.default.errors.min_status_code".
+	accessLogRuntimeKey = "access_log.%s.%s.%s"
+
+	hostHeader = ":authority"
+	pathHeader = ":path"
+)
+
+// buildPolicyAccessLogs builds the access logs of the access log policies selecting the proxy for the
+// listener of the given service port. The policies with routes are only applied to the HTTP listeners.
+func buildPolicyAccessLogs(push *model.PushContext, node *model.Proxy, port *model.Port, http bool) []*accesslog.AccessLog {
+	if push == nil {
+		return nil
+	}
+	policies := push.AccessLogPolicies.PoliciesForWorkload(node.ConfigNamespace, node.WorkloadLabels)
+	if len(policies) == 0 {
+		return nil
+	}
+
+	servicePort := 0
+	if port != nil {
+		servicePort = port.Port
+	}
+
+	var out []*accesslog.AccessLog
+	for _, policy := range policies {
+		spec := policy.Spec
+		if !accesslogpolicy.AppliesToPort(spec, servicePort) || (!http && len(spec.Routes) > 0) {
+			continue
+		}
+		filter := buildAccessLogFilter(policy, http)
+		for _, sink := range spec.Sinks {
+			var acc *accesslog.AccessLog
+			var config proto.Message
+			switch s := sink.GetSink().(type) {
+			case *accesslogv1alpha1.Sink_File:
+				if err := accesslogpolicy.ValidateFilePath(s.File); err != nil {
+					log.Warnf("skipping file access log sink of access log policy %s/%s for %s: %v",
+						policy.Namespace, policy.Name, node.ID, err)
+					continue
+				}
+				fl := &accesslogconfig.FileAccessLog{
+					Path: s.File,
+				}
+				encoding := meshconfig.MeshConfig_TEXT
+				if spec.Encoding == accesslogv1alpha1.JSON {
+					encoding = meshconfig.MeshConfig_JSON
+				}
+				buildAccessLogFormat(node, fl, encoding, spec.Format)
+				acc = &accesslog.AccessLog{Name: wellknown.FileAccessLog}
+				config = fl
+			case *accesslogv1alpha1.Sink_Grpc:
+				if err := checkGRPCAccessLogSink(push, node, s.Grpc); err != nil {
+					log.Warnf("skipping gRPC access log sink of access log policy %s/%s for %s: %v",
+						policy.Namespace, policy.Name, node.ID, err)
+					continue
+				}
+				common := buildGRPCAccessLogConfig(policy, s.Grpc)
+				if http {
+					acc = &accesslog.AccessLog{Name: wellknown.HTTPGRPCAccessLog}
+					config = &accesslogconfig.HttpGrpcAccessLogConfig{CommonConfig: common}
+				} else {
+					acc = &accesslog.AccessLog{Name: tcpGRPCAccessLog}
+					config = &accesslogconfig.TcpGrpcAccessLogConfig{CommonConfig: common}
+				}
+			default:
+				continue
+			}
+
+			acc.Filter = filter
+			if util.IsXDSMarshalingToAnyEnabled(node) {
+				acc.ConfigType = &accesslog.AccessLog_TypedConfig{TypedConfig: util.MessageToAny(config)}
+			} else {
+				acc.ConfigType = &accesslog.AccessLog_Config{Config: util.MessageToStruct(config)}
+			}
+			out = append(out, acc)
+		}
+	}
+	return out
+}
+
+// checkGRPCAccessLogSink returns an error if the cluster of the gRPC sink is not configured on the proxy, since
+// Envoy rejects the listeners referencing an unknown access log cluster.
+func checkGRPCAccessLogSink(push *model.PushContext, node *model.Proxy, sink *accesslogv1alpha1.GrpcSink) error {
+	if sink.GetService() == "" {
+		if push.Env == nil || push.Env.Mesh == nil || !push.Env.Mesh.EnableEnvoyAccessLogService {
+			return fmt.Errorf("the mesh access log service is not enabled")
+		}
+		return nil
+	}
+	for _, svc := range node.SidecarScope.Services() {
+		if svc.Hostname != host.Name(sink.Service) {
+			continue
+		}
+		if _, ok := svc.Ports.GetByPort(int(sink.Port)); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("service %s:%d is not in the scope of the proxy", sink.Service, sink.Port)
+}
+
+func buildGRPCAccessLogConfig(policy *model.AccessLogPolicy, sink *accesslogv1alpha1.GrpcSink) *accesslogconfig.CommonGrpcAccessLogConfig {
+	clusterName := EnvoyAccessLogCluster
+	if sink.GetService() != "" {
+		clusterName = model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(sink.Service), int(sink.Port))
+	}
+	logName := sink.GetLogName()
+	if logName == "" {
+		logName = policy.Name
+	}
+	return &accesslogconfig.CommonGrpcAccessLogConfig{
+		LogName: logName,
+		GrpcService: &core.GrpcService{
+			TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+				EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+					ClusterName: clusterName,
+				},
+			},
+		},
+	}
+}
+
+// buildAccessLogFilter builds the access log filter of the policy, all the conditions of the policy filter
+// and any of the routes must match. It returns nil if the policy logs everything.
+func buildAccessLogFilter(policy *model.AccessLogPolicy, http bool) *accesslog.AccessLogFilter {
+	var filters []*accesslog.AccessLogFilter
+
+	runtimeKey := func(filter string) string {
+		return fmt.Sprintf(accessLogRuntimeKey, policy.Namespace, policy.Name, filter)
+	}
+
+	if f := policy.Spec.Filter; f != nil {
+		// The status code is not available for the TCP connections.
+		if http && f.MinStatusCode != 0 {
+			filters = append(filters, statusCodeFilter(accesslog.ComparisonFilter_GE, f.MinStatusCode, runtimeKey("min_status_code")))
+		}
+		if http && f.MaxStatusCode != 0 {
+			filters = append(filters, statusCodeFilter(accesslog.ComparisonFilter_LE, f.MaxStatusCode, runtimeKey("max_status_code")))
+		}
+		if d, err := types.DurationFromProto(f.MinDuration); f.MinDuration != nil && err == nil && d > 0 {
+			filters = append(filters, &accesslog.AccessLogFilter{
+				FilterSpecifier: &accesslog.AccessLogFilter_DurationFilter{
+					DurationFilter: &accesslog.DurationFilter{
+						Comparison: comparisonFilter(accesslog.ComparisonFilter_GE, uint32(d/time.Millisecond), runtimeKey("min_duration")),
+					},
+				},
+			})
+		}
+		if len(f.ResponseFlags) > 0 {
+			filters = append(filters, &accesslog.AccessLogFilter{
+				FilterSpecifier: &accesslog.AccessLogFilter_ResponseFlagFilter{
+					ResponseFlagFilter: &accesslog.ResponseFlagFilter{Flags: f.ResponseFlags},
+				},
+			})
+		}
+		if f.SamplingPercent > 0 && f.SamplingPercent < 100 {
+			filters = append(filters, &accesslog.AccessLogFilter{
+				FilterSpecifier: &accesslog.AccessLogFilter_RuntimeFilter{
+					RuntimeFilter: &accesslog.RuntimeFilter{
+						RuntimeKey: runtimeKey("sampling"),
+						PercentSampled: &envoy_type.FractionalPercent{
+							Numerator:   uint32(f.SamplingPercent * 10000),
+							Denominator: envoy_type.FractionalPercent_MILLION,
+						},
+					},
+				},
+			})
+		}
+	}
+
+	if http && len(policy.Spec.Routes) > 0 {
+		var routes []*accesslog.AccessLogFilter
+		for _, r := range policy.Spec.Routes {
+			var headers []*accesslog.AccessLogFilter
+			if r.Host != "" {
+				headers = append(headers, headerFilter(hostHeader, r.Host))
+			}
+			if r.Path != "" {
+				headers = append(headers, headerFilter(pathHeader, r.Path))
+			}
+			routes = append(routes, andAccessLogFilters(headers))
+		}
+		if len(routes) == 1 {
+			filters = append(filters, routes[0])
+		} else {
+			filters = append(filters, &accesslog.AccessLogFilter{
+				FilterSpecifier: &accesslog.AccessLogFilter_OrFilter{
+					OrFilter: &accesslog.OrFilter{Filters: routes},
+				},
+			})
+		}
+	}
+
+	return andAccessLogFilters(filters)
+}
+
+// andAccessLogFilters returns the filter matching all the given filters, or nil if there is no filter.
+func andAccessLogFilters(filters []*accesslog.AccessLogFilter) *accesslog.AccessLogFilter {
+	switch len(filters) {
+	case 0:
+		return nil
+	case 1:
+		return filters[0]
+	default:
+		return &accesslog.AccessLogFilter{
+			FilterSpecifier: &accesslog.AccessLogFilter_AndFilter{
+				AndFilter: &accesslog.AndFilter{Filters: filters},
+			},
+		}
+	}
+}
+
+func headerFilter(name, value string) *accesslog.AccessLogFilter {
+	return &accesslog.AccessLogFilter{
+		FilterSpecifier: &accesslog.AccessLogFilter_HeaderFilter{
+			HeaderFilter: &accesslog.HeaderFilter{Header: matcher.HeaderMatcher(name, value)},
+		},
+	}
+}
+
+func statusCodeFilter(op accesslog.ComparisonFilter_Op, code uint32, runtimeKey string) *accesslog.AccessLogFilter {
+	return &accesslog.AccessLogFilter{
+		FilterSpecifier: &accesslog.AccessLogFilter_StatusCodeFilter{
+			StatusCodeFilter: &accesslog.StatusCodeFilter{
+				Comparison: comparisonFilter(op, code, runtimeKey),
+			},
+		},
+	}
+}
+
+func comparisonFilter(op accesslog.ComparisonFilter_Op, value uint32, runtimeKey string) *accesslog.ComparisonFilter {
+	return &accesslog.ComparisonFilter{
+		Op: op,
+		Value: &core.RuntimeUInt32{
+			DefaultValue: value,
+			RuntimeKey:   runtimeKey,
+		},
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"testing"
+
+	accesslogconfig "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/conversion"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/fakes"
+	accesslogv1alpha1 "istio.io/istio/pkg/config/accesslog/v1alpha1"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+var testAccessLogPolicies = []struct {
+	name      string
+	namespace string
+	spec      string
+}{
+	{
+		name:      "errors",
+		namespace: "default",
+		spec: `{
+			"selector": {"app": "productpage"},
+			"ports": [9080],
+			"encoding": "JSON",
+			"format": "{\"code\": \"%RESPONSE_CODE%\"}",
+			"filter": {"minStatusCode": 500, "responseFlags": ["UH", "UF"]},
+			"sinks": [{"file": "/dev/stdout"}]
+		}`,
+	},
+	{
+		name:      "api",
+		namespace: "default",
+		spec: `{
+			"routes": [{"host": "*.example.com", "path": "/api/*"}, {"path": "/health"}],
+			"filter": {"minDuration": "1s", "samplingPercent": 10},
+			"sinks": [{"grpc": {"service": "als.istio-system.svc.cluster.local", "port": 9000}}]
+		}`,
+	},
+	{
+		name:      "other",
+		namespace: "other",
+		spec:      `{"sinks": [{"file": "/dev/stdout"}]}`,
+	},
+}
+
+// buildAccessLogTestPush returns a push context with the test access log policies and an access log service.
+func buildAccessLogTestPush(t *testing.T, enableALS bool) *model.PushContext {
+	t.Helper()
+	store := model.MakeIstioStore(memory.Make(schemas.Istio))
+	for _, p := range testAccessLogPolicies {
+		spec := &accesslogv1alpha1.AccessLogPolicy{}
+		if err := jsonpb.UnmarshalString(p.spec, spec); err != nil {
+			t.Fatalf("failed to parse policy %s: %v", p.name, err)
+		}
+		if _, err := store.Create(model.Config{
+			ConfigMeta: model.ConfigMeta{
+				Type:      schemas.AccessLogPolicy.Type,
+				Group:     schemas.AccessLogPolicy.Group,
+				Version:   schemas.AccessLogPolicy.Version,
+				Name:      p.name,
+				Namespace: p.namespace,
+			},
+			Spec: spec,
+		}); err != nil {
+			t.Fatalf("failed to create policy %s: %v", p.name, err)
+		}
+	}
+	m := mesh.DefaultMeshConfig()
+	m.EnableEnvoyAccessLogService = enableALS
+	serviceDiscovery := new(fakes.ServiceDiscovery)
+	serviceDiscovery.ServicesReturns([]*model.Service{{
+		Hostname: "als.istio-system.svc.cluster.local",
+		Ports:    model.PortList{{Name: "grpc", Port: 9000, Protocol: protocol.GRPC}},
+		Attributes: model.ServiceAttributes{
+			Name:      "als",
+			Namespace: "istio-system",
+		},
+	}}, nil)
+	env := &model.Environment{
+		Mesh:             &m,
+		IstioConfigStore: store,
+		ServiceDiscovery: serviceDiscovery,
+		PushContext:      model.NewPushContext(),
+	}
+	if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+		t.Fatalf("failed to init push context: %v", err)
+	}
+	return env.PushContext
+}
+
+// buildAccessLogTestProxy returns a proxy of the default namespace with the given labels.
+func buildAccessLogTestProxy(push *model.PushContext, workloadLabels map[string]string) *model.Proxy {
+	return &model.Proxy{
+		ConfigNamespace: "default",
+		WorkloadLabels:  labels.Collection{workloadLabels},
+		Metadata:        &model.NodeMetadata{},
+		SidecarScope:    model.DefaultSidecarScopeForNamespace(push, "default"),
+	}
+}
+
+// accessLogTestPolicy returns the test access log policy with the given name.
+func accessLogTestPolicy(t *testing.T, push *model.PushContext, namespace, name string) *model.AccessLogPolicy {
+	t.Helper()
+	for _, p := range push.AccessLogPolicies.PoliciesForWorkload(namespace, labels.Collection{{"app": "productpage"}}) {
+		if p.Name == name {
+			return p
+		}
+	}
+	t.Fatalf("policy %s/%s not found", namespace, name)
+	return nil
+}
+
+func TestBuildPolicyAccessLogs(t *testing.T) {
+	push := buildAccessLogTestPush(t, true)
+
+	cases := []struct {
+		name      string
+		labels    map[string]string
+		port      *model.Port
+		http      bool
+		wantNames []string
+	}{
+		{
+			name:      "http matching all policies",
+			labels:    map[string]string{"app": "productpage"},
+			port:      &model.Port{Port: 9080},
+			http:      true,
+			wantNames: []string{wellknown.FileAccessLog, wellknown.HTTPGRPCAccessLog},
+		},
+		{
+			name:      "http on other port",
+			labels:    map[string]string{"app": "productpage"},
+			port:      &model.Port{Port: 8080},
+			http:      true,
+			wantNames: []string{wellknown.HTTPGRPCAccessLog},
+		},
+		{
+			name:      "tcp skips policies with routes",
+			labels:    map[string]string{"app": "productpage"},
+			port:      &model.Port{Port: 9080},
+			wantNames: []string{wellknown.FileAccessLog},
+		},
+		{
+			name:   "tcp without port",
+			labels: map[string]string{"app": "productpage"},
+		},
+		{
+			name:      "other workload",
+			labels:    map[string]string{"app": "reviews"},
+			port:      &model.Port{Port: 9080},
+			http:      true,
+			wantNames: []string{wellknown.HTTPGRPCAccessLog},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := buildPolicyAccessLogs(push, buildAccessLogTestProxy(push, c.labels), c.port, c.http)
+			if len(got) != len(c.wantNames) {
+				t.Fatalf("got %d access logs but want %d: %v", len(got), len(c.wantNames), got)
+			}
+			for i, name := range c.wantNames {
+				if got[i].Name != name {
+					t.Errorf("got access log %s but want %s", got[i].Name, name)
+				}
+				if got[i].Filter == nil {
+					t.Errorf("%s: want filter", name)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildPolicyAccessLogs_Config(t *testing.T) {
+	push := buildAccessLogTestPush(t, true)
+	node := buildAccessLogTestProxy(push, map[string]string{"app": "productpage"})
+	got := buildPolicyAccessLogs(push, node, &model.Port{Port: 9080}, true)
+	if len(got) != 2 {
+		t.Fatalf("got %d access logs but want 2", len(got))
+	}
+
+	fl := &accesslogconfig.FileAccessLog{}
+	if err := getAccessLogConfig(got[0], fl); err != nil {
+		t.Fatal(err)
+	}
+	if fl.Path != "/dev/stdout" || fl.GetJsonFormat().GetFields()["code"].GetStringValue() != "%RESPONSE_CODE%" {
+		t.Errorf("unexpected file access log config: %v", fl)
+	}
+
+	als := &accesslogconfig.HttpGrpcAccessLogConfig{}
+	if err := getAccessLogConfig(got[1], als); err != nil {
+		t.Fatal(err)
+	}
+	if als.CommonConfig.LogName != "api" ||
+		als.CommonConfig.GrpcService.GetEnvoyGrpc().ClusterName != "outbound|9000||als.istio-system.svc.cluster.local" {
+		t.Errorf("unexpected grpc access log config: %v", als)
+	}
+}
+
+func TestBuildPolicyAccessLogs_Sinks(t *testing.T) {
+	meshSink := grpcAccessLogTestSink(&accesslogv1alpha1.GrpcSink{})
+	serviceSink := grpcAccessLogTestSink(&accesslogv1alpha1.GrpcSink{Service: "als.istio-system.svc.cluster.local", Port: 9000})
+
+	cases := []struct {
+		name      string
+		enableALS bool
+		sink      *accesslogv1alpha1.Sink
+		scope     bool
+		want      bool
+	}{
+		{
+			name:      "mesh access log service enabled",
+			enableALS: true,
+			sink:      meshSink,
+			want:      true,
+		},
+		{
+			name: "mesh access log service disabled",
+			sink: meshSink,
+		},
+		{
+			name:  "service in scope",
+			sink:  serviceSink,
+			scope: true,
+			want:  true,
+		},
+		{
+			name: "service not in scope",
+			sink: serviceSink,
+		},
+		{
+			name:  "service port not in scope",
+			sink:  grpcAccessLogTestSink(&accesslogv1alpha1.GrpcSink{Service: "als.istio-system.svc.cluster.local", Port: 9001}),
+			scope: true,
+		},
+		{
+			name:  "file in the log directory",
+			sink:  fileAccessLogTestSink("/var/log/istio/access.log"),
+			scope: true,
+			want:  true,
+		},
+		{
+			name:  "file outside the log directory",
+			sink:  fileAccessLogTestSink("/etc/envoy/envoy.yaml"),
+			scope: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			push := buildAccessLogTestPush(t, c.enableALS)
+			node := buildAccessLogTestProxy(push, map[string]string{"app": "reviews"})
+			if !c.scope {
+				node.SidecarScope = nil
+			}
+			api := accessLogTestPolicy(t, push, "default", "api")
+			api.Spec.Sinks = []*accesslogv1alpha1.Sink{c.sink}
+
+			got := buildPolicyAccessLogs(push, node, &model.Port{Port: 9080}, true)
+			if c.want != (len(got) == 1) {
+				t.Errorf("got %d access logs but want the sink %v", len(got), c.want)
+			}
+		})
+	}
+}
+
+func fileAccessLogTestSink(file string) *accesslogv1alpha1.Sink {
+	return &accesslogv1alpha1.Sink{Sink: &accesslogv1alpha1.Sink_File{File: file}}
+}
+
+func grpcAccessLogTestSink(sink *accesslogv1alpha1.GrpcSink) *accesslogv1alpha1.Sink {
+	return &accesslogv1alpha1.Sink{Sink: &accesslogv1alpha1.Sink_Grpc{Grpc: sink}}
+}
+
+func getAccessLogConfig(acc *accesslog.AccessLog, out proto.Message) error {
+	switch c := acc.ConfigType.(type) {
+	case *accesslog.AccessLog_Config:
+		if err := conversion.StructToMessage(c.Config, out); err != nil {
+			return err
+		}
+	case *accesslog.AccessLog_TypedConfig:
+		if err := ptypes.UnmarshalAny(c.TypedConfig, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestBuildAccessLogFilter(t *testing.T) {
+	push := buildAccessLogTestPush(t, true)
+	errors := accessLogTestPolicy(t, push, "default", "errors")
+	api := accessLogTestPolicy(t, push, "default", "api")
+	other := accessLogTestPolicy(t, push, "other", "other")
+
+	if got := buildAccessLogFilter(other, true); got != nil {
+		t.Errorf("want no filter but got %v", got)
+	}
+
+	// Status code and response flags.
+	and := buildAccessLogFilter(errors, true).GetAndFilter()
+	if len(and.GetFilters()) != 2 {
+		t.Fatalf("want 2 filters but got %v", and)
+	}
+	status := and.Filters[0].GetStatusCodeFilter().GetComparison()
+	if status.Op != accesslog.ComparisonFilter_GE || status.Value.DefaultValue != 500 ||
+		status.Value.RuntimeKey != "access_log.default.errors.min_status_code" {
+		t.Errorf("unexpected status code filter: %v", status)
+	}
+	if flags := and.Filters[1].GetResponseFlagFilter().GetFlags(); len(flags) != 2 {
+		t.Errorf("unexpected response flags: %v", flags)
+	}
+
+	// The status code is ignored for TCP.
+	if got := buildAccessLogFilter(errors, false).GetResponseFlagFilter(); got == nil {
+		t.Errorf("want response flag filter only for TCP")
+	}
+
+	// Duration, sampling and routes.
+	and = buildAccessLogFilter(api, true).GetAndFilter()
+	if len(and.GetFilters()) != 3 {
+		t.Fatalf("want 3 filters but got %v", and)
+	}
+	if d := and.Filters[0].GetDurationFilter().GetComparison().GetValue().GetDefaultValue(); d != 1000 {
+		t.Errorf("got duration %d but want 1000", d)
+	}
+	if p := and.Filters[1].GetRuntimeFilter().GetPercentSampled().GetNumerator(); p != 100000 {
+		t.Errorf("got sampling %d but want 100000", p)
+	}
+	routes := and.Filters[2].GetOrFilter().GetFilters()
+	if len(routes) != 2 {
+		t.Fatalf("want 2 routes but got %v", routes)
+	}
+	hostAndPath := routes[0].GetAndFilter().GetFilters()
+	if len(hostAndPath) != 2 ||
+		hostAndPath[0].GetHeaderFilter().GetHeader().GetSuffixMatch() != ".example.com" ||
+		hostAndPath[1].GetHeaderFilter().GetHeader().GetPrefixMatch() != "/api/" {
+		t.Errorf("unexpected route filter: %v", routes[0])
+	}
+	if path := routes[1].GetHeaderFilter().GetHeader().GetExactMatch(); path != "/health" {
+		t.Errorf("got path %q but want /health", path)
+	}
+}
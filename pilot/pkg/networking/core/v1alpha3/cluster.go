@@ -872,10 +872,54 @@ func applyTrafficPolicy(opts buildClusterOpts, proxy *model.Proxy) {
 		var mtlsCtxType mtlsContextType
 		tls, mtlsCtxType = conditionallyConvertToIstioMtls(tls, opts.serviceAccounts, opts.istioMtlsSni, opts.proxy,
 			autoMTLSEnabled, opts.meshExternal, opts.serviceMTLSMode)
+		// The upgrade must be applied before the TLS settings to advertise HTTP/2 with ALPN.
+		if shouldH2Upgrade(opts.env.Mesh, opts.port, connectionPool, tls, mtlsCtxType) {
+			applyH2Upgrade(opts.cluster)
+		}
 		applyUpstreamTLSSettings(&opts, tls, mtlsCtxType)
 	}
 }
 
+// shouldH2Upgrade returns true if the HTTP/1.1 requests to the outbound cluster should be upgraded to HTTP/2.
+// The upgrade policy of the destination rule overrides the mesh default. An explicit UPGRADE asserts the
+// destination supports HTTP/2 and applies to the HTTP ports. The mesh default only applies to the HTTP ports of the
+// destinations known to run an Istio sidecar, as the sidecar downgrades the requests to the protocol of the
+// application. The destination is known to run a sidecar only if Istio mutual TLS is configured explicitly, auto
+// mutual TLS falls back to plain text for the endpoints without a sidecar.
+//
+// The ports with protocol sniffing use the protocol of the downstream, as the protocol of the peer is not known.
+// An explicit UPGRADE only replaces it for the destinations known to run a sidecar.
+func shouldH2Upgrade(mesh *meshconfig.MeshConfig, port *model.Port, connectionPool *networking.ConnectionPoolSettings,
+	tls *networking.TLSSettings, mtlsCtxType mtlsContextType) bool {
+	if port == nil {
+		return false
+	}
+	sidecarPeer := tls.GetMode() == networking.TLSSettings_ISTIO_MUTUAL && mtlsCtxType == userSupplied
+
+	switch connectionPool.GetHttp().GetH2UpgradePolicy() {
+	case networking.ConnectionPoolSettings_HTTPSettings_UPGRADE:
+		return port.Protocol == protocol.HTTP || (port.Protocol.IsUnsupported() && sidecarPeer)
+	case networking.ConnectionPoolSettings_HTTPSettings_DO_NOT_UPGRADE:
+		return false
+	}
+
+	if mesh.GetH2UpgradePolicy() != meshconfig.MeshConfig_UPGRADE || port.Protocol != protocol.HTTP {
+		return false
+	}
+	return sidecarPeer
+}
+
+// applyH2Upgrade configures the cluster to always use HTTP/2 to the upstream, regardless of the downstream protocol.
+func applyH2Upgrade(cluster *apiv2.Cluster) {
+	cluster.Http2ProtocolOptions = &core.Http2ProtocolOptions{
+		// Envoy default value of 100 is too low for data path.
+		MaxConcurrentStreams: &wrappers.UInt32Value{
+			Value: 1073741824,
+		},
+	}
+	cluster.ProtocolSelection = apiv2.Cluster_USE_CONFIGURED_PROTOCOL
+}
+
 // FIXME: there isn't a way to distinguish between unset values and zero values
 func applyConnectionPool(env *model.Environment, cluster *apiv2.Cluster, settings *networking.ConnectionPoolSettings, direction model.TrafficDirection) {
 	if settings == nil {
@@ -1180,6 +1224,13 @@ func applyUpstreamTLSSettings(opts *buildClusterOpts, tls *networking.TLSSetting
 			}
 		}
 
+		// Trust the roots of the federated trust domain of the upstream, if any. Otherwise, reject the revoked
+		// upstream certificates: the revocation list is issued by the local root only.
+		if tls.Mode == networking.TLSSettings_ISTIO_MUTUAL &&
+			!authn_model.ApplyTrustBundle(cluster.TlsContext.CommonTlsContext, env.FederatedRoots) {
+			authn_model.ApplyCRL(cluster.TlsContext.CommonTlsContext, env.RevocationCRL)
+		}
+
 		// Set default SNI of cluster name for istio_mutual if sni is not set.
 		if len(tls.Sni) == 0 && tls.Mode == networking.TLSSettings_ISTIO_MUTUAL {
 			cluster.TlsContext.Sni = cluster.Name
@@ -222,6 +222,111 @@ func TestCommonHttpProtocolOptions(t *testing.T) {
 	}
 }
 
+func TestH2UpgradePolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	upgradeMesh := testMesh
+	upgradeMesh.H2UpgradePolicy = meshconfig.MeshConfig_UPGRADE
+
+	istioMutual := &networking.TLSSettings{Mode: networking.TLSSettings_ISTIO_MUTUAL}
+	cases := []struct {
+		name          string
+		mesh          meshconfig.MeshConfig
+		policy        networking.ConnectionPoolSettings_HTTPSettings_H2UpgradePolicy
+		tls           *networking.TLSSettings
+		wantHTTP      bool
+		wantAuto      bool
+		wantHTTPAlpn  []string
+		wantAutoProto apiv2.Cluster_ClusterProtocolSelection
+	}{
+		{
+			name:          "mesh default without sidecar peer",
+			mesh:          upgradeMesh,
+			wantAutoProto: apiv2.Cluster_USE_DOWNSTREAM_PROTOCOL,
+		},
+		{
+			name:          "mesh default with istio mutual",
+			mesh:          upgradeMesh,
+			tls:           istioMutual,
+			wantHTTP:      true,
+			wantHTTPAlpn:  util.ALPNInMeshH2,
+			wantAutoProto: apiv2.Cluster_USE_DOWNSTREAM_PROTOCOL,
+		},
+		{
+			name:          "mesh do not upgrade",
+			mesh:          testMesh,
+			tls:           istioMutual,
+			wantHTTPAlpn:  util.ALPNInMesh,
+			wantAutoProto: apiv2.Cluster_USE_DOWNSTREAM_PROTOCOL,
+		},
+		{
+			name:          "destination rule do not upgrade",
+			mesh:          upgradeMesh,
+			policy:        networking.ConnectionPoolSettings_HTTPSettings_DO_NOT_UPGRADE,
+			tls:           istioMutual,
+			wantHTTPAlpn:  util.ALPNInMesh,
+			wantAutoProto: apiv2.Cluster_USE_DOWNSTREAM_PROTOCOL,
+		},
+		{
+			// The protocol of the peer of the sniffed port is not known, the downstream protocol is kept.
+			name:          "destination rule upgrade without sidecar peer",
+			mesh:          testMesh,
+			policy:        networking.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+			wantHTTP:      true,
+			wantAuto:      true,
+			wantAutoProto: apiv2.Cluster_USE_DOWNSTREAM_PROTOCOL,
+		},
+		{
+			name:          "destination rule upgrade with istio mutual",
+			mesh:          testMesh,
+			policy:        networking.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+			tls:           istioMutual,
+			wantHTTP:      true,
+			wantAuto:      true,
+			wantHTTPAlpn:  util.ALPNInMeshH2,
+			wantAutoProto: apiv2.Cluster_USE_CONFIGURED_PROTOCOL,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusters, err := buildTestClusters("*.example.org", 0, model.SidecarProxy, nil, c.mesh,
+				&networking.DestinationRule{
+					Host: "*.example.org",
+					TrafficPolicy: &networking.TrafficPolicy{
+						ConnectionPool: &networking.ConnectionPoolSettings{
+							Http: &networking.ConnectionPoolSettings_HTTPSettings{
+								H2UpgradePolicy: c.policy,
+							},
+						},
+						Tls: c.tls,
+					},
+				})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			httpCluster := findTestCluster("outbound|8080||*.example.org", clusters)
+			autoCluster := findTestCluster("outbound|9090||*.example.org", clusters)
+			g.Expect(httpCluster.Http2ProtocolOptions != nil).To(Equal(c.wantHTTP))
+			g.Expect(autoCluster.ProtocolSelection).To(Equal(c.wantAutoProto))
+			if c.wantAuto {
+				g.Expect(autoCluster.Http2ProtocolOptions).NotTo(BeNil())
+			}
+			if c.wantHTTPAlpn != nil {
+				g.Expect(httpCluster.TlsContext.CommonTlsContext.AlpnProtocols).To(Equal(c.wantHTTPAlpn))
+			}
+		})
+	}
+}
+
+func findTestCluster(name string, clusters []*apiv2.Cluster) *apiv2.Cluster {
+	for _, c := range clusters {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
 func buildTestClusters(serviceHostname string, serviceResolution model.Resolution,
 	nodeType model.NodeType, locality *core.Locality, mesh meshconfig.MeshConfig,
 	destRule proto.Message) ([]*apiv2.Cluster, error) {
@@ -687,7 +687,7 @@ func buildGatewayNetworkFiltersFromTLSRoutes(node *model.Proxy, env *model.Envir
 		filterChains = append(filterChains, &filterChainOpts{
 			sniHosts:       getSNIHostsForServer(server),
 			tlsContext:     nil, // NO TLS context because this is passthrough
-			networkFilters: buildOutboundAutoPassthroughFilterStack(env, node, port),
+			networkFilters: buildOutboundAutoPassthroughFilterStack(env, node, push, port),
 		})
 	} else {
 		virtualServices := push.VirtualServices(node, gatewaysForWorkload)
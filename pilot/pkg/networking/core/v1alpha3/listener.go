@@ -309,15 +309,22 @@ var (
 )
 
 func buildAccessLog(node *model.Proxy, fl *accesslogconfig.FileAccessLog, env *model.Environment) {
-	switch env.Mesh.AccessLogEncoding {
+	buildAccessLogFormat(node, fl, env.Mesh.AccessLogEncoding, env.Mesh.AccessLogFormat)
+}
+
+// buildAccessLogFormat sets the format of the file access log from the encoding and the user provided format,
+// the Istio access log format is used if the format is empty.
+func buildAccessLogFormat(node *model.Proxy, fl *accesslogconfig.FileAccessLog,
+	encoding meshconfig.MeshConfig_AccessLogEncoding, format string) {
+	switch encoding {
 	case meshconfig.MeshConfig_TEXT:
 		formatString := EnvoyTextLogFormat12
 		if util.IsIstioVersionGE13(node) {
 			formatString = EnvoyTextLogFormat13
 		}
 
-		if env.Mesh.AccessLogFormat != "" {
-			formatString = env.Mesh.AccessLogFormat
+		if format != "" {
+			formatString = format
 		}
 		fl.AccessLogFormat = &accesslogconfig.FileAccessLog_Format{
 			Format: formatString,
@@ -327,9 +334,9 @@ func buildAccessLog(node *model.Proxy, fl *accesslogconfig.FileAccessLog, env *m
 		// TODO potential optimization to avoid recomputing the user provided format for every listener
 		// mesh AccessLogFormat field could change so need a way to have a cached value that can be cleared
 		// on changes
-		if env.Mesh.AccessLogFormat != "" {
+		if format != "" {
 			jsonFields := map[string]string{}
-			err := json.Unmarshal([]byte(env.Mesh.AccessLogFormat), &jsonFields)
+			err := json.Unmarshal([]byte(format), &jsonFields)
 			if err == nil {
 				jsonLog = &structpb.Struct{
 					Fields: make(map[string]*structpb.Value, len(jsonFields)),
@@ -352,7 +359,7 @@ func buildAccessLog(node *model.Proxy, fl *accesslogconfig.FileAccessLog, env *m
 			JsonFormat: jsonLog,
 		}
 	default:
-		log.Warnf("unsupported access log format %v", env.Mesh.AccessLogEncoding)
+		log.Warnf("unsupported access log format %v", encoding)
 	}
 }
 
@@ -707,7 +714,7 @@ allChainsLabel:
 
 		case plugin.ListenerProtocolTCP:
 			filterChainMatch = chain.FilterChainMatch
-			tcpNetworkFilters = buildInboundNetworkFilters(pluginParams.Env, pluginParams.Node, pluginParams.ServiceInstance)
+			tcpNetworkFilters = buildInboundNetworkFilters(pluginParams.Env, pluginParams.Node, pluginParams.Push, pluginParams.ServiceInstance)
 
 		case plugin.ListenerProtocolAuto:
 			// Make sure id is not out of boundary of filterChainMatchOption
@@ -727,7 +734,7 @@ allChainsLabel:
 			if filterChainMatchOption[id].Protocol == plugin.ListenerProtocolHTTP {
 				httpOpts = configgen.buildSidecarInboundHTTPListenerOptsForPortOrUDS(node, pluginParams)
 			} else {
-				tcpNetworkFilters = buildInboundNetworkFilters(pluginParams.Env, pluginParams.Node, pluginParams.ServiceInstance)
+				tcpNetworkFilters = buildInboundNetworkFilters(pluginParams.Env, pluginParams.Node, pluginParams.Push, pluginParams.ServiceInstance)
 			}
 			filterChainMatch = &fcm
 		default:
@@ -1450,7 +1457,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListenerForPortOrUDS(n
 	// Lets build the new listener with the filter chains. In the end, we will
 	// merge the filter chains with any existing listener on the same port/bind point
 	l := buildListener(listenerOpts)
-	appendListenerFallthroughRoute(l, &listenerOpts, pluginParams.Node, pluginParams.Env, currentListenerEntry)
+	appendListenerFallthroughRoute(l, &listenerOpts, pluginParams.Node, pluginParams.Env, pluginParams.Push, currentListenerEntry)
 	l.TrafficDirection = core.TrafficDirection_OUTBOUND
 
 	mutable := &plugin.MutableObjects{
@@ -1648,7 +1655,7 @@ func (configgen *ConfigGeneratorImpl) onVirtualOutboundListener(env *model.Envir
 // the pod.
 // So, if a user wants to use kubernetes probes with Istio, she should ensure
 // that the health check ports are distinct from the service ports.
-func buildSidecarInboundMgmtListeners(node *model.Proxy, env *model.Environment, managementPorts model.PortList, managementIP string) []*xdsapi.Listener {
+func buildSidecarInboundMgmtListeners(node *model.Proxy, env *model.Environment, push *model.PushContext, managementPorts model.PortList, managementIP string) []*xdsapi.Listener {
 	listeners := make([]*xdsapi.Listener, 0, len(managementPorts))
 
 	if managementIP == "" {
@@ -1685,7 +1692,7 @@ func buildSidecarInboundMgmtListeners(node *model.Proxy, env *model.Environment,
 				bind: managementIP,
 				port: mPort.Port,
 				filterChainOpts: []*filterChainOpts{{
-					networkFilters: buildInboundNetworkFilters(env, node, instance),
+					networkFilters: buildInboundNetworkFilters(env, node, push, instance),
 				}},
 				// No user filters for the management unless we introduce new listener matches
 				skipUserFilters: true,
@@ -1902,6 +1909,9 @@ func buildHTTPConnectionManager(pluginParams *plugin.InputParams, env *model.Env
 		connectionManager.AccessLog = append(connectionManager.AccessLog, acc)
 	}
 
+	connectionManager.AccessLog = append(connectionManager.AccessLog,
+		buildPolicyAccessLogs(pluginParams.Push, pluginParams.Node, pluginParams.Port, true)...)
+
 	if env.Mesh.EnableTracing {
 		tc := authn_model.GetTraceConfig()
 		connectionManager.Tracing = &http_conn.HttpConnectionManager_Tracing{
@@ -2029,7 +2039,7 @@ func buildListener(opts buildListenerOpts) *xdsapi.Listener {
 // PassthroughCluster. This should be appended as the final filter or it will mask the others.
 // This allows external https traffic, even when port the port (usually 443) is in use by another service.
 func appendListenerFallthroughRoute(l *xdsapi.Listener, opts *buildListenerOpts,
-	node *model.Proxy, env *model.Environment, currentListenerEntry *outboundListenerEntry) {
+	node *model.Proxy, env *model.Environment, push *model.PushContext, currentListenerEntry *outboundListenerEntry) {
 	if features.EnableFallthroughRoute.Get() {
 
 		wildcardMatch := &listener.FilterChainMatch{}
@@ -2052,7 +2062,7 @@ func appendListenerFallthroughRoute(l *xdsapi.Listener, opts *buildListenerOpts,
 			}
 		}
 
-		tcpFilter := newTCPProxyOutboundListenerFilter(env, node)
+		tcpFilter := newTCPProxyOutboundListenerFilter(env, node, push)
 
 		opts.filterChainOpts = append(opts.filterChainOpts, &filterChainOpts{
 			networkFilters: []*listener.Filter{tcpFilter},
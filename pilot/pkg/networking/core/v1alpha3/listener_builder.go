@@ -203,7 +203,7 @@ func (builder *ListenerBuilder) buildSidecarOutboundListeners(configgen *ConfigG
 }
 
 func (builder *ListenerBuilder) buildManagementListeners(_ *ConfigGeneratorImpl,
-	env *model.Environment, node *model.Proxy, _ *model.PushContext) *ListenerBuilder {
+	env *model.Environment, node *model.Proxy, push *model.PushContext) *ListenerBuilder {
 
 	noneMode := node.GetInterceptionMode() == model.InterceptionNone
 
@@ -218,7 +218,7 @@ func (builder *ListenerBuilder) buildManagementListeners(_ *ConfigGeneratorImpl,
 	mgmtListeners := make([]*xdsapi.Listener, 0)
 	for _, ip := range node.IPAddresses {
 		managementPorts := env.ManagementPorts(ip)
-		management := buildSidecarInboundMgmtListeners(node, env, managementPorts, ip)
+		management := buildSidecarInboundMgmtListeners(node, env, push, managementPorts, ip)
 		mgmtListeners = append(mgmtListeners, management...)
 	}
 	addresses := make(map[string]*xdsapi.Listener)
@@ -263,7 +263,7 @@ func (builder *ListenerBuilder) buildVirtualOutboundListener(
 		isTransparentProxy = proto.BoolTrue
 	}
 
-	tcpProxyFilter := newTCPProxyOutboundListenerFilter(env, node)
+	tcpProxyFilter := newTCPProxyOutboundListenerFilter(env, node, push)
 
 	filterChains := []*listener.FilterChain{
 		{
@@ -452,7 +452,7 @@ func newInboundPassthroughFilterChains(configgen *ConfigGeneratorImpl,
 				util.ConvertAddressToCidr(matchingIP),
 			},
 		}
-		setAccessLog(env, node, tcpProxy)
+		setAccessLog(env, node, push, nil, tcpProxy)
 		tcpProxyFilter := &listener.Filter{
 			Name: xdsutil.TCPProxy,
 		}
@@ -578,7 +578,7 @@ func newHTTPPassThroughFilterChain(configgen *ConfigGeneratorImpl, env *model.En
 	return filterChains
 }
 
-func newTCPProxyOutboundListenerFilter(env *model.Environment, node *model.Proxy) *listener.Filter {
+func newTCPProxyOutboundListenerFilter(env *model.Environment, node *model.Proxy, push *model.PushContext) *listener.Filter {
 	tcpProxy := &tcp_proxy.TcpProxy{
 		StatPrefix:       util.BlackHoleCluster,
 		ClusterSpecifier: &tcp_proxy.TcpProxy_Cluster{Cluster: util.BlackHoleCluster},
@@ -589,7 +589,7 @@ func newTCPProxyOutboundListenerFilter(env *model.Environment, node *model.Proxy
 			StatPrefix:       util.PassthroughCluster,
 			ClusterSpecifier: &tcp_proxy.TcpProxy_Cluster{Cluster: util.PassthroughCluster},
 		}
-		setAccessLog(env, node, tcpProxy)
+		setAccessLog(env, node, push, nil, tcpProxy)
 	}
 
 	filter := listener.Filter{
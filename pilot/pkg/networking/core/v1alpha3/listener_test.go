@@ -1758,7 +1758,7 @@ func TestAppendListenerFallthroughRoute(t *testing.T) {
 	for idx := range tests {
 		t.Run(tests[idx].name, func(t *testing.T) {
 			appendListenerFallthroughRoute(tests[idx].listener, tests[idx].listenerOpts,
-				tests[idx].node, env, nil)
+				tests[idx].node, env, nil, nil)
 			if len(tests[idx].listenerOpts.filterChainOpts) != 1 {
 				t.Errorf("Expected exactly 1 filter chain options")
 			}
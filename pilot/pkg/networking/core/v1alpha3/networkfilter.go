@@ -43,19 +43,21 @@ import (
 var redisOpTimeout = 5 * time.Second
 
 // buildInboundNetworkFilters generates a TCP proxy network filter on the inbound path
-func buildInboundNetworkFilters(env *model.Environment, node *model.Proxy, instance *model.ServiceInstance) []*listener.Filter {
+func buildInboundNetworkFilters(env *model.Environment, node *model.Proxy, push *model.PushContext,
+	instance *model.ServiceInstance) []*listener.Filter {
 	clusterName := model.BuildSubsetKey(model.TrafficDirectionInbound, instance.Endpoint.ServicePort.Name,
 		instance.Service.Hostname, instance.Endpoint.ServicePort.Port)
 	tcpProxy := &tcp_proxy.TcpProxy{
 		StatPrefix:       clusterName,
 		ClusterSpecifier: &tcp_proxy.TcpProxy_Cluster{Cluster: clusterName},
 	}
-	tcpFilter := setAccessLogAndBuildTCPFilter(env, node, tcpProxy)
+	tcpFilter := setAccessLogAndBuildTCPFilter(env, node, push, instance.Endpoint.ServicePort, tcpProxy)
 	return buildNetworkFiltersStack(node, instance.Endpoint.ServicePort, tcpFilter, clusterName, clusterName)
 }
 
-// setAccessLog sets the AccessLog configuration in the given TcpProxy instance.
-func setAccessLog(env *model.Environment, node *model.Proxy, config *tcp_proxy.TcpProxy) {
+// setAccessLog sets the AccessLog configuration in the given TcpProxy instance. The port is the service
+// port of the listener, nil if the listener is not specific to a service port.
+func setAccessLog(env *model.Environment, node *model.Proxy, push *model.PushContext, port *model.Port, config *tcp_proxy.TcpProxy) {
 	if env.Mesh.AccessLogFile != "" {
 		fl := &accesslogconfig.FileAccessLog{
 			Path: env.Mesh.AccessLogFile,
@@ -75,12 +77,15 @@ func setAccessLog(env *model.Environment, node *model.Proxy, config *tcp_proxy.T
 
 		config.AccessLog = append(config.AccessLog, acc)
 	}
+
+	config.AccessLog = append(config.AccessLog, buildPolicyAccessLogs(push, node, port, false)...)
 }
 
 // setAccessLogAndBuildTCPFilter sets the AccessLog configuration in the given
 // TcpProxy instance and builds a TCP filter out of it.
-func setAccessLogAndBuildTCPFilter(env *model.Environment, node *model.Proxy, config *tcp_proxy.TcpProxy) *listener.Filter {
-	setAccessLog(env, node, config)
+func setAccessLogAndBuildTCPFilter(env *model.Environment, node *model.Proxy, push *model.PushContext,
+	port *model.Port, config *tcp_proxy.TcpProxy) *listener.Filter {
+	setAccessLog(env, node, push, port, config)
 
 	tcpFilter := &listener.Filter{
 		Name: wellknown.TCPProxy,
@@ -96,7 +101,7 @@ func setAccessLogAndBuildTCPFilter(env *model.Environment, node *model.Proxy, co
 // buildOutboundNetworkFiltersWithSingleDestination takes a single cluster name
 // and builds a stack of network filters.
 func buildOutboundNetworkFiltersWithSingleDestination(env *model.Environment, node *model.Proxy,
-	clusterName string, port *model.Port) []*listener.Filter {
+	push *model.PushContext, clusterName string, port *model.Port) []*listener.Filter {
 
 	tcpProxy := &tcp_proxy.TcpProxy{
 		StatPrefix:       clusterName,
@@ -109,7 +114,7 @@ func buildOutboundNetworkFiltersWithSingleDestination(env *model.Environment, no
 		tcpProxy.IdleTimeout = ptypes.DurationProto(idleTimeout)
 	}
 
-	tcpFilter := setAccessLogAndBuildTCPFilter(env, node, tcpProxy)
+	tcpFilter := setAccessLogAndBuildTCPFilter(env, node, push, port, tcpProxy)
 	return buildNetworkFiltersStack(node, port, tcpFilter, clusterName, clusterName)
 }
 
@@ -147,7 +152,7 @@ func buildOutboundNetworkFiltersWithWeightedClusters(env *model.Environment, nod
 
 	// TODO: Need to handle multiple cluster names for Redis
 	clusterName := clusterSpecifier.WeightedClusters.Clusters[0].Name
-	tcpFilter := setAccessLogAndBuildTCPFilter(env, node, proxyConfig)
+	tcpFilter := setAccessLogAndBuildTCPFilter(env, node, push, port, proxyConfig)
 	return buildNetworkFiltersStack(node, port, tcpFilter, statPrefix, clusterName)
 }
 
@@ -187,7 +192,7 @@ func buildOutboundNetworkFilters(env *model.Environment, node *model.Proxy,
 	if len(routes) == 1 {
 		service := node.SidecarScope.ServiceForHostname(host.Name(routes[0].Destination.Host), push.ServiceByHostnameAndNamespace)
 		clusterName := istio_route.GetDestinationCluster(routes[0].Destination, service, port.Port)
-		return buildOutboundNetworkFiltersWithSingleDestination(env, node, clusterName, port)
+		return buildOutboundNetworkFiltersWithSingleDestination(env, node, push, clusterName, port)
 	}
 	return buildOutboundNetworkFiltersWithWeightedClusters(env, node, routes, push, port, configMeta)
 }
@@ -216,10 +221,11 @@ func buildMongoFilter(statPrefix string, isXDSMarshalingToAnyEnabled bool) *list
 
 // buildOutboundAutoPassthroughFilterStack builds a filter stack with sni_cluster and tcp_proxy
 // used by auto_passthrough gateway servers
-func buildOutboundAutoPassthroughFilterStack(env *model.Environment, node *model.Proxy, port *model.Port) []*listener.Filter {
+func buildOutboundAutoPassthroughFilterStack(env *model.Environment, node *model.Proxy,
+	push *model.PushContext, port *model.Port) []*listener.Filter {
 	// First build tcp_proxy with access logs
 	// then add sni_cluster to the front
-	tcpProxy := buildOutboundNetworkFiltersWithSingleDestination(env, node, util.BlackHoleCluster, port)
+	tcpProxy := buildOutboundNetworkFiltersWithSingleDestination(env, node, push, util.BlackHoleCluster, port)
 	filterstack := make([]*listener.Filter, 0)
 	filterstack = append(filterstack, &listener.Filter{
 		Name: util.SniClusterFilter,
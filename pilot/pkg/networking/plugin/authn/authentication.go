@@ -23,6 +23,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/plugin"
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/security/authn/factory"
+	authn_model "istio.io/istio/pilot/pkg/security/model"
 )
 
 // Plugin implements Istio mTLS auth
@@ -35,8 +36,20 @@ func NewPlugin() plugin.Plugin {
 
 // OnInboundFilterChains setups filter chains based on the authentication policy.
 func (Plugin) OnInboundFilterChains(in *plugin.InputParams) []plugin.FilterChain {
-	return factory.NewPolicyApplier(in.Push,
+	filterChains := factory.NewPolicyApplier(in.Push,
 		in.ServiceInstance).InboundFilterChain(in.Env.Mesh.SdsUdsPath, in.Node.Metadata)
+	// Accept the peers of the federated trust domains, if any. Otherwise, reject the revoked peer certificates:
+	// the revocation list is issued by the local root only, and the peers of the federated trust domains would
+	// be rejected for lack of a list issued by their roots.
+	for _, fc := range filterChains {
+		if fc.TLSContext == nil {
+			continue
+		}
+		if len(in.Env.FederatedRoots) == 0 || !authn_model.ApplyFederatedRoots(fc.TLSContext.CommonTlsContext) {
+			authn_model.ApplyCRL(fc.TLSContext.CommonTlsContext, in.Env.RevocationCRL)
+		}
+	}
+	return filterChains
 }
 
 // OnOutboundListener is called whenever a new outbound listener is added to the LDS output for a given service
@@ -19,10 +19,20 @@
 // to OFF.
 // Note: ClusterRbacConfig is not created with istio installation which means this plugin doesn't
 // generate any RBAC config by default.
+// An AuthorizationPolicy annotated with "istio.io/dry-run: true" is converted to the shadow rules of the
+// Envoy RBAC filter. It is never enforced, Envoy only reports the result in the shadow_allowed and
+// shadow_denied stats and in the shadow_effective_policy_id and shadow_engine_result dynamic metadata.
+// The bindings of a ServiceRole annotated with "istio.io/dry-run: true" are generated as shadow rules likewise.
+// An AuthorizationPolicy annotated with "istio.io/ext-authz-provider: <name>" delegates the decision for the
+// requests selected by its rules to the named external authorization provider, see package extauthz.
+// The peers in the certificate revocation deny list are denied by an RBAC filter added before any other
+// authorization filter, see package revocation.
 package authz
 
 import (
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	tcp_filter "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 
 	istiolog "istio.io/pkg/log"
 
@@ -30,6 +40,8 @@ import (
 	"istio.io/istio/pilot/pkg/networking/plugin"
 	"istio.io/istio/pilot/pkg/networking/util"
 	authz_builder "istio.io/istio/pilot/pkg/security/authz/builder"
+	"istio.io/istio/pilot/pkg/security/authz/extauthz"
+	"istio.io/istio/pilot/pkg/security/authz/revocation"
 	"istio.io/istio/pilot/pkg/security/trustdomain"
 	"istio.io/istio/pkg/spiffe"
 )
@@ -77,15 +89,41 @@ func (Plugin) OnInboundListener(in *plugin.InputParams, mutable *plugin.MutableO
 }
 
 func buildFilter(in *plugin.InputParams, mutable *plugin.MutableObjects) {
+	if revocationBuilder := revocation.NewBuilder(in.Env.RevokedIdentities, util.IsXDSMarshalingToAnyEnabled(in.Node)); revocationBuilder != nil {
+		addFilters(in, mutable, revocationBuilder)
+	}
+
 	// TODO: Get trust domain from MeshConfig instead.
 	// https://github.com/istio/istio/issues/17873
 	trustDomainBundle := trustdomain.NewTrustDomainBundle(spiffe.GetTrustDomain(), in.Env.Mesh.TrustDomainAliases)
+	trustDomainBundle.FederatedTrustDomains = in.Env.FederatedTrustDomains()
+	// The RBAC filter allows the requests delegated to the external authorization filter added after it.
+	extAuthzBuilder := newExtAuthzBuilder(in)
 	builder := authz_builder.NewBuilder(trustDomainBundle, in.ServiceInstance,
-		in.Node.WorkloadLabels, in.Node.ConfigNamespace, in.Push.AuthzPolicies, util.IsXDSMarshalingToAnyEnabled(in.Node))
-	if builder == nil {
-		return
+		in.Node.WorkloadLabels, in.Node.ConfigNamespace, in.Push.AuthzPolicies, extAuthzBuilder,
+		util.IsXDSMarshalingToAnyEnabled(in.Node))
+	if builder != nil {
+		addFilters(in, mutable, builder)
 	}
 
+	if extAuthzBuilder != nil {
+		addFilters(in, mutable, extAuthzBuilder)
+	}
+}
+
+// filterBuilder builds the HTTP and TCP filters for the authorization.
+type filterBuilder interface {
+	BuildHTTPFilter() *http_filter.HttpFilter
+	BuildTCPFilter() *tcp_filter.Filter
+}
+
+func newExtAuthzBuilder(in *plugin.InputParams) *extauthz.Builder {
+	return extauthz.NewBuilder(in.Env.ExtAuthz, in.Node.WorkloadLabels, in.Node.ConfigNamespace,
+		in.Push.AuthzPolicies, util.IsXDSMarshalingToAnyEnabled(in.Node))
+}
+
+func addFilters(in *plugin.InputParams, mutable *plugin.MutableObjects, builder filterBuilder) {
+
 	switch in.ListenerProtocol {
 	case plugin.ListenerProtocolTCP:
 		rbacLog.Debugf("building filter for TCP listener protocol")
@@ -109,9 +147,11 @@ func buildFilter(in *plugin.InputParams, mutable *plugin.MutableObjects) {
 				}
 			}
 		} else {
-			for cnum := range mutable.FilterChains {
-				rbacLog.Debugf("added TCP filter to filter chain %d", cnum)
-				mutable.FilterChains[cnum].TCP = append(mutable.FilterChains[cnum].TCP, tcpFilter)
+			if tcpFilter != nil {
+				for cnum := range mutable.FilterChains {
+					rbacLog.Debugf("added TCP filter to filter chain %d", cnum)
+					mutable.FilterChains[cnum].TCP = append(mutable.FilterChains[cnum].TCP, tcpFilter)
+				}
 			}
 		}
 	case plugin.ListenerProtocolHTTP:
@@ -156,10 +196,22 @@ func (Plugin) OnInboundCluster(in *plugin.InputParams, cluster *xdsapi.Cluster)
 
 // OnOutboundRouteConfiguration implements the Plugin interface method.
 func (Plugin) OnOutboundRouteConfiguration(in *plugin.InputParams, route *xdsapi.RouteConfiguration) {
+	if in.Node.Type != model.Router {
+		// Only care about router.
+		return
+	}
+
+	newExtAuthzBuilder(in).ApplyRouteConfiguration(route)
 }
 
 // OnInboundRouteConfiguration implements the Plugin interface method.
 func (Plugin) OnInboundRouteConfiguration(in *plugin.InputParams, route *xdsapi.RouteConfiguration) {
+	if in.Node.Type != model.SidecarProxy {
+		// Only care about sidecar.
+		return
+	}
+
+	newExtAuthzBuilder(in).ApplyRouteConfiguration(route)
 }
 
 // OnOutboundCluster implements the Plugin interface method.
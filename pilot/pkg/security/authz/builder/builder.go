@@ -16,8 +16,10 @@ package builder
 
 import (
 	tcp_filter "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
 	http_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
 
 	istiolog "istio.io/pkg/log"
 
@@ -31,45 +33,57 @@ import (
 	"istio.io/istio/pkg/config/labels"
 )
 
+const (
+	// delegatedPolicyName is the name of the RBAC policy allowing the requests delegated to another filter.
+	delegatedPolicyName = "delegated"
+)
+
 var (
 	rbacLog = istiolog.RegisterScope("rbac", "rbac debugging", 0)
 )
 
+// DelegatedRequests selects the requests whose authorization decision is delegated to another filter,
+// e.g. the external authorization filter.
+type DelegatedRequests interface {
+	// DelegatedPermission returns the permission matching the delegated requests, or nil if none.
+	DelegatedPermission(forTCPFilter bool) *envoy_rbac.Permission
+}
+
 // Builder wraps all needed information for building the RBAC filter for a service.
 type Builder struct {
 	isXDSMarshalingToAnyEnabled bool
 	generator                   policy.Generator
+	delegated                   DelegatedRequests
 }
 
 // NewBuilder creates a builder instance that can be used to build corresponding RBAC filter config.
+// The delegated requests, if any, are allowed by the RBAC filter so that the decision is only made by
+// the filter they are delegated to.
 func NewBuilder(trustDomainBundle trustdomain.Bundle, serviceInstance *model.ServiceInstance,
 	workloadLabels labels.Collection, configNamespace string,
-	policies *model.AuthorizationPolicies, isXDSMarshalingToAnyEnabled bool) *Builder {
+	policies *model.AuthorizationPolicies, delegated DelegatedRequests, isXDSMarshalingToAnyEnabled bool) *Builder {
 	var generator policy.Generator
 
-	if p := policies.ListAuthorizationPolicies(configNamespace, workloadLabels); len(p) > 0 {
+	// Policies delegated to the external authorization provider are handled by the extauthz builder.
+	p, extAuthzPolicies := authz_model.SplitExtAuthzPolicies(policies.ListAuthorizationPolicies(configNamespace, workloadLabels))
+	// Only the enforced policies disable the v1alpha1 RBAC, a dry-run policy must not change what is enforced.
+	if hasEnforcedPolicy(p) || (len(extAuthzPolicies) > 0 && len(p) > 0) {
 		generator = v1beta1.NewGenerator(trustDomainBundle, p)
 		rbacLog.Debugf("v1beta1 authorization enabled for workload %v in %s", workloadLabels, configNamespace)
+	} else if len(extAuthzPolicies) > 0 {
+		// The workload is only selected by policies delegated to an external authorization provider, the
+		// v1alpha1 RBAC must not apply as for any other workload selected by an AuthorizationPolicy.
+		rbacLog.Debugf("v1beta1 authorization fully delegated for workload %v in %s", workloadLabels, configNamespace)
+		return nil
 	} else {
-		if serviceInstance == nil {
-			return nil
-		}
-		if serviceInstance.Service == nil {
-			rbacLog.Errorf("no service for serviceInstance: %v", serviceInstance)
-			return nil
-		}
-		serviceName := serviceInstance.Service.Attributes.Name
-		serviceNamespace := serviceInstance.Service.Attributes.Namespace
-		serviceMetadata, err := authz_model.NewServiceMetadata(serviceName, serviceNamespace, serviceInstance)
-		if err != nil {
-			rbacLog.Errorf("failed to create ServiceMetadata for %s: %s", serviceName, err)
-			return nil
-		}
-
-		serviceHostname := string(serviceInstance.Service.Hostname)
-		if policies.IsRBACEnabled(serviceHostname, serviceNamespace) {
-			generator = v1alpha1.NewGenerator(trustDomainBundle, serviceMetadata, policies, policies.IsGlobalPermissiveEnabled())
-			rbacLog.Debugf("v1alpha1 RBAC enabled for service %s", serviceHostname)
+		generator = newV1alpha1Generator(trustDomainBundle, serviceInstance, policies)
+		// The workload is only selected by dry-run policies, they are added as shadow rules.
+		if len(p) > 0 {
+			generator = &dryRunGenerator{
+				Generator: generator,
+				dryRun:    v1beta1.NewGenerator(trustDomainBundle, p),
+			}
+			rbacLog.Debugf("v1beta1 dry-run authorization enabled for workload %v in %s", workloadLabels, configNamespace)
 		}
 	}
 
@@ -80,6 +94,91 @@ func NewBuilder(trustDomainBundle trustdomain.Bundle, serviceInstance *model.Ser
 	return &Builder{
 		isXDSMarshalingToAnyEnabled: isXDSMarshalingToAnyEnabled,
 		generator:                   generator,
+		delegated:                   delegated,
+	}
+}
+
+// newV1alpha1Generator returns the v1alpha1 generator of the service, or nil if RBAC is not enabled for it.
+func newV1alpha1Generator(trustDomainBundle trustdomain.Bundle, serviceInstance *model.ServiceInstance,
+	policies *model.AuthorizationPolicies) policy.Generator {
+	if serviceInstance == nil {
+		return nil
+	}
+	if serviceInstance.Service == nil {
+		rbacLog.Errorf("no service for serviceInstance: %v", serviceInstance)
+		return nil
+	}
+	serviceName := serviceInstance.Service.Attributes.Name
+	serviceNamespace := serviceInstance.Service.Attributes.Namespace
+	serviceMetadata, err := authz_model.NewServiceMetadata(serviceName, serviceNamespace, serviceInstance)
+	if err != nil {
+		rbacLog.Errorf("failed to create ServiceMetadata for %s: %s", serviceName, err)
+		return nil
+	}
+
+	serviceHostname := string(serviceInstance.Service.Hostname)
+	if !policies.IsRBACEnabled(serviceHostname, serviceNamespace) {
+		return nil
+	}
+	rbacLog.Debugf("v1alpha1 RBAC enabled for service %s", serviceHostname)
+	return v1alpha1.NewGenerator(trustDomainBundle, serviceMetadata, policies, policies.IsGlobalPermissiveEnabled())
+}
+
+// hasEnforcedPolicy returns true if at least one of the policies is not in dry-run mode.
+func hasEnforcedPolicy(policies []model.Config) bool {
+	for _, p := range policies {
+		if !authz_model.IsDryRun(p.Annotations) {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunGenerator adds the shadow rules of the dry-run policies to the rules of the wrapped generator, if any.
+type dryRunGenerator struct {
+	policy.Generator
+	dryRun policy.Generator
+}
+
+func (g *dryRunGenerator) Generate(forTCPFilter bool) *http_config.RBAC {
+	shadow := g.dryRun.Generate(forTCPFilter).GetShadowRules()
+	if g.Generator == nil {
+		if shadow == nil {
+			return nil
+		}
+		return &http_config.RBAC{ShadowRules: shadow}
+	}
+
+	ret := g.Generator.Generate(forTCPFilter)
+	if ret == nil || shadow == nil {
+		return ret
+	}
+	if ret.ShadowRules == nil {
+		ret.ShadowRules = shadow
+		return ret
+	}
+	for name, p := range shadow.Policies {
+		ret.ShadowRules.Policies[name] = p
+	}
+	return ret
+}
+
+// allowDelegated adds the policy allowing the delegated requests to the enforced rules. Nothing is added if
+// no rule is enforced, as all requests are already allowed.
+func (b *Builder) allowDelegated(rules *envoy_rbac.RBAC, forTCPFilter bool) {
+	if b.delegated == nil || rules == nil {
+		return
+	}
+	permission := b.delegated.DelegatedPermission(forTCPFilter)
+	if permission == nil {
+		return
+	}
+	if rules.Policies == nil {
+		rules.Policies = map[string]*envoy_rbac.Policy{}
+	}
+	rules.Policies[delegatedPolicyName] = &envoy_rbac.Policy{
+		Permissions: []*envoy_rbac.Permission{permission},
+		Principals:  []*envoy_rbac.Principal{{Identifier: &envoy_rbac.Principal_Any{Any: true}}},
 	}
 }
 
@@ -93,6 +192,7 @@ func (b *Builder) BuildHTTPFilter() *http_filter.HttpFilter {
 	if rbacConfig == nil {
 		return nil
 	}
+	b.allowDelegated(rbacConfig.Rules, false /* forTCPFilter */)
 	httpConfig := http_filter.HttpFilter{
 		Name: authz_model.RBACHTTPFilterName,
 	}
@@ -118,6 +218,7 @@ func (b *Builder) BuildTCPFilter() *tcp_filter.Filter {
 	if config == nil {
 		return nil
 	}
+	b.allowDelegated(config.Rules, true /* forTCPFilter */)
 	rbacConfig := &tcp_config.RBAC{
 		Rules:       config.Rules,
 		ShadowRules: config.ShadowRules,
@@ -20,6 +20,7 @@ import (
 
 	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
 	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/conversion"
 
 	istio_rbac "istio.io/api/rbac/v1alpha1"
@@ -66,14 +67,29 @@ func simpleGlobalPermissiveMode() *model.Config {
 	return cfg
 }
 
+// fakeDelegated delegates all the requests.
+type fakeDelegated struct{}
+
+func (fakeDelegated) DelegatedPermission(forTCPFilter bool) *envoy_rbac.Permission {
+	return &envoy_rbac.Permission{Rule: &envoy_rbac.Permission_Any{Any: true}}
+}
+
+func extAuthzPolicy(name string, namespace string) *model.Config {
+	cfg := policy.SimpleAuthzPolicy(name, namespace)
+	cfg.Annotations = map[string]string{authz_model.ExtAuthzProviderAnnotation: "ext-authz"}
+	return cfg
+}
+
 func TestBuilder_BuildHTTPFilter(t *testing.T) {
 	service := newService("bar.a.svc.cluster.local", nil, t)
 
 	testCases := []struct {
 		name                        string
 		policies                    []*model.Config
+		delegated                   DelegatedRequests
 		isXDSMarshalingToAnyEnabled bool
 		wantPolicies                []string
+		wantShadowPolicies          []string
 	}{
 		{
 			name: "XDSMarshalingToAnyEnabled",
@@ -117,11 +133,49 @@ func TestBuilder_BuildHTTPFilter(t *testing.T) {
 			},
 			wantPolicies: []string{"ns[a]-policy[authz-bar]-rule[0]"},
 		},
+		{
+			name: "v1alpha1 and dry-run v1beta1",
+			policies: []*model.Config{
+				policy.SimpleClusterRbacConfig(),
+				policy.SimpleRole("role-1", "a", "bar"),
+				policy.SimpleBinding("binding-1", "a", "role-1"),
+				policy.SimpleDryRunAuthzPolicy("authz-bar", "a"),
+			},
+			wantPolicies:       []string{"role-1"},
+			wantShadowPolicies: []string{"ns[a]-policy[authz-bar]-rule[0]"},
+		},
+		{
+			name: "dry-run v1beta1 only",
+			policies: []*model.Config{
+				policy.SimpleDryRunAuthzPolicy("authz-bar", "a"),
+			},
+			wantPolicies:       []string{},
+			wantShadowPolicies: []string{"ns[a]-policy[authz-bar]-rule[0]"},
+		},
+		{
+			name: "v1beta1 and ext authz",
+			policies: []*model.Config{
+				policy.SimpleAuthzPolicy("authz-bar", "a"),
+				extAuthzPolicy("authz-ext", "a"),
+			},
+			delegated:    fakeDelegated{},
+			wantPolicies: []string{"ns[a]-policy[authz-bar]-rule[0]", delegatedPolicyName},
+		},
+		{
+			name: "ext authz only",
+			policies: []*model.Config{
+				policy.SimpleClusterRbacConfig(),
+				policy.SimpleRole("role-1", "a", "bar"),
+				policy.SimpleBinding("binding-1", "a", "role-1"),
+				extAuthzPolicy("authz-ext", "a"),
+			},
+			delegated: fakeDelegated{},
+		},
 	}
 
 	for _, tc := range testCases {
 		p := policy.NewAuthzPolicies(tc.policies, t)
-		b := NewBuilder(trustdomain.NewTrustDomainBundle("", nil), service, nil, "a", p, tc.isXDSMarshalingToAnyEnabled)
+		b := NewBuilder(trustdomain.NewTrustDomainBundle("", nil), service, nil, "a", p, tc.delegated, tc.isXDSMarshalingToAnyEnabled)
 
 		got := b.BuildHTTPFilter()
 		t.Run(tc.name, func(t *testing.T) {
@@ -158,6 +212,15 @@ func TestBuilder_BuildHTTPFilter(t *testing.T) {
 								t.Errorf("got %d policies but want %d", len(rbacConfig.GetRules().GetPolicies()), len(tc.wantPolicies))
 							}
 						}
+						gotShadow := rbacConfig.GetShadowRules().GetPolicies()
+						for _, want := range tc.wantShadowPolicies {
+							if _, found := gotShadow[want]; !found {
+								t.Errorf("got shadow rules with policies %v but want %v", gotShadow, want)
+							}
+						}
+						if len(tc.wantShadowPolicies) != len(gotShadow) {
+							t.Errorf("got %d shadow policies but want %d", len(gotShadow), len(tc.wantShadowPolicies))
+						}
 					}
 				}
 			}
@@ -211,7 +274,7 @@ func TestBuilder_BuildTCPFilter(t *testing.T) {
 
 	for _, tc := range testCases {
 		p := policy.NewAuthzPolicies(tc.policies, t)
-		b := NewBuilder(trustdomain.NewTrustDomainBundle("", nil), service, nil, "a", p, tc.isXDSMarshalingToAnyEnabled)
+		b := NewBuilder(trustdomain.NewTrustDomainBundle("", nil), service, nil, "a", p, nil, tc.isXDSMarshalingToAnyEnabled)
 
 		t.Run(tc.name, func(t *testing.T) {
 			got := b.BuildTCPFilter()
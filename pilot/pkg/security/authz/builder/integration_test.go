@@ -88,6 +88,15 @@ func TestBuildHTTPFilter(t *testing.T) {
 			policies:          getPolicies("testdata/v1beta1/simple-policy-multiple-td-aliases-in.yaml", t),
 			want:              getProto("testdata/v1beta1/simple-policy-multiple-td-aliases-out.yaml", t),
 		},
+		{
+			name: "v1beta1 federated trust domain principal",
+			trustDomainBundle: trustdomain.Bundle{
+				TrustDomains:          []string{"td1", "cluster.local"},
+				FederatedTrustDomains: []string{"partner.com"},
+			},
+			policies: getPolicies("testdata/v1beta1/simple-policy-federated-principal-in.yaml", t),
+			want:     getProto("testdata/v1beta1/simple-policy-federated-principal-out.yaml", t),
+		},
 		{
 			name:              "v1alpha1 one trust domain alias",
 			trustDomainBundle: trustdomain.NewTrustDomainBundle("td1", []string{"cluster.local"}),
@@ -127,7 +136,7 @@ func TestBuildHTTPFilter(t *testing.T) {
 	service := newService("httpbin.foo.svc.cluster.local", httpbinLabels, t)
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			b := NewBuilder(tc.trustDomainBundle, service, labels.Collection{httpbinLabels}, "foo", tc.policies, false)
+			b := NewBuilder(tc.trustDomainBundle, service, labels.Collection{httpbinLabels}, "foo", tc.policies, nil, false)
 			if b == nil {
 				t.Fatalf("failed to create builder")
 			}
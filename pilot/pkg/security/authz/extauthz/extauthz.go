@@ -0,0 +1,448 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extauthz builds the Envoy external authorization filter for the AuthorizationPolicy
+// annotated with an external authorization provider.
+//
+// The rules of such policy select the requests sent to the provider. The selection is done on the
+// route level by matching the request path, method and host with the authz model matchers. The RBAC
+// filter of the workload allows the selected requests, so that they are only decided by the provider.
+//
+// The source, the conditions and the ports of a rule can't be evaluated when selecting the route. Ignoring
+// them would send more requests to the provider than the rule selects and bypass the other policies of the
+// workload for these requests, so a rule using them can't be delegated. The same applies to the fields
+// unknown to this version of the API, e.g. the not-fields of newer versions.
+//
+// A policy that can't be delegated, because a rule can't be delegated, its provider is not found or the
+// workload already uses another provider, fails closed: all the requests to the workload are denied by an
+// RBAC filter instead.
+package extauthz
+
+import (
+	"fmt"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	tcp_filter "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	http_rbac "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
+	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/ext_authz/v2"
+	http_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	tcp_rbac "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
+	envoy_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+
+	authpb "istio.io/api/security/v1beta1"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	authz_model "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pilot/pkg/security/authz/model/matcher"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+)
+
+const (
+	// StatPrefix is the stat prefix of the external authorization network filter.
+	StatPrefix = "ext_authz."
+
+	methodHeader = ":method"
+	pathHeader   = ":path"
+	hostHeader   = ":authority"
+)
+
+var (
+	authzLog = authz_model.RBACLog
+)
+
+// Builder wraps all needed information for building the external authorization filter for a workload.
+type Builder struct {
+	isXDSMarshalingToAnyEnabled bool
+	provider                    *model.ExtAuthzProvider
+
+	// denyAll is true if a policy selecting the workload could not be delegated to a provider, all the
+	// requests to the workload are then denied.
+	denyAll bool
+
+	// matchAll is true if all requests to the workload are sent to the provider.
+	matchAll bool
+	// matches are the header matchers that select the requests sent to the provider, each
+	// element is a list of header matchers that must all match.
+	matches [][]*route.HeaderMatcher
+}
+
+// NewBuilder creates a builder instance that can be used to build the external authorization filter
+// config. It returns nil if no AuthorizationPolicy delegates the decision to an external provider.
+func NewBuilder(extAuthz *model.ExtAuthzConfig, workloadLabels labels.Collection, configNamespace string,
+	policies *model.AuthorizationPolicies, isXDSMarshalingToAnyEnabled bool) *Builder {
+	_, extAuthzPolicies := authz_model.SplitExtAuthzPolicies(policies.ListAuthorizationPolicies(configNamespace, workloadLabels))
+	if len(extAuthzPolicies) == 0 {
+		return nil
+	}
+
+	b := &Builder{
+		isXDSMarshalingToAnyEnabled: isXDSMarshalingToAnyEnabled,
+	}
+	for _, config := range extAuthzPolicies {
+		name := config.Annotations[authz_model.ExtAuthzProviderAnnotation]
+		provider := extAuthz.Provider(name)
+		if provider == nil {
+			authzLog.Errorf("denying all requests to workload %v in %s: policy %s/%s uses unknown external "+
+				"authorization provider %q", workloadLabels, configNamespace, config.Namespace, config.Name, name)
+			b.denyAll = true
+			continue
+		}
+		// Envoy identifies the per route config by filter name, only one provider can be used per workload.
+		if b.provider != nil && b.provider.Name != provider.Name {
+			authzLog.Errorf("denying all requests to workload %v in %s: policy %s/%s uses external authorization "+
+				"provider %q but the workload already uses %q", workloadLabels, configNamespace, config.Namespace,
+				config.Name, name, b.provider.Name)
+			b.denyAll = true
+			continue
+		}
+		spec := config.Spec.(*authpb.AuthorizationPolicy)
+		if err := checkDelegable(spec); err != nil {
+			authzLog.Errorf("denying all requests to workload %v in %s: policy %s/%s can't be delegated to "+
+				"external authorization provider %q: %v", workloadLabels, configNamespace, config.Namespace,
+				config.Name, name, err)
+			b.denyAll = true
+			continue
+		}
+		b.provider = provider
+		b.addPolicy(spec)
+	}
+
+	if b.denyAll {
+		return b
+	}
+	if b.provider == nil || (!b.matchAll && len(b.matches) == 0) {
+		return nil
+	}
+	authzLog.Debugf("external authorization provider %s enabled for workload %v in %s", b.provider.Name, workloadLabels, configNamespace)
+	return b
+}
+
+// checkDelegable returns an error if a rule of the policy uses a field that can't be evaluated when
+// selecting the requests sent to the provider.
+func checkDelegable(spec *authpb.AuthorizationPolicy) error {
+	for i, rule := range spec.GetRules() {
+		if rule == nil {
+			continue
+		}
+		switch {
+		case len(rule.From) > 0:
+			return fmt.Errorf("rule %d: from is not supported", i)
+		case len(rule.When) > 0:
+			return fmt.Errorf("rule %d: when is not supported", i)
+		case len(rule.XXX_unrecognized) > 0:
+			return fmt.Errorf("rule %d: unknown fields are not supported", i)
+		}
+		for _, to := range rule.To {
+			if to == nil {
+				continue
+			}
+			if len(to.XXX_unrecognized) > 0 {
+				return fmt.Errorf("rule %d: unknown fields are not supported", i)
+			}
+			op := to.GetOperation()
+			if op == nil {
+				continue
+			}
+			switch {
+			case len(op.Ports) > 0:
+				return fmt.Errorf("rule %d: ports is not supported", i)
+			case len(op.XXX_unrecognized) > 0:
+				return fmt.Errorf("rule %d: unknown operation fields are not supported", i)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Builder) addPolicy(spec *authpb.AuthorizationPolicy) {
+	for _, rule := range spec.GetRules() {
+		if rule == nil {
+			continue
+		}
+		if len(rule.To) == 0 {
+			b.matchAll = true
+			return
+		}
+		for _, to := range rule.To {
+			op := to.GetOperation()
+			if op == nil || (len(op.Paths) == 0 && len(op.Methods) == 0 && len(op.Hosts) == 0) {
+				b.matchAll = true
+				return
+			}
+			b.matches = append(b.matches, operationMatches(op)...)
+		}
+	}
+}
+
+// operationMatches returns the header matchers for all the combinations of paths, methods and hosts
+// in the operation.
+func operationMatches(op *authpb.Operation) [][]*route.HeaderMatcher {
+	ret := [][]*route.HeaderMatcher{nil}
+	for _, h := range []struct {
+		name   string
+		values []string
+	}{
+		{name: pathHeader, values: op.Paths},
+		{name: methodHeader, values: op.Methods},
+		{name: hostHeader, values: op.Hosts},
+	} {
+		if len(h.values) == 0 {
+			continue
+		}
+		var next [][]*route.HeaderMatcher
+		for _, prev := range ret {
+			for _, v := range h.values {
+				m := append(append([]*route.HeaderMatcher{}, prev...), matcher.HeaderMatcher(h.name, v))
+				next = append(next, m)
+			}
+		}
+		ret = next
+	}
+	return ret
+}
+
+// BuildHTTPFilter builds the external authorization HTTP filter, or the RBAC HTTP filter denying all requests
+// if a policy could not be delegated.
+func (b *Builder) BuildHTTPFilter() *http_filter.HttpFilter {
+	if b == nil {
+		return nil
+	}
+	if b.denyAll {
+		return b.buildDenyAllHTTPFilter()
+	}
+
+	extAuthz := &http_config.ExtAuthz{
+		FailureModeAllow: b.provider.FailOpen,
+	}
+	timeout := ptypes.DurationProto(b.provider.GetTimeout())
+	switch b.provider.Protocol {
+	case model.ExtAuthzProtocolHTTP:
+		extAuthz.Services = &http_config.ExtAuthz_HttpService{
+			HttpService: &http_config.HttpService{
+				ServerUri: &core.HttpUri{
+					Uri: fmt.Sprintf("http://%s:%d", b.provider.Service, b.provider.Port),
+					HttpUpstreamType: &core.HttpUri_Cluster{
+						Cluster: b.clusterName(),
+					},
+					Timeout: timeout,
+				},
+				PathPrefix:           b.provider.PathPrefix,
+				AuthorizationRequest: b.authorizationRequest(),
+			},
+		}
+	default:
+		extAuthz.Services = &http_config.ExtAuthz_GrpcService{
+			GrpcService: b.grpcService(),
+		}
+	}
+
+	httpConfig := &http_filter.HttpFilter{
+		Name: wellknown.HTTPExternalAuthorization,
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		httpConfig.ConfigType = &http_filter.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(extAuthz)}
+	} else {
+		httpConfig.ConfigType = &http_filter.HttpFilter_Config{Config: util.MessageToStruct(extAuthz)}
+	}
+
+	authzLog.Debugf("built external authorization http filter config: %v", httpConfig)
+	return httpConfig
+}
+
+// BuildTCPFilter builds the external authorization TCP filter. The TCP filter is only built if the provider
+// uses the gRPC protocol and all requests to the workload are selected, as the rules with paths, methods or
+// hosts could not match a TCP connection. The RBAC TCP filter denying all connections is built instead if a
+// policy could not be delegated.
+func (b *Builder) BuildTCPFilter() *tcp_filter.Filter {
+	if b == nil {
+		return nil
+	}
+	if b.denyAll {
+		return b.buildDenyAllTCPFilter()
+	}
+	if !b.matchAll || b.provider.Protocol != model.ExtAuthzProtocolGRPC {
+		return nil
+	}
+
+	extAuthz := &tcp_config.ExtAuthz{
+		StatPrefix:       StatPrefix,
+		GrpcService:      b.grpcService(),
+		FailureModeAllow: b.provider.FailOpen,
+	}
+	tcpConfig := &tcp_filter.Filter{
+		Name: wellknown.ExternalAuthorization,
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		tcpConfig.ConfigType = &tcp_filter.Filter_TypedConfig{TypedConfig: util.MessageToAny(extAuthz)}
+	} else {
+		tcpConfig.ConfigType = &tcp_filter.Filter_Config{Config: util.MessageToStruct(extAuthz)}
+	}
+
+	authzLog.Debugf("built external authorization tcp filter config: %v", tcpConfig)
+	return tcpConfig
+}
+
+// ApplyRouteConfiguration updates the routes so that only the selected requests are sent to the provider.
+// Each route is duplicated with the additional header matchers of the selected requests and the original
+// route disables the external authorization.
+func (b *Builder) ApplyRouteConfiguration(rc *xdsapi.RouteConfiguration) {
+	if b == nil || b.denyAll || b.matchAll || rc == nil {
+		return
+	}
+
+	for _, vhost := range rc.VirtualHosts {
+		routes := make([]*route.Route, 0, len(vhost.Routes)*(len(b.matches)+1))
+		for _, r := range vhost.Routes {
+			for _, m := range b.matches {
+				selected := proto.Clone(r).(*route.Route)
+				if selected.Match == nil {
+					selected.Match = &route.RouteMatch{}
+				}
+				selected.Match.Headers = append(selected.Match.Headers, m...)
+				routes = append(routes, selected)
+			}
+			b.disableOnRoute(r)
+			routes = append(routes, r)
+		}
+		vhost.Routes = routes
+	}
+}
+
+// DelegatedPermission returns the RBAC permission matching the requests sent to the provider, or nil if no
+// request is sent to the provider. The RBAC filter of the workload allows these requests so that only the
+// provider decides.
+func (b *Builder) DelegatedPermission(forTCPFilter bool) *envoy_rbac.Permission {
+	if b == nil || b.denyAll {
+		return nil
+	}
+	if forTCPFilter {
+		// Same condition as BuildTCPFilter, the connections are not delegated without the TCP filter.
+		if !b.matchAll || b.provider.Protocol != model.ExtAuthzProtocolGRPC {
+			return nil
+		}
+		return &envoy_rbac.Permission{Rule: &envoy_rbac.Permission_Any{Any: true}}
+	}
+	if b.matchAll {
+		return &envoy_rbac.Permission{Rule: &envoy_rbac.Permission_Any{Any: true}}
+	}
+
+	or := &envoy_rbac.Permission_Set{}
+	for _, m := range b.matches {
+		and := &envoy_rbac.Permission_Set{}
+		for _, h := range m {
+			and.Rules = append(and.Rules, &envoy_rbac.Permission{Rule: &envoy_rbac.Permission_Header{Header: h}})
+		}
+		or.Rules = append(or.Rules, &envoy_rbac.Permission{Rule: &envoy_rbac.Permission_AndRules{AndRules: and}})
+	}
+	return &envoy_rbac.Permission{Rule: &envoy_rbac.Permission_OrRules{OrRules: or}}
+}
+
+// denyAllRules returns the RBAC rules denying all requests, i.e. an ALLOW action without any policy.
+func denyAllRules() *envoy_rbac.RBAC {
+	return &envoy_rbac.RBAC{
+		Action:   envoy_rbac.RBAC_ALLOW,
+		Policies: map[string]*envoy_rbac.Policy{},
+	}
+}
+
+func (b *Builder) buildDenyAllHTTPFilter() *http_filter.HttpFilter {
+	rbacConfig := &http_rbac.RBAC{Rules: denyAllRules()}
+	httpConfig := &http_filter.HttpFilter{
+		Name: authz_model.RBACHTTPFilterName,
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		httpConfig.ConfigType = &http_filter.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(rbacConfig)}
+	} else {
+		httpConfig.ConfigType = &http_filter.HttpFilter_Config{Config: util.MessageToStruct(rbacConfig)}
+	}
+
+	authzLog.Debugf("built deny-all http filter config: %v", httpConfig)
+	return httpConfig
+}
+
+func (b *Builder) buildDenyAllTCPFilter() *tcp_filter.Filter {
+	rbacConfig := &tcp_rbac.RBAC{
+		Rules:      denyAllRules(),
+		StatPrefix: StatPrefix,
+	}
+	tcpConfig := &tcp_filter.Filter{
+		Name: authz_model.RBACTCPFilterName,
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		tcpConfig.ConfigType = &tcp_filter.Filter_TypedConfig{TypedConfig: util.MessageToAny(rbacConfig)}
+	} else {
+		tcpConfig.ConfigType = &tcp_filter.Filter_Config{Config: util.MessageToStruct(rbacConfig)}
+	}
+
+	authzLog.Debugf("built deny-all tcp filter config: %v", tcpConfig)
+	return tcpConfig
+}
+
+func (b *Builder) disableOnRoute(r *route.Route) {
+	perRoute := &http_config.ExtAuthzPerRoute{
+		Override: &http_config.ExtAuthzPerRoute_Disabled{Disabled: true},
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		if r.TypedPerFilterConfig == nil {
+			r.TypedPerFilterConfig = map[string]*any.Any{}
+		}
+		r.TypedPerFilterConfig[wellknown.HTTPExternalAuthorization] = util.MessageToAny(perRoute)
+	} else {
+		if r.PerFilterConfig == nil {
+			r.PerFilterConfig = map[string]*structpb.Struct{}
+		}
+		r.PerFilterConfig[wellknown.HTTPExternalAuthorization] = util.MessageToStruct(perRoute)
+	}
+}
+
+func (b *Builder) clusterName() string {
+	return model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(b.provider.Service), b.provider.Port)
+}
+
+func (b *Builder) grpcService() *core.GrpcService {
+	return &core.GrpcService{
+		TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+			EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+				ClusterName: b.clusterName(),
+			},
+		},
+		Timeout: ptypes.DurationProto(b.provider.GetTimeout()),
+	}
+}
+
+func (b *Builder) authorizationRequest() *http_config.AuthorizationRequest {
+	if len(b.provider.IncludeHeaders) == 0 {
+		return nil
+	}
+	patterns := make([]*envoy_matcher.StringMatcher, 0, len(b.provider.IncludeHeaders))
+	for _, h := range b.provider.IncludeHeaders {
+		patterns = append(patterns, &envoy_matcher.StringMatcher{
+			MatchPattern: &envoy_matcher.StringMatcher_Exact{Exact: h},
+		})
+	}
+	return &http_config.AuthorizationRequest{
+		AllowedHeaders: &envoy_matcher.ListStringMatcher{Patterns: patterns},
+	}
+}
@@ -0,0 +1,554 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	http_rbac "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
+	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/ext_authz/v2"
+	tcp_rbac "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/conversion"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+
+	authpb "istio.io/api/security/v1beta1"
+
+	"istio.io/istio/pilot/pkg/model"
+	authz_model "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pilot/pkg/security/authz/policy"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+const extAuthzConfig = `
+providers:
+- name: grpc
+  service: ext-authz.foo.svc.cluster.local
+  port: 9000
+  timeout: 1s
+  failOpen: true
+- name: http
+  service: ext-authz.foo.svc.cluster.local
+  port: 8000
+  protocol: HTTP
+  pathPrefix: /check
+  includeHeaders: ["x-user"]
+`
+
+func newPolicy(name, provider string, rules ...*authpb.Rule) *model.Config {
+	cfg := &model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      schemas.AuthorizationPolicy.Type,
+			Name:      name,
+			Namespace: "foo",
+		},
+		Spec: &authpb.AuthorizationPolicy{
+			Rules: rules,
+		},
+	}
+	if provider != "" {
+		cfg.Annotations = map[string]string{authz_model.ExtAuthzProviderAnnotation: provider}
+	}
+	return cfg
+}
+
+func withDryRun(cfg *model.Config) *model.Config {
+	cfg.Annotations[authz_model.DryRunAnnotation] = "true"
+	return cfg
+}
+
+func newRule(paths, methods []string) *authpb.Rule {
+	return &authpb.Rule{
+		To: []*authpb.Rule_To{
+			{
+				Operation: &authpb.Operation{
+					Paths:   paths,
+					Methods: methods,
+				},
+			},
+		},
+	}
+}
+
+func newBuilder(t *testing.T, policies []*model.Config, isXDSMarshalingToAnyEnabled bool) *Builder {
+	t.Helper()
+	extAuthz, err := model.LoadExtAuthzConfig(extAuthzConfig)
+	if err != nil {
+		t.Fatalf("failed to load external authorization config: %v", err)
+	}
+	return NewBuilder(extAuthz, labels.Collection{}, "foo", policy.NewAuthzPolicies(policies, t), isXDSMarshalingToAnyEnabled)
+}
+
+func TestNewBuilder(t *testing.T) {
+	testCases := []struct {
+		name         string
+		policies     []*model.Config
+		wantNil      bool
+		wantDenyAll  bool
+		wantProvider string
+		wantMatchAll bool
+		wantMatches  int
+	}{
+		{
+			name:    "no policy",
+			wantNil: true,
+		},
+		{
+			name:     "rbac policy only",
+			policies: []*model.Config{policy.SimpleAuthzPolicy("rbac", "foo")},
+			wantNil:  true,
+		},
+		{
+			name:        "unknown provider",
+			policies:    []*model.Config{newPolicy("ext", "unknown", &authpb.Rule{})},
+			wantDenyAll: true,
+		},
+		{
+			name:     "no rules",
+			policies: []*model.Config{newPolicy("ext", "grpc")},
+			wantNil:  true,
+		},
+		{
+			name:         "match all",
+			policies:     []*model.Config{newPolicy("ext", "grpc", &authpb.Rule{})},
+			wantProvider: "grpc",
+			wantMatchAll: true,
+		},
+		{
+			name: "match paths and methods",
+			policies: []*model.Config{
+				newPolicy("ext", "http", newRule([]string{"/admin*", "/login"}, []string{"GET", "POST"})),
+			},
+			wantProvider: "http",
+			wantMatches:  4,
+		},
+		{
+			name: "dry-run policy",
+			policies: []*model.Config{
+				withDryRun(newPolicy("ext", "grpc", &authpb.Rule{})),
+			},
+			wantNil: true,
+		},
+		{
+			name: "rule with from",
+			policies: []*model.Config{
+				newPolicy("ext", "grpc", &authpb.Rule{
+					From: []*authpb.Rule_From{{Source: &authpb.Source{Principals: []string{"foo"}}}},
+				}),
+			},
+			wantDenyAll: true,
+		},
+		{
+			name: "rule with when",
+			policies: []*model.Config{
+				newPolicy("ext", "grpc", &authpb.Rule{
+					When: []*authpb.Condition{{Key: "request.headers[x-foo]", Values: []string{"bar"}}},
+				}),
+			},
+			wantDenyAll: true,
+		},
+		{
+			name: "rule with ports",
+			policies: []*model.Config{
+				newPolicy("ext", "grpc", &authpb.Rule{
+					To: []*authpb.Rule_To{{Operation: &authpb.Operation{Ports: []string{"8080"}}}},
+				}),
+			},
+			wantDenyAll: true,
+		},
+		{
+			// notPaths: ["/public"] of a newer API version, i.e. the field 8 of the Operation.
+			name: "rule with notPaths",
+			policies: []*model.Config{
+				newPolicy("ext", "grpc", &authpb.Rule{
+					To: []*authpb.Rule_To{{Operation: &authpb.Operation{
+						XXX_unrecognized: append([]byte{0x42, 0x07}, "/public"...),
+					}}},
+				}),
+			},
+			wantDenyAll: true,
+		},
+		{
+			name: "second provider",
+			policies: []*model.Config{
+				newPolicy("ext-1", "grpc", newRule([]string{"/admin*"}, nil)),
+				newPolicy("ext-2", "http", &authpb.Rule{}),
+			},
+			wantDenyAll: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newBuilder(t, tc.policies, false)
+			if tc.wantNil {
+				if b != nil {
+					t.Fatalf("want nil builder but got %v", b)
+				}
+				return
+			}
+			if b == nil {
+				t.Fatalf("want builder but got nil")
+			}
+			if b.denyAll != tc.wantDenyAll {
+				t.Fatalf("got denyAll %v but want %v", b.denyAll, tc.wantDenyAll)
+			}
+			if tc.wantDenyAll {
+				return
+			}
+			if b.provider.Name != tc.wantProvider {
+				t.Errorf("got provider %s but want %s", b.provider.Name, tc.wantProvider)
+			}
+			if b.matchAll != tc.wantMatchAll {
+				t.Errorf("got matchAll %v but want %v", b.matchAll, tc.wantMatchAll)
+			}
+			if len(b.matches) != tc.wantMatches {
+				t.Errorf("got %d matches but want %d", len(b.matches), tc.wantMatches)
+			}
+		})
+	}
+}
+
+func TestNewBuilder_NoProviders(t *testing.T) {
+	// The providers are empty if the external authorization config failed to load.
+	policies := policy.NewAuthzPolicies([]*model.Config{newPolicy("ext", "grpc", &authpb.Rule{})}, t)
+	b := NewBuilder(nil, labels.Collection{}, "foo", policies, false)
+	if b == nil || !b.denyAll {
+		t.Fatalf("want builder denying all requests but got %v", b)
+	}
+}
+
+func TestBuilder_BuildHTTPFilter(t *testing.T) {
+	testCases := []struct {
+		name                        string
+		provider                    string
+		isXDSMarshalingToAnyEnabled bool
+	}{
+		{
+			name:     "grpc",
+			provider: "grpc",
+		},
+		{
+			name:     "http",
+			provider: "http",
+		},
+		{
+			name:                        "XDSMarshalingToAnyEnabled",
+			provider:                    "grpc",
+			isXDSMarshalingToAnyEnabled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newBuilder(t, []*model.Config{newPolicy("ext", tc.provider, &authpb.Rule{})}, tc.isXDSMarshalingToAnyEnabled)
+			filter := b.BuildHTTPFilter()
+			if filter == nil {
+				t.Fatalf("want filter but got nil")
+			}
+			if filter.Name != wellknown.HTTPExternalAuthorization {
+				t.Errorf("got filter name %q but want %q", filter.Name, wellknown.HTTPExternalAuthorization)
+			}
+
+			config := &http_config.ExtAuthz{}
+			if tc.isXDSMarshalingToAnyEnabled {
+				if err := ptypes.UnmarshalAny(filter.GetTypedConfig(), config); err != nil {
+					t.Fatalf("failed to unmarshal typed config: %v", err)
+				}
+			} else {
+				if err := conversion.StructToMessage(filter.GetConfig(), config); err != nil {
+					t.Fatalf("failed to convert struct to message: %v", err)
+				}
+			}
+
+			switch tc.provider {
+			case "grpc":
+				grpc := config.GetGrpcService()
+				if got := grpc.GetEnvoyGrpc().GetClusterName(); got != "outbound|9000||ext-authz.foo.svc.cluster.local" {
+					t.Errorf("got cluster %q", got)
+				}
+				if got, _ := ptypes.Duration(grpc.GetTimeout()); got != time.Second {
+					t.Errorf("got timeout %v but want 1s", got)
+				}
+				if !config.FailureModeAllow {
+					t.Errorf("want failure mode allow")
+				}
+			case "http":
+				http := config.GetHttpService()
+				if got := http.GetServerUri().GetCluster(); got != "outbound|8000||ext-authz.foo.svc.cluster.local" {
+					t.Errorf("got cluster %q", got)
+				}
+				if http.GetPathPrefix() != "/check" {
+					t.Errorf("got path prefix %q but want /check", http.GetPathPrefix())
+				}
+				if got := http.GetAuthorizationRequest().GetAllowedHeaders().GetPatterns(); len(got) != 1 || got[0].GetExact() != "x-user" {
+					t.Errorf("got allowed headers %v", got)
+				}
+				if config.FailureModeAllow {
+					t.Errorf("want failure mode deny")
+				}
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildTCPFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   *model.Config
+		wantNil  bool
+		wantName string
+	}{
+		{
+			name:   "grpc match all",
+			policy: newPolicy("ext", "grpc", &authpb.Rule{}),
+		},
+		{
+			name:    "http provider",
+			policy:  newPolicy("ext", "http", &authpb.Rule{}),
+			wantNil: true,
+		},
+		{
+			name:    "grpc match paths",
+			policy:  newPolicy("ext", "grpc", newRule([]string{"/admin"}, nil)),
+			wantNil: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := newBuilder(t, []*model.Config{tc.policy}, false).BuildTCPFilter()
+			if tc.wantNil {
+				if filter != nil {
+					t.Fatalf("want nil filter but got %v", filter)
+				}
+				return
+			}
+			if filter == nil {
+				t.Fatalf("want filter but got nil")
+			}
+			config := &tcp_config.ExtAuthz{}
+			if err := conversion.StructToMessage(filter.GetConfig(), config); err != nil {
+				t.Fatalf("failed to convert struct to message: %v", err)
+			}
+			if config.StatPrefix != StatPrefix {
+				t.Errorf("got stat prefix %q but want %q", config.StatPrefix, StatPrefix)
+			}
+		})
+	}
+}
+
+func TestBuilder_DelegatedPermission(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policies []*model.Config
+		wantHTTP string
+		wantTCP  string
+	}{
+		{
+			name: "no policy",
+		},
+		{
+			name:     "grpc match all",
+			policies: []*model.Config{newPolicy("ext", "grpc", &authpb.Rule{})},
+			wantHTTP: "any",
+			wantTCP:  "any",
+		},
+		{
+			name:     "http match all",
+			policies: []*model.Config{newPolicy("ext", "http", &authpb.Rule{})},
+			wantHTTP: "any",
+		},
+		{
+			name: "match paths and methods",
+			policies: []*model.Config{
+				newPolicy("ext", "grpc", newRule([]string{"/admin*", "/login"}, []string{"GET"})),
+			},
+			wantHTTP: "or",
+		},
+		{
+			name:     "deny all",
+			policies: []*model.Config{newPolicy("ext", "unknown", &authpb.Rule{})},
+		},
+		{
+			name: "rule with ports",
+			policies: []*model.Config{
+				newPolicy("ext", "grpc", &authpb.Rule{
+					To: []*authpb.Rule_To{{Operation: &authpb.Operation{Ports: []string{"8080"}}}},
+				}),
+			},
+		},
+	}
+
+	ruleType := func(p *envoy_rbac.Permission) string {
+		switch {
+		case p == nil:
+			return ""
+		case p.GetAny():
+			return "any"
+		case p.GetOrRules() != nil:
+			return "or"
+		default:
+			return "unknown"
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newBuilder(t, tc.policies, false)
+			if got := ruleType(b.DelegatedPermission(false)); got != tc.wantHTTP {
+				t.Errorf("got http permission %q but want %q", got, tc.wantHTTP)
+			}
+			if got := ruleType(b.DelegatedPermission(true)); got != tc.wantTCP {
+				t.Errorf("got tcp permission %q but want %q", got, tc.wantTCP)
+			}
+		})
+	}
+
+	b := newBuilder(t, []*model.Config{
+		newPolicy("ext", "grpc", newRule([]string{"/admin*", "/login"}, []string{"GET"})),
+	}, false)
+	or := b.DelegatedPermission(false).GetOrRules().GetRules()
+	if len(or) != 2 {
+		t.Fatalf("got %d rules but want 2", len(or))
+	}
+	for _, r := range or {
+		if and := r.GetAndRules().GetRules(); len(and) != 2 || and[0].GetHeader().GetName() != pathHeader ||
+			and[1].GetHeader().GetName() != methodHeader {
+			t.Errorf("got rule %v but want path and method headers", r)
+		}
+	}
+}
+
+func TestBuilder_DenyAll(t *testing.T) {
+	policies := []*model.Config{newPolicy("ext", "unknown", newRule([]string{"/admin"}, nil))}
+	b := newBuilder(t, policies, false)
+
+	httpFilter := b.BuildHTTPFilter()
+	if httpFilter == nil || httpFilter.Name != authz_model.RBACHTTPFilterName {
+		t.Fatalf("want RBAC http filter but got %v", httpFilter)
+	}
+	httpConfig := &http_rbac.RBAC{}
+	if err := conversion.StructToMessage(httpFilter.GetConfig(), httpConfig); err != nil {
+		t.Fatalf("failed to convert struct to message: %v", err)
+	}
+	if httpConfig.Rules.Action != envoy_rbac.RBAC_ALLOW || len(httpConfig.Rules.Policies) != 0 {
+		t.Errorf("want deny-all rules but got %v", httpConfig.Rules)
+	}
+
+	tcpFilter := b.BuildTCPFilter()
+	if tcpFilter == nil || tcpFilter.Name != authz_model.RBACTCPFilterName {
+		t.Fatalf("want RBAC tcp filter but got %v", tcpFilter)
+	}
+	tcpConfig := &tcp_rbac.RBAC{}
+	if err := conversion.StructToMessage(tcpFilter.GetConfig(), tcpConfig); err != nil {
+		t.Fatalf("failed to convert struct to message: %v", err)
+	}
+	if tcpConfig.Rules.Action != envoy_rbac.RBAC_ALLOW || len(tcpConfig.Rules.Policies) != 0 {
+		t.Errorf("want deny-all rules but got %v", tcpConfig.Rules)
+	}
+
+	rc := &xdsapi.RouteConfiguration{
+		VirtualHosts: []*route.VirtualHost{{Routes: []*route.Route{{Name: "default"}}}},
+	}
+	b.ApplyRouteConfiguration(rc)
+	if routes := rc.VirtualHosts[0].Routes; len(routes) != 1 || routes[0].PerFilterConfig != nil {
+		t.Errorf("want routes unchanged but got %v", routes)
+	}
+}
+
+func TestBuilder_ApplyRouteConfiguration(t *testing.T) {
+	newRouteConfig := func() *xdsapi.RouteConfiguration {
+		return &xdsapi.RouteConfiguration{
+			VirtualHosts: []*route.VirtualHost{
+				{
+					Routes: []*route.Route{
+						{
+							Name: "default",
+							Match: &route.RouteMatch{
+								PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("match all", func(t *testing.T) {
+		rc := newRouteConfig()
+		newBuilder(t, []*model.Config{newPolicy("ext", "grpc", &authpb.Rule{})}, false).ApplyRouteConfiguration(rc)
+		if routes := rc.VirtualHosts[0].Routes; len(routes) != 1 || routes[0].PerFilterConfig != nil {
+			t.Errorf("want routes unchanged but got %v", routes)
+		}
+	})
+
+	t.Run("match paths", func(t *testing.T) {
+		rc := newRouteConfig()
+		policies := []*model.Config{newPolicy("ext", "grpc", newRule([]string{"/admin*", "/login"}, []string{"POST"}))}
+		newBuilder(t, policies, false).ApplyRouteConfiguration(rc)
+
+		routes := rc.VirtualHosts[0].Routes
+		if len(routes) != 3 {
+			t.Fatalf("got %d routes but want 3", len(routes))
+		}
+		wantPrefix := []string{"/admin", ""}
+		wantExact := []string{"", "/login"}
+		for i, r := range routes[:2] {
+			headers := r.GetMatch().GetHeaders()
+			if len(headers) != 2 {
+				t.Fatalf("route %d: got %d headers but want 2", i, len(headers))
+			}
+			if headers[0].Name != pathHeader || headers[0].GetPrefixMatch() != wantPrefix[i] || headers[0].GetExactMatch() != wantExact[i] {
+				t.Errorf("route %d: got path matcher %v", i, headers[0])
+			}
+			if headers[1].Name != methodHeader || headers[1].GetExactMatch() != "POST" {
+				t.Errorf("route %d: got method matcher %v", i, headers[1])
+			}
+			if r.PerFilterConfig != nil {
+				t.Errorf("route %d: want external authorization enabled", i)
+			}
+		}
+
+		last := routes[2]
+		if len(last.GetMatch().GetHeaders()) != 0 {
+			t.Errorf("want original route match unchanged but got %v", last.GetMatch())
+		}
+		perRoute := &http_config.ExtAuthzPerRoute{}
+		if err := conversion.StructToMessage(last.PerFilterConfig[wellknown.HTTPExternalAuthorization], perRoute); err != nil {
+			t.Fatalf("failed to convert struct to message: %v", err)
+		}
+		if !perRoute.GetDisabled() {
+			t.Errorf("want external authorization disabled on original route")
+		}
+	})
+
+	t.Run("XDSMarshalingToAnyEnabled", func(t *testing.T) {
+		rc := newRouteConfig()
+		newBuilder(t, []*model.Config{newPolicy("ext", "grpc", newRule([]string{"/admin"}, nil))}, true).ApplyRouteConfiguration(rc)
+		routes := rc.VirtualHosts[0].Routes
+		if len(routes) != 2 {
+			t.Fatalf("got %d routes but want 2", len(routes))
+		}
+		if _, ok := routes[1].TypedPerFilterConfig[wellknown.HTTPExternalAuthorization]; !ok {
+			t.Errorf("want typed per filter config on original route")
+		}
+	})
+}
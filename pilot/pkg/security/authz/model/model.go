@@ -35,6 +35,27 @@ const (
 	RBACTCPFilterName       = "envoy.filters.network.rbac"
 	RBACTCPFilterStatPrefix = "tcp."
 
+	// DryRunAnnotation is the annotation on an AuthorizationPolicy, or a v1alpha1 ServiceRole, to put it in
+	// dry-run mode. A policy in dry-run mode is generated as shadow rules which are evaluated and reported but
+	// never enforced.
+	DryRunAnnotation = "istio.io/dry-run"
+
+	// ExtAuthzProviderAnnotation is the annotation on an AuthorizationPolicy to delegate the authorization
+	// decision to the named external authorization provider. The rules of such policy select the requests
+	// that are sent to the provider instead of being converted to the RBAC filter. All the requests to the
+	// workload are denied if the provider is not found or the workload already uses another provider.
+	// A policy with both this annotation and the dry-run annotation is ignored.
+	ExtAuthzProviderAnnotation = "istio.io/ext-authz-provider"
+
+	// RBACShadowEffectivePolicyID is the dynamic metadata key (under the RBAC filter name) that holds the
+	// name of the shadow policy matched by the request, e.g. "ns[foo]-policy[bar]-rule[0]". It could be used
+	// in the access log format with %DYNAMIC_METADATA(envoy.filters.http.rbac:shadow_effective_policy_id)%.
+	RBACShadowEffectivePolicyID = "shadow_effective_policy_id"
+
+	// RBACShadowEngineResult is the dynamic metadata key (under the RBAC filter name) that holds the result
+	// of the shadow rules, either "allowed" or "denied".
+	RBACShadowEngineResult = "shadow_engine_result"
+
 	// attributes that could be used in both ServiceRoleBinding and ServiceRole.
 	attrRequestHeader = "request.headers" // header name is surrounded by brackets, e.g. "request.headers[User-Agent]".
 
@@ -72,6 +93,9 @@ const (
 
 var (
 	rbacLog = istiolog.RegisterScope("rbac", "rbac debugging", 0)
+
+	// RBACLog is the "rbac" logging scope, shared with the packages building the other authorization filters.
+	RBACLog = rbacLog
 )
 
 // ServiceMetadata is a collection of different kind of information about a service.
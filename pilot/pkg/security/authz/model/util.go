@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"istio.io/istio/pilot/pkg/model"
 )
 
 // stringMatch checks if a string is in a list, it supports four types of string matches:
@@ -101,6 +103,31 @@ func extractActualServiceAccount(istioServiceAccount string) string {
 	return actualSA
 }
 
+// IsDryRun returns true if the annotations put the policy in dry-run mode.
+func IsDryRun(annotations map[string]string) bool {
+	dryRun, err := strconv.ParseBool(annotations[DryRunAnnotation])
+	return err == nil && dryRun
+}
+
+// SplitExtAuthzPolicies splits the policies into the ones enforced by the RBAC filter and the ones
+// delegated to an external authorization provider. A dry-run policy delegating to a provider is in
+// neither list: the provider's decision can't be shadowed, so the policy is ignored.
+func SplitExtAuthzPolicies(policies []model.Config) (rbacPolicies, extAuthzPolicies []model.Config) {
+	for _, p := range policies {
+		if p.Annotations[ExtAuthzProviderAnnotation] != "" {
+			if IsDryRun(p.Annotations) {
+				rbacLog.Debugf("ignoring dry-run policy %s/%s delegated to external authorization provider %s",
+					p.Namespace, p.Name, p.Annotations[ExtAuthzProviderAnnotation])
+				continue
+			}
+			extAuthzPolicies = append(extAuthzPolicies, p)
+		} else {
+			rbacPolicies = append(rbacPolicies, p)
+		}
+	}
+	return
+}
+
 func found(key string, list []string) bool {
 	for _, l := range list {
 		if key == l {
@@ -17,6 +17,8 @@ package model
 import (
 	"strings"
 	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
 )
 
 func TestStringMatch(t *testing.T) {
@@ -55,6 +57,37 @@ func TestStringMatch(t *testing.T) {
 	}
 }
 
+func TestIsDryRun(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Annotations map[string]string
+		Expect      bool
+	}{
+		{
+			Name: "no annotation", Annotations: nil,
+			Expect: false,
+		},
+		{
+			Name: "dry-run true", Annotations: map[string]string{DryRunAnnotation: "true"},
+			Expect: true,
+		},
+		{
+			Name: "dry-run false", Annotations: map[string]string{DryRunAnnotation: "false"},
+			Expect: false,
+		},
+		{
+			Name: "dry-run invalid", Annotations: map[string]string{DryRunAnnotation: "yes please"},
+			Expect: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		if actual := IsDryRun(tc.Annotations); actual != tc.Expect {
+			t.Errorf("%s: expecting: %v, but got: %v", tc.Name, tc.Expect, actual)
+		}
+	}
+}
+
 func TestConvertToPort(t *testing.T) {
 	testCases := []struct {
 		Name   string
@@ -196,3 +229,21 @@ func TestExtractActualServiceAccount(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitExtAuthzPolicies(t *testing.T) {
+	policies := []model.Config{
+		{ConfigMeta: model.ConfigMeta{Name: "rbac"}},
+		{ConfigMeta: model.ConfigMeta{Name: "ext", Annotations: map[string]string{ExtAuthzProviderAnnotation: "foo"}}},
+		{ConfigMeta: model.ConfigMeta{Name: "empty", Annotations: map[string]string{ExtAuthzProviderAnnotation: ""}}},
+		{ConfigMeta: model.ConfigMeta{Name: "ext-dry-run", Annotations: map[string]string{
+			ExtAuthzProviderAnnotation: "foo", DryRunAnnotation: "true"}}},
+	}
+
+	rbacPolicies, extAuthzPolicies := SplitExtAuthzPolicies(policies)
+	if len(rbacPolicies) != 2 || rbacPolicies[0].Name != "rbac" || rbacPolicies[1].Name != "empty" {
+		t.Errorf("got rbac policies %v", rbacPolicies)
+	}
+	if len(extAuthzPolicies) != 1 || extAuthzPolicies[0].Name != "ext" {
+		t.Errorf("got ext authz policies %v", extAuthzPolicies)
+	}
+}
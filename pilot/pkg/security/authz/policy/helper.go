@@ -125,6 +125,14 @@ func SimpleRole(name string, namespace string, service string) *model.Config {
 	}
 }
 
+func SimpleDryRunRole(name string, namespace string, service string) *model.Config {
+	cfg := SimpleRole(name, namespace, service)
+	cfg.Annotations = map[string]string{
+		authz_model.DryRunAnnotation: "true",
+	}
+	return cfg
+}
+
 func CustomPrincipal(trustDomain, namespace, saName string) string {
 	return fmt.Sprintf("%s/ns/%s/sa/%s", trustDomain, namespace, saName)
 }
@@ -216,24 +224,32 @@ func SimpleAuthzPolicy(name string, namespace string) *model.Config {
 	}
 }
 
+func SimpleDryRunAuthzPolicy(name string, namespace string) *model.Config {
+	cfg := SimpleAuthzPolicy(name, namespace)
+	cfg.Annotations = map[string]string{
+		authz_model.DryRunAnnotation: "true",
+	}
+	return cfg
+}
+
 func Verify(got *envoy_rbac.RBAC, want map[string][]string, needToCheckPrincipals bool) error {
 	var err error
 	if len(want) == 0 {
 		if len(got.GetPolicies()) != 0 {
 			err = multierror.Append(err,
-				fmt.Errorf("got %d rules but want 0", len(got.Policies)))
+				fmt.Errorf("got %d rules but want 0", len(got.GetPolicies())))
 		}
 	} else {
-		if got.Action != envoy_rbac.RBAC_ALLOW {
+		if got.GetAction() != envoy_rbac.RBAC_ALLOW {
 			err = multierror.Append(err,
 				fmt.Errorf("got action %s but want %s", got.GetAction(), envoy_rbac.RBAC_ALLOW))
 		}
-		if len(want) != len(got.Policies) {
+		if len(want) != len(got.GetPolicies()) {
 			err = multierror.Append(err, fmt.Errorf("got %d rules but want %d",
-				len(got.Policies), len(want)))
+				len(got.GetPolicies()), len(want)))
 		}
 		for key, values := range want {
-			actual, ok := got.Policies[key]
+			actual, ok := got.GetPolicies()[key]
 			actualStr := spew.Sdump(actual)
 			if !ok {
 				err = multierror.Append(err, fmt.Errorf("not found rule %q", key))
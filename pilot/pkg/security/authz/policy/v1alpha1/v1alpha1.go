@@ -70,11 +70,14 @@ func (g *v1alpha1Generator) Generate(forTCPFilter bool) *http_config.RBAC {
 	for _, roleConfig := range authzPolicies.ListServiceRoles(namespace) {
 		roleName := roleConfig.Name
 		rbacLog.Debugf("checking role %v", roleName)
+		// All the bindings of a ServiceRole in dry-run mode are generated as shadow rules, the same as
+		// the bindings in permissive mode.
+		dryRun := authz_model.IsDryRun(roleConfig.Annotations)
 
 		var enforcedBindings []*istio_rbac.ServiceRoleBinding
 		var permissiveBindings []*istio_rbac.ServiceRoleBinding
 		for _, binding := range bindings[roleName] {
-			if binding.Mode == istio_rbac.EnforcementMode_PERMISSIVE || g.isGlobalPermissiveEnabled {
+			if binding.Mode == istio_rbac.EnforcementMode_PERMISSIVE || g.isGlobalPermissiveEnabled || dryRun {
 				// If RBAC Config is set to permissive mode globally, all policies will be in
 				// permissive mode regardless its own mode.
 				permissiveBindings = append(permissiveBindings, binding)
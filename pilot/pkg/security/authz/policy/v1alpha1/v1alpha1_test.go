@@ -114,6 +114,27 @@ func TestV1alpha1Generator_Generate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "one role and one binding: dry-run",
+			policies: []*model.Config{
+				policy.SimpleRole("role-1", namespaceA, serviceFoo),
+				policy.SimpleBinding("binding-1", namespaceA, "role-1"),
+				policy.SimpleDryRunRole("role-2", namespaceA, serviceFoo),
+				policy.SimpleBinding("binding-2", namespaceA, "role-2"),
+			},
+			wantRules: map[string][]string{
+				"role-1": {
+					policy.RoleTag("role-1"),
+					policy.BindingTag("binding-1"),
+				},
+			},
+			wantShadowRules: map[string][]string{
+				"role-2": {
+					policy.RoleTag("role-2"),
+					policy.BindingTag("binding-2"),
+				},
+			},
+		},
 		{
 			name: "one role and two bindings",
 			policies: []*model.Config{
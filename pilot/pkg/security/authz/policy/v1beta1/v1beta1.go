@@ -48,22 +48,47 @@ func NewGenerator(trustDomainBundle trustdomain.Bundle, policies []model.Config)
 func (g *v1beta1Generator) Generate(forTCPFilter bool) *http_config.RBAC {
 	rbacLog.Debugf("building v1beta1 policy")
 
-	rbac := &envoy_rbac.RBAC{
+	enforcedConfig := &envoy_rbac.RBAC{
+		Action:   envoy_rbac.RBAC_ALLOW,
+		Policies: map[string]*envoy_rbac.Policy{},
+	}
+	dryRunConfig := &envoy_rbac.RBAC{
 		Action:   envoy_rbac.RBAC_ALLOW,
 		Policies: map[string]*envoy_rbac.Policy{},
 	}
 
+	hasEnforcedPolicy := len(g.policies) == 0
 	for _, config := range g.policies {
+		dryRun := authz_model.IsDryRun(config.Annotations)
+		rbac := enforcedConfig
+		if dryRun {
+			rbac = dryRunConfig
+		} else {
+			hasEnforcedPolicy = true
+		}
+
 		spec := config.Spec.(*istio_rbac.AuthorizationPolicy)
 		for i, rule := range spec.Rules {
 			if p := g.generatePolicy(g.trustDomainBundle, rule, forTCPFilter); p != nil {
 				name := fmt.Sprintf("ns[%s]-policy[%s]-rule[%d]", config.Namespace, config.Name, i)
 				rbac.Policies[name] = p
-				rbacLog.Debugf("generated policy %s: %+v", name, p)
+				rbacLog.Debugf("generated policy %s (dry-run: %t): %+v", name, dryRun, p)
 			}
 		}
 	}
-	return &http_config.RBAC{Rules: rbac}
+
+	ret := &http_config.RBAC{}
+	// If all policies are in dry-run mode, there is nothing to enforce and the rules must be left
+	// empty. Otherwise an empty ALLOW config would deny all requests.
+	if hasEnforcedPolicy {
+		ret.Rules = enforcedConfig
+	}
+	// Set ShadowRules only when there is policy in dry-run mode, Envoy then reports the shadow
+	// result in its stats and dynamic metadata without affecting the request.
+	if len(dryRunConfig.Policies) > 0 {
+		ret.ShadowRules = dryRunConfig
+	}
+	return ret
 }
 
 func (g *v1beta1Generator) generatePolicy(trustDomainBundle trustdomain.Bundle, rule *istio_rbac.Rule, forTCPFilter bool) *envoy_rbac.Policy {
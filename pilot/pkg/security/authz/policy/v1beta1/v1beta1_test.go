@@ -27,10 +27,12 @@ import (
 // TODO(pitlv2109): Add unit tests with trust domain aliases.
 func TestV1beta1Generator_Generate(t *testing.T) {
 	testCases := []struct {
-		name         string
-		policies     []model.Config
-		wantRules    map[string][]string
-		forTCPFilter bool
+		name            string
+		policies        []model.Config
+		wantRules       map[string][]string
+		wantShadowRules map[string][]string
+		wantNoRules     bool
+		forTCPFilter    bool
 	}{
 		{
 			name: "no policy",
@@ -61,6 +63,43 @@ func TestV1beta1Generator_Generate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "one dry-run policy",
+			policies: []model.Config{
+				*policy.SimpleDryRunAuthzPolicy("dry-run", "foo"),
+			},
+			wantShadowRules: map[string][]string{
+				"ns[foo]-policy[dry-run]-rule[0]": {
+					policy.AuthzPolicyTag("dry-run"),
+				},
+			},
+			wantNoRules: true,
+		},
+		{
+			name: "one policy and one dry-run policy",
+			policies: []model.Config{
+				*policy.SimpleAuthzPolicy("default", "foo"),
+				*policy.SimpleDryRunAuthzPolicy("dry-run", "foo"),
+			},
+			wantRules: map[string][]string{
+				"ns[foo]-policy[default]-rule[0]": {
+					policy.AuthzPolicyTag("default"),
+				},
+			},
+			wantShadowRules: map[string][]string{
+				"ns[foo]-policy[dry-run]-rule[0]": {
+					policy.AuthzPolicyTag("dry-run"),
+				},
+			},
+		},
+		{
+			name: "one dry-run HTTP policy for TCP filter",
+			policies: []model.Config{
+				*policy.SimpleDryRunAuthzPolicy("dry-run", "foo"),
+			},
+			wantNoRules:  true,
+			forTCPFilter: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -71,12 +110,22 @@ func TestV1beta1Generator_Generate(t *testing.T) {
 			}
 
 			got := g.Generate(tc.forTCPFilter)
-			if got.GetRules() == nil {
+			if tc.wantNoRules {
+				if got.GetRules() != nil {
+					t.Fatalf("rule must be nil but got %s", spew.Sdump(got.GetRules()))
+				}
+			} else if got.GetRules() == nil {
 				t.Fatal("rule must not be nil")
 			}
 			if err := policy.Verify(got.GetRules(), tc.wantRules, false); err != nil {
 				t.Fatalf("%s\n%s", err, spew.Sdump(got))
 			}
+			if len(tc.wantShadowRules) == 0 && got.GetShadowRules() != nil {
+				t.Fatalf("shadow rule must be nil but got %s", spew.Sdump(got.GetShadowRules()))
+			}
+			if err := policy.Verify(got.GetShadowRules(), tc.wantShadowRules, false); err != nil {
+				t.Fatalf("%s\n%s", err, spew.Sdump(got))
+			}
 		})
 	}
 }
@@ -0,0 +1,128 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package revocation builds the Envoy RBAC filter that denies the peers in the certificate revocation deny list.
+//
+// The filter rejects the connections and requests from the revoked identities, matched against the principal of
+// the peer certificate, before their certificates expire. The revoked serial numbers are checked by the TLS
+// validation of both the inbound and outbound connections, against the revocation list published by Citadel. The
+// revoked nodes are only enforced by Citadel, which refuses to issue certificates to the workloads running on them:
+// a connection doesn't carry the name of the node it comes from.
+package revocation
+
+import (
+	tcp_filter "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
+	http_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
+	envoy_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
+
+	"istio.io/istio/pilot/pkg/networking/util"
+	authz_model "istio.io/istio/pilot/pkg/security/authz/model"
+)
+
+const (
+	// PolicyName is the name of the RBAC policy denying the revoked peers.
+	PolicyName = "istio-revocation"
+
+	// StatPrefix is the stat prefix of the revocation RBAC network filter.
+	StatPrefix = "revocation."
+)
+
+// Builder wraps all needed information for building the revocation RBAC filter for a workload.
+type Builder struct {
+	isXDSMarshalingToAnyEnabled bool
+	principals                  []*envoy_rbac.Principal
+}
+
+// NewBuilder creates a builder instance that can be used to build the revocation RBAC filter config.
+// It returns nil if there is no revoked identity.
+func NewBuilder(revokedIdentities []string, isXDSMarshalingToAnyEnabled bool) *Builder {
+	b := &Builder{
+		isXDSMarshalingToAnyEnabled: isXDSMarshalingToAnyEnabled,
+	}
+	for _, id := range revokedIdentities {
+		b.principals = append(b.principals, &envoy_rbac.Principal{
+			Identifier: &envoy_rbac.Principal_Authenticated_{
+				Authenticated: &envoy_rbac.Principal_Authenticated{
+					PrincipalName: &envoy_matcher.StringMatcher{
+						MatchPattern: &envoy_matcher.StringMatcher_Exact{Exact: id},
+					},
+				},
+			},
+		})
+	}
+	if len(b.principals) == 0 {
+		return nil
+	}
+	return b
+}
+
+func (b *Builder) rules() *envoy_rbac.RBAC {
+	return &envoy_rbac.RBAC{
+		Action: envoy_rbac.RBAC_DENY,
+		Policies: map[string]*envoy_rbac.Policy{
+			PolicyName: {
+				Permissions: []*envoy_rbac.Permission{{
+					Rule: &envoy_rbac.Permission_Any{Any: true},
+				}},
+				Principals: b.principals,
+			},
+		},
+	}
+}
+
+// BuildHTTPFilter builds the revocation RBAC HTTP filter.
+func (b *Builder) BuildHTTPFilter() *http_filter.HttpFilter {
+	if b == nil {
+		return nil
+	}
+
+	rbacConfig := &http_config.RBAC{Rules: b.rules()}
+	httpConfig := &http_filter.HttpFilter{
+		Name: authz_model.RBACHTTPFilterName,
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		httpConfig.ConfigType = &http_filter.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(rbacConfig)}
+	} else {
+		httpConfig.ConfigType = &http_filter.HttpFilter_Config{Config: util.MessageToStruct(rbacConfig)}
+	}
+
+	authz_model.RBACLog.Debugf("built revocation http filter config: %v", httpConfig)
+	return httpConfig
+}
+
+// BuildTCPFilter builds the revocation RBAC TCP filter.
+func (b *Builder) BuildTCPFilter() *tcp_filter.Filter {
+	if b == nil {
+		return nil
+	}
+
+	rbacConfig := &tcp_config.RBAC{
+		Rules:      b.rules(),
+		StatPrefix: StatPrefix,
+	}
+	tcpConfig := &tcp_filter.Filter{
+		Name: authz_model.RBACTCPFilterName,
+	}
+	if b.isXDSMarshalingToAnyEnabled {
+		tcpConfig.ConfigType = &tcp_filter.Filter_TypedConfig{TypedConfig: util.MessageToAny(rbacConfig)}
+	} else {
+		tcpConfig.ConfigType = &tcp_filter.Filter_Config{Config: util.MessageToStruct(rbacConfig)}
+	}
+
+	authz_model.RBACLog.Debugf("built revocation tcp filter config: %v", tcpConfig)
+	return tcpConfig
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"testing"
+
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
+	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/conversion"
+	"github.com/golang/protobuf/ptypes"
+
+	authz_model "istio.io/istio/pilot/pkg/security/authz/model"
+)
+
+var revokedIdentities = []string{"spiffe://cluster.local/ns/foo/sa/compromised"}
+
+func verifyRules(t *testing.T, rules *envoy_rbac.RBAC) {
+	t.Helper()
+	if rules.GetAction() != envoy_rbac.RBAC_DENY {
+		t.Errorf("got action %v but want DENY", rules.GetAction())
+	}
+	policy := rules.GetPolicies()[PolicyName]
+	if policy == nil {
+		t.Fatalf("policy %s not found in %v", PolicyName, rules)
+	}
+	if len(policy.Permissions) != 1 || !policy.Permissions[0].GetAny() {
+		t.Errorf("got permissions %v but want any", policy.Permissions)
+	}
+
+	principals := policy.GetPrincipals()
+	if len(principals) != 1 {
+		t.Fatalf("got %d principals but want 1: %v", len(principals), principals)
+	}
+	if got := principals[0].GetAuthenticated().GetPrincipalName().GetExact(); got != "spiffe://cluster.local/ns/foo/sa/compromised" {
+		t.Errorf("got principal name %q", got)
+	}
+}
+
+func TestNewBuilder(t *testing.T) {
+	testCases := []struct {
+		name              string
+		revokedIdentities []string
+		wantNil           bool
+	}{
+		{
+			name:    "nil identities",
+			wantNil: true,
+		},
+		{
+			name:              "empty identities",
+			revokedIdentities: []string{},
+			wantNil:           true,
+		},
+		{
+			name:              "identities",
+			revokedIdentities: revokedIdentities,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBuilder(tc.revokedIdentities, false)
+			if tc.wantNil && b != nil {
+				t.Errorf("want nil builder but got %v", b)
+			} else if !tc.wantNil && b == nil {
+				t.Errorf("want builder but got nil")
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildHTTPFilter(t *testing.T) {
+	testCases := []struct {
+		name                        string
+		isXDSMarshalingToAnyEnabled bool
+	}{
+		{
+			name: "struct",
+		},
+		{
+			name:                        "XDSMarshalingToAnyEnabled",
+			isXDSMarshalingToAnyEnabled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := NewBuilder(revokedIdentities, tc.isXDSMarshalingToAnyEnabled).BuildHTTPFilter()
+			if filter == nil {
+				t.Fatalf("want filter but got nil")
+			}
+			if filter.Name != authz_model.RBACHTTPFilterName {
+				t.Errorf("got filter name %q but want %q", filter.Name, authz_model.RBACHTTPFilterName)
+			}
+
+			config := &http_config.RBAC{}
+			if tc.isXDSMarshalingToAnyEnabled {
+				if err := ptypes.UnmarshalAny(filter.GetTypedConfig(), config); err != nil {
+					t.Fatalf("failed to unmarshal typed config: %v", err)
+				}
+			} else {
+				if err := conversion.StructToMessage(filter.GetConfig(), config); err != nil {
+					t.Fatalf("failed to convert struct to message: %v", err)
+				}
+			}
+			verifyRules(t, config.GetRules())
+		})
+	}
+}
+
+func TestBuilder_BuildTCPFilter(t *testing.T) {
+	var b *Builder
+	if filter := b.BuildTCPFilter(); filter != nil {
+		t.Errorf("want nil filter but got %v", filter)
+	}
+
+	filter := NewBuilder(revokedIdentities, false).BuildTCPFilter()
+	if filter == nil {
+		t.Fatalf("want filter but got nil")
+	}
+	if filter.Name != authz_model.RBACTCPFilterName {
+		t.Errorf("got filter name %q but want %q", filter.Name, authz_model.RBACTCPFilterName)
+	}
+	config := &tcp_config.RBAC{}
+	if err := conversion.StructToMessage(filter.GetConfig(), config); err != nil {
+		t.Fatalf("failed to convert struct to message: %v", err)
+	}
+	if config.StatPrefix != StatPrefix {
+		t.Errorf("got stat prefix %q but want %q", config.StatPrefix, StatPrefix)
+	}
+	verifyRules(t, config.GetRules())
+}
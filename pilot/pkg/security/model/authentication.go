@@ -15,6 +15,7 @@
 package model
 
 import (
+	"strings"
 	"sync"
 
 	auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
@@ -25,6 +26,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/spiffe"
 )
 
 const (
@@ -37,6 +39,10 @@ const (
 	// SDSRootResourceName is the sdsconfig name for root CA, used for fetching root cert.
 	SDSRootResourceName = "ROOTCA"
 
+	// SDSFederatedRootResourceName is the sdsconfig name for root CA along with the trust anchors of the federated
+	// trust domains, each only trusted for the identities of its own trust domain.
+	SDSFederatedRootResourceName = "ROOTCA_FEDERATED"
+
 	// K8sSATrustworthyJwtFileName is the token volume mount file name for k8s trustworthy jwt token.
 	K8sSATrustworthyJwtFileName = "/var/run/secrets/tokens/istio-token"
 
@@ -196,6 +202,111 @@ func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string)
 	return ret
 }
 
+// ApplyTrustBundle makes the TLS context of an upstream trust the root certificates of a federated trust domain
+// instead of the root certificate of the proxy, if all the subject alt names it verifies are SPIFFE identities of
+// that trust domain. Envoy validates the peer certificates against a single set of trusted CAs, and can't bind a
+// root to the identities it may issue: the roots of a federated trust domain are therefore only trusted alone,
+// for the identities of their own trust domain, so they can never vouch for a local workload. The other TLS
+// contexts, including the inbound ones, keep the root of the proxy. The roots are the PEM root certificates of the
+// federated trust domains, by trust domain. It returns whether the context was changed.
+func ApplyTrustBundle(tlsContext *auth.CommonTlsContext, roots map[string][]byte) bool {
+	if tlsContext == nil {
+		return false
+	}
+
+	var subjectAltNames []string
+	switch v := tlsContext.ValidationContextType.(type) {
+	case *auth.CommonTlsContext_ValidationContext:
+		subjectAltNames = v.ValidationContext.GetVerifySubjectAltName()
+	case *auth.CommonTlsContext_CombinedValidationContext:
+		subjectAltNames = v.CombinedValidationContext.GetDefaultValidationContext().GetVerifySubjectAltName()
+	}
+	rootCertsPEM := roots[federatedTrustDomain(subjectAltNames)]
+	if len(rootCertsPEM) == 0 {
+		return false
+	}
+	tlsContext.ValidationContextType = &auth.CommonTlsContext_ValidationContext{
+		ValidationContext: &auth.CertificateValidationContext{
+			TrustedCa: &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{
+					InlineBytes: rootCertsPEM,
+				},
+			},
+			VerifySubjectAltName: subjectAltNames,
+		},
+	}
+	return true
+}
+
+// ApplyFederatedRoots makes the TLS context of a downstream fetch the root certificate of the proxy along with
+// the trust anchors of the federated trust domains from SDS, so that it accepts the peers of both. The trust anchors
+// are name constrained to the identities of their own trust domain by the node agent, so they can never vouch for a
+// local workload. The TLS contexts validating the peers against a root certificate file can't combine them, and
+// keep the root of the proxy only. It returns whether the context was changed.
+func ApplyFederatedRoots(tlsContext *auth.CommonTlsContext) bool {
+	v, ok := tlsContext.GetValidationContextType().(*auth.CommonTlsContext_CombinedValidationContext)
+	if !ok {
+		return false
+	}
+	sdsConfig := v.CombinedValidationContext.GetValidationContextSdsSecretConfig()
+	if sdsConfig.GetName() != SDSRootResourceName {
+		return false
+	}
+	sdsConfig.Name = SDSFederatedRootResourceName
+	return true
+}
+
+// federatedTrustDomain returns the trust domain of the given SPIFFE identities if they all belong to the same
+// trust domain other than the local one, or an empty string otherwise.
+func federatedTrustDomain(subjectAltNames []string) string {
+	trustDomain := ""
+	for _, san := range subjectAltNames {
+		if !strings.HasPrefix(san, spiffe.URIPrefix) {
+			return ""
+		}
+		td := strings.SplitN(strings.TrimPrefix(san, spiffe.URIPrefix), "/", 2)[0]
+		if td == "" || (trustDomain != "" && td != trustDomain) {
+			return ""
+		}
+		trustDomain = td
+	}
+	if trustDomain == spiffe.GetTrustDomain() {
+		return ""
+	}
+	return trustDomain
+}
+
+// ApplyCRL makes the TLS context reject the peer certificates revoked by the given PEM certificate revocation
+// lists, keeping the rest of the validation context. It does nothing if the lists are empty. Envoy checks the
+// revocation of every certificate of the peer chain, so there must be a list issued by every trusted root: it
+// can't be applied to the contexts trusting the roots of the federated trust domains.
+func ApplyCRL(tlsContext *auth.CommonTlsContext, crl []byte) {
+	if tlsContext == nil || len(crl) == 0 {
+		return
+	}
+
+	dataSource := &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{
+			InlineBytes: crl,
+		},
+	}
+	switch v := tlsContext.ValidationContextType.(type) {
+	case *auth.CommonTlsContext_ValidationContext:
+		if v.ValidationContext == nil {
+			v.ValidationContext = &auth.CertificateValidationContext{}
+		}
+		v.ValidationContext.Crl = dataSource
+	case *auth.CommonTlsContext_CombinedValidationContext:
+		if v.CombinedValidationContext == nil {
+			return
+		}
+		if v.CombinedValidationContext.DefaultValidationContext == nil {
+			v.CombinedValidationContext.DefaultValidationContext = &auth.CertificateValidationContext{}
+		}
+		v.CombinedValidationContext.DefaultValidationContext.Crl = dataSource
+	}
+}
+
 // ConstructgRPCCallCredentials is used to construct SDS config which is only available from 1.1
 func ConstructgRPCCallCredentials(tokenFileName, headerKey string) []*core.GrpcService_GoogleGrpc_CallCredentials {
 	// If k8s sa jwt token file exists, envoy only handles plugin credentials.
@@ -158,6 +158,148 @@ func TestConstructSdsSecretConfigForGatewayListener(t *testing.T) {
 	}
 }
 
+func TestApplyTrustBundle(t *testing.T) {
+	rootCert := []byte("partner.com root certificate")
+	roots := map[string][]byte{"partner.com": rootCert}
+	sdsContext := func(subjectAltNames ...string) *auth.CommonTlsContext {
+		return &auth.CommonTlsContext{
+			ValidationContextType: &auth.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext: &auth.CertificateValidationContext{
+						VerifySubjectAltName: subjectAltNames,
+					},
+					ValidationContextSdsSecretConfig: ConstructSdsSecretConfig(SDSRootResourceName, "/tmp/sdsuds.sock",
+						&model.NodeMetadata{}),
+				},
+			},
+		}
+	}
+	partnerContext := &auth.CommonTlsContext_ValidationContext{
+		ValidationContext: &auth.CertificateValidationContext{
+			TrustedCa: &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{InlineBytes: rootCert},
+			},
+			VerifySubjectAltName: []string{"spiffe://partner.com/ns/foo/sa/bar", "spiffe://partner.com/ns/foo/sa/baz"},
+		},
+	}
+	cases := []struct {
+		name       string
+		tlsContext *auth.CommonTlsContext
+		roots      map[string][]byte
+		expected   interface{}
+	}{
+		{
+			name: "file mounted root certificate of a federated upstream",
+			tlsContext: &auth.CommonTlsContext{
+				ValidationContextType: ConstructValidationContext("/etc/certs/root-cert.pem",
+					[]string{"spiffe://partner.com/ns/foo/sa/bar", "spiffe://partner.com/ns/foo/sa/baz"}),
+			},
+			roots:    roots,
+			expected: partnerContext,
+		},
+		{
+			name:       "SDS root certificate of a federated upstream",
+			tlsContext: sdsContext("spiffe://partner.com/ns/foo/sa/bar", "spiffe://partner.com/ns/foo/sa/baz"),
+			roots:      roots,
+			expected:   partnerContext,
+		},
+		{
+			name:       "local upstream",
+			tlsContext: sdsContext("spiffe://cluster.local/ns/foo/sa/bar"),
+			roots:      roots,
+			expected:   sdsContext("spiffe://cluster.local/ns/foo/sa/bar").ValidationContextType,
+		},
+		{
+			name:       "local and federated upstreams",
+			tlsContext: sdsContext("spiffe://cluster.local/ns/foo/sa/bar", "spiffe://partner.com/ns/foo/sa/bar"),
+			roots:      roots,
+			expected: sdsContext("spiffe://cluster.local/ns/foo/sa/bar",
+				"spiffe://partner.com/ns/foo/sa/bar").ValidationContextType,
+		},
+		{
+			name:       "unknown trust domain",
+			tlsContext: sdsContext("spiffe://other.com/ns/foo/sa/bar"),
+			roots:      roots,
+			expected:   sdsContext("spiffe://other.com/ns/foo/sa/bar").ValidationContextType,
+		},
+		{
+			name:       "no subject alt name",
+			tlsContext: sdsContext(),
+			roots:      roots,
+			expected:   sdsContext().ValidationContextType,
+		},
+		{
+			name:       "no federation",
+			tlsContext: sdsContext("spiffe://partner.com/ns/foo/sa/bar"),
+			expected:   sdsContext("spiffe://partner.com/ns/foo/sa/bar").ValidationContextType,
+		},
+	}
+
+	for _, c := range cases {
+		changed := ApplyTrustBundle(c.tlsContext, c.roots)
+		if !reflect.DeepEqual(c.tlsContext.ValidationContextType, c.expected) {
+			t.Errorf("%s: got(%#v) != want(%#v)\n", c.name, c.tlsContext.ValidationContextType, c.expected)
+		}
+		if want := c.expected == partnerContext; changed != want {
+			t.Errorf("%s: got changed %v, want %v", c.name, changed, want)
+		}
+	}
+}
+
+func TestApplyFederatedRoots(t *testing.T) {
+	sdsContext := func(resourceName string) *auth.CommonTlsContext {
+		return &auth.CommonTlsContext{
+			ValidationContextType: &auth.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext: &auth.CertificateValidationContext{},
+					ValidationContextSdsSecretConfig: ConstructSdsSecretConfig(resourceName, "/tmp/sdsuds.sock",
+						&model.NodeMetadata{}),
+				},
+			},
+		}
+	}
+	fileContext := &auth.CommonTlsContext{
+		ValidationContextType: ConstructValidationContext("/etc/certs/root-cert.pem", nil),
+	}
+	cases := []struct {
+		name            string
+		tlsContext      *auth.CommonTlsContext
+		expected        *auth.CommonTlsContext
+		expectedChanged bool
+	}{
+		{
+			name:            "SDS root certificate",
+			tlsContext:      sdsContext(SDSRootResourceName),
+			expected:        sdsContext(SDSFederatedRootResourceName),
+			expectedChanged: true,
+		},
+		{
+			name:       "SDS secret other than the root certificate",
+			tlsContext: sdsContext("ingress-cacert"),
+			expected:   sdsContext("ingress-cacert"),
+		},
+		{
+			name:       "file mounted root certificate",
+			tlsContext: fileContext,
+			expected: &auth.CommonTlsContext{
+				ValidationContextType: ConstructValidationContext("/etc/certs/root-cert.pem", nil),
+			},
+		},
+		{
+			name: "no TLS context",
+		},
+	}
+
+	for _, c := range cases {
+		if changed := ApplyFederatedRoots(c.tlsContext); changed != c.expectedChanged {
+			t.Errorf("%s: got changed %v, want %v", c.name, changed, c.expectedChanged)
+		}
+		if !reflect.DeepEqual(c.tlsContext, c.expected) {
+			t.Errorf("%s: got(%#v) != want(%#v)\n", c.name, c.tlsContext, c.expected)
+		}
+	}
+}
+
 func constructLocalChannelCredConfig() *core.GrpcService_GoogleGrpc_ChannelCredentials {
 	return &core.GrpcService_GoogleGrpc_ChannelCredentials{
 		CredentialSpecifier: &core.GrpcService_GoogleGrpc_ChannelCredentials_LocalCredentials{
@@ -201,3 +343,71 @@ func constructsdsconfighelper(tokenFileName, headerKey string, metaConfig *envoy
 		},
 	}
 }
+
+func TestApplyCRL(t *testing.T) {
+	crl := []byte("crl")
+	crlDataSource := &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{InlineBytes: crl},
+	}
+	sdsConfig := ConstructSdsSecretConfig(SDSRootResourceName, "/tmp/sdsuds.sock", &model.NodeMetadata{})
+	cases := []struct {
+		name       string
+		tlsContext *auth.CommonTlsContext
+		crl        []byte
+		expected   interface{}
+	}{
+		{
+			name: "file mounted root certificate",
+			tlsContext: &auth.CommonTlsContext{
+				ValidationContextType: ConstructValidationContext("/etc/certs/root-cert.pem", []string{"spiffe://cluster.local/ns/foo/sa/bar"}),
+			},
+			crl: crl,
+			expected: &auth.CommonTlsContext_ValidationContext{
+				ValidationContext: &auth.CertificateValidationContext{
+					TrustedCa: &core.DataSource{
+						Specifier: &core.DataSource_Filename{Filename: "/etc/certs/root-cert.pem"},
+					},
+					VerifySubjectAltName: []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+					Crl:                  crlDataSource,
+				},
+			},
+		},
+		{
+			name: "SDS root certificate",
+			tlsContext: &auth.CommonTlsContext{
+				ValidationContextType: &auth.CommonTlsContext_CombinedValidationContext{
+					CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
+						DefaultValidationContext: &auth.CertificateValidationContext{
+							VerifySubjectAltName: []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+						},
+						ValidationContextSdsSecretConfig: sdsConfig,
+					},
+				},
+			},
+			crl: crl,
+			expected: &auth.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext: &auth.CertificateValidationContext{
+						VerifySubjectAltName: []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+						Crl:                  crlDataSource,
+					},
+					ValidationContextSdsSecretConfig: sdsConfig,
+				},
+			},
+		},
+		{
+			name: "no revocation list",
+			tlsContext: &auth.CommonTlsContext{
+				ValidationContextType: ConstructValidationContext("/etc/certs/root-cert.pem", nil),
+			},
+			expected: ConstructValidationContext("/etc/certs/root-cert.pem", nil),
+		},
+	}
+
+	for _, c := range cases {
+		ApplyCRL(c.tlsContext, c.crl)
+		if !reflect.DeepEqual(c.tlsContext.ValidationContextType, c.expected) {
+			t.Errorf("%s: got(%#v) != want(%#v)\n", c.name, c.tlsContext.ValidationContextType, c.expected)
+		}
+	}
+}
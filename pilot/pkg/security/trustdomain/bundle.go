@@ -35,6 +35,10 @@ type Bundle struct {
 	// Any service with the identity `td1/ns/foo/sa/a-service-account`, `td2/ns/foo/sa/a-service-account`,
 	// or `td3/ns/foo/sa/a-service-account` will be treated the same in the Istio mesh.
 	TrustDomains []string
+
+	// FederatedTrustDomains are the foreign trust domains federated with the mesh. They have their own roots of
+	// trust, so their principals are kept as-is instead of being treated as the local trust domain.
+	FederatedTrustDomains []string
 }
 
 func NewTrustDomainBundle(trustDomain string, trustDomainAliases []string) Bundle {
@@ -70,6 +74,9 @@ func (t Bundle) ReplaceTrustDomainAliases(principals []string) []string {
 		if stringMatch(trustDomainFromPrincipal, t.TrustDomains) || trustDomainFromPrincipal == "cluster.local" {
 			// Generate configuration for trust domain and trust domain aliases.
 			principalsIncludingAliases = append(principalsIncludingAliases, t.replaceTrustDomains(principal, trustDomainFromPrincipal)...)
+		} else if isKeyInList(trustDomainFromPrincipal, t.FederatedTrustDomains) {
+			// The principals of the federated trust domains are accepted as they are.
+			principalsIncludingAliases = append(principalsIncludingAliases, principal)
 		} else {
 			rbacLog.Warnf("Trust domain %s from principal %s does not match the current trust "+
 				"domain or its aliases", trustDomainFromPrincipal, principal)
@@ -56,6 +56,15 @@ func TestReplaceTrustDomainAliases(t *testing.T) {
 			principals:        []string{"cluster.local/ns/foo/sa/bar", "cluster.local/ns/yyy/sa/zzz"},
 			expect:            []string{"td1/ns/foo/sa/bar", "cluster.local/ns/foo/sa/bar", "td1/ns/yyy/sa/zzz", "cluster.local/ns/yyy/sa/zzz"},
 		},
+		{
+			name: "Federated trust domain principal as-is",
+			trustDomainBundle: Bundle{
+				TrustDomains:          []string{"td1", "cluster.local"},
+				FederatedTrustDomains: []string{"partner.com"},
+			},
+			principals: []string{"partner.com/ns/foo/sa/bar", "cluster.local/ns/foo/sa/bar"},
+			expect:     []string{"partner.com/ns/foo/sa/bar", "td1/ns/foo/sa/bar", "cluster.local/ns/foo/sa/bar"},
+		},
 		{
 			name:              "One trust domain alias, principals with * as-is",
 			trustDomainBundle: NewTrustDomainBundle("td1", []string{"cluster.local"}),
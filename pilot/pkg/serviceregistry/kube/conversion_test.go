@@ -62,6 +62,7 @@ func TestConvertProtocol(t *testing.T) {
 		{8888, "https-test", coreV1.ProtocolTCP, protocol.HTTPS},
 		{8888, "http2", coreV1.ProtocolTCP, protocol.HTTP2},
 		{8888, "http2-test", coreV1.ProtocolTCP, protocol.HTTP2},
+		{8888, "h2c-test", coreV1.ProtocolTCP, protocol.HTTP2},
 		{8888, "grpc", coreV1.ProtocolTCP, protocol.GRPC},
 		{8888, "grpc-test", coreV1.ProtocolTCP, protocol.GRPC},
 		{8888, "grpc-web", coreV1.ProtocolTCP, protocol.GRPCWeb},
@@ -0,0 +1,71 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package accesslog provides the helpers of the AccessLogPolicy resource, configuring the Envoy access logs of the
+// workloads selected by the policy. The resource spec is defined in the v1alpha1 package.
+package accesslog
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"istio.io/istio/pkg/config/accesslog/v1alpha1"
+)
+
+//go:generate $REPO_ROOT/bin/mixer_codegen.sh -f pkg/config/accesslog/v1alpha1/accesslog.proto
+
+const (
+	// Stdout is the path of the standard output of the proxy.
+	Stdout = "/dev/stdout"
+
+	// Stderr is the path of the standard error of the proxy.
+	Stderr = "/dev/stderr"
+
+	// LogDirectory is the directory of the access log files of the proxy, other than the standard output and
+	// error. The directory must be mounted in the proxy container for the files to be written.
+	LogDirectory = "/var/log/istio"
+)
+
+// ResponseFlags are the response flags supported by the Envoy access log filter.
+var ResponseFlags = map[string]bool{
+	"LH": true, "UH": true, "UT": true, "LR": true, "UR": true, "UF": true, "UC": true,
+	"UO": true, "NR": true, "DI": true, "FI": true, "RL": true, "UAEX": true, "RLSE": true,
+	"DC": true, "URX": true, "SI": true, "IH": true, "DPE": true,
+}
+
+// AppliesToPort returns true if the policy applies to the listener of the given service port.
+func AppliesToPort(policy *v1alpha1.AccessLogPolicy, port int) bool {
+	if len(policy.Ports) == 0 {
+		return true
+	}
+	for _, ap := range policy.Ports {
+		if int(ap) == port {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFilePath returns an error unless the path of a file sink is the standard output or error, or a file in
+// the LogDirectory, so that a policy can't make the proxy write to an arbitrary file.
+func ValidateFilePath(file string) error {
+	if file == Stdout || file == Stderr {
+		return nil
+	}
+	if !path.IsAbs(file) || path.Clean(file) != file || !strings.HasPrefix(file, LogDirectory+"/") {
+		return fmt.Errorf("file must be %s, %s or a file in %s", Stdout, Stderr, LogDirectory)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/config/accesslog/v1alpha1"
+)
+
+func TestAppliesToPort(t *testing.T) {
+	p := &v1alpha1.AccessLogPolicy{}
+	if !AppliesToPort(p, 80) || !AppliesToPort(p, 0) {
+		t.Errorf("policy without ports should apply to all ports")
+	}
+	p.Ports = []uint32{80}
+	if !AppliesToPort(p, 80) || AppliesToPort(p, 81) || AppliesToPort(p, 0) {
+		t.Errorf("policy with ports should only apply to the ports")
+	}
+}
+
+func TestValidateFilePath(t *testing.T) {
+	cases := []struct {
+		file  string
+		valid bool
+	}{
+		{file: "/dev/stdout", valid: true},
+		{file: "/dev/stderr", valid: true},
+		{file: "/var/log/istio/access.log", valid: true},
+		{file: "/var/log/istio/app/access.log", valid: true},
+		{file: "/var/log/istio"},
+		{file: "/var/log/istio/"},
+		{file: "/var/log/istio/../envoy.log"},
+		{file: "/var/log/istio-access.log"},
+		{file: "var/log/istio/access.log"},
+		{file: "/etc/istio/proxy/envoy-rev0.json"},
+		{file: "/dev/null"},
+	}
+	for _, c := range cases {
+		if err := ValidateFilePath(c.file); (err == nil) != c.valid {
+			t.Errorf("%s: got %v but want valid %v", c.file, err, c.valid)
+		}
+	}
+}
@@ -27,7 +27,8 @@ const (
 	// HTTP declares that the port carries HTTP/1.1 traffic.
 	// Note that HTTP/1.0 or earlier may not be supported by the proxy.
 	HTTP Instance = "HTTP"
-	// HTTP2 declares that the port carries HTTP/2 traffic.
+	// HTTP2 declares that the port carries HTTP/2 traffic, the ports named "h2c" are
+	// parsed as HTTP2 as they carry HTTP/2 over plain text.
 	HTTP2 Instance = "HTTP2"
 	// HTTPS declares that the port carries HTTPS traffic.
 	HTTPS Instance = "HTTPS"
@@ -63,7 +64,7 @@ func Parse(s string) Instance {
 		return GRPCWeb
 	case "http":
 		return HTTP
-	case "http2":
+	case "http2", "h2c":
 		return HTTP2
 	case "https":
 		return HTTPS
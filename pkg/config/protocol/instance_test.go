@@ -40,6 +40,8 @@ func TestParse(t *testing.T) {
 		{"Http", protocol.HTTP},
 		{"https", protocol.HTTPS},
 		{"http2", protocol.HTTP2},
+		{"h2c", protocol.HTTP2},
+		{"H2C", protocol.HTTP2},
 		{"grpc", protocol.GRPC},
 		{"grpc-web", protocol.GRPCWeb},
 		{"gRPC-Web", protocol.GRPCWeb},
@@ -246,6 +246,19 @@ var (
 		VariableName:  "AuthorizationPolicy",
 	}
 
+	// AccessLogPolicy describes the access logs of the selected workloads.
+	AccessLogPolicy = schema.Instance{
+		Type:          "access-log-policy",
+		Plural:        "accesslogpolicies",
+		Group:         "telemetry",
+		Version:       "v1alpha1",
+		MessageName:   "istio.telemetry.v1alpha1.AccessLogPolicy",
+		Validate:      validation.ValidateAccessLogPolicy,
+		Collection:    "istio/telemetry/v1alpha1/accesslogpolicies",
+		ClusterScoped: false,
+		VariableName:  "AccessLogPolicy",
+	}
+
 	// Istio lists all Istio schemas.
 	Istio = schema.Set{
 		VirtualService,
@@ -266,5 +279,6 @@ var (
 		RbacConfig,
 		ClusterRbacConfig,
 		AuthorizationPolicy,
+		AccessLogPolicy,
 	}
 )
@@ -15,6 +15,7 @@
 package validation
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -39,6 +40,8 @@ import (
 	authz "istio.io/api/security/v1beta1"
 	"istio.io/pkg/log"
 
+	"istio.io/istio/pkg/config/accesslog"
+	accesslogv1alpha1 "istio.io/istio/pkg/config/accesslog/v1alpha1"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/gateway"
 	"istio.io/istio/pkg/config/host"
@@ -1510,6 +1513,96 @@ func ValidateAuthorizationPolicy(_, _ string, msg proto.Message) error {
 	return nil
 }
 
+// ValidateAccessLogPolicy checks that AccessLogPolicy is well-formed.
+func ValidateAccessLogPolicy(_, _ string, msg proto.Message) (errs error) {
+	policy, ok := msg.(*accesslogv1alpha1.AccessLogPolicy)
+	if !ok {
+		return fmt.Errorf("cannot cast to AccessLogPolicy")
+	}
+
+	errs = appendErrors(errs, labels.Instance(policy.Selector).Validate())
+	for _, port := range policy.Ports {
+		errs = appendErrors(errs, ValidatePort(int(port)))
+	}
+	for _, r := range policy.Routes {
+		if r == nil || (r.Host == "" && r.Path == "") {
+			errs = appendErrors(errs, fmt.Errorf("route must set host or path"))
+		}
+	}
+
+	switch policy.Encoding {
+	case accesslogv1alpha1.TEXT:
+	case accesslogv1alpha1.JSON:
+		if policy.Format != "" {
+			if err := json.Unmarshal([]byte(policy.Format), &map[string]string{}); err != nil {
+				errs = appendErrors(errs, fmt.Errorf("invalid JSON format: %v", err))
+			}
+		}
+	default:
+		errs = appendErrors(errs, fmt.Errorf("unsupported encoding %v", policy.Encoding))
+	}
+
+	errs = appendErrors(errs, validateAccessLogFilter(policy.Filter))
+
+	if len(policy.Sinks) == 0 {
+		errs = appendErrors(errs, fmt.Errorf("at least one sink must be set"))
+	}
+	for _, sink := range policy.Sinks {
+		errs = appendErrors(errs, validateAccessLogSink(sink))
+	}
+	return
+}
+
+func validateAccessLogFilter(f *accesslogv1alpha1.Filter) (errs error) {
+	if f == nil {
+		return
+	}
+	for _, code := range []uint32{f.MinStatusCode, f.MaxStatusCode} {
+		if code != 0 && (code < 100 || code > 599) {
+			errs = appendErrors(errs, fmt.Errorf("invalid status code %d", code))
+		}
+	}
+	if f.MinStatusCode != 0 && f.MaxStatusCode != 0 && f.MinStatusCode > f.MaxStatusCode {
+		errs = appendErrors(errs, fmt.Errorf("minStatusCode must not be greater than maxStatusCode"))
+	}
+	if f.MinDuration != nil {
+		if d, err := types.DurationFromProto(f.MinDuration); err != nil || d <= 0 {
+			errs = appendErrors(errs, fmt.Errorf("invalid minDuration %v", f.MinDuration))
+		}
+	}
+	for _, flag := range f.ResponseFlags {
+		if !accesslog.ResponseFlags[flag] {
+			errs = appendErrors(errs, fmt.Errorf("unsupported response flag %q", flag))
+		}
+	}
+	if f.SamplingPercent < 0 || f.SamplingPercent > 100 {
+		errs = appendErrors(errs, fmt.Errorf("samplingPercent must be in the (0, 100] range"))
+	}
+	return
+}
+
+func validateAccessLogSink(sink *accesslogv1alpha1.Sink) error {
+	switch s := sink.GetSink().(type) {
+	case *accesslogv1alpha1.Sink_File:
+		if err := accesslog.ValidateFilePath(s.File); err != nil {
+			return fmt.Errorf("invalid file %q: %v", s.File, err)
+		}
+	case *accesslogv1alpha1.Sink_Grpc:
+		if s.Grpc == nil || s.Grpc.Service == "" {
+			return nil
+		}
+		if err := ValidateFQDN(s.Grpc.Service); err != nil {
+			return fmt.Errorf("invalid grpc service: %v", err)
+		}
+		if err := ValidatePort(int(s.Grpc.Port)); err != nil {
+			return fmt.Errorf("invalid grpc port: %v", err)
+		}
+	default:
+		return fmt.Errorf("one of file and grpc must be set in a sink")
+	}
+	return nil
+}
+
 // ValidateServiceRole checks that ServiceRole is well-formed.
 func ValidateServiceRole(_, _ string, msg proto.Message) error {
 	in, ok := msg.(*rbac.ServiceRole)
@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/hashicorp/go-multierror"
@@ -33,6 +34,7 @@ import (
 	authz "istio.io/api/security/v1beta1"
 	api "istio.io/api/type/v1beta1"
 
+	accesslogv1alpha1 "istio.io/istio/pkg/config/accesslog/v1alpha1"
 	"istio.io/istio/pkg/config/constants"
 )
 
@@ -3983,6 +3985,108 @@ func TestValidateAuthorizationPolicy(t *testing.T) {
 	}
 }
 
+func TestValidateAccessLogPolicy(t *testing.T) {
+	cases := []struct {
+		name         string
+		in           string
+		expectErrMsg string
+	}{
+		{
+			name: "valid",
+			in: `{
+				"selector": {"app": "productpage"},
+				"ports": [9080],
+				"filter": {
+					"minStatusCode": 500,
+					"maxStatusCode": 599,
+					"minDuration": "100ms",
+					"responseFlags": ["UH"],
+					"samplingPercent": 50
+				},
+				"sinks": [{"file": "/dev/stdout"}, {"grpc": {}}]
+			}`,
+		},
+		{
+			name:         "missing sink",
+			in:           `{}`,
+			expectErrMsg: "at least one sink must be set",
+		},
+		{
+			name:         "empty sink",
+			in:           `{"sinks": [{}]}`,
+			expectErrMsg: "one of file and grpc must be set in a sink",
+		},
+		{
+			name: "file in the log directory",
+			in:   `{"sinks": [{"file": "/var/log/istio/access.log"}, {"file": "/dev/stderr"}]}`,
+		},
+		{
+			name:         "file outside the log directory",
+			in:           `{"sinks": [{"file": "/etc/istio/proxy/envoy-rev0.json"}]}`,
+			expectErrMsg: "invalid file",
+		},
+		{
+			name:         "file escaping the log directory",
+			in:           `{"sinks": [{"file": "/var/log/istio/../../../etc/passwd"}]}`,
+			expectErrMsg: "invalid file",
+		},
+		{
+			name:         "grpc service without port",
+			in:           `{"sinks": [{"grpc": {"service": "als.istio-system.svc.cluster.local"}}]}`,
+			expectErrMsg: "invalid grpc port",
+		},
+		{
+			name:         "invalid JSON format",
+			in:           `{"encoding": "JSON", "format": "%RESPONSE_CODE%", "sinks": [{"file": "/dev/stdout"}]}`,
+			expectErrMsg: "invalid JSON format",
+		},
+		{
+			name:         "unsupported encoding",
+			in:           `{"encoding": 2, "sinks": [{"file": "/dev/stdout"}]}`,
+			expectErrMsg: "unsupported encoding",
+		},
+		{
+			name: "invalid filter",
+			in: `{
+				"filter": {
+					"minStatusCode": 500,
+					"maxStatusCode": 400,
+					"minDuration": "-1s",
+					"responseFlags": ["XX"],
+					"samplingPercent": 200
+				},
+				"sinks": [{"file": "/dev/stdout"}]
+			}`,
+			expectErrMsg: "minStatusCode must not be greater than maxStatusCode",
+		},
+		{
+			name:         "empty route",
+			in:           `{"routes": [{}], "sinks": [{"file": "/dev/stdout"}]}`,
+			expectErrMsg: "route must set host or path",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			in := &accesslogv1alpha1.AccessLogPolicy{}
+			if err := jsonpb.UnmarshalString(c.in, in); err != nil {
+				t.Fatalf("failed to parse policy: %v", err)
+			}
+			err := ValidateAccessLogPolicy(someName, someNamespace, in)
+			if c.expectErrMsg == "" {
+				if err != nil {
+					t.Errorf("got %v but want nil", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), c.expectErrMsg) {
+				t.Errorf("got %v but want %q", err, c.expectErrMsg)
+			}
+		})
+	}
+
+	if err := ValidateAccessLogPolicy(someName, someNamespace, &rbac.ServiceRole{}); err == nil {
+		t.Errorf("want error for invalid proto")
+	}
+}
+
 func TestValidateServiceRole(t *testing.T) {
 	cases := []struct {
 		name         string
@@ -16,7 +16,10 @@ package main
 
 import (
 	"context"
+	"crypto"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -32,12 +35,19 @@ import (
 	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/security/pkg/caclient"
 	"istio.io/istio/security/pkg/cmd"
+	"istio.io/istio/security/pkg/k8s/configmap"
 	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/security/pkg/pki/audit"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/federation"
+	"istio.io/istio/security/pkg/pki/revocation"
+	"istio.io/istio/security/pkg/pki/signer"
+	"istio.io/istio/security/pkg/pki/util"
 	probecontroller "istio.io/istio/security/pkg/probe"
 	"istio.io/istio/security/pkg/registry"
 	"istio.io/istio/security/pkg/registry/kube"
 	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/istio/security/pkg/server/ca/policy"
 	"istio.io/istio/security/pkg/server/monitoring"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/ctrlz"
@@ -68,11 +78,25 @@ type cliOptions struct { // nolint: maligned
 	signingKeyFile  string
 	rootCertFile    string
 
+	// The type of the external signer holding the CA signing key, "pkcs11" or "kms". The signing key is read from
+	// signingKeyFile if empty.
+	externalSigner   string
+	kmsPluginSocket  string
+	kmsKeyID         string
+	pkcs11Module     string
+	pkcs11TokenLabel string
+	pkcs11PINFile    string
+	pkcs11KeyLabel   string
+
 	selfSignedCA                            bool
 	selfSignedCACertTTL                     time.Duration
 	selfSignedRootCertCheckInterval         time.Duration
 	selfSignedRootCertGracePeriodPercentile int
 	enableJitterForRootCertRotator          bool
+	// The type of EC signature algorithm of the self-signed CA key, e.g. ECDSA. RSA if empty.
+	selfSignedCAKeyECSigAlg string
+	// The elliptic curve of the self-signed CA key, e.g. P256 or P384.
+	selfSignedCAKeyECCCurve string
 
 	workloadCertTTL    time.Duration
 	maxWorkloadCertTTL time.Duration
@@ -83,6 +107,11 @@ type cliOptions struct { // nolint: maligned
 	// The minimum grace period for workload cert rotation.
 	workloadCertMinGracePeriod time.Duration
 
+	// The interval Citadel checks the state of the root rollover at, disabling the rollovers if not positive.
+	rootRolloverCheckInterval time.Duration
+	// The minimum duration of a root rollover phase, for the workloads using SDS to rotate their certificates.
+	rootRolloverMinPhaseDuration time.Duration
+
 	// Comma separated string containing all possible host name that clients may use to connect to.
 	grpcHosts  string
 	grpcPort   int
@@ -100,6 +129,9 @@ type cliOptions struct { // nolint: maligned
 	// Enable profiling in monitoring
 	enableProfiling bool
 
+	// Port of the SPIFFE bundle endpoint serving the trust bundle of the trust domain
+	trustBundlePort int
+
 	// The path to the file which indicates the liveness of the server by its existence.
 	// This will be used for k8s liveness probe. If empty, it does nothing.
 	LivenessProbeOptions *probe.Options
@@ -123,6 +155,24 @@ type cliOptions struct { // nolint: maligned
 
 	// Whether SDS is enabled on.
 	sdsEnabled bool
+
+	// The file or the ConfigMap, in the Citadel storage namespace, holding the deny list of revoked identities,
+	// certificates and nodes.
+	revocationDenyListFile      string
+	revocationDenyListConfigMap string
+	// The validity and the refresh period of the published certificate revocation lists.
+	crlValidity      time.Duration
+	crlRefreshPeriod time.Duration
+
+	// The configuration file of the OIDC authenticator.
+	oidcAuthenticatorConfig string
+
+	// The configuration file of the CSR policy and the rate limits of the CA server.
+	csrPolicyConfig string
+
+	// The file of the audit log of the issued certificates, and the PEM private key signing its entries.
+	auditLogFile       string
+	auditLogSigningKey string
 }
 
 var (
@@ -172,6 +222,8 @@ var (
 	}
 
 	rootCertRotatorChan chan struct{}
+	// rootRollover applies the root rollovers to the CA, nil if they are disabled.
+	rootRollover *ca.RootRolloverController
 )
 
 func fatalf(template string, args ...interface{}) {
@@ -210,11 +262,32 @@ func initCLI() {
 		"multiple Citadels generating self-signed key and cert. Please make sure one and only one Citadel instance has this flag set "+
 		"to false.")
 
+	// Root rollover configuration.
+	flags.DurationVar(&opts.rootRolloverCheckInterval, "root-rollover-check-interval", time.Minute,
+		"The interval Citadel checks the state of the root rollover started by 'istioctl experimental ca rollover' at. "+
+			"Setting this interval to zero or a negative value disables the root rollovers.")
+	flags.DurationVar(&opts.rootRolloverMinPhaseDuration, "root-rollover-min-phase-duration", 24*time.Hour,
+		"The minimum duration of a root rollover phase, giving the workloads using SDS the time to rotate their "+
+			"certificates. It should be larger than the workload certificate rotation period.")
+
 	// Configuration if Citadel accepts key/cert configured through arguments.
 	flags.StringVar(&opts.certChainFile, "cert-chain", "", "Path to the certificate chain file.")
 	flags.StringVar(&opts.signingCertFile, "signing-cert", "", "Path to the CA signing certificate file.")
 	flags.StringVar(&opts.signingKeyFile, "signing-key", "", "Path to the CA signing key file.")
 
+	// Configuration if the CA signing key is held by an external key manager.
+	flags.StringVar(&opts.externalSigner, "external-signer", "",
+		"Type of the external signer holding the CA signing key, \""+signer.PKCS11+"\" or \""+signer.KMS+"\". "+
+			"When set, the '--signing-key' option is ignored and the signatures are delegated to the signer.")
+	flags.StringVar(&opts.kmsPluginSocket, "kms-plugin-socket", "",
+		"Path of the Unix domain socket of the KMS plugin holding the CA signing key.")
+	flags.StringVar(&opts.kmsKeyID, "kms-key-id", "", "ID of the CA signing key in the KMS plugin.")
+	flags.StringVar(&opts.pkcs11Module, "pkcs11-module", "",
+		"Path of the PKCS #11 module of the token holding the CA signing key, e.g. /usr/lib/softhsm/libsofthsm2.so.")
+	flags.StringVar(&opts.pkcs11TokenLabel, "pkcs11-token-label", "", "Label of the PKCS #11 token.")
+	flags.StringVar(&opts.pkcs11PINFile, "pkcs11-pin-file", "", "Path to the file holding the PIN of the PKCS #11 token.")
+	flags.StringVar(&opts.pkcs11KeyLabel, "pkcs11-key-label", "", "Label of the CA signing key in the PKCS #11 token.")
+
 	// Both self-signed or non-self-signed Citadel may take a root certificate file with a list of root certificates.
 	flags.StringVar(&opts.rootCertFile, "root-cert", "", "Path to the root certificate file.")
 
@@ -222,6 +295,11 @@ func initCLI() {
 	flags.BoolVar(&opts.selfSignedCA, "self-signed-ca", false,
 		"Indicates whether to use auto-generated self-signed CA certificate. "+
 			"When set to true, the '--signing-cert' and '--signing-key' options are ignored.")
+	flags.StringVar(&opts.selfSignedCAKeyECSigAlg, "self-signed-ca-ecc-sig-alg", "",
+		"The type of Elliptical Signature algorithm of the auto-generated self-signed CA key, e.g. ECDSA. "+
+			"An RSA key is generated if empty.")
+	flags.StringVar(&opts.selfSignedCAKeyECCCurve, "self-signed-ca-ecc-curve", "",
+		"The elliptic curve of the auto-generated self-signed CA key, P256 or P384. Defaults to P256 if empty.")
 	flags.StringVar(&opts.trustDomain, "trust-domain", "",
 		"The domain serves to identify the system with SPIFFE.")
 	// Upstream CA configuration if Citadel interacts with upstream CA.
@@ -257,6 +335,11 @@ func initCLI() {
 		"If unspecified, Citadel will disable monitoring.")
 	flags.BoolVar(&opts.enableProfiling, "enable-profiling", false, "Enabling profiling when monitoring Citadel.")
 
+	// SPIFFE federation
+	flags.IntVar(&opts.trustBundlePort, "trust-bundle-port", 0, "The port number of the SPIFFE bundle endpoint "+
+		"serving the root certificate of the trust domain to the federated trust domains. The endpoint is served "+
+		"over plain HTTP and is expected to be exposed through a TLS gateway. If unspecified, it is disabled.")
+
 	// Liveness Probe configuration
 	flags.StringVar(&opts.LivenessProbeOptions.Path, "liveness-probe-path", "",
 		"Path to the file for the liveness probe.")
@@ -276,6 +359,36 @@ func initCLI() {
 
 	flags.BoolVar(&opts.sdsEnabled, "sds-enabled", false, "Whether SDS is enabled.")
 
+	flags.StringVar(&opts.revocationDenyListFile, "revocation-deny-list-file", "",
+		"Path to the deny list of revoked identities, certificates and nodes, which Citadel refuses to issue "+
+			"certificates to. The file is reloaded when it changes. Requires a self-signed CA, publishing the "+
+			"revocation list of the certificates.")
+	flags.StringVar(&opts.revocationDenyListConfigMap, "revocation-deny-list-configmap", "",
+		"Name of the ConfigMap in the Citadel storage namespace holding the deny list of revoked identities, "+
+			"certificates and nodes under the \""+revocation.DenyListKey+"\" key. Ignored if "+
+			"--revocation-deny-list-file is set.")
+	flags.DurationVar(&opts.crlValidity, "crl-validity", revocation.DefaultCRLValidity,
+		"Validity of the published certificate revocation lists. The proxies reject all their peers, not only "+
+			"the revoked ones, once the list is expired: if Citadel can't re-publish the lists for that long, every "+
+			"mTLS connection of the mesh fails.")
+	flags.DurationVar(&opts.crlRefreshPeriod, "crl-refresh-period", revocation.DefaultCRLRefreshPeriod,
+		"Period at which the certificate revocation lists are re-published. Must be shorter than --crl-validity.")
+
+	flags.StringVar(&opts.oidcAuthenticatorConfig, "oidc-authenticator-config", "",
+		"Path to the configuration of the trusted OIDC issuers. If set, the CSRs authenticated with a JWT of "+
+			"one of the issuers are signed for the SPIFFE identities mapped from the token claims.")
+
+	flags.StringVar(&opts.csrPolicyConfig, "csr-policy-config", "",
+		"Path to the CSR policy of the CA server. If set, the CSRs are checked against the allowed key types and "+
+			"sizes, SAN formats, TTLs and extensions, and the requests are rate limited per peer address, identity and node.")
+
+	flags.StringVar(&opts.auditLogFile, "audit-log-file", "",
+		"Path of the append-only audit log of the issued certificates. If set, the serial number, identities, "+
+			"requester, node and TTL of each certificate are recorded in hash-chained entries. A certificate that "+
+			"can't be recorded is not issued.")
+	flags.StringVar(&opts.auditLogSigningKey, "audit-log-signing-key", "",
+		"Path of the PEM private key signing the audit log entries. Required if --audit-log-file is set.")
+
 	rootCmd.AddCommand(version.CobraCommand())
 
 	rootCmd.AddCommand(collateral.CobraCommand(rootCmd, &doc.GenManHeader{
@@ -285,6 +398,7 @@ func initCLI() {
 	}))
 
 	rootCmd.AddCommand(cmd.NewProbeCmd())
+	rootCmd.AddCommand(cmd.NewAuditCmd())
 
 	opts.loggingOptions.AttachCobraFlags(rootCmd)
 	opts.ctrlzOptions.AttachCobraFlags(rootCmd)
@@ -342,7 +456,14 @@ func runCA() {
 	if err != nil {
 		fatalf("Could not create k8s clientset: %v", err)
 	}
-	ca := createCA(cs.CoreV1())
+	ca := createCA(cs.CoreV1(), listenedNamespaces)
+
+	var auditLog *audit.Logger
+	if opts.auditLogFile != "" {
+		if auditLog, err = audit.NewFileLogger(opts.auditLogFile, opts.auditLogSigningKey); err != nil {
+			fatalf("Failed to create the audit log: %v", err)
+		}
+	}
 
 	stopCh := make(chan struct{})
 	if !opts.serverOnly {
@@ -355,6 +476,10 @@ func runCA() {
 		if err != nil {
 			fatalf("Failed to create secret controller: %v", err)
 		}
+		if rootRollover != nil {
+			sc.SetRootRollover(rootRollover)
+		}
+		sc.SetAuditLog(auditLog)
 		sc.Run(stopCh)
 	} else {
 		log.Info("Citadel is running in server only mode, certificates will not be propagated via secret.")
@@ -384,14 +509,49 @@ func runCA() {
 		serviceAccountController := kube.NewServiceAccountController(cs.CoreV1(), listenedNamespaces, reg)
 		serviceAccountController.Run(ch)
 
+		var denyList *revocation.Store
+		if opts.revocationDenyListFile != "" {
+			denyList = revocation.NewStore()
+			if err := denyList.WatchFile(opts.revocationDenyListFile, stopCh); err != nil {
+				fatalf("Failed to load the revocation deny list: %v", err)
+			}
+		} else if opts.revocationDenyListConfigMap != "" {
+			denyList = revocation.NewStore()
+			denyList.WatchConfigMap(cs.CoreV1(), opts.istioCaStorageNamespace, opts.revocationDenyListConfigMap, stopCh)
+		}
+		if denyList != nil {
+			if opts.crlRefreshPeriod <= 0 || opts.crlRefreshPeriod >= opts.crlValidity {
+				fatalf("The CRL refresh period (%v) must be positive and shorter than the CRL validity (%v).",
+					opts.crlRefreshPeriod, opts.crlValidity)
+			}
+			// Publish the revoked serial numbers to the proxies, which check them when validating the peers. The
+			// proxies require a list issued by every CA of the peer chain, so a CA with an intermediate chain
+			// would never publish one and the revoked certificates would silently be accepted.
+			if _, err := revocation.NewCRLIssuer(ca.GetCAKeyCertBundle()); err != nil {
+				fatalf("The revocation deny list requires a CA publishing a certificate revocation list: %v", err)
+			}
+			go denyList.PublishCRL(ca, configmap.NewController(opts.istioCaStorageNamespace, cs.CoreV1()),
+				opts.crlValidity, opts.crlRefreshPeriod, stopCh)
+		}
+
+		var csrPolicy *policy.Policy
+		if opts.csrPolicyConfig != "" {
+			config, err := policy.LoadFile(opts.csrPolicyConfig)
+			if err != nil {
+				fatalf("Failed to load the CSR policy: %v", err)
+			}
+			csrPolicy = policy.New(config, opts.workloadCertTTL)
+		}
+
 		// The CA API uses cert with the max workload cert TTL.
 		hostnames := append(strings.Split(opts.grpcHosts, ","), fqdn())
 		caServer, startErr := caserver.New(ca, opts.maxWorkloadCertTTL,
 			opts.signCACerts, hostnames, opts.grpcPort, spiffe.GetTrustDomain(),
-			opts.sdsEnabled)
+			opts.sdsEnabled, denyList, opts.oidcAuthenticatorConfig, csrPolicy)
 		if startErr != nil {
 			fatalf("Failed to create istio ca server: %v", startErr)
 		}
+		caServer.SetAuditLog(auditLog)
 		if serverErr := caServer.Run(); serverErr != nil {
 			// stop the registry-related controllers
 			ch <- struct{}{}
@@ -412,6 +572,22 @@ func runCA() {
 		defer monitor.Close()
 	}
 
+	// Start the SPIFFE bundle endpoint of the trust domain.
+	if opts.trustBundlePort > 0 {
+		bundleServer := &http.Server{
+			Addr: fmt.Sprintf(":%d", opts.trustBundlePort),
+			Handler: federation.NewBundleHandler(spiffe.GetTrustDomain(), ca.GetCAKeyCertBundle().GetRootCertPem,
+				federation.DefaultRefreshInterval),
+		}
+		go func() {
+			if err := bundleServer.ListenAndServe(); err != http.ErrServerClosed {
+				log.Errorf("Trust bundle endpoint failed: %v", err)
+			}
+		}()
+		log.Infof("Citadel trust bundle endpoint has started on port %d.", opts.trustBundlePort)
+		defer bundleServer.Close()
+	}
+
 	log.Info("Citadel has started")
 
 	rotatorErrCh := make(chan error)
@@ -448,7 +624,7 @@ func runCA() {
 	}
 }
 
-func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
+func createCA(client corev1.CoreV1Interface, listenedNamespaces []string) *ca.IstioCA {
 	var caOpts *ca.IstioCAOptions
 	var err error
 
@@ -469,10 +645,22 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 			opts.selfSignedRootCertCheckInterval, opts.workloadCertTTL,
 			opts.maxWorkloadCertTTL, spiffe.GetTrustDomain(), opts.dualUse,
 			opts.istioCaStorageNamespace, checkInterval, client, opts.rootCertFile,
-			opts.enableJitterForRootCertRotator)
+			opts.enableJitterForRootCertRotator, util.SupportedECSignatureAlgorithms(opts.selfSignedCAKeyECSigAlg),
+			util.SupportedEllipticCurves(opts.selfSignedCAKeyECCCurve))
 		if err != nil {
 			fatalf("Failed to create a self-signed Citadel (error: %v)", err)
 		}
+	} else if opts.externalSigner != "" {
+		log.Infof("Use certificate from argument as the CA certificate, signing with the %s signer", opts.externalSigner)
+		extSigner, signerErr := createExternalSigner()
+		if signerErr != nil {
+			fatalf("Failed to create the %s signer (error: %v)", opts.externalSigner, signerErr)
+		}
+		caOpts, err = ca.NewExternalSignerIstioCAOptions(opts.certChainFile, opts.signingCertFile, opts.rootCertFile,
+			extSigner, opts.workloadCertTTL, opts.maxWorkloadCertTTL, opts.istioCaStorageNamespace, client)
+		if err != nil {
+			fatalf("Failed to create an Citadel (error: %v)", err)
+		}
 	} else {
 		log.Info("Use certificate from argument as the CA certificate")
 		caOpts, err = ca.NewPluggedCertIstioCAOptions(opts.certChainFile, opts.signingCertFile, opts.signingKeyFile,
@@ -500,6 +688,11 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 			livenessProbeChecker.Run()
 		}
 	}
+	// The key of an external signer and the certificates of an upstream CA are not managed by Citadel.
+	if opts.rootRolloverCheckInterval > 0 && opts.externalSigner == "" && len(opts.cAClientConfig.CAAddress) == 0 {
+		rootRollover = istioCA.EnableRootRollover(client, opts.istioCaStorageNamespace, listenedNamespaces,
+			opts.rootRolloverCheckInterval, opts.rootRolloverMinPhaseDuration)
+	}
 	// rootCertRotatorChan channel accepts signals to stop root cert rotator for
 	// self-signed CA.
 	rootCertRotatorChan = make(chan struct{})
@@ -510,6 +703,25 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	return istioCA
 }
 
+func createExternalSigner() (crypto.Signer, error) {
+	config := signer.Config{
+		Type:             opts.externalSigner,
+		PKCS11Module:     opts.pkcs11Module,
+		PKCS11TokenLabel: opts.pkcs11TokenLabel,
+		PKCS11KeyLabel:   opts.pkcs11KeyLabel,
+		KMSPluginSocket:  opts.kmsPluginSocket,
+		KMSKeyID:         opts.kmsKeyID,
+	}
+	if opts.pkcs11PINFile != "" {
+		pin, err := ioutil.ReadFile(opts.pkcs11PINFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the PKCS #11 PIN: %v", err)
+		}
+		config.PKCS11PIN = strings.TrimSpace(string(pin))
+	}
+	return signer.New(config)
+}
+
 func verifyCommandLineOptions() {
 	if opts.selfSignedCA {
 		return
@@ -521,7 +733,12 @@ func verifyCommandLineOptions() {
 				"or use '-self-signed-ca'")
 	}
 
-	if opts.signingKeyFile == "" {
+	if opts.externalSigner != "" {
+		if len(opts.cAClientConfig.CAAddress) != 0 {
+			fatalf("The CA signing key of an external signer cannot be rotated by an upstream CA, " +
+				"'--external-signer' and '--upstream-ca-address' are exclusive")
+		}
+	} else if opts.signingKeyFile == "" {
 		fatalf(
 			"No signing key has been specified. Either specify a key file via '-signing-key' option " +
 				"or use '-self-signed-ca'")
@@ -16,6 +16,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -24,12 +25,18 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"istio.io/istio/pkg/cmd"
+	"istio.io/istio/pkg/kube"
+	securitycmd "istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/nodeagent/cache"
+	"istio.io/istio/security/pkg/nodeagent/caclient"
 	"istio.io/istio/security/pkg/nodeagent/sds"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
+	"istio.io/istio/security/pkg/pki/audit"
+	"istio.io/istio/security/pkg/pki/federation"
 	"istio.io/istio/security/pkg/server/monitoring"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/env"
@@ -94,6 +101,39 @@ const (
 	// validate the certificate's format which is returned by CA.
 	skipValidateCertFlag = "SKIP_CERT_VALIDATION"
 
+	// The environmental variable name for the type of Elliptical Signature algorithm of the
+	// generated workload keys, e.g. "ECDSA". RSA keys are generated if unset.
+	eccSigAlg     = "ECC_SIGNATURE_ALGORITHM"
+	eccSigAlgFlag = "eccSigAlg"
+
+	// The environmental variable name for the elliptic curve of the generated ECDSA workload keys.
+	// example value format like "P384"
+	eccCurve     = "ECC_CURVE"
+	eccCurveFlag = "eccCurve"
+
+	// The environmental variable name for the file of the audit log of the certificates issued to the workloads.
+	auditLogFile     = "AUDIT_LOG_FILE"
+	auditLogFileFlag = "auditLogFile"
+
+	// The environmental variable name for the PEM private key signing the audit log entries, required if
+	// AUDIT_LOG_FILE is set.
+	auditLogSigningKey     = "AUDIT_LOG_SIGNING_KEY"
+	auditLogSigningKeyFlag = "auditLogSigningKey"
+
+	// The environmental variable name for the name of the node the node agent runs on, recorded in the audit log.
+	nodeName     = "NODE_NAME"
+	nodeNameFlag = "nodeName"
+
+	// The environmental variable name for the YAML file mapping the SDS resource names to the files of their
+	// certificates and keys, e.g. issued by an external PKI agent.
+	fileSecretsConfig     = "FILE_SECRETS_CONFIG"
+	fileSecretsConfigFlag = "fileSecretsConfig"
+
+	// The environmental variable name for the SPIFFE federation configuration, whose trust anchors are served with
+	// the root certificate as the ROOTCA_FEDERATED resource.
+	federationConfig     = "FEDERATION_CONFIG"
+	federationConfigFlag = "federationConfig"
+
 	// The environmental variable name for secret TTL, node agent decides whether a secret
 	// is expired if time.now - secret.createtime >= secretTTL.
 	// example value format like "90m"
@@ -130,6 +170,10 @@ var (
 	gatewaySdsCacheOptions  cache.Options
 	serverOptions           sds.Options
 	gatewaySecretChan       chan struct{}
+	auditLogFilePath        string
+	auditLogSigningKeyPath  string
+	fileSecretsConfigPath   string
+	federationConfigPath    string
 	loggingOptions          = log.DefaultOptions()
 	ctrlzOptions            = ctrlz.DefaultOptions()
 	// rootCmd defines the command for node agent.
@@ -144,6 +188,14 @@ var (
 			applyEnvVars(c)
 			_, _ = ctrlz.Run(ctrlzOptions, nil)
 			gatewaySdsCacheOptions = workloadSdsCacheOptions
+			// The file secrets are only served by the workload SDS.
+			if fileSecretsConfigPath != "" {
+				fileSecrets, err := cache.LoadFileSecrets(fileSecretsConfigPath)
+				if err != nil {
+					return err
+				}
+				workloadSdsCacheOptions.FileSecrets = fileSecrets
+			}
 
 			if err := validateOptions(); err != nil {
 				return err
@@ -151,7 +203,7 @@ var (
 
 			stop := make(chan struct{})
 
-			workloadSecretCache, gatewaySecretCache := newSecretCache(serverOptions)
+			workloadSecretCache, gatewaySecretCache := newSecretCache(serverOptions, stop)
 			if workloadSecretCache != nil {
 				defer workloadSecretCache.Close()
 			}
@@ -197,25 +249,51 @@ func exitOnMonitorServerError(errCh <-chan error) {
 
 // newSecretCache creates the cache for workload secrets and/or gateway secrets.
 // Although currently not used, Citadel Agent can serve both workload and gateway secrets at the same time.
-func newSecretCache(serverOptions sds.Options) (workloadSecretCache, gatewaySecretCache *cache.SecretCache) {
+func newSecretCache(serverOptions sds.Options, stop <-chan struct{}) (workloadSecretCache, gatewaySecretCache *cache.SecretCache) {
 	if serverOptions.EnableWorkloadSDS {
 		wSecretFetcher, err := secretfetcher.NewSecretFetcher(false, serverOptions.CAEndpoint,
 			serverOptions.CAProviderName, true, []byte(serverOptions.VaultTLSRootCert),
 			serverOptions.VaultAddress, serverOptions.VaultRole, serverOptions.VaultAuthPath,
-			serverOptions.VaultSignCsrPath)
+			serverOptions.VaultSignCsrPath, serverOptions.TrustDomain)
 		if err != nil {
 			log.Errorf("failed to create secretFetcher for workload proxy: %v", err)
 			os.Exit(1)
 		}
 		workloadSdsCacheOptions.TrustDomain = serverOptions.TrustDomain
 		workloadSdsCacheOptions.Plugins = sds.NewPlugins(serverOptions.PluginNames)
+		if auditLogFilePath != "" {
+			if workloadSdsCacheOptions.AuditLog, err = audit.NewFileLogger(auditLogFilePath, auditLogSigningKeyPath); err != nil {
+				log.Errorf("failed to create the audit log: %v", err)
+				os.Exit(1)
+			}
+		}
+		if len(workloadSdsCacheOptions.FileSecrets) > 0 {
+			cs, err := kube.CreateClientset("", "")
+			if err != nil {
+				log.Errorf("failed to create the k8s clientset authenticating the proxies: %v", err)
+				os.Exit(1)
+			}
+			workloadSdsCacheOptions.FileSecretAuthenticator = cache.NewKubeAuthenticator(
+				cs.AuthenticationV1().TokenReviews(), serverOptions.TrustDomain)
+		}
+		var federationStore *federation.Store
+		if federationConfigPath != "" {
+			if federationStore, err = newFederationStore(federationConfigPath, serverOptions.TrustDomain); err != nil {
+				log.Errorf("failed to load the trust bundles of the federated trust domains: %v", err)
+				os.Exit(1)
+			}
+			workloadSdsCacheOptions.FederatedRoots = federationStore.TrustAnchorsPEM
+		}
 		workloadSecretCache = cache.NewSecretCache(wSecretFetcher, sds.NotifyProxy, workloadSdsCacheOptions)
+		if federationStore != nil {
+			go federationStore.Run(federation.DefaultRefreshInterval, stop, workloadSecretCache.FederatedRootsChanged)
+		}
 	} else {
 		workloadSecretCache = nil
 	}
 
 	if serverOptions.EnableIngressGatewaySDS {
-		gSecretFetcher, err := secretfetcher.NewSecretFetcher(true, "", "", false, nil, "", "", "", "")
+		gSecretFetcher, err := secretfetcher.NewSecretFetcher(true, "", "", false, nil, "", "", "", "", "")
 		if err != nil {
 			log.Errorf("failed to create secretFetcher for gateway proxy: %v", err)
 			os.Exit(1)
@@ -229,12 +307,54 @@ func newSecretCache(serverOptions sds.Options) (workloadSecretCache, gatewaySecr
 	return workloadSecretCache, gatewaySecretCache
 }
 
+// newFederationStore loads the trust bundles of the trust domains federated with the given local trust domain. A
+// trust bundle failing to load is an error, rather than silently federating with fewer trust domains than
+// configured.
+func newFederationStore(configPath, trustDomain string) (*federation.Store, error) {
+	in, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the federation configuration: %v", err)
+	}
+	config, err := federation.LoadConfig(in)
+	if err != nil {
+		return nil, err
+	}
+	if trustDomain == "" {
+		trustDomain = "cluster.local"
+	}
+	for _, td := range config.TrustDomains {
+		if td.Name == trustDomain {
+			return nil, fmt.Errorf("the local trust domain %s can't be federated", td.Name)
+		}
+	}
+
+	// The clientset is only needed by the bundles loaded from ConfigMaps, which fail to load without it.
+	var core corev1.CoreV1Interface
+	if cs, err := kube.CreateClientset("", ""); err == nil {
+		core = cs.CoreV1()
+	} else {
+		log.Warnf("failed to create the k8s clientset loading the trust bundles: %v", err)
+	}
+	store := federation.NewStore(config, core)
+	if _, err := store.Refresh(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
 var (
 	pluginNamesEnv                     = env.RegisterStringVar(pluginNames, "", "").Get()
 	enableWorkloadSDSEnv               = env.RegisterBoolVar(enableWorkloadSDS, true, "").Get()
 	enableIngressGatewaySDSEnv         = env.RegisterBoolVar(enableIngressGatewaySDS, false, "").Get()
 	alwaysValidTokenFlagEnv            = env.RegisterBoolVar(alwaysValidTokenFlag, false, "").Get()
 	skipValidateCertFlagEnv            = env.RegisterBoolVar(skipValidateCertFlag, false, "").Get()
+	eccSigAlgEnv                       = env.RegisterStringVar(eccSigAlg, "", "").Get()
+	eccCurveEnv                        = env.RegisterStringVar(eccCurve, "", "").Get()
+	auditLogFileEnv                    = env.RegisterStringVar(auditLogFile, "", "").Get()
+	auditLogSigningKeyEnv              = env.RegisterStringVar(auditLogSigningKey, "", "").Get()
+	nodeNameEnv                        = env.RegisterStringVar(nodeName, "", "").Get()
+	fileSecretsConfigEnv               = env.RegisterStringVar(fileSecretsConfig, "", "").Get()
+	federationConfigEnv                = env.RegisterStringVar(federationConfig, "", "").Get()
 	caProviderEnv                      = env.RegisterStringVar(caProvider, "", "").Get()
 	caEndpointEnv                      = env.RegisterStringVar(caEndpoint, "", "").Get()
 	trustDomainEnv                     = env.RegisterStringVar(trustDomain, "", "").Get()
@@ -321,6 +441,34 @@ func applyEnvVars(cmd *cobra.Command) {
 		workloadSdsCacheOptions.SkipValidateCert = skipValidateCertFlagEnv
 	}
 
+	if !cmd.Flag(eccSigAlgFlag).Changed {
+		workloadSdsCacheOptions.ECCSigAlg = eccSigAlgEnv
+	}
+
+	if !cmd.Flag(eccCurveFlag).Changed {
+		workloadSdsCacheOptions.ECCCurve = eccCurveEnv
+	}
+
+	if !cmd.Flag(auditLogFileFlag).Changed {
+		auditLogFilePath = auditLogFileEnv
+	}
+
+	if !cmd.Flag(auditLogSigningKeyFlag).Changed {
+		auditLogSigningKeyPath = auditLogSigningKeyEnv
+	}
+
+	if !cmd.Flag(nodeNameFlag).Changed {
+		workloadSdsCacheOptions.NodeName = nodeNameEnv
+	}
+
+	if !cmd.Flag(fileSecretsConfigFlag).Changed {
+		fileSecretsConfigPath = fileSecretsConfigEnv
+	}
+
+	if !cmd.Flag(federationConfigFlag).Changed {
+		federationConfigPath = federationConfigEnv
+	}
+
 	serverOptions.RecycleInterval = staledConnectionRecycleIntervalEnv
 
 	if !cmd.Flag(InitialBackoffFlag).Changed {
@@ -338,6 +486,11 @@ func validateOptions() error {
 		return fmt.Errorf("initial backoff should be within range 10 to 120000, found: %d", initBackoff)
 	}
 
+	keyOptions := cache.KeyOptions{ECCSigAlg: workloadSdsCacheOptions.ECCSigAlg, ECCCurve: workloadSdsCacheOptions.ECCCurve}
+	if err := keyOptions.Validate(); err != nil {
+		return err
+	}
+
 	if serverOptions.EnableIngressGatewaySDS && serverOptions.EnableWorkloadSDS &&
 		serverOptions.IngressGatewayUDSPath == serverOptions.WorkloadUDSPath {
 		return fmt.Errorf("UDS paths for ingress gateway and workload cannot be the same: %s", serverOptions.IngressGatewayUDSPath)
@@ -347,7 +500,7 @@ func validateOptions() error {
 		if serverOptions.CAProviderName == "" {
 			return fmt.Errorf("CA provider cannot be empty when workload SDS is enabled")
 		}
-		if serverOptions.CAEndpoint == "" {
+		if serverOptions.CAEndpoint == "" && serverOptions.CAProviderName != caclient.KubernetesCAName {
 			return fmt.Errorf("CA endpoint cannot be empty when workload SDS is enabled")
 		}
 	}
@@ -395,6 +548,31 @@ func main() {
 		false,
 		"If true, node agent assume token passed from envoy is always valid.")
 
+	rootCmd.PersistentFlags().StringVar(&workloadSdsCacheOptions.ECCSigAlg, eccSigAlgFlag, "",
+		"The type of Elliptical Signature algorithm of the workload keys, e.g. ECDSA. RSA keys are generated if empty.")
+
+	rootCmd.PersistentFlags().StringVar(&workloadSdsCacheOptions.ECCCurve, eccCurveFlag, "",
+		"The elliptic curve of the ECDSA workload keys, P256 or P384. Defaults to P256 if empty.")
+
+	rootCmd.PersistentFlags().StringVar(&auditLogFilePath, auditLogFileFlag, "",
+		"Path of the append-only audit log of the certificates issued to the workloads. Not recorded if empty. "+
+			"A certificate that can't be recorded is not given to the workload.")
+
+	rootCmd.PersistentFlags().StringVar(&auditLogSigningKeyPath, auditLogSigningKeyFlag, "",
+		"Path of the PEM private key signing the audit log entries. Required if --auditLogFile is set.")
+
+	rootCmd.PersistentFlags().StringVar(&workloadSdsCacheOptions.NodeName, nodeNameFlag, "",
+		"Name of the node the node agent runs on, recorded in the audit log of the certificates.")
+
+	rootCmd.PersistentFlags().StringVar(&fileSecretsConfigPath, fileSecretsConfigFlag, "",
+		"Path of the YAML file mapping the SDS resource names to the files of their certificates and keys, "+
+			"and to the identities of the proxies allowed to fetch them. The files are served by the workload SDS, "+
+			"watched and pushed to the proxies when they are rotated.")
+
+	rootCmd.PersistentFlags().StringVar(&federationConfigPath, federationConfigFlag, "",
+		"Path of the SPIFFE federation configuration. The roots of the federated trust domains, each only trusted "+
+			"for its own identities, are served with the root certificate as the ROOTCA_FEDERATED resource.")
+
 	rootCmd.PersistentFlags().BoolVar(&workloadSdsCacheOptions.SkipValidateCert, skipValidateCertFlag,
 		false,
 		"If true, node agent skip validating format of certificate returned from CA.")
@@ -416,6 +594,7 @@ func main() {
 	ctrlzOptions.AttachCobraFlags(rootCmd)
 
 	rootCmd.AddCommand(version.CobraCommand())
+	rootCmd.AddCommand(securitycmd.NewAuditCmd())
 	rootCmd.AddCommand(collateral.CobraCommand(rootCmd, &doc.GenManHeader{
 		Title:   "Istio Node Agent K8s",
 		Section: "node_agent_k8s CLI",
@@ -67,6 +67,35 @@ func TestValidateOptions(t *testing.T) {
 			},
 			errorMsg: "CA endpoint cannot be empty when workload SDS is enabled",
 		},
+		{
+			name: "empty CA endpoint with Kubernetes CA",
+			setExtraOptions: func() {
+				serverOptions.CAEndpoint = ""
+				serverOptions.CAProviderName = "KubernetesCA"
+			},
+		},
+		{
+			name: "ECDSA workload keys",
+			setExtraOptions: func() {
+				workloadSdsCacheOptions.ECCSigAlg = "ECDSA"
+				workloadSdsCacheOptions.ECCCurve = "P384"
+			},
+		},
+		{
+			name: "unsupported ECC signature algorithm",
+			setExtraOptions: func() {
+				workloadSdsCacheOptions.ECCSigAlg = "ED25519"
+			},
+			errorMsg: "unsupported ECC signature algorithm",
+		},
+		{
+			name: "unsupported elliptic curve",
+			setExtraOptions: func() {
+				workloadSdsCacheOptions.ECCSigAlg = "ECDSA"
+				workloadSdsCacheOptions.ECCCurve = "P224"
+			},
+			errorMsg: "unsupported elliptic curve",
+		},
 	}
 
 	for _, c := range cases {
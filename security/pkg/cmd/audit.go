@@ -0,0 +1,162 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/security/pkg/pki/audit"
+)
+
+type auditOptions struct {
+	file          string
+	filter        audit.Filter
+	since         string
+	until         string
+	verify        bool
+	publicKeyFile string
+	output        string
+}
+
+// NewAuditCmd creates the cobra.Command for the audit command, querying and verifying the certificate issuance
+// audit log.
+func NewAuditCmd() *cobra.Command {
+	opts := &auditOptions{}
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query and verify the certificate issuance audit log",
+		Example: `  # List the certificates issued to an identity in the last day
+  audit --file /var/log/citadel/audit.log --id spiffe://cluster.local/ns/default/sa/sleep \
+    --since 2019-10-01T00:00:00Z
+
+  # Verify the chain and the signatures of the whole log
+  audit --file /var/log/citadel/audit.log --verify --public-key audit-cert.pem`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit(cmd, opts)
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.file, "file", "", "Path of the audit log file.")
+	cmd.PersistentFlags().StringVar(&opts.filter.ID, "id", "", "Only show the certificates issued to this identity.")
+	cmd.PersistentFlags().StringVar(&opts.filter.SerialNumber, "serial", "",
+		"Only show the certificate of this hexadecimal serial number.")
+	cmd.PersistentFlags().StringVar(&opts.filter.Requester, "requester", "",
+		"Only show the certificates whose requester contains this string.")
+	cmd.PersistentFlags().StringVar(&opts.filter.Node, "node", "", "Only show the certificates requested from this node.")
+	cmd.PersistentFlags().StringVar(&opts.since, "since", "",
+		"Only show the certificates issued at or after this RFC 3339 time.")
+	cmd.PersistentFlags().StringVar(&opts.until, "until", "",
+		"Only show the certificates issued at or before this RFC 3339 time.")
+	cmd.PersistentFlags().BoolVar(&opts.verify, "verify", false,
+		"Verify the hash chain of the whole log, and the signatures if --public-key is set.")
+	cmd.PersistentFlags().StringVar(&opts.publicKeyFile, "public-key", "",
+		"Path of the PEM public key, or certificate, of the audit signing key.")
+	cmd.PersistentFlags().StringVarP(&opts.output, "output", "o", "table", "Output format, table or json.")
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, opts *auditOptions) error {
+	if opts.file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	if opts.output != "table" && opts.output != "json" {
+		return fmt.Errorf("unsupported output format %q", opts.output)
+	}
+	var err error
+	if opts.filter.Since, err = parseAuditTime(opts.since); err != nil {
+		return err
+	}
+	if opts.filter.Until, err = parseAuditTime(opts.until); err != nil {
+		return err
+	}
+	var publicKey crypto.PublicKey
+	if opts.publicKeyFile != "" {
+		keyPEM, err := ioutil.ReadFile(opts.publicKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the public key: %v", err)
+		}
+		if publicKey, err = audit.ParsePublicKey(keyPEM); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(opts.file)
+	if err != nil {
+		return fmt.Errorf("failed to open the audit log: %v", err)
+	}
+	defer f.Close()
+
+	verifier := audit.NewVerifier(publicKey)
+	var entries []*audit.Entry
+	count := 0
+	if err := audit.Read(f, func(e *audit.Entry) error {
+		count++
+		if opts.verify {
+			if err := verifier.Verify(e); err != nil {
+				return fmt.Errorf("audit log verification failed: %v", err)
+			}
+		}
+		if opts.filter.Match(e) {
+			entries = append(entries, e)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if opts.output == "json" {
+		encoder := json.NewEncoder(out)
+		for _, e := range entries {
+			if err := encoder.Encode(e); err != nil {
+				return err
+			}
+		}
+	} else {
+		w := tabwriter.NewWriter(out, 0, 8, 1, ' ', 0)
+		fmt.Fprintln(w, "SEQ\tTIME\tSERIAL\tIDS\tREQUESTER\tNODE\tTTL")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Sequence, e.Time.Format(time.RFC3339), e.SerialNumber,
+				strings.Join(e.IDs, ","), e.Requester, e.Node, e.TTL)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if opts.verify {
+		fmt.Fprintf(cmd.OutOrStderr(), "verified %d entries\n", count)
+	}
+	return nil
+}
+
+func parseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339: %v", s, err)
+	}
+	return t, nil
+}
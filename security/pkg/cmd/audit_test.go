@@ -0,0 +1,157 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/audit"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestAuditCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "audit.istio-system",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		IsClient:     true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for file, content := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+		if err := ioutil.WriteFile(file, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logFile := filepath.Join(dir, "audit.log")
+	l, err := audit.NewFileLogger(logFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"spiffe://cluster.local/ns/default/sa/sleep", "spiffe://cluster.local/ns/default/sa/httpbin"} {
+		cert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Host:         id,
+			TTL:          time.Hour,
+			IsSelfSigned: true,
+			RSAKeySize:   2048,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.RecordCert(cert, "citadel secret controller", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		want      []string
+		notWant   []string
+		wantError string
+	}{
+		{
+			name:    "filter by identity",
+			args:    []string{"--file", logFile, "--id", "spiffe://cluster.local/ns/default/sa/sleep"},
+			want:    []string{"SERIAL", "spiffe://cluster.local/ns/default/sa/sleep", "10.0.0.1", "1h0m0s"},
+			notWant: []string{"httpbin"},
+		},
+		{
+			name:    "json output",
+			args:    []string{"--file", logFile, "--node", "10.0.0.1", "-o", "json"},
+			want:    []string{`"seq":1`, `"seq":2`, `"signature":`},
+			notWant: []string{"SERIAL"},
+		},
+		{
+			name:    "time range",
+			args:    []string{"--file", logFile, "--until", "2000-01-01T00:00:00Z"},
+			notWant: []string{"spiffe://"},
+		},
+		{
+			name: "verify",
+			args: []string{"--file", logFile, "--verify", "--public-key", certFile},
+			want: []string{"verified 2 entries"},
+		},
+		{
+			name:      "missing file",
+			args:      []string{"--id", "spiffe://cluster.local/ns/default/sa/sleep"},
+			wantError: "--file is required",
+		},
+		{
+			name:      "invalid time",
+			args:      []string{"--file", logFile, "--since", "yesterday"},
+			wantError: "invalid time",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cmd := NewAuditCmd()
+			cmd.SetArgs(tc.args)
+			cmd.SetOutput(out)
+			err := cmd.Execute()
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Fatalf("got error %v, want %q", err, tc.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("expected %q in the output:\n%s", w, out.String())
+				}
+			}
+			for _, w := range tc.notWant {
+				if strings.Contains(out.String(), w) {
+					t.Errorf("unexpected %q in the output:\n%s", w, out.String())
+				}
+			}
+		})
+	}
+
+	// An altered entry fails the verification.
+	content, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	altered := strings.Replace(string(content), "citadel secret controller", "someone else", 1)
+	if err := ioutil.WriteFile(logFile, []byte(altered), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewAuditCmd()
+	cmd.SetArgs([]string{"--file", logFile, "--verify"})
+	cmd.SetOutput(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("expected the altered log to fail the verification, got %v", err)
+	}
+}
@@ -31,9 +31,11 @@ var configMapLog = log.RegisterScope("configMapController", "ConfigMap controlle
 const (
 	IstioSecurityConfigMapName = "istio-security"
 	CATLSRootCertName          = "caTLSRootCert"
+	// CRLName is the key of the PEM certificate revocation list, signed by the CA, in the ConfigMap.
+	CRLName = "crl"
 )
 
-// Controller manages the CA TLS root cert in ConfigMap.
+// Controller manages the CA TLS root cert and the certificate revocation list in ConfigMap.
 type Controller struct {
 	core      corev1.CoreV1Interface
 	namespace string
@@ -49,6 +51,22 @@ func NewController(namespace string, core corev1.CoreV1Interface) *Controller {
 
 // InsertCATLSRootCert updates the CA TLS root certificate in the configmap.
 func (c *Controller) InsertCATLSRootCert(value string) error {
+	if err := c.insertData(CATLSRootCertName, value); err != nil {
+		return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
+	}
+	return nil
+}
+
+// InsertCRL updates the PEM certificate revocation list in the configmap.
+func (c *Controller) InsertCRL(value string) error {
+	if err := c.insertData(CRLName, value); err != nil {
+		return fmt.Errorf("failed to insert CRL: %v", err)
+	}
+	return nil
+}
+
+// insertData sets the given key of the configmap, creating the configmap if it does not exist.
+func (c *Controller) insertData(key, value string) error {
 	configmap, err := c.core.ConfigMaps(c.namespace).Get(IstioSecurityConfigMapName, metav1.GetOptions{})
 	exists := true
 	if err != nil {
@@ -63,20 +81,19 @@ func (c *Controller) InsertCATLSRootCert(value string) error {
 			}
 			exists = false
 		} else {
-			return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
+			return err
 		}
 	}
-	configmap.Data[CATLSRootCertName] = value
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data[key] = value
 	if exists {
-		if _, err = c.core.ConfigMaps(c.namespace).Update(configmap); err != nil {
-			return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
-		}
+		_, err = c.core.ConfigMaps(c.namespace).Update(configmap)
 	} else {
-		if _, err = c.core.ConfigMaps(c.namespace).Create(configmap); err != nil {
-			return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
-		}
+		_, err = c.core.ConfigMaps(c.namespace).Create(configmap)
 	}
-	return nil
+	return err
 }
 
 // InsertCATLSRootCertWithRetry updates the CA TLS root certificate in the configmap with
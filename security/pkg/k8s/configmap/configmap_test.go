@@ -321,3 +321,22 @@ func checkActions(actual, expected []ktesting.Action) error {
 
 	return nil
 }
+
+func TestInsertCRL(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	if _, err := client.CoreV1().ConfigMaps("test-ns").Create(
+		createConfigMap("test-ns", map[string]string{CATLSRootCertName: "ABCD"})); err != nil {
+		t.Fatalf("Failed to create configmap %v", err)
+	}
+	controller := NewController("test-ns", client.CoreV1())
+	if err := controller.InsertCRL("CRL"); err != nil {
+		t.Fatalf("Failed to insert CRL: %v", err)
+	}
+	configmap, err := client.CoreV1().ConfigMaps("test-ns").Get(IstioSecurityConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get configmap %v", err)
+	}
+	if configmap.Data[CRLName] != "CRL" || configmap.Data[CATLSRootCertName] != "ABCD" {
+		t.Errorf("Unexpected configmap data %v", configmap.Data)
+	}
+}
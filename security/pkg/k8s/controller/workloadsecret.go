@@ -32,6 +32,7 @@ import (
 	"istio.io/istio/pkg/spiffe"
 	k8ssecret "istio.io/istio/security/pkg/k8s/secret"
 	"istio.io/istio/security/pkg/listwatch"
+	"istio.io/istio/security/pkg/pki/audit"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
 	certutil "istio.io/istio/security/pkg/util"
@@ -58,6 +59,10 @@ const (
 	PrivateKeyID = "key.pem"
 	// The ID/name for the CA root certificate file.
 	RootCertID = "root-cert.pem"
+
+	// The requester of the certificates in the audit log.
+	auditRequester = "citadel secret controller"
+
 	// The key to specify corresponding service account in the annotation of K8s secrets.
 	ServiceAccountNameAnnotationKey = "istio.io/service-account.name"
 
@@ -110,6 +115,12 @@ type certificateAuthority interface {
 	GetCAKeyCertBundle() util.KeyCertBundle
 }
 
+// RootRollover reports whether a root rollover of the CA is in progress.
+type RootRollover interface {
+	// InProgress returns whether a rollover owns the KeyCertBundle of the CA.
+	InProgress() bool
+}
+
 // SecretController manages the service accounts' secrets that contains Istio keys and certificates.
 type SecretController struct {
 	monitoring monitoringMetrics
@@ -163,6 +174,12 @@ type SecretController struct {
 	// The most recent time when root cert in keycertbundle is synced with root
 	// cert in istio-ca-secret.
 	lastKCBSyncTime time.Time
+
+	// rootRollover is the root rollover of the CA, if enabled.
+	rootRollover RootRollover
+
+	// auditLog records the issued certificates, if not nil.
+	auditLog *audit.Logger
 }
 
 // NewSecretController returns a pointer to a newly constructed SecretController instance.
@@ -256,6 +273,18 @@ func NewSecretController(ca certificateAuthority, enableNamespacesByDefault bool
 	return c, nil
 }
 
+// SetRootRollover sets the root rollover of the CA. While a rollover is in progress, the KeyCertBundle of the CA is
+// not synced with istio-ca-secret, and the secrets whose certificate is not issued by the current CA certificate are
+// refreshed.
+func (sc *SecretController) SetRootRollover(r RootRollover) {
+	sc.rootRollover = r
+}
+
+// SetAuditLog sets the audit log recording the certificates issued for the workload secrets.
+func (sc *SecretController) SetAuditLog(l *audit.Logger) {
+	sc.auditLog = l
+}
+
 // Run starts the SecretController until a value is sent to stopCh.
 func (sc *SecretController) Run(stopCh chan struct{}) {
 	go sc.scrtController.Run(stopCh)
@@ -477,6 +506,9 @@ func (sc *SecretController) generateKeyAndCert(saName string, saNamespace string
 		sc.monitoring.GetCertSignError(signErr.(*caerror.Error).ErrorType()).Increment()
 		return nil, nil, fmt.Errorf("CSR signing error (%v)", signErr.(*caerror.Error))
 	}
+	if err := sc.auditLog.RecordCert(certPEM, auditRequester, ""); err != nil {
+		return nil, nil, err
+	}
 	certPEM = append(certPEM, certChainPEM...)
 
 	return certPEM, keyPEM, nil
@@ -493,8 +525,9 @@ func (sc *SecretController) scrtUpdated(oldObj, newObj interface{}) {
 
 	_, waitErr := sc.certUtil.GetWaitTime(scrt.Data[CertChainID], time.Now(), sc.minGracePeriod)
 
+	rolloverInProgress := sc.rootRollover != nil && sc.rootRollover.InProgress()
 	caCert, _, _, rootCertificate := sc.ca.GetCAKeyCertBundle().GetAllPem()
-	if !bytes.Equal(rootCertificate, scrt.Data[RootCertID]) {
+	if !bytes.Equal(rootCertificate, scrt.Data[RootCertID]) && !rolloverInProgress {
 		var err error
 		rootCertificate, err = sc.tryToSyncKeyCertBundle(rootCertificate, caCert)
 		if err != nil {
@@ -507,8 +540,10 @@ func (sc *SecretController) scrtUpdated(oldObj, newObj interface{}) {
 	// Refresh the secret if 1) the certificate contained in the secret is about
 	// to expire, or 2) the root certificate in the secret is different than the
 	// one held by the ca (this may happen when the CA is restarted and
-	// a new self-signed CA cert is generated).
-	if waitErr != nil || !bytes.Equal(rootCertificate, scrt.Data[RootCertID]) {
+	// a new self-signed CA cert is generated), or 3) a root rollover is in progress
+	// and the certificate is not issued by the current CA certificate.
+	outdatedIssuer := rolloverInProgress && !issuedBy(scrt.Data[CertChainID], caCert)
+	if waitErr != nil || !bytes.Equal(rootCertificate, scrt.Data[RootCertID]) || outdatedIssuer {
 		// if the namespace is not managed, don't refresh the expired secret, delete it
 		secretNamespace, err := sc.core.Namespaces().Get(namespace, metav1.GetOptions{})
 		if err == nil {
@@ -522,6 +557,8 @@ func (sc *SecretController) scrtUpdated(oldObj, newObj interface{}) {
 
 		if waitErr != nil {
 			k8sControllerLog.Infof("Refreshing about to expire secret %s/%s: %s", namespace, GetSecretName(name), waitErr.Error())
+		} else if outdatedIssuer {
+			k8sControllerLog.Infof("Refreshing secret %s/%s (outdated issuer)", namespace, GetSecretName(name))
 		} else {
 			k8sControllerLog.Infof("Refreshing secret %s/%s (outdated root cert)", namespace, GetSecretName(name))
 		}
@@ -576,6 +613,19 @@ func (sc *SecretController) tryToSyncKeyCertBundle(rootCertInMem, caCertInMem []
 	return rootCertInMem, nil
 }
 
+// issuedBy returns whether the leaf certificate of the cert chain is signed by the CA certificate.
+func issuedBy(certChain, caCert []byte) bool {
+	leaf, err := util.ParsePemEncodedCertificate(certChain)
+	if err != nil {
+		return false
+	}
+	ca, err := util.ParsePemEncodedCertificate(caCert)
+	if err != nil {
+		return false
+	}
+	return leaf.CheckSignatureFrom(ca) == nil
+}
+
 // refreshSecret is an inner func to refresh cert secrets when necessary
 func (sc *SecretController) refreshSecret(scrt *v1.Secret) error {
 	namespace := scrt.GetNamespace()
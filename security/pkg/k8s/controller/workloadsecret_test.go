@@ -354,6 +354,7 @@ func TestUpdateSecret(t *testing.T) {
 		rootCertMatchBundle bool
 		originalKCBSyncTime time.Time
 		expectedKCBSyncTime bool
+		rolloverInProgress  bool
 	}{
 		"Does not update non-expiring secret": {
 			expectedActions:     []ktesting.Action{},
@@ -449,6 +450,33 @@ func TestUpdateSecret(t *testing.T) {
 			rootCert:            []byte(cert1Pem),
 			originalKCBSyncTime: time.Now(),
 		},
+		"Update secret not issued by the CA cert during a root rollover": {
+			expectedActions: []ktesting.Action{
+				ktesting.NewGetAction(nsSchema, "test-ns", "test-ns"),
+				ktesting.NewUpdateAction(secretSchema, "test-ns", istioTestSecret),
+			},
+			ttl:                 time.Hour,
+			gracePeriodRatio:    0.5,
+			minGracePeriod:      10 * time.Minute,
+			originalKCBSyncTime: time.Now(),
+			rolloverInProgress:  true,
+		},
+		"Skip reloading key cert bundle during a root rollover": {
+			expectedActions: []ktesting.Action{
+				ktesting.NewCreateAction(secretSchema, "", k8ssecret.BuildSecret("",
+					CASecret, "", nil, nil, []byte(cert1Pem),
+					[]byte(cert1Pem), []byte(key1Pem), IstioSecretType)),
+				ktesting.NewGetAction(nsSchema, "test-ns", "test-ns"),
+				ktesting.NewUpdateAction(secretSchema, "test-ns", istioTestSecret),
+			},
+			ttl:                 time.Hour,
+			gracePeriodRatio:    0.5,
+			minGracePeriod:      10 * time.Minute,
+			rootCert:            []byte("Outdated root cert"),
+			createIstioCASecret: true,
+			originalKCBSyncTime: time.Time{},
+			rolloverInProgress:  true,
+		},
 	}
 
 	for k, tc := range testCases {
@@ -461,6 +489,7 @@ func TestUpdateSecret(t *testing.T) {
 			t.Errorf("failed to create secret controller: %v", err)
 		}
 		controller.lastKCBSyncTime = tc.originalKCBSyncTime
+		controller.SetRootRollover(fakeRootRollover(tc.rolloverInProgress))
 		scrt := istioTestSecret
 		if rc := tc.rootCert; rc != nil {
 			scrt.Data[RootCertID] = rc
@@ -669,6 +698,12 @@ func checkActions(actual, expected []ktesting.Action) error {
 	return nil
 }
 
+type fakeRootRollover bool
+
+func (f fakeRootRollover) InProgress() bool {
+	return bool(f)
+}
+
 func createFakeCA() *mockca.FakeCA {
 	return &mockca.FakeCA{
 		SignedCert: signedCert,
@@ -23,15 +23,40 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	k8sauth "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	authnclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+
+	"istio.io/pkg/cache"
 )
 
 const (
 	// The default audience for SDS trustworthy JWT. This is to make sure that the CSR requests
 	// contain the JWTs intended for Citadel.
 	defaultAudience = "istio-ca"
+
+	// serviceAccountUserPrefix is the prefix of the user names of the authenticated service account tokens.
+	serviceAccountUserPrefix = "system:serviceaccount:"
+
+	// serviceAccountsGroup is the group of the authenticated service account tokens.
+	serviceAccountsGroup = "system:serviceaccounts"
+
+	// nodeNameExtraKey is the extra user info holding the name of the node the token is bound to, reported for
+	// the tokens of the pods on Kubernetes v1.30 and above.
+	nodeNameExtraKey = "authentication.kubernetes.io/node-name"
+
+	// podPathTemplate is the path of a pod in the API server.
+	podPathTemplate = "/api/v1/namespaces/%s/pods/%s"
+
+	// The node of a pod never changes, the nodes of the bound pods are cached by pod UID to only get each pod
+	// once. The cache is bounded as the UIDs of the deleted pods are never looked up again.
+	podNodeCacheExpiration = time.Hour
+	podNodeCacheEviction   = time.Minute
+	podNodeCacheSize       = 10000
 )
 
 type specForSaValidationRequest struct {
@@ -50,6 +75,12 @@ type K8sSvcAcctAuthn struct {
 	apiServerAddr string
 	callerToken   string
 	httpClient    *http.Client
+
+	// needNode returns whether the node of the reviewed tokens must be resolved from the pod they are bound to,
+	// when the API server doesn't report it. The node is never resolved if nil.
+	needNode func() bool
+	// podNodes caches the node names by pod UID.
+	podNodes cache.ExpiringCache
 }
 
 // NewK8sSvcAcctAuthn creates a new authenticator for authenticating k8s JWTs.
@@ -81,6 +112,14 @@ func NewK8sSvcAcctAuthn(apiServerAddr string, apiServerCert []byte, callerToken
 	}
 }
 
+// ResolveBoundPodNodes makes the authenticator resolve the node of a token from the pod it is bound to, whenever
+// the API server doesn't report the node of the token and needNode returns true. Resolving the node costs a GET of
+// the pod on the API server, so it should only be done when a decision depends on the node.
+func (authn *K8sSvcAcctAuthn) ResolveBoundPodNodes(needNode func() bool) {
+	authn.needNode = needNode
+	authn.podNodes = cache.NewLRU(podNodeCacheExpiration, podNodeCacheEviction, podNodeCacheSize)
+}
+
 // reviewServiceAccountAtK8sAPIServer reviews the CSR credential (k8s service account) at k8s API server.
 // targetToken: the JWT of the K8s service account to be reviewed
 func (authn *K8sSvcAcctAuthn) reviewServiceAccountAtK8sAPIServer(targetToken string) (*http.Response, error) {
@@ -113,7 +152,9 @@ func (authn *K8sSvcAcctAuthn) reviewServiceAccountAtK8sAPIServer(targetToken str
 }
 
 // ValidateK8sJwt validates a k8s JWT at API server.
-// Return {<namespace>, <serviceaccountname>} in the targetToken when the validation passes.
+// Return {<namespace>, <serviceaccountname>, <nodename>} in the targetToken when the validation passes. The node
+// name is empty if the token is not bound to a pod, or if the API server doesn't report it and the node is not
+// needed, see ResolveBoundPodNodes.
 // Otherwise, return the error.
 // targetToken: the JWT of the K8s service account to be reviewed
 func (authn *K8sSvcAcctAuthn) ValidateK8sJwt(targetToken string) ([]string, error) {
@@ -146,9 +187,6 @@ func (authn *K8sSvcAcctAuthn) ValidateK8sJwt(targetToken string) ([]string, erro
 	if err != nil {
 		return nil, fmt.Errorf("unmarshal response body returns an error: %v", err)
 	}
-	if tokenReview.Status.Error != "" {
-		return nil, fmt.Errorf("the service account authentication returns an error: %v", tokenReview.Status.Error)
-	}
 	// An example SA token:
 	// {"alg":"RS256","typ":"JWT"}
 	// {"iss":"kubernetes/serviceaccount",
@@ -158,7 +196,38 @@ func (authn *K8sSvcAcctAuthn) ValidateK8sJwt(targetToken string) ([]string, erro
 	//  "kubernetes.io/serviceaccount/service-account.uid":"ff578a9e-65d3-11e8-aad2-42010a8a001d",
 	//  "sub":"system:serviceaccount:default:example-pod-sa"
 	//  }
+	namespace, saName, err := ServiceAccount(tokenReview.Status)
+	if err != nil {
+		return nil, err
+	}
+	nodeName := ""
+	if nodeNames := tokenReview.Status.User.Extra[nodeNameExtraKey]; len(nodeNames) == 1 {
+		nodeName = nodeNames[0]
+	} else if authn.needNode != nil && authn.needNode() {
+		// Before Kubernetes v1.30, the node is the one of the pod the token is bound to.
+		if nodeName, err = authn.boundPodNode(targetToken); err != nil {
+			return nil, err
+		}
+	}
+
+	return []string{namespace, saName, nodeName}, nil
+}
 
+// ReviewServiceAccount reviews the token with the TokenReview API of the given client, and returns the namespace
+// and the name of its service account.
+func ReviewServiceAccount(tokenReviews authnclient.TokenReviewInterface, token string) (string, string, error) {
+	review, err := tokenReviews.Create(&k8sauth.TokenReview{
+		Spec: k8sauth.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to review the token: %v", err)
+	}
+	return ServiceAccount(review.Status)
+}
+
+// ServiceAccount returns the namespace and the name of the service account of the reviewed token, or an error if
+// the token is not an authenticated service account token.
+func ServiceAccount(status k8sauth.TokenReviewStatus) (string, string, error) {
 	// An example token review status
 	// "status":{
 	//   "authenticated":true,
@@ -168,30 +237,90 @@ func (authn *K8sSvcAcctAuthn) ValidateK8sJwt(targetToken string) ([]string, erro
 	//     "groups":["system:serviceaccounts","system:serviceaccounts:default","system:authenticated"]
 	//    }
 	// }
-
-	if !tokenReview.Status.Authenticated {
-		return nil, fmt.Errorf("the token is not authenticated")
+	if status.Error != "" {
+		return "", "", fmt.Errorf("the service account authentication returns an error: %v", status.Error)
+	}
+	if !status.Authenticated {
+		return "", "", fmt.Errorf("the token is not authenticated")
 	}
 	inServiceAccountGroup := false
-	for _, group := range tokenReview.Status.User.Groups {
-		if group == "system:serviceaccounts" {
+	for _, group := range status.User.Groups {
+		if group == serviceAccountsGroup {
 			inServiceAccountGroup = true
 			break
 		}
 	}
-	if !inServiceAccountGroup {
-		return nil, fmt.Errorf("the token is not a service account")
-	}
 	// "username" is in the form of system:serviceaccount:{namespace}:{service account name}",
 	// e.g., "username":"system:serviceaccount:default:example-pod-sa"
-	subStrings := strings.Split(tokenReview.Status.User.Username, ":")
-	if len(subStrings) != 4 {
-		return nil, fmt.Errorf("invalid username field in the token review result")
+	parts := strings.Split(strings.TrimPrefix(status.User.Username, serviceAccountUserPrefix), ":")
+	if !inServiceAccountGroup || !strings.HasPrefix(status.User.Username, serviceAccountUserPrefix) || len(parts) != 2 {
+		return "", "", fmt.Errorf("the token is not a service account token: %s", status.User.Username)
 	}
-	namespace := subStrings[2]
-	saName := subStrings[3]
+	return parts[0], parts[1], nil
+}
 
-	return []string{namespace, saName}, nil
+// boundPodNode returns the name of the node running the pod the reviewed token is bound to, or an empty string if
+// the token is not bound to a pod. The pod is checked to still be the one the token was issued to, unless its node
+// is already cached.
+func (authn *K8sSvcAcctAuthn) boundPodNode(reviewedToken string) (string, error) {
+	var claims struct {
+		Kubernetes struct {
+			Namespace string `json:"namespace"`
+			Pod       struct {
+				Name string `json:"name"`
+				UID  string `json:"uid"`
+			} `json:"pod"`
+		} `json:"kubernetes.io"`
+	}
+	parts := strings.Split(reviewedToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("jwt may be invalid: %s", reviewedToken)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode jwt: %v", err)
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("failed to unmarshal jwt: %v", err)
+	}
+	namespace, pod := claims.Kubernetes.Namespace, claims.Kubernetes.Pod
+	if namespace == "" || pod.Name == "" {
+		return "", nil
+	}
+	if node, ok := authn.podNodes.Get(pod.UID); ok {
+		return node.(string), nil
+	}
+
+	u, err := url.Parse(authn.apiServerAddr)
+	if err != nil {
+		return "", fmt.Errorf("invalid API server address: %v", err)
+	}
+	u.Path, u.RawQuery = fmt.Sprintf(podPathTemplate, url.PathEscape(namespace), url.PathEscape(pod.Name)), ""
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a HTTP request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+authn.callerToken)
+	resp, err := authn.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the pod %s/%s: %v", namespace, pod.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the pod %s/%s: status code %d", namespace, pod.Name, resp.StatusCode)
+	}
+	var p corev1.Pod
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the pod %s/%s: %v", namespace, pod.Name, err)
+	}
+	if string(p.UID) != pod.UID {
+		return "", fmt.Errorf("the pod %s/%s the token is bound to no longer exists", namespace, pod.Name)
+	}
+	// A pod is not cached until it is scheduled.
+	if p.Spec.NodeName != "" {
+		authn.podNodes.Set(pod.UID, p.Spec.NodeName)
+	}
+	return p.Spec.NodeName, nil
 }
 
 // isTrustworthyJwt checks if a jwt is a trustworthy jwt type.
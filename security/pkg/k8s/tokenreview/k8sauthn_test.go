@@ -21,10 +21,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	k8sauth "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 var (
@@ -32,10 +36,20 @@ var (
 	testJwtPrefix = "test-jwt"
 )
 
+const (
+	// boundPodPath and boundPodUID are the path and the UID of the pod the trustworthy jwt is bound to.
+	boundPodPath = "/api/v1/namespaces/default/pods/sleep-695c67cb5-4q985"
+	boundPodUID  = "81a87b5b-a7f0-11e9-a9b9-42010a8001f1"
+)
+
 type mockAPIServer struct {
 	httpServer    *httptest.Server
 	apiPath       string
 	reviewerToken string
+	// nodeNameExtra is the node name extra of the reviewed tokens, not reported if empty.
+	nodeNameExtra string
+	// pod is the pod the trustworthy jwt is bound to, if any.
+	pod *corev1.Pod
 }
 
 type clientConfig struct {
@@ -103,7 +117,7 @@ func TestOnMockAPIServer(t *testing.T) {
 	ch := make(chan *mockAPIServer)
 	go func() {
 		// create a test Vault server
-		server := newMockAPIServer(t, "/review-path", "Bearer fake-reviewer-token")
+		server := newMockAPIServer(t, "/review-path", "Bearer fake-reviewer-token", "node-1", nil)
 		ch <- server
 	}()
 	s := <-ch
@@ -118,7 +132,7 @@ func TestOnMockAPIServer(t *testing.T) {
 		authn := NewK8sSvcAcctAuthn(s.httpServer.URL+"/"+tc.cliConfig.reviewPath, tc.cliConfig.tlsCert,
 			tc.cliConfig.reviewerToken)
 
-		_, err := authn.ValidateK8sJwt(tc.cliConfig.jwt)
+		got, err := authn.ValidateK8sJwt(tc.cliConfig.jwt)
 
 		if err != nil {
 			t.Logf("Error: %v", err.Error())
@@ -129,24 +143,130 @@ func TestOnMockAPIServer(t *testing.T) {
 			t.Logf("No error")
 			if tc.expectedErr != "" {
 				t.Errorf("Test case [%s]: expect error: %s but got no error", id, tc.expectedErr)
+			} else if want := []string{"default", "example-pod-sa", "node-1"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Test case [%s]: got %v, want %v", id, got, want)
+			}
+		}
+	}
+}
+
+// TestNodeOfBoundPod checks that the node of the token is the one of the pod it is bound to, when the API server
+// doesn't report the node name extra.
+func TestNodeOfBoundPod(t *testing.T) {
+	jwt := getJwtFromFile("testdata/trustworthy-jwt.jwt", t)
+	boundPod := func(uid string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleep-695c67cb5-4q985", Namespace: "default", UID: types.UID(uid)},
+			Spec:       corev1.PodSpec{NodeName: "node-2"},
+		}
+	}
+	testCases := map[string]struct {
+		nodeNameExtra string
+		pod           *corev1.Pod
+		notNeeded     bool
+		expectedNode  string
+		expectedErr   string
+	}{
+		"node name extra": {
+			nodeNameExtra: "node-1",
+			pod:           boundPod(boundPodUID),
+			expectedNode:  "node-1",
+		},
+		"bound pod": {
+			pod:          boundPod(boundPodUID),
+			expectedNode: "node-2",
+		},
+		"node not needed": {
+			notNeeded:    true,
+			expectedNode: "",
+		},
+		"replaced pod": {
+			pod:         boundPod("another-uid"),
+			expectedErr: "the pod default/sleep-695c67cb5-4q985 the token is bound to no longer exists",
+		},
+		"missing pod": {
+			expectedErr: "failed to get the pod default/sleep-695c67cb5-4q985: status code 404",
+		},
+	}
+
+	for id, tc := range testCases {
+		s := newMockAPIServer(t, "/review-path", "Bearer fake-reviewer-token", tc.nodeNameExtra, tc.pod)
+		tlsCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.httpServer.Certificate().Raw})
+		authn := NewK8sSvcAcctAuthn(s.httpServer.URL+"/review-path", tlsCert, "fake-reviewer-token")
+		authn.ResolveBoundPodNodes(func() bool { return !tc.notNeeded })
+
+		got, err := authn.ValidateK8sJwt(jwt)
+		s.httpServer.Close()
+		if tc.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("Test case [%s]: expected error containing %q, got %v", id, tc.expectedErr, err)
 			}
+		} else if err != nil {
+			t.Errorf("Test case [%s]: unexpected error: %v", id, err)
+		} else if want := []string{"default", "example-pod-sa", tc.expectedNode}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Test case [%s]: got %v, want %v", id, got, want)
 		}
 	}
 }
 
+// TestNodeOfBoundPodCache checks that the node of a pod is only got once from the API server.
+func TestNodeOfBoundPodCache(t *testing.T) {
+	jwt := getJwtFromFile("testdata/trustworthy-jwt.jwt", t)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleep-695c67cb5-4q985", Namespace: "default", UID: types.UID(boundPodUID)},
+		Spec:       corev1.PodSpec{NodeName: "node-2"},
+	}
+
+	s := newMockAPIServer(t, "/review-path", "Bearer fake-reviewer-token", "", pod)
+	defer s.httpServer.Close()
+	tlsCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.httpServer.Certificate().Raw})
+	authn := NewK8sSvcAcctAuthn(s.httpServer.URL+"/review-path", tlsCert, "fake-reviewer-token")
+	authn.ResolveBoundPodNodes(func() bool { return true })
+	if _, err := authn.ValidateK8sJwt(jwt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The pod is no longer served, its node must be taken from the cache.
+	s2 := newMockAPIServer(t, "/review-path", "Bearer fake-reviewer-token", "", nil)
+	defer s2.httpServer.Close()
+	tlsCert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s2.httpServer.Certificate().Raw})
+	cached := NewK8sSvcAcctAuthn(s2.httpServer.URL+"/review-path", tlsCert, "fake-reviewer-token")
+	cached.ResolveBoundPodNodes(func() bool { return true })
+	cached.podNodes = authn.podNodes
+	got, err := cached.ValidateK8sJwt(jwt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"default", "example-pod-sa", "node-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 // newMockAPIServer creates a mock k8s API server for testing purpose.
 // apiPath: the path to call token review API
 // reviewerToken: the token of the reviewer
-func newMockAPIServer(t *testing.T, apiPath, reviewerToken string) *mockAPIServer {
+// nodeNameExtra: the node name extra of the reviewed tokens, not reported if empty
+// pod: the pod the trustworthy jwt is bound to, if any
+func newMockAPIServer(t *testing.T, apiPath, reviewerToken, nodeNameExtra string, pod *corev1.Pod) *mockAPIServer {
 	apiServer := &mockAPIServer{
 		apiPath:       apiPath,
 		reviewerToken: reviewerToken,
+		nodeNameExtra: nodeNameExtra,
+		pod:           pod,
 	}
 
 	handler := http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		t.Logf("request: %+v", *req)
 		t.Logf("request URL path: %v", req.URL.Path)
 		switch req.URL.Path {
+		case boundPodPath:
+			if apiServer.pod == nil || apiServer.reviewerToken != req.Header.Get("Authorization") {
+				resp.WriteHeader(http.StatusNotFound)
+				return
+			}
+			podJSON, _ := json.Marshal(apiServer.pod)
+			resp.Header().Set("Content-Type", "application/json")
+			resp.Write(podJSON)
 		case apiServer.apiPath:
 			t.Logf("%v", req.URL)
 			body, err := ioutil.ReadAll(req.Body)
@@ -190,6 +310,9 @@ func newMockAPIServer(t *testing.T, apiPath, reviewerToken string) *mockAPIServe
 						Error: "",
 					},
 				}
+				if apiServer.nodeNameExtra != "" {
+					result.Status.User.Extra = map[string]k8sauth.ExtraValue{nodeNameExtraKey: {apiServer.nodeNameExtra}}
+				}
 				resultJSON, _ := json.Marshal(result)
 				resp.Header().Set("Content-Type", "application/json")
 				resp.Write(resultJSON)
@@ -273,3 +396,66 @@ func getJwtFromFile(filePath string, t *testing.T) string {
 	}
 	return string(jwt)
 }
+
+func TestServiceAccount(t *testing.T) {
+	saGroups := []string{"system:serviceaccounts", "system:serviceaccounts:default", "system:authenticated"}
+	testCases := map[string]struct {
+		status      k8sauth.TokenReviewStatus
+		expectedNs  string
+		expectedSa  string
+		expectedErr string
+	}{
+		"Service account": {
+			status: k8sauth.TokenReviewStatus{
+				Authenticated: true,
+				User:          k8sauth.UserInfo{Username: "system:serviceaccount:default:example-pod-sa", Groups: saGroups},
+			},
+			expectedNs: "default",
+			expectedSa: "example-pod-sa",
+		},
+		"Review error": {
+			status:      k8sauth.TokenReviewStatus{Error: "invalid token"},
+			expectedErr: "the service account authentication returns an error: invalid token",
+		},
+		"Not authenticated": {
+			status:      k8sauth.TokenReviewStatus{},
+			expectedErr: "the token is not authenticated",
+		},
+		"Not in the service accounts group": {
+			status: k8sauth.TokenReviewStatus{
+				Authenticated: true,
+				User:          k8sauth.UserInfo{Username: "system:serviceaccount:default:example-pod-sa"},
+			},
+			expectedErr: "the token is not a service account token",
+		},
+		"User": {
+			status: k8sauth.TokenReviewStatus{
+				Authenticated: true,
+				User:          k8sauth.UserInfo{Username: "alice", Groups: saGroups},
+			},
+			expectedErr: "the token is not a service account token",
+		},
+		"Invalid username": {
+			status: k8sauth.TokenReviewStatus{
+				Authenticated: true,
+				User:          k8sauth.UserInfo{Username: "system:serviceaccount:default", Groups: saGroups},
+			},
+			expectedErr: "the token is not a service account token",
+		},
+	}
+
+	for id, tc := range testCases {
+		ns, sa, err := ServiceAccount(tc.status)
+		if tc.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("Test case [%s]: expected error containing %q, got %v", id, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test case [%s]: unexpected error: %v", id, err)
+		} else if ns != tc.expectedNs || sa != tc.expectedSa {
+			t.Errorf("Test case [%s]: got %s/%s but want %s/%s", id, ns, sa, tc.expectedNs, tc.expectedSa)
+		}
+	}
+}
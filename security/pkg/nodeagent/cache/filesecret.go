@@ -0,0 +1,296 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	authnclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+
+	"istio.io/istio/security/pkg/k8s/tokenreview"
+	"istio.io/istio/security/pkg/nodeagent/model"
+	nodeagentutil "istio.io/istio/security/pkg/nodeagent/util"
+	"istio.io/pkg/filewatcher"
+)
+
+// fileSecretDebounceDuration is the time to wait for the other files of a secret to be written, e.g. the key
+// after the certificate chain, before reloading it.
+const fileSecretDebounceDuration = 100 * time.Millisecond
+
+// FileSecret is the files of the secret of an SDS resource, e.g. issued by an external PKI agent. Either the
+// certificate chain, the private key and the identities allowed to fetch them, or the root certificate, are set.
+type FileSecret struct {
+	// CertificateChain is the path of the PEM certificate chain, leaf certificate first.
+	CertificateChain string `json:"certificateChain,omitempty"`
+
+	// PrivateKey is the path of the PEM private key of the leaf certificate.
+	PrivateKey string `json:"privateKey,omitempty"`
+
+	// RootCert is the path of the PEM root certificate.
+	RootCert string `json:"rootCert,omitempty"`
+
+	// AllowedIdentities are the SPIFFE identities of the proxies allowed to fetch the certificate chain and the
+	// private key, e.g. spiffe://cluster.local/ns/foo/sa/bar.
+	AllowedIdentities []string `json:"allowedIdentities,omitempty"`
+}
+
+// allows returns whether the proxy of the identity may fetch the certificate chain and the private key.
+func (f FileSecret) allows(identity string) bool {
+	for _, id := range f.AllowedIdentities {
+		if id == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator authenticates the credential of a proxy and returns its SPIFFE identity.
+type Authenticator interface {
+	Authenticate(token string) (string, error)
+}
+
+type kubeAuthenticator struct {
+	tokenReviews authnclient.TokenReviewInterface
+	trustDomain  string
+}
+
+// NewKubeAuthenticator returns an Authenticator reviewing the service account tokens of the proxies with the
+// Kubernetes API server.
+func NewKubeAuthenticator(tokenReviews authnclient.TokenReviewInterface, trustDomain string) Authenticator {
+	return &kubeAuthenticator{
+		tokenReviews: tokenReviews,
+		trustDomain:  trustDomain,
+	}
+}
+
+func (a *kubeAuthenticator) Authenticate(token string) (string, error) {
+	namespace, serviceAccount, err := tokenreview.ReviewServiceAccount(a.tokenReviews, token)
+	if err != nil {
+		return "", err
+	}
+	domain := "cluster.local"
+	if a.trustDomain != "" {
+		domain = a.trustDomain
+	}
+	return fmt.Sprintf(identityTemplate, domain, namespace, serviceAccount), nil
+}
+
+// paths returns the files of the secret.
+func (f FileSecret) paths() []string {
+	if f.RootCert != "" {
+		return []string{f.RootCert}
+	}
+	return []string{f.CertificateChain, f.PrivateKey}
+}
+
+// LoadFileSecrets reads the YAML file mapping the SDS resource names to the files of their secrets, e.g.
+//
+//	default:
+//	  certificateChain: /etc/certs/cert-chain.pem
+//	  privateKey: /etc/certs/key.pem
+//	  allowedIdentities:
+//	  - spiffe://cluster.local/ns/foo/sa/bar
+//	ROOTCA:
+//	  rootCert: /etc/certs/root-cert.pem
+func LoadFileSecrets(path string) (map[string]FileSecret, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the file secrets config: %v", err)
+	}
+	secrets := map[string]FileSecret{}
+	if err := yaml.Unmarshal(b, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse the file secrets config: %v", err)
+	}
+	for name, f := range secrets {
+		switch {
+		case f.RootCert != "" && f.CertificateChain == "" && f.PrivateKey == "" && len(f.AllowedIdentities) == 0:
+		case f.RootCert == "" && f.CertificateChain != "" && f.PrivateKey != "" && len(f.AllowedIdentities) > 0:
+		default:
+			return nil, fmt.Errorf("invalid file secret %q: either certificateChain, privateKey and "+
+				"allowedIdentities, or rootCert, must be set", name)
+		}
+	}
+	return secrets, nil
+}
+
+// generateFileSecret reads the secret of the resource from its files.
+func generateFileSecret(f FileSecret, resourceName, token string, t time.Time) (*model.SecretItem, error) {
+	if f.RootCert != "" {
+		rootCert, err := ioutil.ReadFile(f.RootCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the root certificate: %v", err)
+		}
+		expireTime, err := nodeagentutil.ParseCertAndGetExpiryTimestamp(rootCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the root certificate %s: %v", f.RootCert, err)
+		}
+		return &model.SecretItem{
+			ResourceName: resourceName,
+			RootCert:     rootCert,
+			ExpireTime:   expireTime,
+			Token:        token,
+			CreatedTime:  t,
+			Version:      t.String(),
+		}, nil
+	}
+
+	certChain, err := ioutil.ReadFile(f.CertificateChain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificate chain: %v", err)
+	}
+	key, err := ioutil.ReadFile(f.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the private key: %v", err)
+	}
+	if _, err := tls.X509KeyPair(certChain, key); err != nil {
+		return nil, fmt.Errorf("the private key %s does not match the leaf certificate of %s: %v",
+			f.PrivateKey, f.CertificateChain, err)
+	}
+	expireTime, err := nodeagentutil.ParseCertAndGetExpiryTimestamp(certChain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the certificate chain %s: %v", f.CertificateChain, err)
+	}
+	return &model.SecretItem{
+		CertificateChain: certChain,
+		PrivateKey:       key,
+		ResourceName:     resourceName,
+		Token:            token,
+		CreatedTime:      t,
+		ExpireTime:       expireTime,
+		Version:          t.String(),
+	}, nil
+}
+
+// authorizeFileSecret authenticates the token of the proxy and checks that its identity may fetch the secret.
+// The root certificate is public.
+func (sc *SecretCache) authorizeFileSecret(f FileSecret, token string) error {
+	if f.RootCert != "" {
+		return nil
+	}
+	if sc.configOptions.FileSecretAuthenticator == nil {
+		return fmt.Errorf("no authenticator is configured for the file secrets")
+	}
+	identity, err := sc.configOptions.FileSecretAuthenticator.Authenticate(token)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate the proxy: %v", err)
+	}
+	if !f.allows(identity) {
+		return fmt.Errorf("identity %s is not allowed to fetch the secret", identity)
+	}
+	return nil
+}
+
+// isFileSecret returns whether the secret of the resource is read from files.
+func (sc *SecretCache) isFileSecret(resourceName string) bool {
+	_, ok := sc.configOptions.FileSecrets[resourceName]
+	return ok
+}
+
+// watchFileSecrets watches the files of the file secrets, pushing the secrets to the proxies when they change.
+func (sc *SecretCache) watchFileSecrets() error {
+	// The resources of each file, a file may be shared, e.g. the root certificate.
+	resources := map[string][]string{}
+	for name, f := range sc.configOptions.FileSecrets {
+		for _, path := range f.paths() {
+			resources[path] = append(resources[path], name)
+		}
+	}
+
+	sc.fileWatcher = filewatcher.NewWatcher()
+	for path, names := range resources {
+		if err := sc.fileWatcher.Add(path); err != nil {
+			_ = sc.fileWatcher.Close()
+			return fmt.Errorf("failed to watch the secret file %s: %v", path, err)
+		}
+		sort.Strings(names)
+		go sc.watchFileSecret(path, names)
+	}
+	return nil
+}
+
+func (sc *SecretCache) watchFileSecret(path string, resourceNames []string) {
+	var timerC <-chan time.Time
+	events, errs := sc.fileWatcher.Events(path), sc.fileWatcher.Errors(path)
+	for {
+		select {
+		case <-timerC:
+			timerC = nil
+			cacheLog.Infof("secret file %s changed, pushing the secrets of %v", path, resourceNames)
+			for _, name := range resourceNames {
+				sc.UpdateFileSecret(name)
+			}
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+			// Use a timer to debounce file updates
+			if timerC == nil {
+				timerC = time.After(fileSecretDebounceDuration)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			cacheLog.Errorf("error while watching the secret file %s: %v", path, err)
+		}
+	}
+}
+
+// UpdateFileSecret reloads the secret of the resource from its files and pushes it to the proxies. This is
+// called when the files of a file secret change.
+func (sc *SecretCache) UpdateFileSecret(resourceName string) {
+	f, ok := sc.configOptions.FileSecrets[resourceName]
+	if !ok {
+		return
+	}
+	secret, err := generateFileSecret(f, resourceName, "", time.Now())
+	if err != nil {
+		// The files may be partially written, the proxies keep their secret until the next change.
+		cacheLog.Errorf("failed to reload the file secret %s: %v", resourceName, err)
+		return
+	}
+
+	var secretMap sync.Map
+	wg := sync.WaitGroup{}
+	sc.secrets.Range(func(k interface{}, v interface{}) bool {
+		connKey := k.(ConnKey)
+		if connKey.ResourceName != resourceName {
+			return true
+		}
+		ns := *secret
+		ns.Token = v.(model.SecretItem).Token
+		conIDresourceNamePrefix := cacheLogPrefix(connKey.ConnectionID, resourceName)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			secretMap.Store(connKey, &ns)
+			cacheLog.Debugf("%s secret cache is updated", conIDresourceNamePrefix)
+			sc.callbackWithTimeout(connKey, &ns)
+		}()
+		return true
+	})
+	wg.Wait()
+
+	secretMap.Range(func(k interface{}, v interface{}) bool {
+		sc.secrets.Store(k.(ConnKey), *v.(*model.SecretItem))
+		return true
+	})
+}
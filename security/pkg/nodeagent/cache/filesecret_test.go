@@ -0,0 +1,245 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/nodeagent/cache/mock"
+	"istio.io/istio/security/pkg/nodeagent/model"
+	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+// fakeAuthenticator authenticates the tokens of its map as their identities.
+type fakeAuthenticator map[string]string
+
+func (a fakeAuthenticator) Authenticate(token string) (string, error) {
+	if id, ok := a[token]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("invalid token")
+}
+
+func genCertKey(t *testing.T, host string) ([]byte, []byte) {
+	t.Helper()
+	cert, key, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Host:         host,
+		TTL:          time.Hour,
+		NotBefore:    time.Now(),
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestLoadFileSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		name      string
+		config    string
+		want      map[string]FileSecret
+		wantError string
+	}{
+		{
+			name: "valid",
+			config: `
+default:
+  certificateChain: /etc/certs/cert-chain.pem
+  privateKey: /etc/certs/key.pem
+  allowedIdentities:
+  - spiffe://cluster.local/ns/foo/sa/bar
+ROOTCA:
+  rootCert: /etc/certs/root-cert.pem
+`,
+			want: map[string]FileSecret{
+				"default": {CertificateChain: "/etc/certs/cert-chain.pem", PrivateKey: "/etc/certs/key.pem",
+					AllowedIdentities: []string{"spiffe://cluster.local/ns/foo/sa/bar"}},
+				"ROOTCA": {RootCert: "/etc/certs/root-cert.pem"},
+			},
+		},
+		{
+			name:      "missing key",
+			config:    "default: {certificateChain: /etc/certs/cert-chain.pem, allowedIdentities: [spiffe://td/ns/a/sa/b]}",
+			wantError: `invalid file secret "default"`,
+		},
+		{
+			name:      "missing allowed identities",
+			config:    "default: {certificateChain: /etc/certs/cert-chain.pem, privateKey: /etc/certs/key.pem}",
+			wantError: `invalid file secret "default"`,
+		},
+		{
+			name:      "root and key",
+			config:    "default: {rootCert: /etc/certs/root-cert.pem, privateKey: /etc/certs/key.pem}",
+			wantError: `invalid file secret "default"`,
+		},
+		{
+			name:      "invalid YAML",
+			config:    "default: [",
+			wantError: "failed to parse",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config.yaml")
+			if err := ioutil.WriteFile(path, []byte(tc.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadFileSecrets(path)
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Fatalf("got error %v, want %q", err, tc.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFileSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certChain, key := genCertKey(t, "spiffe://cluster.local/ns/foo/sa/external")
+	rotatedCertChain, rotatedKey := genCertKey(t, "spiffe://cluster.local/ns/foo/sa/external")
+	_, mismatchedKey := genCertKey(t, "spiffe://cluster.local/ns/foo/sa/other")
+	certChainFile := filepath.Join(dir, "cert-chain.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	mismatchedKeyFile := filepath.Join(dir, "mismatched-key.pem")
+	rootCertFile := filepath.Join(dir, "root-cert.pem")
+	for file, content := range map[string][]byte{
+		certChainFile:     certChain,
+		keyFile:           key,
+		mismatchedKeyFile: mismatchedKey,
+		rootCertFile:      k8sTLSFallbackSecretCertChain,
+	} {
+		if err := ioutil.WriteFile(file, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pushed := make(chan *model.SecretItem, 10)
+	opt := Options{
+		SecretTTL:        time.Minute,
+		RotationInterval: 300 * time.Microsecond,
+		EvictionDuration: 2 * time.Second,
+		InitialBackoff:   10,
+		SkipValidateCert: true,
+		FileSecrets: map[string]FileSecret{
+			"external": {CertificateChain: certChainFile, PrivateKey: keyFile,
+				AllowedIdentities: []string{"spiffe://cluster.local/ns/foo/sa/sleep"}},
+			"mismatched": {CertificateChain: certChainFile, PrivateKey: mismatchedKeyFile,
+				AllowedIdentities: []string{"spiffe://cluster.local/ns/foo/sa/sleep"}},
+			RootCertReqResourceName: {RootCert: rootCertFile},
+		},
+		FileSecretAuthenticator: fakeAuthenticator{
+			"jwtToken1": "spiffe://cluster.local/ns/foo/sa/sleep",
+			"jwtToken2": "spiffe://cluster.local/ns/bar/sa/httpbin",
+		},
+	}
+	fetcher := &secretfetcher.SecretFetcher{
+		UseCaClient: true,
+		CaClient:    mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain),
+	}
+	sc := NewSecretCache(fetcher, func(_ ConnKey, secret *model.SecretItem) error {
+		pushed <- secret
+		return nil
+	}, opt)
+	defer sc.Close()
+
+	secret, err := sc.GenerateSecret(context.Background(), "proxy1-id", "external", "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	if !bytes.Equal(secret.CertificateChain, certChain) || !bytes.Equal(secret.PrivateKey, key) {
+		t.Errorf("unexpected file secret %+v", secret)
+	}
+	// The certificate and key are only served to the allowed identities.
+	if _, err := sc.GenerateSecret(context.Background(), "proxy2-id", "external", "jwtToken2"); err == nil ||
+		!strings.Contains(err.Error(), "is not allowed") {
+		t.Errorf("expected the identity not to be allowed, got %v", err)
+	}
+	if _, err := sc.GenerateSecret(context.Background(), "proxy3-id", "external", "invalidToken"); err == nil ||
+		!strings.Contains(err.Error(), "failed to authenticate") {
+		t.Errorf("expected the token not to be authenticated, got %v", err)
+	}
+	// A key not matching the leaf certificate is not served.
+	if _, err := sc.GenerateSecret(context.Background(), "proxy1-id", "mismatched", "jwtToken1"); err == nil ||
+		!strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected the mismatched key to be rejected, got %v", err)
+	}
+	// The root certificate is public.
+	root, err := sc.GenerateSecret(context.Background(), "proxy2-id", RootCertReqResourceName, "jwtToken2")
+	if err != nil {
+		t.Fatalf("Failed to get root cert: %v", err)
+	}
+	if !bytes.Equal(root.RootCert, k8sTLSFallbackSecretCertChain) {
+		t.Errorf("expected the root cert to be read from its file, got %q", root.RootCert)
+	}
+	if sc.ShouldWaitForIngressGatewaySecret("proxy1-id", "external", "jwtToken1") {
+		t.Errorf("expected not to wait for a Kubernetes secret for a file secret")
+	}
+
+	// The rotated certificate is pushed to the proxy.
+	if err := ioutil.WriteFile(keyFile, rotatedKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(certChainFile, rotatedCertChain, 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case secret := <-pushed:
+		if !bytes.Equal(secret.CertificateChain, rotatedCertChain) || !bytes.Equal(secret.PrivateKey, rotatedKey) ||
+			secret.Token != "jwtToken1" || secret.ResourceName != "external" {
+			t.Errorf("unexpected pushed secret %+v", secret)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the rotated file secret was not pushed")
+	}
+	// The cache is updated once the proxies are notified.
+	for i := 0; ; i++ {
+		cached, _ := sc.secrets.Load(ConnKey{ConnectionID: "proxy1-id", ResourceName: "external"})
+		if bytes.Equal(cached.(model.SecretItem).CertificateChain, rotatedCertChain) {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("expected the cache to hold the rotated secret")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
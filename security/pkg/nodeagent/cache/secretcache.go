@@ -31,7 +31,9 @@ import (
 	"istio.io/istio/security/pkg/nodeagent/plugin"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
 	nodeagentutil "istio.io/istio/security/pkg/nodeagent/util"
+	"istio.io/istio/security/pkg/pki/audit"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/filewatcher"
 	"istio.io/pkg/log"
 )
 
@@ -52,6 +54,10 @@ const (
 	// RootCertReqResourceName is resource name of discovery request for root certificate.
 	RootCertReqResourceName = "ROOTCA"
 
+	// FederatedRootCertReqResourceName is resource name of discovery request for the root certificate along with
+	// the trust anchors of the federated trust domains.
+	FederatedRootCertReqResourceName = "ROOTCA_FEDERATED"
+
 	// WorkloadKeyCertResourceName is the resource name of the discovery request for workload
 	// identity.
 	// TODO: change all the pilot one reference definition here instead.
@@ -105,6 +111,70 @@ type Options struct {
 
 	// set this flag to true if skip validate format for certificate chain returned from CA.
 	SkipValidateCert bool
+
+	// The type of Elliptical Signature algorithm to use when generating private keys.
+	// Currently only ECDSA is supported. An RSA key is generated if empty.
+	ECCSigAlg string
+
+	// The elliptic curve of the generated ECDSA private keys, e.g. P256 or P384. Defaults to P256 if empty.
+	ECCCurve string
+
+	// AuditLog records the certificates issued to the workloads, if not nil.
+	AuditLog *audit.Logger
+
+	// NodeName is the name of the node the node agent runs on, recorded in the audit log.
+	NodeName string
+
+	// FileSecrets maps the SDS resource names whose secrets are read from files, instead of being issued by
+	// the CA or fetched from Kubernetes, to their files. The files are watched and the secrets pushed to the
+	// proxies when they change.
+	FileSecrets map[string]FileSecret
+
+	// FileSecretAuthenticator authenticates the proxies fetching the certificates and keys of FileSecrets,
+	// which are only served to their allowed identities. Required if FileSecrets has a certificate and key.
+	FileSecretAuthenticator Authenticator
+
+	// FederatedRoots returns the PEM trust anchors of the trust domains federated with the mesh, each only
+	// permitting the identities of its own trust domain, served with the root certificate as the
+	// FederatedRootCertReqResourceName resource. Only the root certificate is served if nil.
+	FederatedRoots func() ([]byte, error)
+}
+
+// KeyOptions are the key type requested by a proxy, overriding the ECCSigAlg and ECCCurve options of the
+// cache for the keys generated for the proxy. The empty fields default to the options of the cache.
+type KeyOptions struct {
+	// The type of Elliptical Signature algorithm of the key, e.g. "ECDSA".
+	ECCSigAlg string
+
+	// The elliptic curve of the ECDSA key, e.g. P256 or P384.
+	ECCCurve string
+}
+
+// Validate returns an error if the key type is not supported.
+func (o KeyOptions) Validate() error {
+	switch util.SupportedECSignatureAlgorithms(o.ECCSigAlg) {
+	case "", util.EcdsaSigAlg:
+	default:
+		return fmt.Errorf("unsupported ECC signature algorithm: %s", o.ECCSigAlg)
+	}
+
+	switch util.SupportedEllipticCurves(o.ECCCurve) {
+	case "", util.P256Curve, util.P384Curve:
+	default:
+		return fmt.Errorf("unsupported elliptic curve: %s", o.ECCCurve)
+	}
+	return nil
+}
+
+type keyOptionsContextKey struct{}
+
+// WithKeyOptions returns a copy of the context carrying the key type requested by the proxy, used by
+// GenerateSecret to generate the key of the proxy.
+func WithKeyOptions(ctx context.Context, o KeyOptions) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, keyOptionsContextKey{}, o)
 }
 
 // SecretManager defines secrets management interface which is used by SDS.
@@ -168,6 +238,9 @@ type SecretCache struct {
 	// Source of random numbers. It is not concurrency safe, requires lock protected.
 	rand      *rand.Rand
 	randMutex *sync.Mutex
+
+	// fileWatcher watches the files of the file secrets.
+	fileWatcher filewatcher.FileWatcher
 }
 
 // NewSecretCache creates a new secret cache.
@@ -190,6 +263,11 @@ func NewSecretCache(fetcher *secretfetcher.SecretFetcher, notifyCb func(ConnKey,
 	atomic.StoreUint64(&ret.secretChangedCount, 0)
 	atomic.StoreUint64(&ret.rootCertChangedCount, 0)
 	atomic.StoreUint32(&ret.skipTokenExpireCheck, 1)
+	if len(options.FileSecrets) > 0 {
+		if err := ret.watchFileSecrets(); err != nil {
+			cacheLog.Errorf("file secrets will not be pushed on change: %v", err)
+		}
+	}
 	go ret.keyCertRotationJob()
 	return ret
 }
@@ -205,7 +283,21 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 	}
 
 	conIDresourceNamePrefix := cacheLogPrefix(connectionID, resourceName)
-	if resourceName != RootCertReqResourceName {
+	if f, ok := sc.configOptions.FileSecrets[resourceName]; ok {
+		if err := sc.authorizeFileSecret(f, token); err != nil {
+			cacheLog.Errorf("%s proxy is not allowed to fetch the file secret: %v", conIDresourceNamePrefix, err)
+			return nil, err
+		}
+		ns, err := generateFileSecret(f, resourceName, token, time.Now())
+		if err != nil {
+			cacheLog.Errorf("%s failed to read file secret for proxy: %v", conIDresourceNamePrefix, err)
+			return nil, err
+		}
+		sc.secrets.Store(connKey, *ns)
+		return ns, nil
+	}
+
+	if !isRootCertResource(resourceName) {
 		// If working as Citadel agent, send request for normal key/cert pair.
 		// If working as ingress gateway agent, fetch key/cert or root cert from SecretFetcher. Resource name for
 		// root cert ends with "-cacert".
@@ -244,7 +336,7 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 	t := time.Now()
 	ns = &model.SecretItem{
 		ResourceName: resourceName,
-		RootCert:     sc.rootCert,
+		RootCert:     sc.rootCertOf(resourceName),
 		ExpireTime:   sc.rootCertExpireTime,
 		Token:        token,
 		CreatedTime:  t,
@@ -275,7 +367,8 @@ func (sc *SecretCache) SecretExist(connectionID, resourceName, token, version st
 // and needs to wait for ingress gateway secret to be ready.
 func (sc *SecretCache) ShouldWaitForIngressGatewaySecret(connectionID, resourceName, token string) bool {
 	// If node agent works as workload agent, node agent does not expect any ingress gateway secret.
-	if sc.fetcher.UseCaClient {
+	// The file secrets are not Kubernetes secrets either.
+	if sc.fetcher.UseCaClient || sc.isFileSecret(resourceName) {
 		return false
 	}
 
@@ -345,6 +438,9 @@ func (sc *SecretCache) callbackWithTimeout(connKey ConnKey, secret *model.Secret
 
 // Close shuts down the secret cache.
 func (sc *SecretCache) Close() {
+	if sc.fileWatcher != nil {
+		_ = sc.fileWatcher.Close()
+	}
 	sc.closing <- true
 }
 
@@ -457,9 +553,14 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 		e := v.(model.SecretItem)
 		conIDresourceNamePrefix := cacheLogPrefix(connKey.ConnectionID, connKey.ResourceName)
 
+		// The file secrets are pushed when their files change.
+		if sc.isFileSecret(connKey.ResourceName) {
+			return true
+		}
+
 		// only refresh root cert if updateRootFlag is set to true.
 		if updateRootFlag {
-			if connKey.ResourceName != RootCertReqResourceName {
+			if !isRootCertResource(connKey.ResourceName) {
 				return true
 			}
 
@@ -467,7 +568,7 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 			t := time.Now()
 			ns := &model.SecretItem{
 				ResourceName: connKey.ResourceName,
-				RootCert:     sc.rootCert,
+				RootCert:     sc.rootCertOf(connKey.ResourceName),
 				ExpireTime:   sc.rootCertExpireTime,
 				Token:        e.Token,
 				CreatedTime:  t,
@@ -481,7 +582,7 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 		}
 
 		// If updateRootFlag isn't set, return directly if cached item is root cert.
-		if connKey.ResourceName == RootCertReqResourceName {
+		if isRootCertResource(connKey.ResourceName) {
 			return true
 		}
 
@@ -513,7 +614,8 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 				// If token is still valid, re-generated the secret and push change to proxy.
 				// Most likey this code path may not necessary, since TTL of cert is much longer than token.
 				// When cert has expired, we could make it simple by assuming token has already expired.
-				ns, err := sc.generateSecret(context.Background(), e.Token, connKey, now)
+				ctx := WithKeyOptions(context.Background(), KeyOptions{ECCSigAlg: e.ECCSigAlg, ECCCurve: e.ECCCurve})
+				ns, err := sc.generateSecret(ctx, e.Token, connKey, now)
 				if err != nil {
 					cacheLog.Errorf("%s failed to rotate secret: %v", conIDresourceNamePrefix, err)
 					return
@@ -593,9 +695,12 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 			" resource name. The failed jwt above is: %s", conIDresourceNamePrefix, err, token)
 		csrHostName = connKey.ResourceName
 	}
+	keyOptions := sc.keyOptions(ctx)
 	options := util.CertOptions{
 		Host:       csrHostName,
 		RSAKeySize: keySize,
+		ECSigAlg:   util.SupportedECSignatureAlgorithms(keyOptions.ECCSigAlg),
+		ECCCurve:   util.SupportedEllipticCurves(keyOptions.ECCCurve),
 	}
 
 	// Generate the cert/key, send CSR to CA.
@@ -634,6 +739,11 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 			return nil, fmt.Errorf("failed to extract expire time from server certificate in CSR response: %v", err)
 		}
 	}
+	// The requester is the workload identity of the token, not the connection ID which is not traceable.
+	if err := sc.configOptions.AuditLog.RecordCert(certChain, csrHostName, sc.configOptions.NodeName); err != nil {
+		cacheLog.Errorf("%s %v", conIDresourceNamePrefix, err)
+		return nil, err
+	}
 
 	length := len(certChainPEM)
 	sc.rootCertMutex.Lock()
@@ -661,12 +771,60 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 		PrivateKey:       keyPEM,
 		ResourceName:     connKey.ResourceName,
 		Token:            token,
+		ECCSigAlg:        keyOptions.ECCSigAlg,
+		ECCCurve:         keyOptions.ECCCurve,
 		CreatedTime:      t,
 		ExpireTime:       expireTime,
 		Version:          t.String(),
 	}, nil
 }
 
+// FederatedRootsChanged pushes the root certificates to the proxies after the trust anchors of the federated trust
+// domains changed.
+func (sc *SecretCache) FederatedRootsChanged() {
+	cacheLog.Info("Federated trust anchors have changed, start rotating root cert for SDS clients")
+	sc.rotate(true /*updateRootFlag*/)
+}
+
+// isRootCertResource returns whether the resource is a root certificate.
+func isRootCertResource(resourceName string) bool {
+	return resourceName == RootCertReqResourceName || resourceName == FederatedRootCertReqResourceName
+}
+
+// rootCertOf returns the root certificates of the root certificate resource. The federated trust anchors failing
+// to load are left out, so that their peers are rejected.
+func (sc *SecretCache) rootCertOf(resourceName string) []byte {
+	if resourceName != FederatedRootCertReqResourceName || sc.configOptions.FederatedRoots == nil {
+		return sc.rootCert
+	}
+	anchors, err := sc.configOptions.FederatedRoots()
+	if err != nil {
+		cacheLog.Errorf("failed to load the federated trust anchors: %v", err)
+	}
+	rootCert := make([]byte, 0, len(sc.rootCert)+len(anchors)+1)
+	rootCert = append(rootCert, sc.rootCert...)
+	if len(rootCert) > 0 && rootCert[len(rootCert)-1] != '\n' {
+		rootCert = append(rootCert, '\n')
+	}
+	return append(rootCert, anchors...)
+}
+
+// keyOptions returns the key type requested by the proxy in the context, the unset fields defaulting to the
+// options of the cache.
+func (sc *SecretCache) keyOptions(ctx context.Context) KeyOptions {
+	var o KeyOptions
+	if ctx != nil {
+		o, _ = ctx.Value(keyOptionsContextKey{}).(KeyOptions)
+	}
+	if o.ECCSigAlg == "" {
+		o.ECCSigAlg = sc.configOptions.ECCSigAlg
+	}
+	if o.ECCCurve == "" {
+		o.ECCCurve = sc.configOptions.ECCCurve
+	}
+	return o
+}
+
 func (sc *SecretCache) shouldRefresh(s *model.SecretItem) bool {
 	// secret should be refreshed before it expired, SecretRefreshGraceDuration is the grace period;
 	return time.Now().After(s.ExpireTime.Add(-sc.configOptions.SecretRefreshGraceDuration))
@@ -17,9 +17,14 @@ package cache
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"sync/atomic"
@@ -36,6 +41,8 @@ import (
 	"istio.io/istio/security/pkg/nodeagent/model"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
 	nodeagentutil "istio.io/istio/security/pkg/nodeagent/util"
+	"istio.io/istio/security/pkg/pki/audit"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 )
 
 var (
@@ -295,6 +302,210 @@ func testWorkloadAgentGenerateSecret(t *testing.T, isUsingPluginProvider bool) {
 	}
 }
 
+func TestWorkloadAgentGenerateSecretWithECDSA(t *testing.T) {
+	fakeCACli := mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain)
+	opt := Options{
+		SecretTTL:        time.Minute,
+		RotationInterval: 300 * time.Microsecond,
+		EvictionDuration: 2 * time.Second,
+		InitialBackoff:   10,
+		SkipValidateCert: true,
+		ECCSigAlg:        "ECDSA",
+		ECCCurve:         "P384",
+	}
+	fetcher := &secretfetcher.SecretFetcher{
+		UseCaClient: true,
+		CaClient:    fakeCACli,
+	}
+	sc := NewSecretCache(fetcher, notifyCb, opt)
+	defer sc.Close()
+
+	gotSecret, err := sc.GenerateSecret(context.Background(), "proxy1-id", testResourceName, "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+
+	key, err := pkiutil.ParsePemEncodedKey(gotSecret.PrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to parse the private key: %v", err)
+	}
+	if curve, err := pkiutil.GetEllipticCurve(key); err != nil || curve != pkiutil.P384Curve {
+		t.Errorf("Private key: got curve %q (error %v), want %q", curve, err, pkiutil.P384Curve)
+	}
+}
+
+func TestWorkloadAgentGenerateFederatedRootCert(t *testing.T) {
+	fakeCACli := mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain)
+	anchors := []byte("federated-anchors\n")
+	opt := Options{
+		SecretTTL:        time.Minute,
+		RotationInterval: 300 * time.Microsecond,
+		EvictionDuration: 2 * time.Second,
+		InitialBackoff:   10,
+		SkipValidateCert: true,
+		FederatedRoots:   func() ([]byte, error) { return anchors, nil },
+	}
+	fetcher := &secretfetcher.SecretFetcher{
+		UseCaClient: true,
+		CaClient:    fakeCACli,
+	}
+	sc := NewSecretCache(fetcher, notifyCb, opt)
+	defer sc.Close()
+
+	ctx := context.Background()
+	if _, err := sc.GenerateSecret(ctx, "proxy1-id", testResourceName, "jwtToken1"); err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	gotSecretRoot, err := sc.GenerateSecret(ctx, "proxy1-id", RootCertReqResourceName, "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	if got, want := gotSecretRoot.RootCert, []byte("rootcert"); !bytes.Equal(got, want) {
+		t.Errorf("RootCert: got: %s, want: %s", got, want)
+	}
+	gotSecretRoot, err = sc.GenerateSecret(ctx, "proxy1-id", FederatedRootCertReqResourceName, "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	if got, want := gotSecretRoot.RootCert, []byte("rootcert\nfederated-anchors\n"); !bytes.Equal(got, want) {
+		t.Errorf("RootCert: got: %s, want: %s", got, want)
+	}
+
+	// The anchors failing to load are left out.
+	sc.configOptions.FederatedRoots = func() ([]byte, error) { return nil, errors.New("invalid bundle") }
+	gotSecretRoot, err = sc.GenerateSecret(ctx, "proxy1-id", FederatedRootCertReqResourceName, "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	if got, want := gotSecretRoot.RootCert, []byte("rootcert\n"); !bytes.Equal(got, want) {
+		t.Errorf("RootCert: got: %s, want: %s", got, want)
+	}
+}
+
+func TestWorkloadAgentGenerateSecretWithProxyKeyOptions(t *testing.T) {
+	fakeCACli := mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain)
+	opt := Options{
+		SecretTTL:        time.Minute,
+		RotationInterval: 300 * time.Microsecond,
+		EvictionDuration: 2 * time.Second,
+		InitialBackoff:   10,
+		SkipValidateCert: true,
+	}
+	fetcher := &secretfetcher.SecretFetcher{
+		UseCaClient: true,
+		CaClient:    fakeCACli,
+	}
+	sc := NewSecretCache(fetcher, notifyCb, opt)
+	defer sc.Close()
+
+	ctx := WithKeyOptions(context.Background(), KeyOptions{ECCSigAlg: "ECDSA", ECCCurve: "P384"})
+	gotSecret, err := sc.GenerateSecret(ctx, "proxy1-id", testResourceName, "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+
+	key, err := pkiutil.ParsePemEncodedKey(gotSecret.PrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to parse the private key: %v", err)
+	}
+	if curve, err := pkiutil.GetEllipticCurve(key); err != nil || curve != pkiutil.P384Curve {
+		t.Errorf("Private key: got curve %q (error %v), want %q", curve, err, pkiutil.P384Curve)
+	}
+	if gotSecret.ECCSigAlg != "ECDSA" || gotSecret.ECCCurve != "P384" {
+		t.Errorf("Secret key type: got %q %q, want the key type requested by the proxy",
+			gotSecret.ECCSigAlg, gotSecret.ECCCurve)
+	}
+}
+
+type auditCAClient struct {
+	certChain []string
+}
+
+func (c *auditCAClient) CSRSign(context.Context, []byte, string, int64) ([]string, error) {
+	return c.certChain, nil
+}
+
+func TestWorkloadAgentGenerateSecretAuditLog(t *testing.T) {
+	token, err := ioutil.ReadFile("./testdata/testjwt")
+	if err != nil {
+		t.Fatalf("failed to read test jwt file %v", err)
+	}
+	id := "spiffe://cluster.local/ns/default/sa/sleep"
+	cert, _, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Host:         id,
+		TTL:          time.Hour,
+		RSAKeySize:   2048,
+		IsSelfSigned: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	sink, err := audit.NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.NewLogger(sink, signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := Options{
+		SecretTTL:        time.Minute,
+		RotationInterval: time.Minute,
+		EvictionDuration: time.Minute,
+		InitialBackoff:   10,
+		SkipValidateCert: true,
+		AuditLog:         auditLog,
+		NodeName:         "node-1",
+	}
+	fetcher := &secretfetcher.SecretFetcher{
+		UseCaClient: true,
+		CaClient:    &auditCAClient{certChain: []string{string(cert), string(cert)}},
+	}
+	sc := NewSecretCache(fetcher, notifyCb, opt)
+	defer sc.Close()
+
+	if _, err := sc.GenerateSecret(context.Background(), "proxy1-id", testResourceName, string(token)); err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []*audit.Entry
+	if err := audit.Read(f, func(e *audit.Entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Requester != id || entries[0].Node != "node-1" {
+		t.Errorf("Audit log: got entries %+v, want one entry requested by %s on node-1", entries, id)
+	}
+
+	// No certificate is returned when it can't be recorded.
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.GenerateSecret(context.Background(), "proxy2-id", testResourceName, string(token)); err == nil {
+		t.Errorf("Expected the secret generation to fail when the audit log fails")
+	}
+}
+
 func TestWorkloadAgentRefreshSecret(t *testing.T) {
 	fakeCACli := mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain)
 	opt := Options{
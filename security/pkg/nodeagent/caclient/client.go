@@ -25,6 +25,7 @@ import (
 	caClientInterface "istio.io/istio/security/pkg/nodeagent/caclient/interface"
 	citadel "istio.io/istio/security/pkg/nodeagent/caclient/providers/citadel"
 	gca "istio.io/istio/security/pkg/nodeagent/caclient/providers/google"
+	k8sca "istio.io/istio/security/pkg/nodeagent/caclient/providers/kubernetes"
 	vault "istio.io/istio/security/pkg/nodeagent/caclient/providers/vault"
 	"istio.io/pkg/env"
 	"istio.io/pkg/log"
@@ -38,15 +39,19 @@ const (
 	maxRetries    = 100
 )
 
+// KubernetesCAName is the CA provider submitting the CSRs to the Kubernetes CSR API, it needs no CA endpoint.
+const KubernetesCAName = "KubernetesCA"
+
 var namespace = env.RegisterStringVar("NAMESPACE", "istio-system", "namespace that nodeagent/citadel run in").Get()
 
 type configMap interface {
 	GetCATLSRootCert() (string, error)
 }
 
-// NewCAClient create an CA client.
+// NewCAClient create an CA client. The trust domain is only used by the Kubernetes CA client, to check the
+// identities requested by the workloads.
 func NewCAClient(endpoint, caProviderName string, tlsFlag bool, tlsRootCert []byte, vaultAddr, vaultRole,
-	vaultAuthPath, vaultSignCsrPath string) (caClientInterface.Client, error) {
+	vaultAuthPath, vaultSignCsrPath, trustDomain string) (caClientInterface.Client, error) {
 	switch caProviderName {
 	case googleCAName:
 		return gca.NewGoogleCAClient(endpoint, tlsFlag)
@@ -63,9 +68,17 @@ func NewCAClient(endpoint, caProviderName string, tlsFlag bool, tlsRootCert []by
 			return nil, err
 		}
 		return citadel.NewCitadelClient(endpoint, tlsFlag, rootCert)
+	case KubernetesCAName:
+		cs, err := kube.CreateClientset("", "")
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s clientset: %v", err)
+		}
+		return k8sca.NewKubernetesCAClient(cs.CertificatesV1beta1().CertificateSigningRequests(),
+			cs.AuthenticationV1().TokenReviews(), trustDomain), nil
 	default:
 		return nil, fmt.Errorf(
-			"CA provider %q isn't supported. Currently Istio supports %q", caProviderName, strings.Join([]string{googleCAName, citadelName, vaultCAName}, ","))
+			"CA provider %q isn't supported. Currently Istio supports %q", caProviderName,
+			strings.Join([]string{googleCAName, citadelName, vaultCAName, KubernetesCAName}, ","))
 	}
 }
 
@@ -41,7 +41,7 @@ func TestNewCAClient(t *testing.T) {
 	}{
 		"Not supported": {
 			provider:    "random",
-			expectedErr: "CA provider \"random\" isn't supported. Currently Istio supports \"GoogleCA,Citadel,VaultCA\"",
+			expectedErr: "CA provider \"random\" isn't supported. Currently Istio supports \"GoogleCA,Citadel,VaultCA,KubernetesCA\"",
 		},
 		"Google CA": {
 			provider:    googleCAName,
@@ -55,7 +55,7 @@ func TestNewCAClient(t *testing.T) {
 	}
 
 	for id, tc := range testCases {
-		_, err := NewCAClient("abc:0", tc.provider, false, nil, "", "", "", "")
+		_, err := NewCAClient("abc:0", tc.provider, false, nil, "", "", "", "", "")
 		if tc.expectedErr == "" {
 			if err != nil {
 				t.Errorf("Test case [%s]: Expect no error, got %q",
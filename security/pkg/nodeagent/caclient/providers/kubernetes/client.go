@@ -0,0 +1,278 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	authnclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"istio.io/istio/security/pkg/k8s/tokenreview"
+	caClientInterface "istio.io/istio/security/pkg/nodeagent/caclient/interface"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/env"
+	"istio.io/pkg/log"
+)
+
+const (
+	// SignerNameAnnotation is the annotation of the submitted CSRs carrying the name of the signer expected to
+	// approve and sign them. The certificates.k8s.io/v1beta1 API in use has no signerName field, external
+	// approvers and signers select the CSRs meant for them with this annotation.
+	SignerNameAnnotation = "security.istio.io/signer-name"
+
+	// DefaultSignerName is the signer name used if not configured.
+	DefaultSignerName = "istio.io/workload"
+
+	csrNamePrefix = "istio-csr-"
+	// defaultTrustDomain is the trust domain of the identities if not configured.
+	defaultTrustDomain = "cluster.local"
+	readInterval       = time.Second
+	readTimeout        = 5 * time.Minute
+)
+
+var (
+	k8sCAClientLog = log.RegisterScope("k8sCAClientLog", "Kubernetes CSR API CA client debugging", 0)
+	signerName     = env.RegisterStringVar("K8S_CSR_SIGNER_NAME", DefaultSignerName,
+		"The name of the signer expected to approve and sign the workload CSRs submitted to the Kubernetes CSR API").Get()
+	caCertPath = env.RegisterStringVar("K8S_CSR_CA_CERT_PATH", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		"The path of the root certificate of the signer of the workload CSRs submitted to the Kubernetes CSR API").Get()
+)
+
+type k8sCAClient struct {
+	certClient   certclient.CertificateSigningRequestInterface
+	tokenReviews authnclient.TokenReviewInterface
+	signerName   string
+	trustDomain  string
+	caCertPath   string
+	readInterval time.Duration
+	readTimeout  time.Duration
+}
+
+// NewKubernetesCAClient creates a CA client submitting the CSRs to the Kubernetes CSR API, for the signer set
+// by the K8S_CSR_SIGNER_NAME environment variable. The CSRs are submitted with the credentials of the node agent,
+// so the token of the workload is reviewed before submitting its CSR. The CSRs may only request identities of the
+// given trust domain, cluster.local if empty.
+func NewKubernetesCAClient(certClient certclient.CertificateSigningRequestInterface,
+	tokenReviews authnclient.TokenReviewInterface, trustDomain string) caClientInterface.Client {
+	if trustDomain == "" {
+		trustDomain = defaultTrustDomain
+	}
+	k8sCAClientLog.Infof("created Kubernetes CSR API client for signer %s", signerName)
+	return &k8sCAClient{
+		certClient:   certClient,
+		tokenReviews: tokenReviews,
+		signerName:   signerName,
+		trustDomain:  trustDomain,
+		caCertPath:   caCertPath,
+		readInterval: readInterval,
+		readTimeout:  readTimeout,
+	}
+}
+
+// CSRSign submits the CSR as a Kubernetes CSR, waits for it to be approved and signed, and returns the
+// certificate chain ending with the root certificate of the signer. The subject ID is the Kubernetes service
+// account token of the workload: the CSR is only submitted if the token is valid and the CSR only requests the
+// SPIFFE identity of the service account. The TTL can't be requested through the Kubernetes CSR API, it is
+// decided by the signer.
+func (c *k8sCAClient) CSRSign(ctx context.Context, csrPEM []byte, subjectID string,
+	certValidTTLInSec int64) ([]string /*PEM-encoded certificate chain*/, error) {
+	csr, id, err := c.authorize(csrPEM, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	csrName := csrNamePrefix + rand.String(16)
+	k8sCSR := &cert.CertificateSigningRequest{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "certificates.k8s.io/v1beta1",
+			Kind:       "CertificateSigningRequest",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        csrName,
+			Annotations: map[string]string{SignerNameAnnotation: c.signerName},
+		},
+		Spec: cert.CertificateSigningRequestSpec{
+			Request: csrPEM,
+			Usages: []cert.KeyUsage{
+				cert.UsageDigitalSignature,
+				cert.UsageKeyEncipherment,
+				cert.UsageServerAuth,
+				cert.UsageClientAuth,
+			},
+		},
+	}
+	if _, err := c.certClient.Create(k8sCSR); err != nil {
+		return nil, fmt.Errorf("failed to create CSR %s: %v", csrName, err)
+	}
+	k8sCAClientLog.Debugf("created CSR %s for signer %s", csrName, c.signerName)
+	defer func() {
+		if err := c.certClient.Delete(csrName, nil); err != nil {
+			k8sCAClientLog.Warnf("failed to delete CSR %s: %v", csrName, err)
+		}
+	}()
+
+	certPEM, err := c.waitForCertificate(ctx, csrName)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, err := ioutil.ReadFile(c.caCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the root certificate of signer %s: %v", c.signerName, err)
+	}
+	return buildCertChain(certPEM, caCert, csr, id)
+}
+
+// authorize reviews the service account token of the workload, and checks that the CSR only requests the
+// SPIFFE identity of the service account in the trust domain of the client. The parsed CSR and the SPIFFE
+// identity of the service account are returned.
+func (c *k8sCAClient) authorize(csrPEM []byte, token string) (*x509.CertificateRequest, string, error) {
+	namespace, serviceAccount, err := tokenreview.ReviewServiceAccount(c.tokenReviews, token)
+	if err != nil {
+		return nil, "", err
+	}
+	path := fmt.Sprintf("/ns/%s/sa/%s", namespace, serviceAccount)
+
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, "", err
+	}
+	ids, err := util.ExtractIDs(csr.Extensions)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to extract the identities of the CSR: %v", err)
+	}
+	for _, id := range ids {
+		u, err := url.Parse(id)
+		if err != nil || u.Scheme != "spiffe" || u.Host != c.trustDomain || u.Path != path {
+			return nil, "", fmt.Errorf("the CSR identity %s is not the identity of service account %s/%s in trust domain %s",
+				id, namespace, serviceAccount, c.trustDomain)
+		}
+	}
+	return csr, "spiffe://" + c.trustDomain + path, nil
+}
+
+// waitForCertificate waits for the CSR to be approved and signed, and returns the issued certificate.
+func (c *k8sCAClient) waitForCertificate(ctx context.Context, csrName string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.readTimeout)
+	defer cancel()
+
+	var certPEM []byte
+	var denied string
+	err := wait.PollImmediateUntil(c.readInterval, func() (bool, error) {
+		r, err := c.certClient.Get(csrName, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to get CSR %s: %v", csrName, err)
+		}
+		for _, cond := range r.Status.Conditions {
+			if cond.Type == cert.CertificateDenied {
+				denied = fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+				return true, nil
+			}
+		}
+		if len(r.Status.Certificate) > 0 {
+			certPEM = r.Status.Certificate
+			return true, nil
+		}
+		return false, nil
+	}, ctx.Done())
+	switch {
+	case err == wait.ErrWaitTimeout:
+		return nil, fmt.Errorf("timed out waiting for CSR %s to be signed by %s", csrName, c.signerName)
+	case err != nil:
+		return nil, err
+	case denied != "":
+		return nil, fmt.Errorf("CSR %s is denied (%s)", csrName, denied)
+	}
+	return certPEM, nil
+}
+
+// buildCertChain splits the issued certificates, which may already include intermediate certificates, into
+// the PEM-encoded certificate chain, verifies the chain against the root certificate of the signer, and appends
+// the root certificate unless the signer already did. The leaf certificate must hold the public key of the CSR
+// and only the requested SPIFFE identity: the signer may have issued it for another request.
+func buildCertChain(certPEM, caCert []byte, csr *x509.CertificateRequest, id string) ([]string, error) {
+	var chain []string
+	var certs []*x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in the signed CSR: %v", err)
+		}
+		chain = append(chain, string(pem.EncodeToMemory(block)))
+		certs = append(certs, c)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("invalid certificate in the signed CSR")
+	}
+	if err := checkLeaf(certs[0], csr, id); err != nil {
+		return nil, err
+	}
+
+	root, _ := pem.Decode(caCert)
+	if root == nil {
+		return nil, fmt.Errorf("invalid root certificate of the signer")
+	}
+	rootCert, err := x509.ParseCertificate(root.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root certificate of the signer: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("the signed certificate is not issued by the root certificate of the signer: %v", err)
+	}
+
+	if !certs[len(certs)-1].Equal(rootCert) {
+		chain = append(chain, string(pem.EncodeToMemory(root)))
+	}
+	return chain, nil
+}
+
+// checkLeaf checks that the leaf certificate holds the public key of the CSR and the requested SPIFFE identity
+// as its only URI SAN.
+func checkLeaf(leaf *x509.Certificate, csr *x509.CertificateRequest, id string) error {
+	if !bytes.Equal(leaf.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo) {
+		return fmt.Errorf("the signed certificate does not hold the public key of the CSR")
+	}
+	if len(leaf.URIs) != 1 || leaf.URIs[0].String() != id {
+		return fmt.Errorf("the signed certificate identities %v are not the requested identity %s", leaf.URIs, id)
+	}
+	return nil
+}
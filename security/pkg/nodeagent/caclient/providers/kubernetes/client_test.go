@@ -0,0 +1,330 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	authn "k8s.io/api/authentication/v1"
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+	ktesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type testCert struct {
+	pem  string
+	cert *x509.Certificate
+	key  crypto.PrivateKey
+}
+
+// genCert generates a certificate for the host, signed by the given signer or self-signed if nil.
+func genCert(t *testing.T, host string, isCA bool, signer *testCert) *testCert {
+	t.Helper()
+	options := util.CertOptions{
+		Host:         host,
+		TTL:          time.Hour,
+		NotBefore:    time.Now(),
+		Org:          "MyOrg",
+		IsCA:         isCA,
+		IsSelfSigned: signer == nil,
+		RSAKeySize:   1024,
+	}
+	if signer != nil {
+		options.SignerCert = signer.cert
+		options.SignerPriv = signer.key
+	}
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(options)
+	if err != nil {
+		t.Fatalf("failed to generate cert: %v", err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatalf("failed to parse cert: %v", err)
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	return &testCert{pem: string(certPEM), cert: cert, key: key}
+}
+
+func genCSR(t *testing.T, host string) []byte {
+	t.Helper()
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: host, RSAKeySize: 1024})
+	if err != nil {
+		t.Fatalf("failed to generate CSR: %v", err)
+	}
+	return csrPEM
+}
+
+// newFakeClient returns a client authenticating the token "valid" as the given user.
+func newFakeClient(user string) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		review := action.(ktesting.CreateAction).GetObject().(*authn.TokenReview)
+		if review.Spec.Token == "valid" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = user
+			review.Status.User.Groups = []string{"system:serviceaccounts", "system:authenticated"}
+		}
+		return true, review, nil
+	})
+	return client
+}
+
+// issueFor returns a function issuing the certificate of the given identity for the public key of a CSR, signed
+// by the issuer and followed by the rest of the chain.
+func issueFor(t *testing.T, issuer *testCert, id string, rest ...string) func(csrPEM []byte) string {
+	return func(csrPEM []byte) string {
+		csr, err := util.ParsePemEncodedCSR(csrPEM)
+		if err != nil {
+			t.Errorf("failed to parse CSR: %v", err)
+			return ""
+		}
+		der, err := util.GenCertFromCSR(csr, issuer.cert, csr.PublicKey, issuer.key, []string{id}, time.Hour, false)
+		if err != nil {
+			t.Errorf("failed to sign CSR: %v", err)
+			return ""
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})) + strings.Join(rest, "")
+	}
+}
+
+// fakeSigner handles the first CSR submitted for the signer, either by issuing the certificate returned by issue or
+// by denying it.
+func fakeSigner(t *testing.T, certClient certclient.CertificateSigningRequestInterface,
+	issue func(csrPEM []byte) string, deny bool) {
+	go func() {
+		for i := 0; i < 100; i++ {
+			time.Sleep(10 * time.Millisecond)
+			csrs, err := certClient.List(metav1.ListOptions{})
+			if err != nil || len(csrs.Items) == 0 {
+				continue
+			}
+			csr := &csrs.Items[0]
+			if csr.Annotations[SignerNameAnnotation] != DefaultSignerName {
+				t.Errorf("got signer name %q but want %q", csr.Annotations[SignerNameAnnotation], DefaultSignerName)
+			}
+			if deny {
+				csr.Status.Conditions = append(csr.Status.Conditions, cert.CertificateSigningRequestCondition{
+					Type:    cert.CertificateDenied,
+					Reason:  "PolicyViolation",
+					Message: "identity not allowed",
+				})
+			} else {
+				csr.Status.Conditions = append(csr.Status.Conditions, cert.CertificateSigningRequestCondition{
+					Type: cert.CertificateApproved,
+				})
+				csr.Status.Certificate = []byte(issue(csr.Spec.Request))
+			}
+			if _, err := certClient.UpdateStatus(csr); err != nil {
+				t.Errorf("failed to update CSR status: %v", err)
+			}
+			return
+		}
+	}()
+}
+
+func TestK8sCAClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k8sca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := genCert(t, "root.cluster.local", true, nil)
+	rootCert := root.pem
+	rootCertPath := filepath.Join(dir, "ca.crt")
+	if err := ioutil.WriteFile(rootCertPath, []byte(rootCert), 0644); err != nil {
+		t.Fatal(err)
+	}
+	otherRootCertPath := filepath.Join(dir, "other.crt")
+	if err := ioutil.WriteFile(otherRootCertPath, []byte(genCert(t, "other.cluster.local", true, nil).pem), 0644); err != nil {
+		t.Fatal(err)
+	}
+	intermediate := genCert(t, "intermediate.cluster.local", true, root)
+	intermediateCert := intermediate.pem
+	spiffeID := "spiffe://cluster.local/ns/foo/sa/bar"
+	otherKeyCert := genCert(t, spiffeID, false, root).pem
+
+	testCases := map[string]struct {
+		// issue returns the certificates issued for the CSR, expectedChain is the chain expected after the leaf.
+		issue         func(csrPEM []byte) string
+		deny          bool
+		noSigner      bool
+		token         string
+		user          string
+		csrHost       string
+		caCertPath    string
+		expectedChain []string
+		expectedErr   string
+		expectNoCSR   bool
+	}{
+		"Leaf certificate": {
+			issue:         issueFor(t, root, spiffeID),
+			caCertPath:    rootCertPath,
+			expectedChain: []string{rootCert},
+		},
+		"Certificate chain": {
+			issue:         issueFor(t, intermediate, spiffeID, intermediateCert),
+			caCertPath:    rootCertPath,
+			expectedChain: []string{intermediateCert, rootCert},
+		},
+		"Certificate chain with root": {
+			issue:         issueFor(t, intermediate, spiffeID, intermediateCert, rootCert),
+			caCertPath:    rootCertPath,
+			expectedChain: []string{intermediateCert, rootCert},
+		},
+		"Certificate not issued by the root certificate": {
+			issue:       issueFor(t, root, spiffeID),
+			caCertPath:  otherRootCertPath,
+			expectedErr: "is not issued by the root certificate of the signer",
+		},
+		"Certificate for another key": {
+			issue:       func([]byte) string { return otherKeyCert },
+			caCertPath:  rootCertPath,
+			expectedErr: "does not hold the public key of the CSR",
+		},
+		"Certificate for another identity": {
+			issue:       issueFor(t, root, "spiffe://cluster.local/ns/foo/sa/other"),
+			caCertPath:  rootCertPath,
+			expectedErr: "are not the requested identity spiffe://cluster.local/ns/foo/sa/bar",
+		},
+		"Certificate with another identity": {
+			issue:       issueFor(t, root, spiffeID+",spiffe://cluster.local/ns/foo/sa/other"),
+			caCertPath:  rootCertPath,
+			expectedErr: "are not the requested identity spiffe://cluster.local/ns/foo/sa/bar",
+		},
+		"Denied": {
+			deny:        true,
+			caCertPath:  rootCertPath,
+			expectedErr: "is denied (PolicyViolation: identity not allowed)",
+		},
+		"Not signed": {
+			noSigner:    true,
+			caCertPath:  rootCertPath,
+			expectedErr: "timed out waiting for CSR",
+		},
+		"Invalid certificate": {
+			issue:       func([]byte) string { return "invalid" },
+			caCertPath:  rootCertPath,
+			expectedErr: "invalid certificate in the signed CSR",
+		},
+		"Missing root certificate": {
+			issue:       issueFor(t, root, spiffeID),
+			caCertPath:  filepath.Join(dir, "missing.crt"),
+			expectedErr: "failed to read the root certificate",
+		},
+		"Invalid token": {
+			token:       "invalid",
+			noSigner:    true,
+			expectedErr: "the token is not authenticated",
+			expectNoCSR: true,
+		},
+		"Not a service account token": {
+			user:        "alice",
+			noSigner:    true,
+			expectedErr: "the token is not a service account token",
+			expectNoCSR: true,
+		},
+		"Identity of another service account": {
+			csrHost:     "spiffe://cluster.local/ns/foo/sa/other",
+			noSigner:    true,
+			expectedErr: "is not the identity of service account foo/bar",
+			expectNoCSR: true,
+		},
+		"Identity of the service account in another trust domain": {
+			csrHost:     "spiffe://other.domain/ns/foo/sa/bar",
+			noSigner:    true,
+			expectedErr: "is not the identity of service account foo/bar in trust domain cluster.local",
+			expectNoCSR: true,
+		},
+	}
+
+	for id, tc := range testCases {
+		if tc.token == "" {
+			tc.token = "valid"
+		}
+		if tc.user == "" {
+			tc.user = "system:serviceaccount:foo:bar"
+		}
+		if tc.csrHost == "" {
+			tc.csrHost = spiffeID
+		}
+		client := newFakeClient(tc.user)
+		certClient := client.CertificatesV1beta1().CertificateSigningRequests()
+		cli := &k8sCAClient{
+			certClient:   certClient,
+			tokenReviews: client.AuthenticationV1().TokenReviews(),
+			signerName:   DefaultSignerName,
+			trustDomain:  "cluster.local",
+			caCertPath:   tc.caCertPath,
+			readInterval: 10 * time.Millisecond,
+			readTimeout:  time.Second,
+		}
+		var issued string
+		if !tc.noSigner {
+			issue := tc.issue
+			fakeSigner(t, certClient, func(csrPEM []byte) string {
+				if issue == nil {
+					return ""
+				}
+				issued = issue(csrPEM)
+				return issued
+			}, tc.deny)
+		}
+
+		chain, err := cli.CSRSign(context.Background(), genCSR(t, tc.csrHost), tc.token, 3600)
+		if tc.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("%s: expected error containing %q, got %v", id, tc.expectedErr, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+		} else if len(chain) != len(tc.expectedChain)+1 || !strings.HasPrefix(issued, chain[0]) ||
+			strings.Join(chain[1:], "") != strings.Join(tc.expectedChain, "") {
+			t.Errorf("%s: got chain %v but want the issued certificate followed by %v", id, chain, tc.expectedChain)
+		}
+
+		// The CSR is deleted once handled, and never created for an unauthorized workload.
+		csrs, err := certClient.List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("%s: failed to list CSRs: %v", id, err)
+		}
+		if len(csrs.Items) != 0 {
+			t.Errorf("%s: expected the CSR to be deleted, got %v", id, csrs.Items)
+		}
+		if tc.expectNoCSR {
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "create" && action.GetResource().Resource == "certificatesigningrequests" {
+					t.Errorf("%s: expected no CSR to be created", id)
+				}
+			}
+		}
+	}
+}
@@ -37,6 +37,11 @@ type SecretItem struct {
 	// CSR to CA to sign certificate.
 	Token string
 
+	// ECCSigAlg and ECCCurve are the key type requested by the proxy, if any. They are reused to generate
+	// the key when the secret is rotated.
+	ECCSigAlg string
+	ECCCurve  string
+
 	// Version is used(together with token and ResourceName) to identify discovery request from
 	// envoy which is used only for confirm purpose.
 	Version string
@@ -52,6 +52,12 @@ const (
 	// Binary header name must has suffix "-bin", according to https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md.
 	// Same value defined in pilot pkg(k8sSAJwtTokenHeaderKey)
 	k8sSAJwtTokenHeaderKey = "istio_sds_credentials_header-bin"
+
+	// eccSigAlgMetadataKey and eccCurveMetadataKey are the node metadata keys of the key type requested by the
+	// proxy, set from the ISTIO_META_ECC_SIGNATURE_ALGORITHM and ISTIO_META_ECC_CURVE environment variables
+	// of the proxy. They override the key type configured in the node agent.
+	eccSigAlgMetadataKey = "ECC_SIGNATURE_ALGORITHM"
+	eccCurveMetadataKey  = "ECC_CURVE"
 )
 
 var (
@@ -304,7 +310,14 @@ func (s *sdsservice) StreamSecrets(stream sds.SecretDiscoveryService_StreamSecre
 				continue
 			}
 
-			secret, err := s.st.GenerateSecret(ctx, conID, resourceName, token)
+			keyOptions, err := parseKeyOptions(discReq.Node)
+			if err != nil {
+				sdsServiceLog.Errorf("%s Close connection. Invalid key type requested by proxy %q: %v",
+					conIDresourceNamePrefix, discReq.Node.Id, err)
+				return err
+			}
+
+			secret, err := s.st.GenerateSecret(cache.WithKeyOptions(ctx, keyOptions), conID, resourceName, token)
 			if err != nil {
 				sdsServiceLog.Errorf("%s Close connection. Failed to get secret for proxy %q from "+
 					"secret cache: %v", conIDresourceNamePrefix, discReq.Node.Id, err)
@@ -375,8 +388,14 @@ func (s *sdsservice) FetchSecrets(ctx context.Context, discReq *xdsapi.Discovery
 		return nil, err
 	}
 
+	keyOptions, err := parseKeyOptions(discReq.Node)
+	if err != nil {
+		sdsServiceLog.Errorf("Invalid key type requested by proxy %q: %v", discReq.Node.Id, err)
+		return nil, err
+	}
+
 	connID := constructConnectionID(discReq.Node.Id)
-	secret, err := s.st.GenerateSecret(ctx, connID, resourceName, token)
+	secret, err := s.st.GenerateSecret(cache.WithKeyOptions(ctx, keyOptions), connID, resourceName, token)
 	if err != nil {
 		sdsServiceLog.Errorf("Failed to get secret for proxy %q from secret cache: %v", connID, err)
 		return nil, err
@@ -478,6 +497,19 @@ func parseDiscoveryRequest(discReq *xdsapi.DiscoveryRequest) (string /*resourceN
 	return "", fmt.Errorf("discovery request %+v has invalid resourceNames %+v", discReq, discReq.ResourceNames)
 }
 
+// parseKeyOptions returns the key type requested by the proxy in its node metadata.
+func parseKeyOptions(node *core.Node) (cache.KeyOptions, error) {
+	fields := node.GetMetadata().GetFields()
+	o := cache.KeyOptions{
+		ECCSigAlg: fields[eccSigAlgMetadataKey].GetStringValue(),
+		ECCCurve:  fields[eccCurveMetadataKey].GetStringValue(),
+	}
+	if err := o.Validate(); err != nil {
+		return cache.KeyOptions{}, err
+	}
+	return o, nil
+}
+
 func getCredentialToken(ctx context.Context) (string, error) {
 	metadata, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
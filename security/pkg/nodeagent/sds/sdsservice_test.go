@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
+	structpb "github.com/golang/protobuf/ptypes/struct"
 	"google.golang.org/genproto/googleapis/rpc/status"
 
 	api "github.com/envoyproxy/go-control-plane/envoy/api/v2"
@@ -431,6 +432,55 @@ func getClientConID(proxyID string) string {
 	return ""
 }
 
+func TestParseKeyOptions(t *testing.T) {
+	node := func(metadata map[string]string) *core.Node {
+		fields := map[string]*structpb.Value{}
+		for k, v := range metadata {
+			fields[k] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}
+		}
+		return &core.Node{Id: "sidecar~127.0.0.1~id~local", Metadata: &structpb.Struct{Fields: fields}}
+	}
+
+	cases := []struct {
+		name     string
+		node     *core.Node
+		want     cache.KeyOptions
+		errorMsg string
+	}{
+		{
+			name: "no metadata",
+			node: &core.Node{Id: "sidecar~127.0.0.1~id~local"},
+		},
+		{
+			name: "ECDSA key",
+			node: node(map[string]string{"ECC_SIGNATURE_ALGORITHM": "ECDSA", "ECC_CURVE": "P384"}),
+			want: cache.KeyOptions{ECCSigAlg: "ECDSA", ECCCurve: "P384"},
+		},
+		{
+			name:     "unsupported curve",
+			node:     node(map[string]string{"ECC_SIGNATURE_ALGORITHM": "ECDSA", "ECC_CURVE": "P224"}),
+			errorMsg: "unsupported elliptic curve",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseKeyOptions(c.node)
+			if c.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), c.errorMsg) {
+					t.Errorf("got error %v, want error containing %q", err, c.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("got key options %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
 func TestStreamSecretsPush(t *testing.T) {
 	// reset connectionNumber since since its value is kept in memory for all unit test cases
 	// lifetime, reset since it may be updated in other test case.
@@ -119,7 +119,7 @@ func fatalf(template string, args ...interface{}) {
 
 // NewSecretFetcher returns a pointer to a newly constructed SecretFetcher instance.
 func NewSecretFetcher(ingressGatewayAgent bool, endpoint, caProviderName string, tlsFlag bool,
-	tlsRootCert []byte, vaultAddr, vaultRole, vaultAuthPath, vaultSignCsrPath string) (*SecretFetcher, error) {
+	tlsRootCert []byte, vaultAddr, vaultRole, vaultAuthPath, vaultSignCsrPath, trustDomain string) (*SecretFetcher, error) {
 	ret := &SecretFetcher{}
 
 	if ingressGatewayAgent {
@@ -133,7 +133,7 @@ func NewSecretFetcher(ingressGatewayAgent bool, endpoint, caProviderName string,
 		ret.InitWithKubeClient(cs.CoreV1())
 	} else {
 		caClient, err := ca.NewCAClient(endpoint, caProviderName, tlsFlag, tlsRootCert,
-			vaultAddr, vaultRole, vaultAuthPath, vaultSignCsrPath)
+			vaultAddr, vaultRole, vaultAuthPath, vaultSignCsrPath, trustDomain)
 		if err != nil {
 			secretFetcherLog.Errorf("failed to create caClient: %v", err)
 			return ret, fmt.Errorf("failed to create caClient")
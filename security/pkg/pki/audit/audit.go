@@ -0,0 +1,317 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+var auditLog = log.RegisterScope("audit", "Certificate issuance audit log", 0)
+
+// Entry records the issuance of a certificate. The entries are chained: each entry holds the hash of the
+// previous one, so that altering, removing or reordering entries breaks the chain.
+type Entry struct {
+	// Sequence is the position of the entry in the log, starting at 1.
+	Sequence uint64 `json:"seq"`
+
+	// Time is the time of the issuance.
+	Time time.Time `json:"time"`
+
+	// SerialNumber is the hexadecimal serial number of the certificate.
+	SerialNumber string `json:"serialNumber"`
+
+	// IDs are the identities, e.g. SPIFFE IDs, the certificate was issued to.
+	IDs []string `json:"ids"`
+
+	// Requester identifies the requester of the certificate, e.g. the identities of the authenticated caller.
+	Requester string `json:"requester"`
+
+	// Node is the node the request came from, if known.
+	Node string `json:"node,omitempty"`
+
+	// TTL is the validity duration of the certificate, e.g. "24h0m0s".
+	TTL string `json:"ttl"`
+
+	// PrevHash is the hash of the previous entry, empty for the first entry.
+	PrevHash string `json:"prevHash"`
+
+	// Hash is the hexadecimal SHA-256 hash of the entry, computed with an empty Hash and Signature.
+	Hash string `json:"hash"`
+
+	// Signature is the base64 signature of the hash by the audit signing key.
+	Signature string `json:"signature,omitempty"`
+}
+
+// NewEntry creates the entry of the issuance of the given PEM certificate. Only the first certificate of a chain
+// is recorded.
+func NewEntry(certPEM []byte, requester, node string) (*Entry, error) {
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := util.ExtractIDs(cert.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{
+		SerialNumber: cert.SerialNumber.Text(16),
+		IDs:          ids,
+		Requester:    requester,
+		Node:         node,
+		TTL:          cert.NotAfter.Sub(cert.NotBefore).String(),
+	}, nil
+}
+
+// computeHash returns the hash of the entry.
+func (e *Entry) computeHash() ([]byte, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+	unhashed.Signature = ""
+	b, err := json.Marshal(&unhashed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the audit entry: %v", err)
+	}
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
+
+// Logger records the entries in a sink, chaining and signing them. A nil Logger records nothing.
+type Logger struct {
+	sink   Sink
+	signer crypto.Signer
+
+	mutex    sync.Mutex
+	sequence uint64
+	lastHash string
+}
+
+// NewLogger creates a Logger appending to the given sink, resuming the chain from its last entry. The entries
+// are signed with the given signer, which is required: anyone able to write the log could recompute the hashes
+// of an unsigned chain after altering it.
+func NewLogger(sink Sink, signer crypto.Signer) (*Logger, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("the audit log requires a signing key")
+	}
+	last, err := sink.Last()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the last audit entry: %v", err)
+	}
+	l := &Logger{sink: sink, signer: signer}
+	if last != nil {
+		l.sequence, l.lastHash = last.Sequence, last.Hash
+	}
+	return l, nil
+}
+
+// Record chains, signs and appends the entry to the log. The sequence, the time if zero and the hashes of the
+// entry are set.
+func (l *Logger) Record(e *Entry) error {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	e.Sequence = l.sequence + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.PrevHash = l.lastHash
+	hash, err := e.computeHash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hex.EncodeToString(hash)
+	sig, err := l.signer.Sign(rand.Reader, hash, crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign the audit entry: %v", err)
+	}
+	e.Signature = base64.StdEncoding.EncodeToString(sig)
+	if err := l.sink.Append(e); err != nil {
+		return fmt.Errorf("failed to append the audit entry: %v", err)
+	}
+	l.sequence, l.lastHash = e.Sequence, e.Hash
+	return nil
+}
+
+// RecordCert records the issuance of the given PEM certificate. The caller must refuse the issuance if an error
+// is returned: a certificate missing from the log could not be traced.
+func (l *Logger) RecordCert(certPEM []byte, requester, node string) error {
+	if l == nil {
+		return nil
+	}
+	e, err := NewEntry(certPEM, requester, node)
+	if err == nil {
+		err = l.Record(e)
+	}
+	if err != nil {
+		auditLog.Errorf("failed to record the issuance of a certificate for %s: %v", requester, err)
+		return fmt.Errorf("failed to record the certificate in the audit log: %v", err)
+	}
+	return nil
+}
+
+// Verifier checks the chain, and the signatures if a public key is set, of the consecutive entries of a log.
+type Verifier struct {
+	publicKey crypto.PublicKey
+	prev      *Entry
+}
+
+// NewVerifier creates a Verifier. The signatures are verified with the given public key, if not nil.
+func NewVerifier(publicKey crypto.PublicKey) *Verifier {
+	return &Verifier{publicKey: publicKey}
+}
+
+// Verify checks the next entry of the log.
+func (v *Verifier) Verify(e *Entry) error {
+	wantSequence, wantPrevHash := uint64(1), ""
+	if v.prev != nil {
+		wantSequence, wantPrevHash = v.prev.Sequence+1, v.prev.Hash
+	}
+	if e.Sequence != wantSequence {
+		return fmt.Errorf("entry %d: expected sequence %d, entries are missing or reordered", e.Sequence, wantSequence)
+	}
+	if e.PrevHash != wantPrevHash {
+		return fmt.Errorf("entry %d: previous hash mismatch, the chain is broken", e.Sequence)
+	}
+	hash, err := e.computeHash()
+	if err != nil {
+		return err
+	}
+	if e.Hash != hex.EncodeToString(hash) {
+		return fmt.Errorf("entry %d: hash mismatch, the entry was altered", e.Sequence)
+	}
+	if v.publicKey != nil {
+		if err := verifySignature(v.publicKey, hash, e.Signature); err != nil {
+			return fmt.Errorf("entry %d: %v", e.Sequence, err)
+		}
+	}
+	v.prev = e
+	return nil
+}
+
+// ParsePublicKey parses the PEM public key verifying the signatures, or the PEM certificate holding it.
+func ParsePublicKey(keyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM-encoded public key")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the certificate: %v", err)
+		}
+		return cert.PublicKey, nil
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type for a public key: %s", block.Type)
+	}
+}
+
+func verifySignature(publicKey crypto.PublicKey, hash []byte, signature string) error {
+	if signature == "" {
+		return fmt.Errorf("missing signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash, sig); err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		var ecdsaSig struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(sig, &ecdsaSig); err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+		if !ecdsa.Verify(pub, hash, ecdsaSig.R, ecdsaSig.S) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
+
+// Filter selects the entries of a query. The empty fields match any entry.
+type Filter struct {
+	// ID matches the entries issued to the identity.
+	ID string
+	// SerialNumber matches the entry of the hexadecimal serial number, case insensitively.
+	SerialNumber string
+	// Requester matches the entries whose requester contains the string.
+	Requester string
+	// Node matches the entries of the node.
+	Node string
+	// Since and Until match the entries recorded in the time range.
+	Since time.Time
+	Until time.Time
+}
+
+// Match returns whether the entry matches the filter.
+func (f *Filter) Match(e *Entry) bool {
+	if f.ID != "" && !containsString(e.IDs, f.ID) {
+		return false
+	}
+	if f.SerialNumber != "" && !strings.EqualFold(strings.TrimLeft(f.SerialNumber, "0"), e.SerialNumber) {
+		return false
+	}
+	if f.Requester != "" && !strings.Contains(e.Requester, f.Requester) {
+		return false
+	}
+	if f.Node != "" && e.Node != f.Node {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
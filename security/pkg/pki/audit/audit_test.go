@@ -0,0 +1,228 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type memSink struct {
+	entries []*Entry
+	// err is returned by Append if set.
+	err error
+}
+
+func (s *memSink) Last() (*Entry, error) {
+	if len(s.entries) == 0 {
+		return nil, nil
+	}
+	return s.entries[len(s.entries)-1], nil
+}
+
+func (s *memSink) Append(e *Entry) error {
+	if s.err != nil {
+		return s.err
+	}
+	copied := *e
+	s.entries = append(s.entries, &copied)
+	return nil
+}
+
+func genCert(t *testing.T, id string, ttl time.Duration) []byte {
+	cert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         id,
+		TTL:          ttl,
+		RSAKeySize:   2048,
+		IsSelfSigned: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func verifyAll(entries []*Entry, publicKey crypto.PublicKey) error {
+	v := NewVerifier(publicKey)
+	for _, e := range entries {
+		copied := *e
+		if err := v.Verify(&copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestNewEntry(t *testing.T) {
+	id := "spiffe://cluster.local/ns/default/sa/default"
+	e, err := NewEntry(genCert(t, id, time.Hour), "caller", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.IDs) != 1 || e.IDs[0] != id || e.TTL != "1h0m0s" || e.SerialNumber == "" ||
+		e.Requester != "caller" || e.Node != "10.0.0.1" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if _, err := NewEntry([]byte("invalid"), "caller", ""); err == nil {
+		t.Errorf("expected an error for an invalid certificate")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		signer crypto.Signer
+	}{
+		{name: "ECDSA", signer: ecKey},
+		{name: "RSA", signer: rsaKey},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := &memSink{}
+			l, err := NewLogger(sink, tc.signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := l.RecordCert(genCert(t, "spiffe://cluster.local/ns/default/sa/default", time.Hour), "caller", ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// A new logger resumes the chain.
+			if l, err = NewLogger(sink, tc.signer); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.RecordCert(genCert(t, "spiffe://cluster.local/ns/default/sa/other", time.Hour), "other", ""); err != nil {
+				t.Fatal(err)
+			}
+			if len(sink.entries) != 3 || sink.entries[2].Sequence != 3 || sink.entries[2].PrevHash != sink.entries[1].Hash {
+				t.Fatalf("unexpected entries %+v", sink.entries)
+			}
+
+			if err := verifyAll(sink.entries, tc.signer.Public()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err := verifyAll(sink.entries, otherKey.Public()); err == nil {
+				t.Errorf("expected the signatures not to match another key")
+			}
+			unsigned := *sink.entries[0]
+			unsigned.Signature = ""
+			if err := verifyAll([]*Entry{&unsigned}, tc.signer.Public()); err == nil ||
+				!strings.Contains(err.Error(), "missing signature") {
+				t.Errorf("expected an unsigned entry to fail the signature verification, got %v", err)
+			}
+
+			altered := append([]*Entry{}, sink.entries...)
+			copied := *altered[1]
+			copied.Requester = "attacker"
+			altered[1] = &copied
+			if err := verifyAll(altered, nil); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+				t.Errorf("expected an altered entry to be detected, got %v", err)
+			}
+			removed := []*Entry{sink.entries[0], sink.entries[2]}
+			if err := verifyAll(removed, nil); err == nil || !strings.Contains(err.Error(), "missing or reordered") {
+				t.Errorf("expected a removed entry to be detected, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewLogger(&memSink{}, nil); err == nil || !strings.Contains(err.Error(), "requires a signing key") {
+		t.Errorf("expected a logger without signing key to be rejected, got %v", err)
+	}
+
+	var l *Logger
+	if err := l.Record(&Entry{}); err != nil {
+		t.Errorf("expected a nil logger to record nothing, got %v", err)
+	}
+}
+
+func TestRecordCertFailingSink(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &memSink{err: errors.New("disk full")}
+	l, err := NewLogger(sink, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := genCert(t, "spiffe://cluster.local/ns/default/sa/default", time.Hour)
+	if err := l.RecordCert(cert, "caller", ""); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the failure of the sink to be returned, got %v", err)
+	}
+
+	// The chain is not advanced by the failed entry.
+	sink.err = nil
+	if err := l.RecordCert(cert, "caller", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.entries) != 1 || sink.entries[0].Sequence != 1 || sink.entries[0].PrevHash != "" {
+		t.Errorf("unexpected entries %+v", sink.entries)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	e := &Entry{
+		Time:         now,
+		SerialNumber: "a1b2",
+		IDs:          []string{"spiffe://cluster.local/ns/default/sa/default"},
+		Requester:    "spiffe://cluster.local/ns/default/sa/default",
+		Node:         "10.0.0.1",
+	}
+	for _, tc := range []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty", filter: Filter{}, want: true},
+		{name: "ID", filter: Filter{ID: "spiffe://cluster.local/ns/default/sa/default"}, want: true},
+		{name: "other ID", filter: Filter{ID: "spiffe://cluster.local/ns/default/sa/other"}},
+		{name: "serial", filter: Filter{SerialNumber: "00A1B2"}, want: true},
+		{name: "other serial", filter: Filter{SerialNumber: "a1b3"}},
+		{name: "requester", filter: Filter{Requester: "ns/default"}, want: true},
+		{name: "node", filter: Filter{Node: "10.0.0.2"}},
+		{name: "time range", filter: Filter{Since: now.Add(-time.Hour), Until: now.Add(time.Hour)}, want: true},
+		{name: "since", filter: Filter{Since: now.Add(time.Minute)}},
+		{name: "until", filter: Filter{Until: now.Add(-time.Minute)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.Match(e); got != tc.want {
+				t.Errorf("got match %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// maxEntrySize is the maximum size of a serialized entry.
+const maxEntrySize = 1024 * 1024
+
+// Sink stores the entries of the audit log. Other sinks than a file, e.g. a remote log service, can be plugged
+// into the Logger.
+type Sink interface {
+	// Last returns the last entry of the log, or nil if the log is empty.
+	Last() (*Entry, error)
+
+	// Append appends the entry to the log durably.
+	Append(e *Entry) error
+}
+
+// FileSink appends the entries to a file, one JSON entry per line.
+type FileSink struct {
+	path  string
+	mutex sync.Mutex
+	file  *os.File
+}
+
+var _ Sink = &FileSink{}
+
+// NewFileSink creates a FileSink appending to the file at the given path, created if it does not exist.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %v", err)
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+// NewFileLogger creates a Logger appending to the file at the given path. The entries are signed with the PEM
+// private key in signingKeyFile, which is required.
+func NewFileLogger(path, signingKeyFile string) (*Logger, error) {
+	if signingKeyFile == "" {
+		return nil, fmt.Errorf("the audit log requires a signing key")
+	}
+	keyPEM, err := ioutil.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the audit signing key: %v", err)
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the audit signing key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported audit signing key type %T", key)
+	}
+	sink, err := NewFileSink(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := NewLogger(sink, signer)
+	if err != nil {
+		sink.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Last implements Sink.
+func (s *FileSink) Last() (*Entry, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var last *Entry
+	err = Read(f, func(e *Entry) error {
+		last = e
+		return nil
+	})
+	return last, err
+}
+
+// Append implements Sink. The file is synced after each entry.
+func (s *FileSink) Append(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// Read calls f for each entry of a log written by a FileSink, stopping at the first error.
+func Read(r io.Reader, f func(*Entry) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxEntrySize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return fmt.Errorf("invalid audit entry at line %d: %v", line, err)
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
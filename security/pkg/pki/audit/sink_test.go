@@ -0,0 +1,97 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, err := sink.Last(); last != nil || err != nil {
+		t.Fatalf("expected an empty log, got %v (error %v)", last, err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewLogger(sink, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RecordCert(genCert(t, "spiffe://cluster.local/ns/default/sa/default", time.Hour), "caller", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The log is appended to when reopened.
+	if sink, err = NewFileSink(path); err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if l, err = NewLogger(sink, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RecordCert(genCert(t, "spiffe://cluster.local/ns/default/sa/other", time.Hour), "other", "10.0.0.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	v := NewVerifier(key.Public())
+	var entries []*Entry
+	if err := Read(f, func(e *Entry) error {
+		entries = append(entries, e)
+		return v.Verify(e)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Node != "10.0.0.2" || entries[1].Sequence != 2 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	if _, err := NewFileLogger(filepath.Join(dir, "unsigned.log"), ""); err == nil ||
+		!strings.Contains(err.Error(), "requires a signing key") {
+		t.Errorf("expected a file logger without signing key to be rejected, got %v", err)
+	}
+
+	if err := Read(strings.NewReader("{\"seq\": 1}\nnot json\n"), func(*Entry) error { return nil }); err == nil ||
+		!strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an invalid entry to be reported, got %v", err)
+	}
+}
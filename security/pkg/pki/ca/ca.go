@@ -16,7 +16,7 @@ package ca
 
 import (
 	"context"
-	"crypto/x509"
+	"crypto"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
@@ -31,6 +31,7 @@ import (
 	"istio.io/istio/security/pkg/k8s/configmap"
 	k8ssecret "istio.io/istio/security/pkg/k8s/secret"
 	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/revocation"
 	"istio.io/istio/security/pkg/pki/util"
 	certutil "istio.io/istio/security/pkg/util"
 	"istio.io/pkg/log"
@@ -90,11 +91,13 @@ type IstioCAOptions struct {
 }
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
+// The CA key is an RSA key unless ecSigAlg is set, in which case it is an EC key on the eccCurve.
 func NewSelfSignedIstioCAOptions(ctx context.Context,
 	rootCertGracePeriodPercentile int, caCertTTL, rootCertCheckInverval, certTTL,
 	maxCertTTL time.Duration, org string, dualUse bool, namespace string,
 	readCertRetryInterval time.Duration, client corev1.CoreV1Interface,
-	rootCertFile string, enableJitter bool) (caOpts *IstioCAOptions, err error) {
+	rootCertFile string, enableJitter bool, ecSigAlg util.SupportedECSignatureAlgorithms,
+	eccCurve util.SupportedEllipticCurves) (caOpts *IstioCAOptions, err error) {
 	// For the first time the CA is up, if readSigningCertOnly is unset,
 	// it generates a self-signed key/cert pair and write it to CASecret.
 	// For subsequent restart, CA will reads key/cert from CASecret.
@@ -141,6 +144,8 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 			IsCA:         true,
 			IsSelfSigned: true,
 			RSAKeySize:   caKeySize,
+			ECSigAlg:     ecSigAlg,
+			ECCCurve:     eccCurve,
 			IsDualUse:    dualUse,
 		}
 		pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
@@ -188,31 +193,52 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 // NewPluggedCertIstioCAOptions returns a new IstioCAOptions instance using given certificate.
 func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile, rootCertFile string,
 	certTTL, maxCertTTL time.Duration, namespace string, client corev1.CoreV1Interface) (caOpts *IstioCAOptions, err error) {
-	caOpts = &IstioCAOptions{
-		CAType:     pluggedCertCA,
-		CertTTL:    certTTL,
-		MaxCertTTL: maxCertTTL,
-	}
-	if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromFile(
-		signingCertFile, signingKeyFile, certChainFile, rootCertFile); err != nil {
+	keyCertBundle, err := util.NewVerifiedKeyCertBundleFromFile(
+		signingCertFile, signingKeyFile, certChainFile, rootCertFile)
+	if err != nil {
 		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 	}
+	return newPluggedCertIstioCAOptions(keyCertBundle, certTTL, maxCertTTL, namespace, client)
+}
 
-	// Validate that the passed in signing cert can be used as CA.
-	// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
-	// validate workload certificates (i.e., where the leaf certificate is not a CA).
-	b, err := ioutil.ReadFile(signingCertFile)
+// NewExternalSignerIstioCAOptions returns a new IstioCAOptions instance using given certificate, whose private key
+// is held by an external key manager signing on behalf of the CA.
+func NewExternalSignerIstioCAOptions(certChainFile, signingCertFile, rootCertFile string, signer crypto.Signer,
+	certTTL, maxCertTTL time.Duration, namespace string, client corev1.CoreV1Interface) (caOpts *IstioCAOptions, err error) {
+	signingCertBytes, err := ioutil.ReadFile(signingCertFile)
 	if err != nil {
 		return nil, err
 	}
-	block, _ := pem.Decode(b)
-	if block == nil {
-		return nil, fmt.Errorf("invalid PEM encoded certificate")
+	certChainBytes := []byte{}
+	if len(certChainFile) != 0 {
+		if certChainBytes, err = ioutil.ReadFile(certChainFile); err != nil {
+			return nil, err
+		}
+	}
+	rootCertBytes, err := ioutil.ReadFile(rootCertFile)
+	if err != nil {
+		return nil, err
 	}
-	cert, err := x509.ParseCertificate(block.Bytes)
+	keyCertBundle, err := util.NewVerifiedKeyCertBundleFromSigner(signingCertBytes, signer, certChainBytes, rootCertBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse X.509 certificate")
+		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
+	}
+	return newPluggedCertIstioCAOptions(keyCertBundle, certTTL, maxCertTTL, namespace, client)
+}
+
+func newPluggedCertIstioCAOptions(keyCertBundle util.KeyCertBundle, certTTL, maxCertTTL time.Duration,
+	namespace string, client corev1.CoreV1Interface) (*IstioCAOptions, error) {
+	caOpts := &IstioCAOptions{
+		CAType:        pluggedCertCA,
+		CertTTL:       certTTL,
+		MaxCertTTL:    maxCertTTL,
+		KeyCertBundle: keyCertBundle,
 	}
+
+	// Validate that the passed in signing cert can be used as CA.
+	// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
+	// validate workload certificates (i.e., where the leaf certificate is not a CA).
+	cert, _, _, _ := keyCertBundle.GetAll()
 	if !cert.IsCA {
 		return nil, fmt.Errorf("certificate is not authorized to sign other certificates")
 	}
@@ -221,7 +247,7 @@ func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile
 	if len(crt) == 0 {
 		crt = caOpts.KeyCertBundle.GetRootCertPem()
 	}
-	if err = updateCertInConfigmap(namespace, client, crt); err != nil {
+	if err := updateCertInConfigmap(namespace, client, crt); err != nil {
 		pkiCaLog.Errorf("Failed to write Citadel cert to configmap (%v). Node agents will not be able to connect.", err)
 	}
 	return caOpts, nil
@@ -239,6 +265,11 @@ type IstioCA struct {
 	// rootCertRotator periodically rotates self-signed root cert for CA. It is nil
 	// if CA is not self-signed CA.
 	rootCertRotator *SelfSignedCARootCertRotator
+
+	// rootRollover applies the root rollovers to the CA. It is nil if the rollovers are not enabled.
+	rootRollover *RootRolloverController
+
+	selfSigned bool
 }
 
 // NewIstioCA returns a new IstioCA instance.
@@ -248,6 +279,7 @@ func NewIstioCA(opts *IstioCAOptions) (*IstioCA, error) {
 		maxCertTTL:    opts.MaxCertTTL,
 		keyCertBundle: opts.KeyCertBundle,
 		livenessProbe: probe.NewProbe(),
+		selfSigned:    opts.CAType == selfSignedCA,
 	}
 
 	if opts.CAType == selfSignedCA && opts.RotatorConfig.CheckInterval > time.Duration(0) {
@@ -261,6 +293,9 @@ func (ca *IstioCA) Run(stopChan chan struct{}) {
 		// Start root cert rotator in a separate goroutine.
 		go ca.rootCertRotator.Run(stopChan)
 	}
+	if ca.rootRollover != nil {
+		go ca.rootRollover.Run(stopChan)
+	}
 }
 
 // Sign takes a PEM-encoded CSR, subject IDs and lifetime, and returns a signed certificate. If forCA is true,
@@ -320,6 +355,27 @@ func (ca *IstioCA) GetCAKeyCertBundle() util.KeyCertBundle {
 	return ca.keyCertBundle
 }
 
+// CRLIssuers implements revocation.CRLIssuers: the CA issues the revocation list of its root, or during a root
+// rollover the lists of both the old and the new roots, as long as the workloads trust them both.
+func (ca *IstioCA) CRLIssuers() ([]revocation.CRLIssuer, error) {
+	if issuers, err := ca.rootRollover.crlIssuers(); err != nil || issuers != nil {
+		return issuers, err
+	}
+	issuer, err := revocation.NewCRLIssuer(ca.keyCertBundle)
+	if err != nil {
+		return nil, err
+	}
+	return []revocation.CRLIssuer{issuer}, nil
+}
+
+// CRLIssuersChanged implements revocation.CRLIssuers, notified whenever a root rollover changes the KeyCertBundle.
+func (ca *IstioCA) CRLIssuersChanged() <-chan struct{} {
+	if ca.rootRollover == nil {
+		return nil
+	}
+	return ca.rootRollover.bundleChanged
+}
+
 func updateCertInConfigmap(namespace string, client corev1.CoreV1Interface, cert []byte) error {
 	certEncoded := base64.StdEncoding.EncodeToString(cert)
 	cmc := configmap.NewController(namespace, client)
@@ -17,6 +17,7 @@ package ca
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
@@ -101,7 +102,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 		maxCertTTL, org, false, caNamespace, -1, client.CoreV1(),
-		rootCertFile, false)
+		rootCertFile, false, "", "")
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -192,7 +193,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, certTTL, maxCertTTL,
 		org, false, caNamespace, -1, client.CoreV1(),
-		rootCertFile, false)
+		rootCertFile, false, "", "")
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -262,7 +263,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, 0,
 		caCertTTL, certTTL, rootCertCheckInverval, maxCertTTL, org, false,
-		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false)
+		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false, "", "")
 	if err == nil {
 		t.Errorf("Expected error, but succeeded.")
 	} else if err.Error() != expectedErr {
@@ -281,7 +282,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel1()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, 0,
 		caCertTTL, certTTL, rootCertCheckInverval, maxCertTTL, org, false,
-		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false)
+		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false, "", "")
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -428,6 +429,50 @@ func TestSignCSRForWorkload(t *testing.T) {
 	}
 }
 
+func TestSignCSRWithECDSASelfSignedCA(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
+		0, time.Hour, time.Hour, 30*time.Minute, time.Hour, "test.ca.Org", false,
+		"default", -1, client.CoreV1(), "", false, util.EcdsaSigAlg, util.P384Curve)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating self-signed CA: %v", err)
+	}
+
+	_, caKey, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAll()
+	if curve, err := util.GetEllipticCurve(*caKey); err != nil || curve != util.P384Curve {
+		t.Errorf("Unexpected CA key curve %q (error %v)", curve, err)
+	}
+
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	cases := map[string]util.CertOptions{
+		"RSA workload key":   {Host: subjectID, RSAKeySize: 2048},
+		"ECDSA workload key": {Host: subjectID, ECSigAlg: util.EcdsaSigAlg},
+	}
+	for id, opts := range cases {
+		csrPEM, keyPEM, err := util.GenCSR(opts)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 30*time.Minute, false)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		fields := &util.VerifyFields{
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			IsCA:        false,
+			Host:        subjectID,
+		}
+		if err = util.VerifyCertificate(keyPEM, certPEM, rootCertBytes, fields); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+}
+
 func TestSignCSRForCA(t *testing.T) {
 	subjectID := "spiffe://example.com/ns/foo/sa/baz"
 	opts := util.CertOptions{
@@ -590,6 +635,75 @@ func TestSignWithCertChain(t *testing.T) {
 	}
 }
 
+// externalSigner only exposes the signing key as a crypto.Signer, like a key held by an HSM or a key manager.
+type externalSigner struct {
+	crypto.Signer
+}
+
+func TestSignWithExternalSigner(t *testing.T) {
+	rootCertFile := "../testdata/multilevelpki/root-cert.pem"
+	certChainFile := "../testdata/multilevelpki/int-cert-chain.pem"
+	signingCertFile := "../testdata/multilevelpki/int-cert.pem"
+	signingKeyFile := "../testdata/multilevelpki/int-key.pem"
+
+	keyBytes, err := ioutil.ReadFile(signingKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := util.ParsePemEncodedKey(keyBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := externalSigner{key.(crypto.Signer)}
+
+	client := fake.NewSimpleClientset()
+	if _, err := NewExternalSignerIstioCAOptions(certChainFile, "../testdata/multilevelpki/int2-cert.pem",
+		rootCertFile, signer, 30*time.Minute, time.Hour, "default", client.CoreV1()); err == nil {
+		t.Errorf("Expected an error for a signing cert not matching the signer")
+	}
+	caopts, err := NewExternalSignerIstioCAOptions(certChainFile, signingCertFile, rootCertFile, signer,
+		30*time.Minute, time.Hour, "default", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create an external signer CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating external signer CA: %v", err)
+	}
+	if _, keyPem, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); len(keyPem) != 0 {
+		t.Errorf("Expected no signing key pem, got %s", keyPem)
+	}
+
+	csrPEM, _, err := util.GenCSR(util.CertOptions{
+		Host:       "spiffe://example.com/ns/foo/sa/bar",
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, signErr := ca.Sign(csrPEM, []string{"spiffe://example.com/ns/foo/sa/bar"}, time.Hour, false)
+	if signErr != nil {
+		t.Fatalf("Failed to sign the CSR: %v", signErr)
+	}
+
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, certChainBytes, rootCertBytes := ca.GetCAKeyCertBundle().GetAllPem()
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(rootCertBytes)
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM(certChainBytes)
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		t.Errorf("Failed to verify the certificate signed by the external signer: %v", err)
+	}
+}
+
 func createCA(maxTTL time.Duration) (*IstioCA, error) {
 	// Generate root CA key and cert.
 	rootCAOpts := util.CertOptions{
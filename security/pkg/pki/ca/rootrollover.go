@@ -0,0 +1,324 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/security/pkg/pki/revocation"
+	"istio.io/istio/security/pkg/pki/rollover"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// workloadSecretType is the type of the workload secrets written by Citadel.
+	workloadSecretType = "istio.io/key-and-cert"
+
+	// maxPendingSecrets is the maximum number of outdated workload secrets listed in the rollover status.
+	maxPendingSecrets = 10
+)
+
+var rootRolloverLog = log.RegisterScope("rootRollover", "CA root cert rollover log", 0)
+
+// RootRolloverController applies the current phase of a root rollover to the KeyCertBundle of the CA, and reports
+// the propagation of the phase to the workload secrets. Workloads using SDS pick up the trust bundle and the new
+// certificates at their next certificate rotation, which is not observed: a phase only propagates once it has
+// lasted for a minimum duration giving them the time to rotate.
+type RootRolloverController struct {
+	ca                  *IstioCA
+	store               *rollover.Store
+	core                corev1.CoreV1Interface
+	configMapController *configmap.Controller
+	caSecretController  *controller.CaSecretController
+	caStorageNamespace  string
+	namespaces          []string
+	checkInterval       time.Duration
+	minPhaseDuration    time.Duration
+
+	mutex sync.RWMutex
+	// phase is the last observed phase, empty if there is no rollover.
+	phase rollover.Phase
+	// oldRootCerts is the trust bundle before the rollover, restored if it is aborted.
+	oldRootCerts []byte
+
+	// bundleChanged is notified whenever the rollover changes the KeyCertBundle of the CA.
+	bundleChanged chan struct{}
+}
+
+// EnableRootRollover enables the root rollovers of the CA, checking the rollover state in the CA storage namespace
+// at the given interval. The propagation is observed on the workload secrets of the given namespaces, all of them
+// if empty, and each phase lasts at least minPhaseDuration.
+func (ca *IstioCA) EnableRootRollover(core corev1.CoreV1Interface, caStorageNamespace string, namespaces []string,
+	checkInterval, minPhaseDuration time.Duration) *RootRolloverController {
+	ca.rootRollover = &RootRolloverController{
+		ca:                  ca,
+		store:               rollover.NewStore(core, caStorageNamespace),
+		core:                core,
+		configMapController: configmap.NewController(caStorageNamespace, core),
+		caSecretController:  controller.NewCaSecretController(core),
+		caStorageNamespace:  caStorageNamespace,
+		namespaces:          namespaces,
+		checkInterval:       checkInterval,
+		minPhaseDuration:    minPhaseDuration,
+		bundleChanged:       make(chan struct{}, 1),
+	}
+	return ca.rootRollover
+}
+
+// InProgress returns whether a rollover is in progress. The rollover then owns the KeyCertBundle of the CA, which
+// must not be reloaded from the CA secret.
+func (r *RootRolloverController) InProgress() bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.phase != "" && r.phase != rollover.PhaseCompleted
+}
+
+// Run checks the rollover state periodically until stopCh is closed.
+func (r *RootRolloverController) Run(stopCh <-chan struct{}) {
+	r.reconcile()
+	ticker := time.NewTicker(r.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.reconcile()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// reconcile applies the current phase of the rollover to the CA and reports its propagation.
+func (r *RootRolloverController) reconcile() {
+	state, err := r.store.Get()
+	if err != nil {
+		rootRolloverLog.Errorf("failed to check the root rollover: %v", err)
+		return
+	}
+	if state == nil {
+		r.clear()
+		return
+	}
+	if state.OldRootCerts == "" {
+		if state, err = r.store.RecordOldRootCerts(r.ca.keyCertBundle.GetRootCertPem()); err != nil {
+			rootRolloverLog.Errorf("failed to record the root certs before the rollover: %v", err)
+			return
+		}
+	}
+	r.setPhase(state.Phase, []byte(state.OldRootCerts))
+
+	// A completed rollover of a self-signed CA has been persisted in the CA secret, which is the source of truth
+	// again.
+	if state.Phase == rollover.PhaseCompleted && r.ca.selfSigned {
+		return
+	}
+
+	status := rollover.Status{ObservedTime: time.Now()}
+	m, err := r.store.Material()
+	if err == nil {
+		err = r.apply(state, m)
+	}
+	if err == nil {
+		err = r.observe(state, m, &status)
+	}
+	if err != nil {
+		rootRolloverLog.Errorf("root rollover in phase %s failed: %v", state.Phase, err)
+		status.Message = err.Error()
+	}
+	next, err := r.store.UpdateStatus(state.Phase, status)
+	if err != nil {
+		rootRolloverLog.Warnf("failed to report the root rollover status: %v", err)
+		return
+	}
+	if next.Phase != state.Phase {
+		rootRolloverLog.Infof("root rollover advanced from phase %s to %s", state.Phase, next.Phase)
+		r.reconcile()
+	}
+}
+
+// apply sets the KeyCertBundle of the CA for the phase of the rollover.
+func (r *RootRolloverController) apply(state *rollover.State, m *rollover.Material) error {
+	certBytes, keyBytes, certChainBytes, rootCertBytes := r.ca.keyCertBundle.GetAllPem()
+	newRootCerts := rollover.RootCerts(state.Phase, []byte(state.OldRootCerts), m.RootCert)
+	newCertBytes, newKeyBytes, newCertChainBytes := certBytes, keyBytes, certChainBytes
+	if state.Phase != rollover.PhaseDistributing {
+		newCertBytes, newKeyBytes, newCertChainBytes = m.SigningCert, m.SigningKey, m.CertChain
+	}
+
+	if !bytes.Equal(certBytes, newCertBytes) || !bytes.Equal(keyBytes, newKeyBytes) ||
+		!bytes.Equal(certChainBytes, newCertChainBytes) || !bytes.Equal(rootCertBytes, newRootCerts) {
+		if err := r.ca.keyCertBundle.VerifyAndSetAll(newCertBytes, newKeyBytes, newCertChainBytes,
+			newRootCerts); err != nil {
+			return fmt.Errorf("failed to update the CA KeyCertBundle: %v", err)
+		}
+		rootRolloverLog.Infof("CA KeyCertBundle updated for root rollover phase %s", state.Phase)
+		r.notifyBundleChanged()
+		if err := r.configMapController.InsertCATLSRootCert(base64.StdEncoding.EncodeToString(newRootCerts)); err != nil {
+			return err
+		}
+	}
+
+	if r.ca.selfSigned && state.Phase == rollover.PhaseRetiring {
+		// Persist the new root once the old one is retired, for the CA to load it on restart.
+		caSecret, err := r.caSecretController.LoadCASecretWithRetry(CASecret, r.caStorageNamespace,
+			time.Second, 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to load CA secret: %v", err)
+		}
+		if !bytes.Equal(caSecret.Data[caCertID], m.SigningCert) {
+			caSecret.Data[caCertID] = m.SigningCert
+			caSecret.Data[caPrivateKeyID] = m.SigningKey
+			if err := r.caSecretController.UpdateCASecretWithRetry(caSecret, time.Second, 10*time.Second); err != nil {
+				return fmt.Errorf("failed to update CA secret: %v", err)
+			}
+			rootRolloverLog.Info("New root cert persisted in the CA secret")
+		}
+	}
+	return nil
+}
+
+// observe counts the workload secrets up-to-date for the phase of the rollover.
+func (r *RootRolloverController) observe(state *rollover.State, m *rollover.Material, status *rollover.Status) error {
+	namespaces := r.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	selector := fields.SelectorFromSet(map[string]string{"type": workloadSecretType}).String()
+	for _, ns := range namespaces {
+		secrets, err := r.core.Secrets(ns).List(metav1.ListOptions{FieldSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list the workload secrets: %v", err)
+		}
+		for _, s := range secrets.Items {
+			if s.Type != workloadSecretType {
+				continue
+			}
+			status.Total++
+			if rollover.UpToDate(state.Phase, m, []byte(state.OldRootCerts), s.Data[RootCertID], s.Data[CertChainID]) {
+				status.Updated++
+			} else if len(status.Pending) < maxPendingSecrets {
+				status.Pending = append(status.Pending, s.Namespace+"/"+s.Name)
+			}
+		}
+	}
+	status.Propagated = status.Updated == status.Total
+	if remaining := r.minPhaseDuration - time.Since(state.LastTransitionTime); status.Propagated && remaining > 0 {
+		status.Propagated = false
+		status.Message = fmt.Sprintf("waiting %v for the workloads using SDS to rotate their certificates",
+			remaining.Round(time.Second))
+	}
+	return nil
+}
+
+func (r *RootRolloverController) setPhase(phase rollover.Phase, oldRootCerts []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.phase = phase
+	r.oldRootCerts = oldRootCerts
+}
+
+// clear restores the trust bundle of a rollover aborted before issuing from the new root.
+func (r *RootRolloverController) clear() {
+	r.mutex.Lock()
+	phase, oldRootCerts := r.phase, r.oldRootCerts
+	r.phase, r.oldRootCerts = "", nil
+	r.mutex.Unlock()
+	if phase != rollover.PhaseDistributing {
+		return
+	}
+
+	rootRolloverLog.Info("Root rollover aborted, restoring the previous root certs")
+	certBytes, keyBytes, certChainBytes, _ := r.ca.keyCertBundle.GetAllPem()
+	if err := r.ca.keyCertBundle.VerifyAndSetAll(certBytes, keyBytes, certChainBytes, oldRootCerts); err != nil {
+		rootRolloverLog.Errorf("failed to restore the previous root certs: %v", err)
+		return
+	}
+	r.notifyBundleChanged()
+	if err := r.configMapController.InsertCATLSRootCert(base64.StdEncoding.EncodeToString(oldRootCerts)); err != nil {
+		rootRolloverLog.Errorf("failed to restore the previous root certs: %v", err)
+	}
+}
+
+func (r *RootRolloverController) notifyBundleChanged() {
+	select {
+	case r.bundleChanged <- struct{}{}:
+	default:
+	}
+}
+
+// crlIssuers returns the issuers of the revocation lists while both the old and the new roots of a self-signed CA
+// are trusted, or nil if only the root of the KeyCertBundle is. The old root keeps signing until the new one
+// issues, and stays in the CA secret until it is retired.
+func (r *RootRolloverController) crlIssuers() ([]revocation.CRLIssuer, error) {
+	if r == nil || !r.ca.selfSigned {
+		return nil, nil
+	}
+	r.mutex.RLock()
+	phase := r.phase
+	r.mutex.RUnlock()
+	if phase != rollover.PhaseDistributing && phase != rollover.PhaseIssuing {
+		return nil, nil
+	}
+
+	caSecret, err := r.caSecretController.LoadCASecretWithRetry(CASecret, r.caStorageNamespace,
+		time.Second, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA secret: %v", err)
+	}
+	oldIssuer, err := newCRLIssuer(caSecret.Data[caCertID], caSecret.Data[caPrivateKeyID])
+	if err != nil {
+		return nil, fmt.Errorf("invalid old root: %v", err)
+	}
+	m, err := r.store.Material()
+	if err != nil {
+		return nil, err
+	}
+	newIssuer, err := newCRLIssuer(m.SigningCert, m.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new root: %v", err)
+	}
+	return []revocation.CRLIssuer{oldIssuer, newIssuer}, nil
+}
+
+func newCRLIssuer(certPEM, keyPEM []byte) (revocation.CRLIssuer, error) {
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return revocation.CRLIssuer{}, err
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		return revocation.CRLIssuer{}, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return revocation.CRLIssuer{}, fmt.Errorf("the private key of type %T can't sign the CRL", key)
+	}
+	return revocation.CRLIssuer{Cert: cert, Key: signer}, nil
+}
@@ -0,0 +1,319 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/revocation"
+	"istio.io/istio/security/pkg/pki/rollover"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func newRolloverTestCA(t *testing.T) (*IstioCA, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewPluggedCertIstioCAOptions("../testdata/multilevelpki/int-cert-chain.pem",
+		"../testdata/multilevelpki/int-cert.pem", "../testdata/multilevelpki/int-key.pem",
+		"../testdata/multilevelpki/root-cert.pem", time.Hour, 24*time.Hour, "istio-system", client.CoreV1())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, client
+}
+
+func newRolloverMaterial(t *testing.T) *rollover.Material {
+	cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          24 * time.Hour,
+		Org:          "cluster.local",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &rollover.Material{SigningCert: cert, SigningKey: key, RootCert: cert}
+}
+
+// writeWorkloadSecret writes the workload secret as the secret controller does, with a certificate issued by the
+// current CA certificate and the current trust bundle.
+func writeWorkloadSecret(t *testing.T, ca *IstioCA, client *fake.Clientset) {
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: "spiffe://cluster.local/ns/default/sa/default", RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := ca.SignWithCertChain(csrPEM, []string{"spiffe://cluster.local/ns/default/sa/default"}, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio.default", Namespace: "default"},
+		Data: map[string][]byte{
+			CertChainID: chain,
+			RootCertID:  ca.GetCAKeyCertBundle().GetRootCertPem(),
+		},
+		Type: workloadSecretType,
+	}
+	if _, err := client.CoreV1().Secrets("default").Update(secret); err != nil {
+		if _, err := client.CoreV1().Secrets("default").Create(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkRolloverState(t *testing.T, store *rollover.Store, phase rollover.Phase, propagated bool) {
+	t.Helper()
+	state, err := store.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Phase != phase || state.Status.Propagated != propagated || state.Status.Message != "" {
+		t.Fatalf("expected phase %s propagated %v, got %+v", phase, propagated, state)
+	}
+}
+
+func TestRootRollover(t *testing.T) {
+	ca, client := newRolloverTestCA(t)
+	oldSigningCert, _, _, oldRootCerts := ca.GetCAKeyCertBundle().GetAllPem()
+	r := ca.EnableRootRollover(client.CoreV1(), "istio-system", nil, time.Minute, 0)
+	writeWorkloadSecret(t, ca, client)
+
+	r.reconcile()
+	if r.InProgress() {
+		t.Errorf("expected no rollover in progress")
+	}
+
+	store := rollover.NewStore(client.CoreV1(), "istio-system")
+	m := newRolloverMaterial(t)
+	if _, err := store.Start(m, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new root is distributed, the CA still issues from the old one.
+	r.reconcile()
+	if !r.InProgress() {
+		t.Errorf("expected a rollover in progress")
+	}
+	signingCert, _, _, rootCerts := ca.GetCAKeyCertBundle().GetAllPem()
+	if !bytes.Equal(signingCert, oldSigningCert) ||
+		!bytes.Equal(rootCerts, rollover.RootCerts(rollover.PhaseDistributing, oldRootCerts, m.RootCert)) {
+		t.Errorf("expected the combined bundle and the old signing cert, got root certs\n%s", rootCerts)
+	}
+	checkRolloverState(t, store, rollover.PhaseDistributing, false)
+	writeWorkloadSecret(t, ca, client)
+	r.reconcile()
+	checkRolloverState(t, store, rollover.PhaseDistributing, true)
+
+	// The CA issues from the new root.
+	if _, err := store.Advance(false); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	if signingCert, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); !bytes.Equal(signingCert, m.SigningCert) {
+		t.Errorf("expected the new signing cert")
+	}
+	checkRolloverState(t, store, rollover.PhaseIssuing, false)
+	writeWorkloadSecret(t, ca, client)
+	r.reconcile()
+	checkRolloverState(t, store, rollover.PhaseIssuing, true)
+
+	// The old root is retired.
+	if _, err := store.Advance(false); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	if rootCerts := ca.GetCAKeyCertBundle().GetRootCertPem(); !bytes.Equal(rootCerts, m.RootCert) {
+		t.Errorf("expected the new root only, got\n%s", rootCerts)
+	}
+	checkRolloverState(t, store, rollover.PhaseRetiring, false)
+	writeWorkloadSecret(t, ca, client)
+	r.reconcile()
+	checkRolloverState(t, store, rollover.PhaseRetiring, true)
+
+	if _, err := store.Advance(false); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	if r.InProgress() {
+		t.Errorf("expected the rollover to be completed")
+	}
+	// The plugged-in CA keeps the new key and certificates until the rollover is finished.
+	if signingCert, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); !bytes.Equal(signingCert, m.SigningCert) {
+		t.Errorf("expected the new signing cert once completed")
+	}
+}
+
+func TestRootRolloverAbort(t *testing.T) {
+	ca, client := newRolloverTestCA(t)
+	oldRootCerts := ca.GetCAKeyCertBundle().GetRootCertPem()
+	r := ca.EnableRootRollover(client.CoreV1(), "istio-system", []string{"default"}, time.Minute, time.Hour)
+
+	store := rollover.NewStore(client.CoreV1(), "istio-system")
+	if _, err := store.Start(newRolloverMaterial(t), true); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	if bytes.Equal(ca.GetCAKeyCertBundle().GetRootCertPem(), oldRootCerts) {
+		t.Errorf("expected the new root to be distributed")
+	}
+	// Without workload secret, the automatic rollover waits for the workloads using SDS.
+	state, err := store.Get()
+	if err != nil || state.Phase != rollover.PhaseDistributing || state.Status.Propagated ||
+		!strings.Contains(state.Status.Message, "workloads using SDS") {
+		t.Errorf("expected the rollover to wait for the minimum phase duration, got %+v (error %v)", state, err)
+	}
+
+	if err := store.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	if r.InProgress() {
+		t.Errorf("expected no rollover in progress")
+	}
+	if !bytes.Equal(ca.GetCAKeyCertBundle().GetRootCertPem(), oldRootCerts) {
+		t.Errorf("expected the old root certs to be restored")
+	}
+}
+
+type fakeCRLWriter struct {
+	mu  sync.Mutex
+	crl string
+}
+
+func (w *fakeCRLWriter) InsertCRL(value string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.crl = value
+	return nil
+}
+
+// waitForCRLs waits for the published value to hold one revocation list per given CA certificate, signed by it and
+// revoking the given serial number.
+func waitForCRLs(t *testing.T, w *fakeCRLWriter, serial string, caCertsPEM ...[]byte) {
+	t.Helper()
+	var err error
+	for i := 0; i < 100; i++ {
+		w.mu.Lock()
+		crls := w.crl
+		w.mu.Unlock()
+		if err = checkCRLs(crls, serial, caCertsPEM); err == nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("unexpected CRLs: %v", err)
+}
+
+func checkCRLs(crlsPEM, serial string, caCertsPEM [][]byte) error {
+	var crls []*pkix.CertificateList
+	for rest := []byte(crlsPEM); ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		crl, err := x509.ParseDERCRL(block.Bytes)
+		if err != nil {
+			return err
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) != len(caCertsPEM) {
+		return fmt.Errorf("got %d CRLs, want %d", len(crls), len(caCertsPEM))
+	}
+	for i, crl := range crls {
+		caCert, err := util.ParsePemEncodedCertificate(caCertsPEM[i])
+		if err != nil {
+			return err
+		}
+		if err := caCert.CheckCRLSignature(crl); err != nil {
+			return fmt.Errorf("CRL %d is not signed by its CA: %v", i, err)
+		}
+		revoked := crl.TBSCertList.RevokedCertificates
+		if len(revoked) != 1 || revoked[0].SerialNumber.Text(16) != serial {
+			return fmt.Errorf("CRL %d revokes %v, want %s", i, revoked, serial)
+		}
+	}
+	return nil
+}
+
+// TestRootRolloverCRL checks that both the old and the new roots of a self-signed CA publish the revocation list
+// as long as the workloads trust them both, so that the peers issued by either of them can be validated.
+func TestRootRolloverCRL(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), 0, 24*time.Hour, time.Hour, time.Hour,
+		24*time.Hour, "cluster.local", false, "istio-system", -1, client.CoreV1(), "", false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldRootCert := ca.GetCAKeyCertBundle().GetRootCertPem()
+	r := ca.EnableRootRollover(client.CoreV1(), "istio-system", nil, time.Minute, 0)
+
+	d, err := revocation.Parse([]byte(`serialNumbers: ["ff00"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	denyList := revocation.NewStore()
+	denyList.Set(d)
+	w := &fakeCRLWriter{}
+	stop := make(chan struct{})
+	defer close(stop)
+	go denyList.PublishCRL(ca, w, revocation.DefaultCRLValidity, revocation.DefaultCRLRefreshPeriod, stop)
+	waitForCRLs(t, w, "ff00", oldRootCert)
+
+	store := rollover.NewStore(client.CoreV1(), "istio-system")
+	m := newRolloverMaterial(t)
+	if _, err := store.Start(m, false); err != nil {
+		t.Fatal(err)
+	}
+	r.reconcile()
+	waitForCRLs(t, w, "ff00", oldRootCert, m.RootCert)
+
+	for _, phase := range []rollover.Phase{rollover.PhaseIssuing, rollover.PhaseRetiring} {
+		writeWorkloadSecret(t, ca, client)
+		r.reconcile()
+		if _, err := store.Advance(false); err != nil {
+			t.Fatal(err)
+		}
+		r.reconcile()
+		checkRolloverState(t, store, phase, false)
+		if phase == rollover.PhaseIssuing {
+			// The issuers are unchanged, the CA only switches to the new root.
+			waitForCRLs(t, w, "ff00", oldRootCert, m.RootCert)
+		}
+	}
+	// The old root is retired, only the new root is trusted.
+	waitForCRLs(t, w, "ff00", m.RootCert)
+}
@@ -25,6 +25,7 @@ import (
 
 	"istio.io/istio/security/pkg/k8s/configmap"
 	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/security/pkg/pki/rollover"
 	"istio.io/istio/security/pkg/pki/util"
 	certutil "istio.io/istio/security/pkg/util"
 	"istio.io/pkg/log"
@@ -108,6 +109,10 @@ func (rotator *SelfSignedCARootCertRotator) Run(rootCertRotatorChan chan struct{
 // checkAndRotateRootCert decides whether root cert should be refreshed, and rotates
 // root cert for self-signed Citadel.
 func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCert() {
+	if rotator.ca.rootRollover.InProgress() {
+		rootCertRotatorLog.Info("Root rollover in progress, skip root cert rotation job")
+		return
+	}
 	caSecret, scrtErr := rotator.caSecretController.LoadCASecretWithRetry(CASecret,
 		rotator.config.caStorageNamespace, rotator.config.retryInterval, 30*time.Second)
 
@@ -166,6 +171,10 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 	}
 
 	rootCertRotatorLog.Infof("Refresh root certificate, root cert is about to expire: %s", err.Error())
+	if rotator.ca.rootRollover != nil {
+		rotator.startRootRollover()
+		return
+	}
 	options := util.CertOptions{
 		TTL:           rotator.config.caCertTTL,
 		SignerPrivPem: caSecret.Data[caPrivateKeyID],
@@ -206,6 +215,44 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 	rootCertRotatorLog.Info("Root certificate rotation is completed successfully.")
 }
 
+// startRootRollover starts an automatic rollover to a new root certificate with a new key, instead of replacing the
+// root certificate in place.
+func (rotator *SelfSignedCARootCertRotator) startRootRollover() {
+	options := util.CertOptions{
+		TTL:          rotator.config.caCertTTL,
+		Org:          rotator.config.org,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   caKeySize,
+		IsDualUse:    rotator.config.dualUse,
+	}
+	if _, privKey, _, _ := rotator.ca.GetCAKeyCertBundle().GetAll(); privKey != nil {
+		if curve, err := util.GetEllipticCurve(*privKey); err == nil {
+			options.ECSigAlg = util.EcdsaSigAlg
+			options.ECCCurve = curve
+		}
+	}
+	pemCert, pemKey, err := util.GenCertKeyFromOptions(options)
+	if err != nil {
+		rootCertRotatorLog.Errorf("unable to generate CA cert and key for the root rollover: %s", err.Error())
+		return
+	}
+	pemRootCerts, err := util.AppendRootCerts(pemCert, rotator.config.rootCertFile)
+	if err != nil {
+		rootCertRotatorLog.Errorf("failed to append root certificates: %s", err.Error())
+		return
+	}
+	if _, err := rotator.ca.rootRollover.store.Start(&rollover.Material{
+		SigningCert: pemCert,
+		SigningKey:  pemKey,
+		RootCert:    pemRootCerts,
+	}, true); err != nil {
+		rootCertRotatorLog.Errorf("failed to start the root rollover: %s", err.Error())
+		return
+	}
+	rootCertRotatorLog.Info("Automatic root rollover to a new root certificate has started.")
+}
+
 // updateRootCertificate updates root certificate in istio-ca-secret, keycertbundle and configmap. It takes a scrt
 // object, cert, and key, and a flag rollForward indicating whether this update is to roll forward root certificate or
 // to roll backward.
@@ -0,0 +1,216 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package federation implements the SPIFFE trust bundles used to federate the mesh with foreign trust domains,
+// which have their own roots of trust. Refer to
+// [SPIFFE Trust Domain and Bundle](https://github.com/spiffe/spiffe/blob/master/standards/SPIFFE_Trust_Domain_and_Bundle.md)
+package federation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// x509SVIDUse is the "use" of the JSON Web Keys holding the X.509 SVID roots of a trust domain.
+	x509SVIDUse = "x509-svid"
+
+	pemCertificateType = "CERTIFICATE"
+)
+
+// Bundle is the trust bundle of a trust domain, i.e. the root certificates of its X.509 SVIDs.
+type Bundle struct {
+	// TrustDomain is the name of the trust domain, e.g. "partner.com".
+	TrustDomain string
+
+	// RootCerts are the root certificates of the X.509 SVIDs of the trust domain.
+	RootCerts []*x509.Certificate
+
+	// RefreshHint is how often the consumers of the bundle should check for updates. Zero if not set.
+	RefreshHint time.Duration
+}
+
+// spiffeBundle is the SPIFFE bundle format, a JSON Web Key Set with SPIFFE specific parameters.
+type spiffeBundle struct {
+	Keys        []jose.JSONWebKey `json:"keys"`
+	Sequence    uint64            `json:"spiffe_sequence,omitempty"`
+	RefreshHint int64             `json:"spiffe_refresh_hint,omitempty"`
+}
+
+// NewBundle creates the bundle of the given trust domain from its PEM-encoded root certificates.
+func NewBundle(trustDomain string, rootCertsPEM []byte) (*Bundle, error) {
+	var certs []*x509.Certificate
+	for rest := bytes.TrimSpace(rootCertsPEM); len(rest) > 0; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("invalid PEM-encoded root certificates of trust domain %s", trustDomain)
+		}
+		if block.Type != pemCertificateType {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root certificate of trust domain %s: %v", trustDomain, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no root certificate for trust domain %s", trustDomain)
+	}
+	return &Bundle{TrustDomain: trustDomain, RootCerts: certs}, nil
+}
+
+// ParseBundle parses the bundle of the given trust domain, either in the SPIFFE bundle format or as PEM-encoded
+// root certificates.
+func ParseBundle(trustDomain string, in []byte) (*Bundle, error) {
+	in = bytes.TrimSpace(in)
+	if !bytes.HasPrefix(in, []byte("{")) {
+		return NewBundle(trustDomain, in)
+	}
+
+	var sb spiffeBundle
+	if err := json.Unmarshal(in, &sb); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE bundle of trust domain %s: %v", trustDomain, err)
+	}
+	b := &Bundle{
+		TrustDomain: trustDomain,
+		RefreshHint: time.Duration(sb.RefreshHint) * time.Second,
+	}
+	for _, k := range sb.Keys {
+		// The JWT SVID keys are not used by the mesh.
+		if k.Use != x509SVIDUse {
+			continue
+		}
+		if len(k.Certificates) != 1 {
+			return nil, fmt.Errorf("invalid SPIFFE bundle of trust domain %s: an X.509 SVID key must have "+
+				"exactly one certificate but got %d", trustDomain, len(k.Certificates))
+		}
+		b.RootCerts = append(b.RootCerts, k.Certificates[0])
+	}
+	if len(b.RootCerts) == 0 {
+		return nil, fmt.Errorf("no root certificate for trust domain %s", trustDomain)
+	}
+	return b, nil
+}
+
+// Marshal returns the bundle in the SPIFFE bundle format.
+func (b *Bundle) Marshal() ([]byte, error) {
+	sb := spiffeBundle{
+		Keys:        make([]jose.JSONWebKey, 0, len(b.RootCerts)),
+		RefreshHint: int64(b.RefreshHint / time.Second),
+	}
+	for _, cert := range b.RootCerts {
+		sb.Keys = append(sb.Keys, jose.JSONWebKey{
+			Key:          cert.PublicKey,
+			Use:          x509SVIDUse,
+			Certificates: []*x509.Certificate{cert},
+		})
+	}
+	return json.MarshalIndent(sb, "", "  ")
+}
+
+// RootCertsPEM returns the PEM-encoded root certificates of the bundle.
+func (b *Bundle) RootCertsPEM() []byte {
+	var out []byte
+	for _, cert := range b.RootCerts {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemCertificateType, Bytes: cert.Raw})...)
+	}
+	return out
+}
+
+// TrustAnchorsPEM returns the PEM-encoded root certificates of the bundle re-issued with a name constraint only
+// permitting the SPIFFE identities of the trust domain, so that they can be trusted along with the roots of other
+// trust domains without vouching for their identities. The re-issued roots keep the subject, the key identifier
+// and the public key of the roots, so they validate the same chains. The signature of a trust anchor is not
+// verified, so they are signed by throwaway keys.
+func (b *Bundle) TrustAnchorsPEM() ([]byte, error) {
+	var out []byte
+	for _, root := range b.RootCerts {
+		// The verifiers only take a certificate for self-signed if its signature algorithm matches its key.
+		key, err := throwawayKey(root.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the signing key of the trust anchor of trust domain %s: %v",
+				b.TrustDomain, err)
+		}
+		keyUsage := root.KeyUsage
+		if keyUsage == 0 {
+			keyUsage = x509.KeyUsageCertSign
+		}
+		template := &x509.Certificate{
+			SerialNumber:                root.SerialNumber,
+			RawSubject:                  root.RawSubject,
+			NotBefore:                   root.NotBefore,
+			NotAfter:                    root.NotAfter,
+			KeyUsage:                    keyUsage,
+			BasicConstraintsValid:       true,
+			IsCA:                        true,
+			MaxPathLen:                  root.MaxPathLen,
+			MaxPathLenZero:              root.MaxPathLenZero,
+			SubjectKeyId:                root.SubjectKeyId,
+			PermittedURIDomains:         []string{b.TrustDomain},
+			PermittedDNSDomainsCritical: true,
+		}
+		// The issuer is the subject of the root, so that the anchor is self-issued as the root is.
+		parent := &x509.Certificate{
+			RawSubject:   root.RawSubject,
+			SubjectKeyId: root.SubjectKeyId,
+			PublicKey:    key.Public(),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, root.PublicKey, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the trust anchor of trust domain %s: %v", b.TrustDomain, err)
+		}
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemCertificateType, Bytes: der})...)
+	}
+	return out, nil
+}
+
+// throwawayKey generates a private key of the same type as the given public key.
+func throwawayKey(pub interface{}) (crypto.Signer, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.GenerateKey(rand.Reader, k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return ecdsa.GenerateKey(k.Curve, rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// Equal returns whether the two bundles have the same root certificates.
+func (b *Bundle) Equal(o *Bundle) bool {
+	if b == nil || o == nil {
+		return b == o
+	}
+	if b.TrustDomain != o.TrustDomain || len(b.RootCerts) != len(o.RootCerts) {
+		return false
+	}
+	for i := range b.RootCerts {
+		if !b.RootCerts[i].Equal(o.RootCerts[i]) {
+			return false
+		}
+	}
+	return true
+}
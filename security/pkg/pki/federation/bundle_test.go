@@ -0,0 +1,181 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func genRootCert(t *testing.T, org string, ec bool) []byte {
+	t.Helper()
+	opts := util.CertOptions{
+		TTL:          time.Hour,
+		NotBefore:    time.Now(),
+		Org:          org,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	}
+	if ec {
+		opts.ECSigAlg = util.EcdsaSigAlg
+	}
+	certPEM, _, err := util.GenCertKeyFromOptions(opts)
+	if err != nil {
+		t.Fatalf("failed to generate root certificate: %v", err)
+	}
+	return certPEM
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	rootCerts := append(genRootCert(t, "rsa.partner.com", false), genRootCert(t, "ec.partner.com", true)...)
+	b, err := NewBundle("partner.com", rootCerts)
+	if err != nil {
+		t.Fatalf("failed to create the bundle: %v", err)
+	}
+	b.RefreshHint = time.Minute
+
+	out, err := b.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal the bundle: %v", err)
+	}
+	for _, s := range []string{`"use": "x509-svid"`, `"x5c"`, `"spiffe_refresh_hint": 60`} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("expected %s in the SPIFFE bundle:\n%s", s, out)
+		}
+	}
+
+	parsed, err := ParseBundle("partner.com", out)
+	if err != nil {
+		t.Fatalf("failed to parse the SPIFFE bundle: %v", err)
+	}
+	if !parsed.Equal(b) || parsed.RefreshHint != time.Minute {
+		t.Errorf("got bundle %+v but want %+v", parsed, b)
+	}
+	if !bytes.Equal(parsed.RootCertsPEM(), rootCerts) {
+		t.Errorf("got root certificates %s but want %s", parsed.RootCertsPEM(), rootCerts)
+	}
+
+	parsed, err = ParseBundle("partner.com", rootCerts)
+	if err != nil {
+		t.Fatalf("failed to parse the PEM bundle: %v", err)
+	}
+	if !parsed.Equal(b) {
+		t.Errorf("got bundle %+v but want %+v", parsed, b)
+	}
+}
+
+func TestParseBundleErrors(t *testing.T) {
+	testCases := map[string]struct {
+		in     string
+		errMsg string
+	}{
+		"empty": {
+			in:     "",
+			errMsg: "no root certificate for trust domain partner.com",
+		},
+		"invalid PEM": {
+			in:     "not a certificate",
+			errMsg: "invalid PEM-encoded root certificates",
+		},
+		"invalid JSON": {
+			in:     "{keys",
+			errMsg: "invalid SPIFFE bundle",
+		},
+		"no X.509 SVID key": {
+			in:     `{"keys": [{"kty": "oct", "k": "c2VjcmV0", "use": "jwt-svid"}]}`,
+			errMsg: "no root certificate for trust domain partner.com",
+		},
+	}
+
+	for id, tc := range testCases {
+		if _, err := ParseBundle("partner.com", []byte(tc.in)); err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+			t.Errorf("%s: expected error containing %q, got %v", id, tc.errMsg, err)
+		}
+	}
+}
+
+func TestTrustAnchorsPEM(t *testing.T) {
+	rootPEM, rootKeyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		NotBefore:    time.Now(),
+		Org:          "partner.com",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate root certificate: %v", err)
+	}
+	root, err := util.ParsePemEncodedCertificate(rootPEM)
+	if err != nil {
+		t.Fatalf("failed to parse root certificate: %v", err)
+	}
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPEM)
+	if err != nil {
+		t.Fatalf("failed to parse root key: %v", err)
+	}
+	genLeaf := func(id string) *x509.Certificate {
+		certPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Host:       id,
+			TTL:        time.Hour,
+			NotBefore:  time.Now(),
+			SignerCert: root,
+			SignerPriv: rootKey,
+			IsClient:   true,
+			RSAKeySize: 1024,
+		})
+		if err != nil {
+			t.Fatalf("failed to generate the certificate of %s: %v", id, err)
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		if err != nil {
+			t.Fatalf("failed to parse the certificate of %s: %v", id, err)
+		}
+		return cert
+	}
+
+	b, err := NewBundle("partner.com", rootPEM)
+	if err != nil {
+		t.Fatalf("failed to create the bundle: %v", err)
+	}
+	anchorsPEM, err := b.TrustAnchorsPEM()
+	if err != nil {
+		t.Fatalf("failed to create the trust anchors: %v", err)
+	}
+	anchors := x509.NewCertPool()
+	if !anchors.AppendCertsFromPEM(anchorsPEM) {
+		t.Fatalf("invalid trust anchors %s", anchorsPEM)
+	}
+	opts := x509.VerifyOptions{Roots: anchors, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+
+	if _, err := genLeaf("spiffe://partner.com/ns/foo/sa/bar").Verify(opts); err != nil {
+		t.Errorf("the identity of the trust domain is rejected: %v", err)
+	}
+	forged := genLeaf("spiffe://cluster.local/ns/foo/sa/bar")
+	if _, err := forged.Verify(opts); err == nil {
+		t.Errorf("the identity of another trust domain is accepted")
+	}
+	opts.Roots = x509.NewCertPool()
+	opts.Roots.AddCert(root)
+	if _, err := forged.Verify(opts); err != nil {
+		t.Errorf("the identity of another trust domain is rejected by the unconstrained root: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/spiffe"
+)
+
+// BundleKey is the key of the trust bundle in the bundle ConfigMaps.
+const BundleKey = "bundle"
+
+// Config is the configuration of the foreign trust domains federated with the mesh.
+type Config struct {
+	// TrustDomains are the federated trust domains.
+	TrustDomains []*TrustDomain `json:"trustDomains"`
+}
+
+// TrustDomain is a federated trust domain and the source of its trust bundle. Exactly one source must be set.
+type TrustDomain struct {
+	// Name is the name of the trust domain, e.g. "partner.com".
+	Name string `json:"name"`
+
+	// BundleFile is the path of a file holding the bundle.
+	BundleFile string `json:"bundleFile,omitempty"`
+
+	// BundleConfigMap is the "<namespace>/<name>" of a ConfigMap holding the bundle under the BundleKey key.
+	BundleConfigMap string `json:"bundleConfigMap,omitempty"`
+
+	// BundleEndpoint is the https URL of the bundle endpoint of the trust domain. The endpoint is authenticated
+	// with the system root certificates, since the bundle can't authenticate its own source.
+	BundleEndpoint string `json:"bundleEndpoint,omitempty"`
+}
+
+// LoadConfig returns a new Config decoded and validated from the input YAML.
+func LoadConfig(in []byte) (*Config, error) {
+	out := &Config{}
+	if err := yaml.Unmarshal(in, out); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the federation configuration: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, td := range out.TrustDomains {
+		if td.Name == "" {
+			return nil, fmt.Errorf("trust domain name must be set")
+		}
+		if strings.HasPrefix(td.Name, spiffe.URIPrefix) || strings.Contains(td.Name, "/") {
+			return nil, fmt.Errorf("invalid trust domain name %q", td.Name)
+		}
+		if names[td.Name] {
+			return nil, fmt.Errorf("duplicate trust domain %q", td.Name)
+		}
+		names[td.Name] = true
+
+		sources := 0
+		if td.BundleFile != "" {
+			sources++
+		}
+		if td.BundleConfigMap != "" {
+			sources++
+			if _, _, err := splitConfigMap(td.BundleConfigMap); err != nil {
+				return nil, fmt.Errorf("trust domain %q: %v", td.Name, err)
+			}
+		}
+		if td.BundleEndpoint != "" {
+			sources++
+			u, err := url.Parse(td.BundleEndpoint)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return nil, fmt.Errorf("trust domain %q: invalid bundle endpoint %q, expected an https URL", td.Name, td.BundleEndpoint)
+			}
+		}
+		if sources != 1 {
+			return nil, fmt.Errorf("trust domain %q: exactly one of bundleFile, bundleConfigMap and bundleEndpoint "+
+				"must be set", td.Name)
+		}
+	}
+	return out, nil
+}
+
+func splitConfigMap(ref string) (namespace, name string, err error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid bundle ConfigMap %q, expected <namespace>/<name>", ref)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	testCases := map[string]struct {
+		in     string
+		errMsg string
+	}{
+		"valid": {
+			in: `
+trustDomains:
+- name: a.com
+  bundleFile: /etc/federation/a.com.json
+- name: b.com
+  bundleConfigMap: istio-system/b-com-bundle
+- name: c.com
+  bundleEndpoint: https://citadel.c.com/
+`,
+		},
+		"no name": {
+			in:     "trustDomains: [{bundleFile: /etc/a.json}]",
+			errMsg: "trust domain name must be set",
+		},
+		"SPIFFE ID as name": {
+			in:     "trustDomains: [{name: 'spiffe://a.com', bundleFile: /etc/a.json}]",
+			errMsg: `invalid trust domain name "spiffe://a.com"`,
+		},
+		"duplicate": {
+			in:     "trustDomains: [{name: a.com, bundleFile: /etc/a.json}, {name: a.com, bundleFile: /etc/b.json}]",
+			errMsg: `duplicate trust domain "a.com"`,
+		},
+		"no source": {
+			in:     "trustDomains: [{name: a.com}]",
+			errMsg: "exactly one of bundleFile, bundleConfigMap and bundleEndpoint must be set",
+		},
+		"several sources": {
+			in:     "trustDomains: [{name: a.com, bundleFile: /etc/a.json, bundleEndpoint: 'https://a.com'}]",
+			errMsg: "exactly one of bundleFile, bundleConfigMap and bundleEndpoint must be set",
+		},
+		"invalid ConfigMap": {
+			in:     "trustDomains: [{name: a.com, bundleConfigMap: a-bundle}]",
+			errMsg: `invalid bundle ConfigMap "a-bundle"`,
+		},
+		"invalid endpoint": {
+			in:     "trustDomains: [{name: a.com, bundleEndpoint: 'ftp://a.com/bundle'}]",
+			errMsg: `invalid bundle endpoint "ftp://a.com/bundle"`,
+		},
+		"plain HTTP endpoint": {
+			in:     "trustDomains: [{name: a.com, bundleEndpoint: 'http://a.com/bundle'}]",
+			errMsg: `invalid bundle endpoint "http://a.com/bundle", expected an https URL`,
+		},
+	}
+
+	for id, tc := range testCases {
+		c, err := LoadConfig([]byte(tc.in))
+		if tc.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", id, err)
+			} else if len(c.TrustDomains) != 3 {
+				t.Errorf("%s: got trust domains %v", id, c.TrustDomains)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+			t.Errorf("%s: expected error containing %q, got %v", id, tc.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"net/http"
+	"time"
+)
+
+// NewBundleHandler returns the bundle endpoint of the local trust domain, serving the root certificates
+// returned by rootCertsPEM in the SPIFFE bundle format. The refresh hint tells the federated trust domains how
+// often to check for a new bundle.
+func NewBundleHandler(trustDomain string, rootCertsPEM func() []byte, refreshHint time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		b, err := NewBundle(trustDomain, rootCertsPEM())
+		if err != nil {
+			federationLog.Errorf("failed to build the trust bundle: %v", err)
+			http.Error(w, "trust bundle not available", http.StatusServiceUnavailable)
+			return
+		}
+		b.RefreshHint = refreshHint
+		out, err := b.Marshal()
+		if err != nil {
+			federationLog.Errorf("failed to marshal the trust bundle: %v", err)
+			http.Error(w, "trust bundle not available", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(out)
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultRefreshInterval is how often the bundles are refreshed from their sources.
+	DefaultRefreshInterval = 5 * time.Minute
+
+	endpointTimeout = 10 * time.Second
+	maxBundleSize   = 1 << 20
+)
+
+var federationLog = log.RegisterScope("federation", "SPIFFE federation log", 0)
+
+// Store holds the bundles of the federated trust domains, refreshed from their sources.
+type Store struct {
+	config     *Config
+	core       corev1.CoreV1Interface
+	httpClient *http.Client
+
+	mu      sync.RWMutex
+	bundles map[string]*Bundle
+	// anchors and anchorsErr are the trust anchors of the bundles, created when they change.
+	anchors    []byte
+	anchorsErr error
+}
+
+// NewStore creates a Store for the trust domains of the given configuration. The Kubernetes client is only
+// needed to load the bundles from ConfigMaps, and may be nil otherwise.
+func NewStore(config *Config, core corev1.CoreV1Interface) *Store {
+	return &Store{
+		config: config,
+		core:   core,
+		httpClient: &http.Client{
+			Timeout: endpointTimeout,
+			// The bundle endpoints must not downgrade to plain HTTP.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Scheme != "https" {
+					return fmt.Errorf("refusing redirect to non-https URL %s", req.URL)
+				}
+				return nil
+			},
+		},
+		bundles: map[string]*Bundle{},
+	}
+}
+
+// Refresh loads the bundles of the federated trust domains from their sources, and returns whether any of them
+// changed. A source failing to load keeps its previous bundle, and its error is returned.
+func (s *Store) Refresh() (bool, error) {
+	var errs error
+	bundles := map[string]*Bundle{}
+	for _, td := range s.config.TrustDomains {
+		b, err := s.fetch(td)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("trust domain %s: %v", td.Name, err))
+			continue
+		}
+		bundles[td.Name] = b
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for name, b := range bundles {
+		if !b.Equal(s.bundles[name]) {
+			federationLog.Infof("trust bundle of trust domain %s updated", name)
+			s.bundles[name] = b
+			changed = true
+		}
+	}
+	if changed {
+		s.anchors, s.anchorsErr = s.trustAnchorsPEM()
+	}
+	return changed, errs
+}
+
+// Run refreshes the bundles at the given interval until stop is closed, and calls onChange whenever they change.
+func (s *Store) Run(interval time.Duration, stop <-chan struct{}, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := s.Refresh()
+			if err != nil {
+				federationLog.Warnf("failed to refresh the trust bundles: %v", err)
+			}
+			if changed && onChange != nil {
+				onChange()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Store) fetch(td *TrustDomain) (*Bundle, error) {
+	var in []byte
+	var err error
+	switch {
+	case td.BundleFile != "":
+		in, err = ioutil.ReadFile(td.BundleFile)
+	case td.BundleConfigMap != "":
+		in, err = s.fetchConfigMap(td.BundleConfigMap)
+	default:
+		in, err = s.fetchEndpoint(td.BundleEndpoint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseBundle(td.Name, in)
+}
+
+func (s *Store) fetchConfigMap(ref string) ([]byte, error) {
+	if s.core == nil {
+		return nil, fmt.Errorf("no Kubernetes client to get the bundle ConfigMap %s", ref)
+	}
+	namespace, name, err := splitConfigMap(ref)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := s.core.ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the bundle ConfigMap %s: %v", ref, err)
+	}
+	bundle, found := cm.Data[BundleKey]
+	if !found {
+		return nil, fmt.Errorf("no %q key in the bundle ConfigMap %s", BundleKey, ref)
+	}
+	return []byte(bundle), nil
+}
+
+func (s *Store) fetchEndpoint(url string) ([]byte, error) {
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the bundle from %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the bundle from %s: status code %d", url, resp.StatusCode)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxBundleSize))
+}
+
+// TrustDomains returns the sorted names of the federated trust domains having a bundle. A nil Store has none.
+func (s *Store) TrustDomains() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.trustDomains()
+}
+
+func (s *Store) trustDomains() []string {
+	names := make([]string, 0, len(s.bundles))
+	for name := range s.bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bundle returns the bundle of the given federated trust domain, or nil if not found.
+func (s *Store) Bundle(trustDomain string) *Bundle {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bundles[trustDomain]
+}
+
+// RootCertsPEM returns the PEM root certificates of the federated trust domains having a bundle, by trust domain.
+// A nil Store has none.
+func (s *Store) RootCertsPEM() map[string][]byte {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	roots := make(map[string][]byte, len(s.bundles))
+	for name, b := range s.bundles {
+		roots[name] = b.RootCertsPEM()
+	}
+	return roots
+}
+
+// TrustAnchorsPEM returns the PEM trust anchors of the federated trust domains having a bundle, each only permitting
+// the identities of its own trust domain. A trust domain whose anchors can't be created is left out, and its error
+// is returned. A nil Store has none.
+func (s *Store) TrustAnchorsPEM() ([]byte, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.anchors, s.anchorsErr
+}
+
+func (s *Store) trustAnchorsPEM() ([]byte, error) {
+	var errs error
+	var anchors []byte
+	for _, name := range s.trustDomains() {
+		a, err := s.bundles[name].TrustAnchorsPEM()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		anchors = append(anchors, a...)
+	}
+	return anchors, errs
+}
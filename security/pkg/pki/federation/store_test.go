@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "federation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a.com publishes its bundle in a file, b.com in a ConfigMap and c.com on its bundle endpoint.
+	aRoot := genRootCert(t, "a.com", false)
+	aFile := filepath.Join(dir, "a.com.pem")
+	if err := ioutil.WriteFile(aFile, aRoot, 0644); err != nil {
+		t.Fatal(err)
+	}
+	bRoot := genRootCert(t, "b.com", true)
+	bBundle, err := NewBundle("b.com", bRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bJSON, err := bBundle.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "b-com-bundle"},
+		Data:       map[string]string{BundleKey: string(bJSON)},
+	})
+	cRoot := genRootCert(t, "c.com", false)
+	endpoint := httptest.NewTLSServer(NewBundleHandler("c.com", func() []byte { return cRoot }, time.Minute))
+	defer endpoint.Close()
+
+	store := NewStore(&Config{
+		TrustDomains: []*TrustDomain{
+			{Name: "c.com", BundleEndpoint: endpoint.URL},
+			{Name: "a.com", BundleFile: aFile},
+			{Name: "b.com", BundleConfigMap: "istio-system/b-com-bundle"},
+		},
+	}, client.CoreV1())
+	store.httpClient.Transport = endpoint.Client().Transport
+
+	changed, err := store.Refresh()
+	if err != nil || !changed {
+		t.Fatalf("expected the first refresh to change the bundles, got %v (error %v)", changed, err)
+	}
+	if got := store.TrustDomains(); !reflect.DeepEqual(got, []string{"a.com", "b.com", "c.com"}) {
+		t.Errorf("got trust domains %v", got)
+	}
+	if store.Bundle("c.com").RefreshHint != time.Minute {
+		t.Errorf("got refresh hint %v from the bundle endpoint", store.Bundle("c.com").RefreshHint)
+	}
+	for td, want := range map[string][]byte{"a.com": aRoot, "b.com": bRoot, "c.com": cRoot} {
+		if got := store.Bundle(td).RootCertsPEM(); !bytes.Equal(got, want) {
+			t.Errorf("got %s bundle\n%s\nbut want\n%s", td, got, want)
+		}
+	}
+
+	if changed, err := store.Refresh(); err != nil || changed {
+		t.Errorf("expected an unchanged refresh, got %v (error %v)", changed, err)
+	}
+
+	// A source failing to load keeps its previous bundle.
+	if err := ioutil.WriteFile(aFile, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := store.Refresh(); err == nil || !strings.Contains(err.Error(), "trust domain a.com") || changed {
+		t.Errorf("expected a failed refresh of a.com, got %v (error %v)", changed, err)
+	}
+	if got := store.Bundle("a.com").RootCertsPEM(); !bytes.Equal(got, aRoot) {
+		t.Errorf("got a.com bundle\n%s\nbut want\n%s", got, aRoot)
+	}
+
+	newARoot := genRootCert(t, "a.com", false)
+	if err := ioutil.WriteFile(aFile, newARoot, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := store.Refresh(); err != nil || !changed {
+		t.Errorf("expected the new a.com bundle to be loaded, got %v (error %v)", changed, err)
+	}
+	if !bytes.Equal(store.Bundle("a.com").RootCertsPEM(), newARoot) {
+		t.Errorf("got a.com bundle %s", store.Bundle("a.com").RootCertsPEM())
+	}
+	if got := store.RootCertsPEM(); len(got) != 3 || !bytes.Equal(got["a.com"], newARoot) {
+		t.Errorf("got root certificates %v", got)
+	}
+	anchors, err := store.TrustAnchorsPEM()
+	if err != nil {
+		t.Fatalf("failed to get the trust anchors: %v", err)
+	}
+	if n := strings.Count(string(anchors), "BEGIN CERTIFICATE"); n != 3 {
+		t.Errorf("got %d trust anchors but want 3", n)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	endpoint := httptest.NewTLSServer(http.NotFoundHandler())
+	defer endpoint.Close()
+	redirect := httptest.NewTLSServer(http.RedirectHandler("http://c.com/bundle", http.StatusFound))
+	defer redirect.Close()
+
+	store := NewStore(&Config{
+		TrustDomains: []*TrustDomain{
+			{Name: "a.com", BundleConfigMap: "istio-system/a-com-bundle"},
+			{Name: "b.com", BundleEndpoint: endpoint.URL},
+			{Name: "c.com", BundleEndpoint: redirect.URL},
+		},
+	}, nil)
+	store.httpClient.Transport = endpoint.Client().Transport
+
+	changed, err := store.Refresh()
+	if changed || err == nil {
+		t.Fatalf("expected a failed refresh, got %v (error %v)", changed, err)
+	}
+	for _, msg := range []string{"no Kubernetes client", "status code 404", "refusing redirect"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error containing %q, got %v", msg, err)
+		}
+	}
+	if got := store.TrustDomains(); len(got) != 0 {
+		t.Errorf("expected no trust bundle, got %v", got)
+	}
+
+	var nilStore *Store
+	if nilStore.TrustDomains() != nil || nilStore.Bundle("a.com") != nil || nilStore.RootCertsPEM() != nil {
+		t.Errorf("expected a nil store to have no bundle")
+	}
+}
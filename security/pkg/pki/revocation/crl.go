@@ -0,0 +1,148 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// DefaultCRLValidity is the default validity of the published certificate revocation lists.
+	//
+	// The proxies reject all the peers once the list they validate them with is past its next update, not only
+	// the revoked ones. If the CA can't re-publish the lists for longer than their validity, e.g. because it is
+	// down, every mTLS connection of the mesh fails. The validity is therefore much longer than the refresh
+	// period, so that a CA outage has to last weeks before it breaks the mesh.
+	DefaultCRLValidity = 30 * 24 * time.Hour
+
+	// DefaultCRLRefreshPeriod is the default period at which the lists are re-published, besides whenever the
+	// deny list or the issuers change.
+	DefaultCRLRefreshPeriod = time.Hour
+)
+
+// CRLWriter stores the PEM certificate revocation list distributed to the proxies.
+type CRLWriter interface {
+	InsertCRL(value string) error
+}
+
+// CreateCRL returns the PEM certificate revocation list of the serial numbers revoked by the deny list, signed
+// by the given CA. The identities and nodes can't be expressed in a revocation list: they are only enforced by
+// the CA, which refuses to issue certificates to them.
+func (d *DenyList) CreateCRL(caCert *x509.Certificate, caKey crypto.Signer, now time.Time,
+	validity time.Duration) ([]byte, error) {
+	var serials []string
+	if d != nil {
+		for sn := range d.serials {
+			serials = append(serials, sn)
+		}
+	}
+	sort.Strings(serials)
+
+	revoked := make([]pkix.RevokedCertificate, 0, len(serials))
+	for _, sn := range serials {
+		n, _ := new(big.Int).SetString(sn, 16)
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: n, RevocationTime: now})
+	}
+	der, err := caCert.CreateCRL(rand.Reader, caKey, revoked, now, now.Add(validity))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CRL: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// CRLIssuer is a self-signed CA issuing a certificate revocation list.
+type CRLIssuer struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// CRLIssuers provides the self-signed CAs whose roots are trusted by the proxies. The proxies check every
+// certificate of the peer chain against the revocation list of its issuer, so each trusted root must publish its
+// own list, e.g. both the old and the new roots during a root rollover.
+type CRLIssuers interface {
+	// CRLIssuers returns the current issuers of the revocation lists.
+	CRLIssuers() ([]CRLIssuer, error)
+
+	// CRLIssuersChanged is notified whenever the issuers change. It may be nil if they never change.
+	CRLIssuersChanged() <-chan struct{}
+}
+
+// NewCRLIssuer returns the issuer of the revocation list of the CA of the given bundle. The proxies check the
+// revocation of every certificate of the peer chain, so the list is only published by a self-signed CA: an
+// intermediate CA can't publish the list of its issuers, and can't enforce a deny list.
+func NewCRLIssuer(bundle util.KeyCertBundle) (CRLIssuer, error) {
+	cert, key, certChain, _ := bundle.GetAll()
+	if len(certChain) > 0 {
+		return CRLIssuer{}, fmt.Errorf("the CRL is only published by a self-signed CA")
+	}
+	if key == nil {
+		return CRLIssuer{}, fmt.Errorf("the CA has no private key")
+	}
+	signer, ok := (*key).(crypto.Signer)
+	if !ok {
+		return CRLIssuer{}, fmt.Errorf("the CA private key of type %T can't sign the CRL", *key)
+	}
+	return CRLIssuer{Cert: cert, Key: signer}, nil
+}
+
+// PublishCRL writes the certificate revocation lists of the current deny list, one per issuer, valid for the given
+// validity, whenever the deny list or the issuers change and at every refresh period, until stop is closed. The
+// refresh period must be shorter than the validity, see DefaultCRLValidity.
+func (s *Store) PublishCRL(issuers CRLIssuers, w CRLWriter, validity, refresh time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	for {
+		if err := s.publishCRL(issuers, w, validity); err != nil {
+			revocationLog.Errorf("failed to publish the CRL: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.changed:
+		case <-issuers.CRLIssuersChanged():
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Store) publishCRL(issuers CRLIssuers, w CRLWriter, validity time.Duration) error {
+	cas, err := issuers.CRLIssuers()
+	if err != nil {
+		return err
+	}
+	if len(cas) == 0 {
+		return fmt.Errorf("no CA issues the CRL")
+	}
+	d, now := s.DenyList(), time.Now()
+	var crls []byte
+	for _, ca := range cas {
+		crl, err := d.CreateCRL(ca.Cert, ca.Key, now, validity)
+		if err != nil {
+			return err
+		}
+		crls = append(crls, crl...)
+	}
+	return w.InsertCRL(string(crls))
+}
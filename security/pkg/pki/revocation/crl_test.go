@@ -0,0 +1,234 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type fakeCRLWriter struct {
+	mu   sync.Mutex
+	crls []string
+}
+
+func (w *fakeCRLWriter) InsertCRL(value string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.crls = append(w.crls, value)
+	return nil
+}
+
+func (w *fakeCRLWriter) last() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.crls) == 0 {
+		return ""
+	}
+	return w.crls[len(w.crls)-1]
+}
+
+func newCABundle(t *testing.T) util.KeyCertBundle {
+	t.Helper()
+	certPem, keyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		NotBefore:    time.Now(),
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the CA cert: %v", err)
+	}
+	bundle, err := util.NewVerifiedKeyCertBundleFromPem(certPem, keyPem, nil, certPem)
+	if err != nil {
+		t.Fatalf("failed to create the CA bundle: %v", err)
+	}
+	return bundle
+}
+
+// fakeCRLIssuers are the CRLIssuers of the given bundles, which may be replaced by set.
+type fakeCRLIssuers struct {
+	mu      sync.Mutex
+	bundles []util.KeyCertBundle
+	changed chan struct{}
+}
+
+func (f *fakeCRLIssuers) CRLIssuers() ([]CRLIssuer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var issuers []CRLIssuer
+	for _, b := range f.bundles {
+		issuer, err := NewCRLIssuer(b)
+		if err != nil {
+			return nil, err
+		}
+		issuers = append(issuers, issuer)
+	}
+	return issuers, nil
+}
+
+func (f *fakeCRLIssuers) CRLIssuersChanged() <-chan struct{} {
+	return f.changed
+}
+
+func (f *fakeCRLIssuers) set(bundles ...util.KeyCertBundle) {
+	f.mu.Lock()
+	f.bundles = bundles
+	f.mu.Unlock()
+	f.changed <- struct{}{}
+}
+
+func revokedSerials(t *testing.T, bundle util.KeyCertBundle, crlPem string) []string {
+	t.Helper()
+	crl, err := x509.ParseCRL([]byte(crlPem))
+	if err != nil {
+		t.Fatalf("failed to parse the CRL: %v", err)
+	}
+	caCert, _, _, _ := bundle.GetAll()
+	if err := caCert.CheckCRLSignature(crl); err != nil {
+		t.Errorf("the CRL is not signed by the CA: %v", err)
+	}
+	serials := []string{}
+	for _, r := range crl.TBSCertList.RevokedCertificates {
+		serials = append(serials, r.SerialNumber.Text(16))
+	}
+	return serials
+}
+
+// splitCRLs returns the PEM revocation lists of the published value.
+func splitCRLs(crlsPem string) []string {
+	var crls []string
+	for rest := []byte(crlsPem); ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return crls
+		}
+		crls = append(crls, string(pem.EncodeToMemory(block)))
+	}
+}
+
+func TestNewCRLIssuer(t *testing.T) {
+	root := newCABundle(t)
+	if _, err := NewCRLIssuer(root); err != nil {
+		t.Errorf("failed to create the CRL issuer of a self-signed CA: %v", err)
+	}
+
+	rootCert, rootKey, _, rootCertPem := root.GetAll()
+	certPem, keyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:        time.Hour,
+		NotBefore:  time.Now(),
+		Org:        "MyOrg",
+		IsCA:       true,
+		SignerCert: rootCert,
+		SignerPriv: *rootKey,
+		RSAKeySize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the intermediate CA cert: %v", err)
+	}
+	intermediate, err := util.NewVerifiedKeyCertBundleFromPem(certPem, keyPem, certPem, rootCertPem)
+	if err != nil {
+		t.Fatalf("failed to create the intermediate CA bundle: %v", err)
+	}
+	if _, err := NewCRLIssuer(intermediate); err == nil || !strings.Contains(err.Error(), "self-signed") {
+		t.Errorf("expected an intermediate CA to be refused, got %v", err)
+	}
+}
+
+func TestCreateCRL(t *testing.T) {
+	d, err := Parse([]byte(testDenyList))
+	if err != nil {
+		t.Fatalf("failed to parse the deny list: %v", err)
+	}
+	bundle := newCABundle(t)
+	caCert, caKey, _, _ := bundle.GetAll()
+
+	now := time.Now()
+	crlPem, err := d.CreateCRL(caCert, (*caKey).(crypto.Signer), now, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create the CRL: %v", err)
+	}
+	if got, want := revokedSerials(t, bundle, string(crlPem)), []string{"a1b2c", "ff00"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got revoked serial numbers %v, want %v", got, want)
+	}
+
+	crl, _ := x509.ParseCRL(crlPem)
+	if next := crl.TBSCertList.NextUpdate; next.Before(now.Add(59*time.Minute)) || next.After(now.Add(61*time.Minute)) {
+		t.Errorf("got next update %v, want %v", next, now.Add(time.Hour))
+	}
+}
+
+func TestPublishCRL(t *testing.T) {
+	oldBundle, newBundle := newCABundle(t), newCABundle(t)
+	issuers := &fakeCRLIssuers{bundles: []util.KeyCertBundle{oldBundle}, changed: make(chan struct{})}
+	s := NewStore()
+	w := &fakeCRLWriter{}
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.PublishCRL(issuers, w, DefaultCRLValidity, DefaultCRLRefreshPeriod, stop)
+
+	// waitForCRLs waits for one revocation list per bundle, signed by the bundle and revoking the given serials.
+	waitForCRLs := func(want []string, bundles ...util.KeyCertBundle) {
+		t.Helper()
+		var got [][]string
+		for i := 0; i < 100; i++ {
+			if crls := splitCRLs(w.last()); len(crls) == len(bundles) {
+				got = nil
+				for j, crl := range crls {
+					got = append(got, revokedSerials(t, bundles[j], crl))
+				}
+				if reflect.DeepEqual(got, repeat(want, len(bundles))) {
+					return
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("got revoked serial numbers %v, want %v from %d CAs", got, want, len(bundles))
+	}
+	waitForCRLs([]string{}, oldBundle)
+
+	d, err := Parse([]byte(testDenyList))
+	if err != nil {
+		t.Fatalf("failed to parse the deny list: %v", err)
+	}
+	s.Set(d)
+	waitForCRLs([]string{"a1b2c", "ff00"}, oldBundle)
+
+	// Both roots are trusted during a root rollover, each of them publishes its list.
+	issuers.set(oldBundle, newBundle)
+	waitForCRLs([]string{"a1b2c", "ff00"}, oldBundle, newBundle)
+
+	issuers.set(newBundle)
+	waitForCRLs([]string{"a1b2c", "ff00"}, newBundle)
+}
+
+func repeat(serials []string, n int) [][]string {
+	out := make([][]string, n)
+	for i := range out {
+		out[i] = serials
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// DenyList is the list of revoked workload identities, certificates and nodes. The CA refuses to issue
+// certificates to any of them. The sidecars reject the peers presenting the revoked identities, and the revoked
+// certificates through the revocation list published by the CA.
+type DenyList struct {
+	// Identities are the revoked SPIFFE IDs, e.g. "spiffe://cluster.local/ns/default/sa/bookinfo".
+	Identities []string `json:"identities,omitempty"`
+	// SerialNumbers are the hex encoded serial numbers of the revoked certificates.
+	SerialNumbers []string `json:"serialNumbers,omitempty"`
+	// Nodes are the names of the revoked nodes.
+	Nodes []string `json:"nodes,omitempty"`
+
+	identities map[string]bool
+	serials    map[string]bool
+	nodes      map[string]bool
+}
+
+// Parse parses and validates a YAML or JSON encoded deny list.
+func Parse(in []byte) (*DenyList, error) {
+	d := &DenyList{}
+	if err := yaml.Unmarshal(in, d); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the deny list: %v", err)
+	}
+
+	d.identities = make(map[string]bool, len(d.Identities))
+	for _, id := range d.Identities {
+		if !strings.HasPrefix(id, spiffe.URIPrefix) {
+			return nil, fmt.Errorf("invalid identity %q: must be a SPIFFE ID", id)
+		}
+		d.identities[id] = true
+	}
+
+	d.serials = make(map[string]bool, len(d.SerialNumbers))
+	for _, sn := range d.SerialNumbers {
+		n, ok := new(big.Int).SetString(strings.Replace(sn, ":", "", -1), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %q: must be hex encoded", sn)
+		}
+		d.serials[n.Text(16)] = true
+	}
+
+	d.nodes = make(map[string]bool, len(d.Nodes))
+	for _, node := range d.Nodes {
+		if node == "" {
+			return nil, fmt.Errorf("invalid node %q: must be a node name", node)
+		}
+		d.nodes[node] = true
+	}
+
+	return d, nil
+}
+
+// ReadFile reads and parses the deny list in the given file.
+func ReadFile(path string) (*DenyList, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the deny list: %v", err)
+	}
+	return Parse(in)
+}
+
+// IsEmpty returns true if nothing is revoked.
+func (d *DenyList) IsEmpty() bool {
+	return d == nil || len(d.identities) == 0 && len(d.serials) == 0 && len(d.nodes) == 0
+}
+
+// IsIdentityDenied returns true if the given SPIFFE ID is revoked.
+func (d *DenyList) IsIdentityDenied(id string) bool {
+	return d != nil && d.identities[id]
+}
+
+// IsSerialNumberDenied returns true if the certificate with the given serial number is revoked.
+func (d *DenyList) IsSerialNumberDenied(sn *big.Int) bool {
+	return d != nil && sn != nil && d.serials[sn.Text(16)]
+}
+
+// IsNodeDenied returns true if the node with the given name is revoked.
+func (d *DenyList) IsNodeDenied(node string) bool {
+	return d != nil && node != "" && d.nodes[node]
+}
+
+// HasNodes returns true if any node is revoked.
+func (d *DenyList) HasNodes() bool {
+	return d != nil && len(d.nodes) > 0
+}
+
+// CheckIdentities returns an error if any of the given SPIFFE IDs is revoked.
+func (d *DenyList) CheckIdentities(ids []string) error {
+	for _, id := range ids {
+		if d.IsIdentityDenied(id) {
+			return fmt.Errorf("identity %q is revoked", id)
+		}
+	}
+	return nil
+}
+
+// CheckCertificate returns an error if the given certificate, or any of the identities it carries, is revoked.
+func (d *DenyList) CheckCertificate(cert *x509.Certificate) error {
+	if d.IsSerialNumberDenied(cert.SerialNumber) {
+		return fmt.Errorf("certificate with serial number %s is revoked", cert.SerialNumber.Text(16))
+	}
+	ids, err := util.ExtractIDs(cert.Extensions)
+	if err != nil {
+		// Certificates without SAN extension can only be revoked by serial number.
+		return nil
+	}
+	return d.CheckIdentities(ids)
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"crypto/x509"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const testDenyList = `
+identities:
+- spiffe://cluster.local/ns/default/sa/compromised
+serialNumbers:
+- 0a:1b:2c
+- FF00
+nodes:
+- node-1
+- node-2
+`
+
+func TestParse(t *testing.T) {
+	testCases := map[string]struct {
+		in     string
+		errMsg string
+	}{
+		"valid": {
+			in: testDenyList,
+		},
+		"empty": {
+			in: "",
+		},
+		"invalid yaml": {
+			in:     "identities: {",
+			errMsg: "failed to unmarshal the deny list",
+		},
+		"invalid identity": {
+			in:     "identities: [cluster.local/ns/default/sa/foo]",
+			errMsg: "must be a SPIFFE ID",
+		},
+		"invalid serial number": {
+			in:     "serialNumbers: [xyz]",
+			errMsg: "must be hex encoded",
+		},
+		"invalid node": {
+			in:     `nodes: [""]`,
+			errMsg: "must be a node name",
+		},
+	}
+
+	for id, tc := range testCases {
+		_, err := Parse([]byte(tc.in))
+		if tc.errMsg == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+		} else if tc.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tc.errMsg)) {
+			t.Errorf("%s: expected error containing %q, got %v", id, tc.errMsg, err)
+		}
+	}
+}
+
+func TestDenyList(t *testing.T) {
+	d, err := Parse([]byte(testDenyList))
+	if err != nil {
+		t.Fatalf("failed to parse the deny list: %v", err)
+	}
+	if d.IsEmpty() {
+		t.Error("expected the deny list to not be empty")
+	}
+
+	if !d.IsIdentityDenied("spiffe://cluster.local/ns/default/sa/compromised") {
+		t.Error("expected the identity to be denied")
+	}
+	if d.IsIdentityDenied("spiffe://cluster.local/ns/default/sa/default") {
+		t.Error("expected the identity to not be denied")
+	}
+
+	for _, sn := range []int64{0x0a1b2c, 0xff00} {
+		if !d.IsSerialNumberDenied(big.NewInt(sn)) {
+			t.Errorf("expected serial number %x to be denied", sn)
+		}
+	}
+	if d.IsSerialNumberDenied(big.NewInt(0xff)) {
+		t.Error("expected serial number ff to not be denied")
+	}
+
+	for _, node := range []string{"node-1", "node-2"} {
+		if !d.IsNodeDenied(node) {
+			t.Errorf("expected node %s to be denied", node)
+		}
+	}
+	for _, node := range []string{"node-3", ""} {
+		if d.IsNodeDenied(node) {
+			t.Errorf("expected node %q to not be denied", node)
+		}
+	}
+}
+
+func TestNilDenyList(t *testing.T) {
+	var d *DenyList
+	if !d.IsEmpty() {
+		t.Error("expected a nil deny list to be empty")
+	}
+	if d.IsIdentityDenied("spiffe://cluster.local/ns/default/sa/default") ||
+		d.IsSerialNumberDenied(big.NewInt(1)) || d.IsNodeDenied("node-1") {
+		t.Error("expected a nil deny list to deny nothing")
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	d, err := Parse([]byte(testDenyList))
+	if err != nil {
+		t.Fatalf("failed to parse the deny list: %v", err)
+	}
+
+	newCert := func(host string) *x509.Certificate {
+		certPem, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Host:         host,
+			TTL:          time.Hour,
+			NotBefore:    time.Now(),
+			Org:          "MyOrg",
+			IsSelfSigned: true,
+			RSAKeySize:   1024,
+		})
+		if err != nil {
+			t.Fatalf("failed to generate cert: %v", err)
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPem)
+		if err != nil {
+			t.Fatalf("failed to parse cert: %v", err)
+		}
+		return cert
+	}
+
+	if err := d.CheckCertificate(newCert("spiffe://cluster.local/ns/default/sa/compromised")); err == nil ||
+		!strings.Contains(err.Error(), "is revoked") {
+		t.Errorf("expected the revoked identity to be rejected, got %v", err)
+	}
+
+	cert := newCert("spiffe://cluster.local/ns/default/sa/default")
+	if err := d.CheckCertificate(cert); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cert.SerialNumber = big.NewInt(0xff00)
+	if err := d.CheckCertificate(cert); err == nil || !strings.Contains(err.Error(), "serial number ff00 is revoked") {
+		t.Errorf("expected the revoked serial number to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/pkg/filewatcher"
+	"istio.io/pkg/log"
+)
+
+const (
+	// DenyListKey is the key of the deny list in the revocation ConfigMap.
+	DenyListKey = "denylist"
+
+	configMapResyncPeriod = time.Minute
+)
+
+var revocationLog = log.RegisterScope("revocation", "Certificate revocation log", 0)
+
+// Store holds the current deny list, which is replaced whenever its source changes.
+type Store struct {
+	mu       sync.RWMutex
+	denyList *DenyList
+
+	// changed is notified whenever the deny list is replaced.
+	changed chan struct{}
+}
+
+// NewStore creates a Store with an empty deny list.
+func NewStore() *Store {
+	return &Store{changed: make(chan struct{}, 1)}
+}
+
+// DenyList returns the current deny list. A nil Store returns a nil deny list, which revokes nothing.
+func (s *Store) DenyList() *DenyList {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.denyList
+}
+
+// Set replaces the current deny list.
+func (s *Store) Set(d *DenyList) {
+	s.mu.Lock()
+	s.denyList = d
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// WatchFile loads the deny list from the given file and reloads it whenever the file changes, until stop is closed.
+// An invalid update is logged and the previous deny list is kept.
+func (s *Store) WatchFile(path string, stop <-chan struct{}) error {
+	d, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s.Set(d)
+
+	fw := filewatcher.NewWatcher()
+	if err := fw.Add(path); err != nil {
+		_ = fw.Close()
+		return fmt.Errorf("failed to watch the deny list file %s: %v", path, err)
+	}
+
+	go func() {
+		defer fw.Close()
+		var timerC <-chan time.Time
+		for {
+			select {
+			case <-timerC:
+				timerC = nil
+				d, err := ReadFile(path)
+				if err != nil {
+					revocationLog.Errorf("failed to reload the deny list from %s: %v", path, err)
+					continue
+				}
+				revocationLog.Infof("reloaded the deny list from %s", path)
+				s.Set(d)
+			case <-fw.Events(path):
+				// Use a timer to debounce file updates
+				if timerC == nil {
+					timerC = time.After(100 * time.Millisecond)
+				}
+			case err := <-fw.Errors(path):
+				revocationLog.Errorf("error while watching the deny list file %s: %v", path, err)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// WatchConfigMap keeps the deny list in sync with the DenyListKey of the given ConfigMap, until stop is closed.
+// A missing ConfigMap revokes nothing, and an invalid update is logged and the previous deny list is kept.
+func (s *Store) WatchConfigMap(core corev1.CoreV1Interface, namespace, name string, stop <-chan struct{}) {
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return core.ConfigMaps(namespace).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return core.ConfigMaps(namespace).Watch(options)
+		},
+	}
+	_, controller := cache.NewInformer(lw, &v1.ConfigMap{}, configMapResyncPeriod, cache.ResourceEventHandlerFuncs{
+		AddFunc: s.configMapUpdated,
+		UpdateFunc: func(_, cur interface{}) {
+			s.configMapUpdated(cur)
+		},
+		DeleteFunc: func(interface{}) {
+			revocationLog.Infof("deny list ConfigMap %s/%s is deleted", namespace, name)
+			s.Set(nil)
+		},
+	})
+	go controller.Run(stop)
+}
+
+func (s *Store) configMapUpdated(obj interface{}) {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+	d, err := Parse([]byte(cm.Data[DenyListKey]))
+	if err != nil {
+		revocationLog.Errorf("failed to load the deny list from ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+	revocationLog.Infof("loaded the deny list from ConfigMap %s/%s", cm.Namespace, cm.Name)
+	s.Set(d)
+}
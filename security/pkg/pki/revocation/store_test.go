@@ -0,0 +1,116 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	compromisedID = "spiffe://cluster.local/ns/default/sa/compromised"
+	otherID       = "spiffe://cluster.local/ns/default/sa/other"
+)
+
+func waitForIdentity(t *testing.T, s *Store, id string, denied bool) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if s.DenyList().IsIdentityDenied(id) == denied {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("expected identity %s to be denied: %v", id, denied)
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	if s.DenyList() != nil {
+		t.Error("expected a nil store to have no deny list")
+	}
+}
+
+func TestStore_WatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "revocation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "denylist")
+
+	s := NewStore()
+	stop := make(chan struct{})
+	defer close(stop)
+
+	if err := s.WatchFile(path, stop); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+
+	if err := ioutil.WriteFile(path, []byte("identities: ["+compromisedID+"]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WatchFile(path, stop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForIdentity(t, s, compromisedID, true)
+
+	// An invalid update keeps the previous deny list.
+	if err := ioutil.WriteFile(path, []byte("identities: [other]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	waitForIdentity(t, s, compromisedID, true)
+
+	if err := ioutil.WriteFile(path, []byte("identities: ["+otherID+"]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForIdentity(t, s, otherID, true)
+	waitForIdentity(t, s, compromisedID, false)
+}
+
+func TestStore_WatchConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	s := NewStore()
+	stop := make(chan struct{})
+	defer close(stop)
+	s.WatchConfigMap(client.CoreV1(), "istio-system", "istio-revocation", stop)
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-revocation", Namespace: "istio-system"},
+		Data:       map[string]string{DenyListKey: "identities: [" + compromisedID + "]"},
+	}
+	if _, err := client.CoreV1().ConfigMaps("istio-system").Create(cm); err != nil {
+		t.Fatal(err)
+	}
+	waitForIdentity(t, s, compromisedID, true)
+
+	cm.Data[DenyListKey] = "identities: [" + otherID + "]"
+	if _, err := client.CoreV1().ConfigMaps("istio-system").Update(cm); err != nil {
+		t.Fatal(err)
+	}
+	waitForIdentity(t, s, otherID, true)
+
+	if err := client.CoreV1().ConfigMaps("istio-system").Delete(cm.Name, &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForIdentity(t, s, otherID, false)
+}
@@ -0,0 +1,229 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rollover implements the state machine of a graceful rollover of the root certificate of the CA. The
+// new root is introduced next to the old one in the trust bundle, the CA starts issuing from the new root once the
+// combined bundle has propagated to the workloads, and the old root is retired once every workload certificate has
+// been reissued from the new root.
+package rollover
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// Phase is a phase of a root rollover.
+type Phase string
+
+const (
+	// PhaseDistributing serves the combined trust bundle, holding both the old and the new roots, while the CA
+	// keeps issuing from the old root.
+	PhaseDistributing Phase = "DistributeCombinedBundle"
+
+	// PhaseIssuing issues the workload certificates from the new root, the trust bundle still holding both roots.
+	PhaseIssuing Phase = "IssueFromNewRoot"
+
+	// PhaseRetiring removes the old root from the trust bundle.
+	PhaseRetiring Phase = "RetireOldRoot"
+
+	// PhaseCompleted is the final phase, reached once the old root is no longer trusted by any workload.
+	PhaseCompleted Phase = "Completed"
+)
+
+// Next returns the phase following p, or an empty phase if p is the final one.
+func (p Phase) Next() Phase {
+	switch p {
+	case PhaseDistributing:
+		return PhaseIssuing
+	case PhaseIssuing:
+		return PhaseRetiring
+	case PhaseRetiring:
+		return PhaseCompleted
+	default:
+		return ""
+	}
+}
+
+// State is the state of a root rollover. The phase is driven by the operator, or by the CA when Auto is set, and
+// the status is reported by the CA.
+type State struct {
+	Phase Phase `json:"phase"`
+
+	// Auto advances the rollover to the next phase as soon as the current one has propagated.
+	Auto bool `json:"auto,omitempty"`
+
+	// OldRootCerts is the PEM-encoded trust bundle of the CA before the rollover. It is recorded by the CA when it
+	// first observes the rollover.
+	OldRootCerts string `json:"oldRootCerts,omitempty"`
+
+	// LastTransitionTime is the time the rollover entered the current phase.
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+
+	Status Status `json:"status"`
+}
+
+// Status is the propagation status of the current phase, as observed by the CA on the workload secrets.
+type Status struct {
+	// Propagated is true once every workload secret is up-to-date for the current phase.
+	Propagated bool `json:"propagated"`
+
+	// Updated is the number of up-to-date workload secrets, out of Total.
+	Updated int `json:"updated"`
+	Total   int `json:"total"`
+
+	// Pending lists some of the outdated workload secrets, as namespace/name.
+	Pending []string `json:"pending,omitempty"`
+
+	// Message reports the last error of the CA, or why the phase has not propagated yet.
+	Message string `json:"message,omitempty"`
+
+	// ObservedTime is the time of the last observation.
+	ObservedTime time.Time `json:"observedTime"`
+}
+
+// Material is the new key and certificates of the CA.
+type Material struct {
+	SigningCert []byte
+	SigningKey  []byte
+	CertChain   []byte
+	RootCert    []byte
+}
+
+// Verify verifies the signing cert is a CA certificate matching the key, and chains up to the new root.
+func (m *Material) Verify() error {
+	if err := util.Verify(m.SigningCert, m.SigningKey, m.CertChain, m.RootCert); err != nil {
+		return err
+	}
+	cert, err := util.ParsePemEncodedCertificate(m.SigningCert)
+	if err != nil {
+		return err
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("certificate is not authorized to sign other certificates")
+	}
+	return nil
+}
+
+// RootCerts returns the trust bundle of the given phase, appending the new root to the old roots until the old
+// root is retired.
+func RootCerts(phase Phase, oldRootCerts, newRootCerts []byte) []byte {
+	switch phase {
+	case PhaseDistributing, PhaseIssuing:
+		return appendMissingCerts(oldRootCerts, newRootCerts)
+	default:
+		return newRootCerts
+	}
+}
+
+// UpToDate returns whether a workload secret holding the given trust bundle and cert chain is up-to-date for
+// the given phase: it trusts the new root while distributing, its certificate is issued by the new signing cert
+// while issuing, and it no longer trusts the old roots once they are retired.
+func UpToDate(phase Phase, m *Material, oldRootCerts, rootCertPEM, certChainPEM []byte) bool {
+	switch phase {
+	case PhaseDistributing:
+		return containsAll(rootCertPEM, m.RootCert)
+	case PhaseIssuing:
+		leaf, err := util.ParsePemEncodedCertificate(certChainPEM)
+		if err != nil {
+			return false
+		}
+		signingCert, err := util.ParsePemEncodedCertificate(m.SigningCert)
+		if err != nil {
+			return false
+		}
+		return leaf.CheckSignatureFrom(signingCert) == nil
+	case PhaseRetiring, PhaseCompleted:
+		retired := subtractCerts(parseCerts(oldRootCerts), parseCerts(m.RootCert))
+		for _, cert := range parseCerts(rootCertPEM) {
+			for _, old := range retired {
+				if cert.Equal(old) {
+					return false
+				}
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// parseCerts returns the certificates of the PEM bundle, skipping the invalid ones.
+func parseCerts(pemCerts []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, pemCerts = pem.Decode(pemCerts)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+func containsAll(bundle, certs []byte) bool {
+	have := parseCerts(bundle)
+	for _, cert := range parseCerts(certs) {
+		found := false
+		for _, h := range have {
+			if h.Equal(cert) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// subtractCerts returns the certificates of a not in b.
+func subtractCerts(a, b []*x509.Certificate) []*x509.Certificate {
+	var diff []*x509.Certificate
+	for _, cert := range a {
+		found := false
+		for _, other := range b {
+			if cert.Equal(other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, cert)
+		}
+	}
+	return diff
+}
+
+// appendMissingCerts appends to the bundle the certificates it does not hold yet.
+func appendMissingCerts(bundle, certs []byte) []byte {
+	if containsAll(bundle, certs) {
+		return bundle
+	}
+	out := bytes.TrimRight(bundle, "\n")
+	if len(out) > 0 {
+		out = append(append([]byte{}, out...), '\n')
+	}
+	return append(out, certs...)
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollover
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// genMaterial generates a self-signed CA.
+func genMaterial(t *testing.T) *Material {
+	cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		Org:          "cluster.local",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Material{SigningCert: cert, SigningKey: key, RootCert: cert}
+}
+
+// genWorkloadCert generates a workload cert chain issued by the given CA.
+func genWorkloadCert(t *testing.T, ca *Material) []byte {
+	signingCert, err := util.ParsePemEncodedCertificate(ca.SigningCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey, err := util.ParsePemEncodedKey(ca.SigningKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://cluster.local/ns/default/sa/default",
+		TTL:          time.Hour,
+		SignerCert:   signingCert,
+		SignerPriv:   signingKey,
+		RSAKeySize:   2048,
+		IsServer:     true,
+		IsClient:     true,
+		IsSelfSigned: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestPhaseNext(t *testing.T) {
+	var phases []Phase
+	for p := PhaseDistributing; p != ""; p = p.Next() {
+		phases = append(phases, p)
+	}
+	want := []Phase{PhaseDistributing, PhaseIssuing, PhaseRetiring, PhaseCompleted}
+	if len(phases) != len(want) {
+		t.Fatalf("got phases %v, want %v", phases, want)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Errorf("got phases %v, want %v", phases, want)
+		}
+	}
+}
+
+func TestMaterialVerify(t *testing.T) {
+	m := genMaterial(t)
+	if err := m.Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	other := genMaterial(t)
+	mismatch := &Material{SigningCert: m.SigningCert, SigningKey: other.SigningKey, RootCert: m.RootCert}
+	if err := mismatch.Verify(); err == nil {
+		t.Errorf("expected an error for a key not matching the signing cert")
+	}
+	untrusted := &Material{SigningCert: m.SigningCert, SigningKey: m.SigningKey, RootCert: other.RootCert}
+	if err := untrusted.Verify(); err == nil {
+		t.Errorf("expected an error for a signing cert not chaining up to the root")
+	}
+}
+
+func TestRootCerts(t *testing.T) {
+	oldRoot := genMaterial(t).RootCert
+	newRoot := genMaterial(t).RootCert
+	combined := append(append([]byte{}, oldRoot...), newRoot...)
+
+	for _, tc := range []struct {
+		phase Phase
+		want  []byte
+	}{
+		{phase: PhaseDistributing, want: combined},
+		{phase: PhaseIssuing, want: combined},
+		{phase: PhaseRetiring, want: newRoot},
+		{phase: PhaseCompleted, want: newRoot},
+	} {
+		if got := RootCerts(tc.phase, oldRoot, newRoot); !bytes.Equal(got, tc.want) {
+			t.Errorf("%s: got root certs\n%s\nwant\n%s", tc.phase, got, tc.want)
+		}
+	}
+	if got := RootCerts(PhaseDistributing, combined, newRoot); !bytes.Equal(got, combined) {
+		t.Errorf("expected the new root not to be appended twice, got\n%s", got)
+	}
+}
+
+func TestUpToDate(t *testing.T) {
+	old := genMaterial(t)
+	m := genMaterial(t)
+	combined := RootCerts(PhaseDistributing, old.RootCert, m.RootCert)
+	oldCert := genWorkloadCert(t, old)
+	newCert := genWorkloadCert(t, m)
+
+	testCases := []struct {
+		name      string
+		phase     Phase
+		rootCerts []byte
+		certChain []byte
+		want      bool
+	}{
+		{name: "old bundle while distributing", phase: PhaseDistributing, rootCerts: old.RootCert, certChain: oldCert},
+		{name: "combined bundle while distributing", phase: PhaseDistributing, rootCerts: combined, certChain: oldCert,
+			want: true},
+		{name: "old cert while issuing", phase: PhaseIssuing, rootCerts: combined, certChain: oldCert},
+		{name: "new cert while issuing", phase: PhaseIssuing, rootCerts: combined, certChain: newCert, want: true},
+		{name: "combined bundle while retiring", phase: PhaseRetiring, rootCerts: combined, certChain: newCert},
+		{name: "new bundle while retiring", phase: PhaseRetiring, rootCerts: m.RootCert, certChain: newCert,
+			want: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := UpToDate(tc.phase, m, old.RootCert, tc.rootCerts, tc.certChain); got != tc.want {
+				t.Errorf("got up-to-date %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollover
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the CA namespace, holding the state of the rollover.
+	ConfigMapName = "istio-ca-root-rollover"
+	// StateKey is the key of the state in the rollover ConfigMap.
+	StateKey = "state"
+
+	// SecretName is the name of the secret, in the CA namespace, holding the new key and certificates of the CA.
+	SecretName = "istio-ca-root-rollover"
+	// SecretType is the type of the rollover secret.
+	SecretType = "istio.io/ca-root"
+
+	// The keys of the rollover secret, matching the files of the plugged-in CA certificates.
+	SigningCertID = "ca-cert.pem"
+	SigningKeyID  = "ca-key.pem"
+	CertChainID   = "cert-chain.pem"
+	RootCertID    = "root-cert.pem"
+)
+
+// Store reads and updates the rollover state and material in the CA namespace.
+type Store struct {
+	core      corev1.CoreV1Interface
+	namespace string
+}
+
+// NewStore creates a Store for the given CA namespace.
+func NewStore(core corev1.CoreV1Interface, namespace string) *Store {
+	return &Store{
+		core:      core,
+		namespace: namespace,
+	}
+}
+
+// Get returns the state of the rollover, or nil if there is no rollover.
+func (s *Store) Get() (*State, error) {
+	state, _, err := s.get()
+	return state, err
+}
+
+func (s *Store) get() (*State, *v1.ConfigMap, error) {
+	cm, err := s.core.ConfigMaps(s.namespace).Get(ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get the rollover state: %v", err)
+	}
+	state := &State{}
+	if err := json.Unmarshal([]byte(cm.Data[StateKey]), state); err != nil {
+		return nil, nil, fmt.Errorf("invalid rollover state in ConfigMap %s/%s: %v", s.namespace, ConfigMapName, err)
+	}
+	return state, cm, nil
+}
+
+// Material returns the new key and certificates of the CA.
+func (s *Store) Material() (*Material, error) {
+	secret, err := s.core.Secrets(s.namespace).Get(SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the rollover secret: %v", err)
+	}
+	return &Material{
+		SigningCert: secret.Data[SigningCertID],
+		SigningKey:  secret.Data[SigningKeyID],
+		CertChain:   secret.Data[CertChainID],
+		RootCert:    secret.Data[RootCertID],
+	}, nil
+}
+
+// Start starts a rollover to the given key and certificates, introducing the new root in the trust bundle.
+func (s *Store) Start(m *Material, auto bool) (*State, error) {
+	if err := m.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid CA key and certificates: %v", err)
+	}
+	state, _, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		return nil, fmt.Errorf("a rollover is already in phase %s", state.Phase)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName,
+			Namespace: s.namespace,
+		},
+		Data: map[string][]byte{
+			SigningCertID: m.SigningCert,
+			SigningKeyID:  m.SigningKey,
+			CertChainID:   m.CertChain,
+			RootCertID:    m.RootCert,
+		},
+		Type: SecretType,
+	}
+	if _, err := s.core.Secrets(s.namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create the rollover secret: %v", err)
+		}
+		// Left over by an aborted rollover.
+		if _, err := s.core.Secrets(s.namespace).Update(secret); err != nil {
+			return nil, fmt.Errorf("failed to update the rollover secret: %v", err)
+		}
+	}
+
+	state = &State{
+		Phase:              PhaseDistributing,
+		Auto:               auto,
+		LastTransitionTime: time.Now(),
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: s.namespace,
+		},
+	}
+	if err := setState(cm, state); err != nil {
+		return nil, err
+	}
+	if _, err := s.core.ConfigMaps(s.namespace).Create(cm); err != nil {
+		return nil, fmt.Errorf("failed to create the rollover state: %v", err)
+	}
+	return state, nil
+}
+
+// Advance moves the rollover to the next phase. Unless forced, the current phase must have propagated to every
+// workload.
+func (s *Store) Advance(force bool) (*State, error) {
+	return s.update(func(state *State) error {
+		next := state.Phase.Next()
+		if next == "" {
+			return fmt.Errorf("the rollover is already completed")
+		}
+		if !force && !state.Status.Propagated {
+			return fmt.Errorf("phase %s has not propagated yet (%d/%d workloads up-to-date)",
+				state.Phase, state.Status.Updated, state.Status.Total)
+		}
+		state.Phase = next
+		state.LastTransitionTime = time.Now()
+		state.Status = Status{}
+		return nil
+	})
+}
+
+// Abort cancels a rollover which has not started issuing from the new root yet.
+func (s *Store) Abort() error {
+	state, _, err := s.get()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no rollover in progress")
+	}
+	if state.Phase != PhaseDistributing {
+		return fmt.Errorf("cannot abort the rollover in phase %s, certificates may already be issued from the new root",
+			state.Phase)
+	}
+	return s.delete()
+}
+
+// Finish removes a completed rollover. For a plugged-in CA, the CA certificate files must be replaced by the new
+// ones first: the CA keeps using the rollover key and certificates until then.
+func (s *Store) Finish() error {
+	state, _, err := s.get()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no rollover in progress")
+	}
+	if state.Phase != PhaseCompleted {
+		return fmt.Errorf("cannot finish the rollover in phase %s", state.Phase)
+	}
+	return s.delete()
+}
+
+// RecordOldRootCerts records the trust bundle of the CA before the rollover, if not recorded yet.
+func (s *Store) RecordOldRootCerts(rootCerts []byte) (*State, error) {
+	return s.update(func(state *State) error {
+		if state.OldRootCerts == "" {
+			state.OldRootCerts = string(rootCerts)
+		}
+		return nil
+	})
+}
+
+// UpdateStatus reports the propagation status of the current phase, advancing the rollover to the next phase if
+// it is automatic and the current phase has propagated.
+func (s *Store) UpdateStatus(phase Phase, status Status) (*State, error) {
+	return s.update(func(state *State) error {
+		if state.Phase != phase {
+			return fmt.Errorf("the rollover moved from phase %s to %s", phase, state.Phase)
+		}
+		state.Status = status
+		if state.Auto && status.Propagated && phase.Next() != "" {
+			state.Phase = phase.Next()
+			state.LastTransitionTime = time.Now()
+			state.Status = Status{}
+		}
+		return nil
+	})
+}
+
+func (s *Store) update(f func(*State) error) (*State, error) {
+	state, cm, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no rollover in progress")
+	}
+	if err := f(state); err != nil {
+		return nil, err
+	}
+	if err := setState(cm, state); err != nil {
+		return nil, err
+	}
+	// The update fails on conflict if the state changed since it was read.
+	if _, err := s.core.ConfigMaps(s.namespace).Update(cm); err != nil {
+		return nil, fmt.Errorf("failed to update the rollover state: %v", err)
+	}
+	return state, nil
+}
+
+func (s *Store) delete() error {
+	if err := s.core.ConfigMaps(s.namespace).Delete(ConfigMapName, &metav1.DeleteOptions{}); err != nil &&
+		!errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the rollover state: %v", err)
+	}
+	if err := s.core.Secrets(s.namespace).Delete(SecretName, &metav1.DeleteOptions{}); err != nil &&
+		!errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the rollover secret: %v", err)
+	}
+	return nil
+}
+
+func setState(cm *v1.ConfigMap, state *State) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the rollover state: %v", err)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[StateKey] = string(b)
+	return nil
+}
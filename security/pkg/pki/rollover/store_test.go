@@ -0,0 +1,127 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollover
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(fake.NewSimpleClientset().CoreV1(), "istio-system")
+	if state, err := store.Get(); state != nil || err != nil {
+		t.Fatalf("expected no rollover, got %v (error %v)", state, err)
+	}
+
+	m := genMaterial(t)
+	if _, err := store.Start(&Material{SigningCert: m.SigningCert, RootCert: m.RootCert}, false); err == nil {
+		t.Errorf("expected invalid material to be rejected")
+	}
+	state, err := store.Start(m, false)
+	if err != nil {
+		t.Fatalf("failed to start the rollover: %v", err)
+	}
+	if state.Phase != PhaseDistributing {
+		t.Errorf("got phase %s", state.Phase)
+	}
+	if _, err := store.Start(m, false); err == nil || !strings.Contains(err.Error(), "already in phase") {
+		t.Errorf("expected a second rollover to be rejected, got %v", err)
+	}
+	got, err := store.Material()
+	if err != nil || !bytes.Equal(got.SigningKey, m.SigningKey) || !bytes.Equal(got.RootCert, m.RootCert) {
+		t.Errorf("got material %v (error %v)", got, err)
+	}
+
+	if _, err := store.RecordOldRootCerts([]byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := store.RecordOldRootCerts([]byte("other")); state.OldRootCerts != "old" {
+		t.Errorf("expected the old root certs to be recorded once, got %q", state.OldRootCerts)
+	}
+
+	if _, err := store.Advance(false); err == nil || !strings.Contains(err.Error(), "has not propagated yet") {
+		t.Errorf("expected the advance to be gated on the propagation, got %v", err)
+	}
+	if _, err := store.UpdateStatus(PhaseIssuing, Status{Propagated: true}); err == nil {
+		t.Errorf("expected the status of another phase to be rejected")
+	}
+	if _, err := store.UpdateStatus(PhaseDistributing, Status{Propagated: true, Updated: 2, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = store.Advance(false); err != nil || state.Phase != PhaseIssuing || state.Status.Total != 0 {
+		t.Errorf("expected to advance to phase %s with a reset status, got %+v (error %v)", PhaseIssuing, state, err)
+	}
+	if err := store.Abort(); err == nil {
+		t.Errorf("expected the abort of a rollover issuing from the new root to be rejected")
+	}
+	if err := store.Finish(); err == nil {
+		t.Errorf("expected the finish of an incomplete rollover to be rejected")
+	}
+
+	if state, err = store.Advance(true); err != nil || state.Phase != PhaseRetiring {
+		t.Errorf("expected a forced advance to phase %s, got %+v (error %v)", PhaseRetiring, state, err)
+	}
+	if state, err = store.Advance(true); err != nil || state.Phase != PhaseCompleted {
+		t.Errorf("expected a forced advance to phase %s, got %+v (error %v)", PhaseCompleted, state, err)
+	}
+	if _, err = store.Advance(true); err == nil {
+		t.Errorf("expected a completed rollover not to advance")
+	}
+	if err := store.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := store.Get(); state != nil || err != nil {
+		t.Errorf("expected no rollover, got %v (error %v)", state, err)
+	}
+}
+
+func TestStoreAuto(t *testing.T) {
+	store := NewStore(fake.NewSimpleClientset().CoreV1(), "istio-system")
+	if _, err := store.Start(genMaterial(t), true); err != nil {
+		t.Fatal(err)
+	}
+	state, err := store.UpdateStatus(PhaseDistributing, Status{Updated: 1, Total: 2})
+	if err != nil || state.Phase != PhaseDistributing || state.Status.Updated != 1 {
+		t.Errorf("expected the phase not to advance before the propagation, got %+v (error %v)", state, err)
+	}
+	state, err = store.UpdateStatus(PhaseDistributing, Status{Propagated: true, Updated: 2, Total: 2})
+	if err != nil || state.Phase != PhaseIssuing {
+		t.Errorf("expected an automatic advance to phase %s, got %+v (error %v)", PhaseIssuing, state, err)
+	}
+
+	if err := NewStore(fake.NewSimpleClientset().CoreV1(), "istio-system").Abort(); err == nil {
+		t.Errorf("expected an error without rollover")
+	}
+}
+
+func TestStoreAbort(t *testing.T) {
+	store := NewStore(fake.NewSimpleClientset().CoreV1(), "istio-system")
+	if _, err := store.Start(genMaterial(t), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Material(); err == nil {
+		t.Errorf("expected the rollover secret to be deleted")
+	}
+	// A new rollover can be started once aborted.
+	if _, err := store.Start(genMaterial(t), false); err != nil {
+		t.Errorf("failed to start a new rollover: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+
+	kms "istio.io/istio/security/proto/kms"
+)
+
+const kmsTimeout = 10 * time.Second
+
+// kmsSigner delegates the signatures to a KMS plugin implementing the KeyManagementService.
+type kmsSigner struct {
+	client    kms.KeyManagementServiceClient
+	keyID     string
+	publicKey crypto.PublicKey
+}
+
+// NewKMSSigner connects to the KMS plugin listening on the given Unix domain socket, and returns a signer
+// delegating the signatures with the given key to the plugin.
+func NewKMSSigner(socket, keyID string) (crypto.Signer, error) {
+	conn, err := grpc.Dial(socket, grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the KMS plugin at %s: %v", socket, err)
+	}
+	return newKMSSigner(kms.NewKeyManagementServiceClient(conn), keyID)
+}
+
+func newKMSSigner(client kms.KeyManagementServiceClient, keyID string) (*kmsSigner, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	resp, err := client.GetPublicKey(ctx, &kms.GetPublicKeyRequest{KeyId: keyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the public key of key %q from the KMS plugin: %v", keyID, err)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of key %q: %v", keyID, err)
+	}
+	signerLog.Infof("using key %q of the KMS plugin", keyID)
+	return &kmsSigner{
+		client:    client,
+		keyID:     keyID,
+		publicKey: publicKey,
+	}, nil
+}
+
+// Public implements crypto.Signer
+func (s *kmsSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign implements crypto.Signer. The KMS plugin returns PKCS #1 v1.5 signatures for RSA keys, RSA-PSS is not
+// supported.
+func (s *kmsSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, fmt.Errorf("RSA-PSS signatures are not supported by the KMS plugin")
+	}
+	hash, err := hashAlgorithm(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	resp, err := s.client.Sign(ctx, &kms.SignRequest{
+		KeyId:         s.keyID,
+		Digest:        digest,
+		HashAlgorithm: hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with key %q of the KMS plugin: %v", s.keyID, err)
+	}
+	return resp.Signature, nil
+}
+
+func hashAlgorithm(h crypto.Hash) (kms.HashAlgorithm, error) {
+	switch h {
+	case crypto.SHA256:
+		return kms.HashAlgorithm_SHA256, nil
+	case crypto.SHA384:
+		return kms.HashAlgorithm_SHA384, nil
+	case crypto.SHA512:
+		return kms.HashAlgorithm_SHA512, nil
+	default:
+		return kms.HashAlgorithm_HASH_ALGORITHM_UNSPECIFIED, fmt.Errorf("unsupported hash algorithm %v", h)
+	}
+}
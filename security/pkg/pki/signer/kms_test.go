@@ -0,0 +1,205 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	kms "istio.io/istio/security/proto/kms"
+)
+
+// fakeKMS is a KMS plugin holding software keys.
+type fakeKMS struct {
+	keys map[string]crypto.Signer
+}
+
+func (f *fakeKMS) GetPublicKey(_ context.Context, req *kms.GetPublicKeyRequest) (*kms.GetPublicKeyResponse, error) {
+	key, ok := f.keys[req.KeyId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no key %q", req.KeyId)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &kms.GetPublicKeyResponse{PublicKey: der}, nil
+}
+
+func (f *fakeKMS) Sign(_ context.Context, req *kms.SignRequest) (*kms.SignResponse, error) {
+	key, ok := f.keys[req.KeyId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no key %q", req.KeyId)
+	}
+	hashes := map[kms.HashAlgorithm]crypto.Hash{
+		kms.HashAlgorithm_SHA256: crypto.SHA256,
+		kms.HashAlgorithm_SHA384: crypto.SHA384,
+		kms.HashAlgorithm_SHA512: crypto.SHA512,
+	}
+	sig, err := key.Sign(rand.Reader, req.Digest, hashes[req.HashAlgorithm])
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &kms.SignResponse{Signature: sig}, nil
+}
+
+func startFakeKMS(t *testing.T, keys map[string]crypto.Signer) (string, func()) {
+	dir, err := ioutil.TempDir("", "kms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "kms.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	kms.RegisterKeyManagementServiceServer(server, &fakeKMS{keys: keys})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	return socket, func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestKMSSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket, stop := startFakeKMS(t, map[string]crypto.Signer{"rsa": rsaKey, "ec": ecKey})
+	defer stop()
+
+	rsaDigest := sha256.Sum256([]byte("message"))
+	ecDigest := sha512.Sum384([]byte("message"))
+	testCases := []struct {
+		name   string
+		keyID  string
+		digest []byte
+		opts   crypto.SignerOpts
+		verify func(sig []byte) error
+	}{
+		{
+			name:   "RSA key",
+			keyID:  "rsa",
+			digest: rsaDigest[:],
+			opts:   crypto.SHA256,
+			verify: func(sig []byte) error {
+				return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, rsaDigest[:], sig)
+			},
+		},
+		{
+			name:   "ECDSA key",
+			keyID:  "ec",
+			digest: ecDigest[:],
+			opts:   crypto.SHA384,
+			verify: func(sig []byte) error {
+				cert := &x509.Certificate{PublicKey: &ecKey.PublicKey, PublicKeyAlgorithm: x509.ECDSA}
+				return cert.CheckSignature(x509.ECDSAWithSHA384, []byte("message"), sig)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Config{Type: KMS, KMSPluginSocket: socket, KMSKeyID: tc.keyID})
+			if err != nil {
+				t.Fatalf("failed to create the signer: %v", err)
+			}
+			want := rsaKey.Public()
+			if tc.keyID == "ec" {
+				want = ecKey.Public()
+			}
+			if !reflect.DeepEqual(s.Public(), want) {
+				t.Errorf("got public key %v, want %v", s.Public(), want)
+			}
+			sig, err := s.Sign(rand.Reader, tc.digest, tc.opts)
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			if err := tc.verify(sig); err != nil {
+				t.Errorf("invalid signature: %v", err)
+			}
+		})
+	}
+}
+
+func TestKMSSignerErrors(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket, stop := startFakeKMS(t, map[string]crypto.Signer{"rsa": rsaKey})
+	defer stop()
+
+	if _, err := NewKMSSigner(socket, "unknown"); err == nil || !strings.Contains(err.Error(), "no key") {
+		t.Errorf("expected an unknown key error, got %v", err)
+	}
+
+	s, err := NewKMSSigner(socket, "rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	if _, err := s.Sign(rand.Reader, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256}); err == nil {
+		t.Errorf("expected RSA-PSS to be rejected")
+	}
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA1); err == nil {
+		t.Errorf("expected SHA-1 to be rejected")
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		config Config
+		err    string
+	}{
+		{config: Config{Type: "vault"}, err: "unknown signer type"},
+		{config: Config{Type: KMS}, err: "the KMS plugin socket must be set"},
+		{config: Config{Type: PKCS11, PKCS11Module: "/lib/softhsm.so"}, err: "token label and key label must be set"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%+v", tc.config), func(t *testing.T) {
+			if _, err := New(tc.config); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !pkcs11
+
+package signer
+
+import (
+	"crypto"
+	"fmt"
+)
+
+// NewPKCS11Signer is not available: the PKCS #11 support requires cgo and is only built with the pkcs11 tag.
+func NewPKCS11Signer(_, _, _, _ string) (crypto.Signer, error) {
+	return nil, fmt.Errorf("PKCS #11 is not supported by this build, it must be built with cgo and the pkcs11 tag")
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build pkcs11
+
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"os"
+	"testing"
+)
+
+// TestPKCS11Signer runs against a SoftHSM token, e.g. initialized with:
+//
+//   softhsm2-util --init-token --free --label istio-ca --pin 1234 --so-pin 1234
+//   pkcs11-tool --module $SOFTHSM_MODULE --login --pin 1234 --token-label istio-ca \
+//     --keypairgen --key-type rsa:2048 --label ca-key
+//
+// and run with:
+//
+//   SOFTHSM_MODULE=/usr/lib/softhsm/libsofthsm2.so go test -tags pkcs11 ./security/pkg/pki/signer/
+func TestPKCS11Signer(t *testing.T) {
+	module := os.Getenv("SOFTHSM_MODULE")
+	if module == "" {
+		t.Skip("SOFTHSM_MODULE is not set")
+	}
+
+	s, err := New(Config{
+		Type:             PKCS11,
+		PKCS11Module:     module,
+		PKCS11TokenLabel: "istio-ca",
+		PKCS11PIN:        "1234",
+		PKCS11KeyLabel:   "ca-key",
+	})
+	if err != nil {
+		t.Fatalf("failed to create the signer: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	cert := &x509.Certificate{PublicKey: s.Public()}
+	algo := x509.SHA256WithRSA
+	if _, ok := s.Public().(*ecdsa.PublicKey); ok {
+		algo = x509.ECDSAWithSHA256
+	}
+	if err := cert.CheckSignature(algo, []byte("message"), sig); err != nil {
+		t.Errorf("invalid signature: %v", err)
+	}
+}
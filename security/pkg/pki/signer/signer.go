@@ -0,0 +1,72 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer provides crypto.Signer implementations delegating the signatures of the CA to an external key
+// manager, so that the CA signing key is never loaded in memory nor stored in a Kubernetes secret.
+package signer
+
+import (
+	"crypto"
+	"fmt"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// PKCS11 is the type of the signers using a key in a PKCS #11 token, e.g. an HSM or SoftHSM.
+	PKCS11 = "pkcs11"
+
+	// KMS is the type of the signers using a key in a key manager through a KMS plugin.
+	KMS = "kms"
+)
+
+var signerLog = log.RegisterScope("signer", "External CA signer log", 0)
+
+// Config is the configuration of an external signer.
+type Config struct {
+	// Type is the type of the signer, either PKCS11 or KMS.
+	Type string
+
+	// PKCS11Module is the path of the PKCS #11 module, e.g. "/usr/lib/softhsm/libsofthsm2.so".
+	PKCS11Module string
+	// PKCS11TokenLabel is the label of the token holding the key.
+	PKCS11TokenLabel string
+	// PKCS11PIN is the user PIN of the token.
+	PKCS11PIN string
+	// PKCS11KeyLabel is the label of the key pair in the token.
+	PKCS11KeyLabel string
+
+	// KMSPluginSocket is the path of the Unix domain socket the KMS plugin listens on.
+	KMSPluginSocket string
+	// KMSKeyID is the ID of the key in the key manager.
+	KMSKeyID string
+}
+
+// New creates the signer of the given configuration.
+func New(c Config) (crypto.Signer, error) {
+	switch c.Type {
+	case PKCS11:
+		if c.PKCS11Module == "" || c.PKCS11TokenLabel == "" || c.PKCS11KeyLabel == "" {
+			return nil, fmt.Errorf("the PKCS #11 module, token label and key label must be set")
+		}
+		return NewPKCS11Signer(c.PKCS11Module, c.PKCS11TokenLabel, c.PKCS11PIN, c.PKCS11KeyLabel)
+	case KMS:
+		if c.KMSPluginSocket == "" {
+			return nil, fmt.Errorf("the KMS plugin socket must be set")
+		}
+		return NewKMSSigner(c.KMSPluginSocket, c.KMSKeyID)
+	default:
+		return nil, fmt.Errorf("unknown signer type %q, must be %q or %q", c.Type, PKCS11, KMS)
+	}
+}
@@ -16,6 +16,8 @@ package util
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -98,3 +100,19 @@ func GetRSAKeySize(privKey crypto.PrivateKey) (int, error) {
 	pkey := privKey.(*rsa.PrivateKey)
 	return pkey.N.BitLen(), nil
 }
+
+// GetEllipticCurve returns the curve of the key if it is an ECDSA key, otherwise it returns an error.
+func GetEllipticCurve(privKey crypto.PrivateKey) (SupportedEllipticCurves, error) {
+	pkey, ok := privKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("key type is not ECDSA: %T", privKey)
+	}
+	switch pkey.Curve {
+	case elliptic.P256():
+		return P256Curve, nil
+	case elliptic.P384():
+		return P384Curve, nil
+	default:
+		return "", fmt.Errorf("unsupported elliptic curve: %s", pkey.Curve.Params().Name)
+	}
+}
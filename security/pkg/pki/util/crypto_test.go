@@ -15,7 +15,10 @@
 package util
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"reflect"
@@ -312,3 +315,52 @@ func TestGetRSAKeySize(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEllipticCurve(t *testing.T) {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the ECDSA key: %v", err)
+	}
+	p224Key, err := ParsePemEncodedKey([]byte(keyECDSA))
+	if err != nil {
+		t.Fatalf("failed to parse the Pem key: %v", err)
+	}
+	rsaKey, err := ParsePemEncodedKey([]byte(keyRSA))
+	if err != nil {
+		t.Fatalf("failed to parse the Pem key: %v", err)
+	}
+
+	testCases := map[string]struct {
+		key    crypto.PrivateKey
+		curve  SupportedEllipticCurves
+		errMsg string
+	}{
+		"Success with P384 key": {
+			key:   p384Key,
+			curve: P384Curve,
+		},
+		"Failure with P224 key": {
+			key:    p224Key,
+			errMsg: "unsupported elliptic curve: P-224",
+		},
+		"Failure with RSA key": {
+			key:    rsaKey,
+			errMsg: "key type is not ECDSA: *rsa.PrivateKey",
+		},
+	}
+
+	for id, c := range testCases {
+		curve, err := GetEllipticCurve(c.key)
+		if c.errMsg != "" {
+			if err == nil {
+				t.Errorf(`%s: no error is returned, expected error: "%s"`, id, c.errMsg)
+			} else if c.errMsg != err.Error() {
+				t.Errorf(`%s: Unexpected error message: expected "%s" but got "%s"`, id, c.errMsg, err.Error())
+			}
+		} else if err != nil {
+			t.Errorf(`%s: Unexpected error: "%s"`, id, err)
+		} else if curve != c.curve {
+			t.Errorf(`%s: Unmatched curve: expected %v but got "%v"`, id, c.curve, curve)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -36,6 +37,22 @@ import (
 	"istio.io/pkg/log"
 )
 
+// SupportedECSignatureAlgorithms are the types of EC signature algorithms to be used in key generation.
+type SupportedECSignatureAlgorithms string
+
+// SupportedEllipticCurves are the elliptic curves of the generated EC keys.
+type SupportedEllipticCurves string
+
+const (
+	// EcdsaSigAlg is the ECDSA signature algorithm.
+	EcdsaSigAlg SupportedECSignatureAlgorithms = "ECDSA"
+
+	// P256Curve is the NIST P-256 curve.
+	P256Curve SupportedEllipticCurves = "P256"
+	// P384Curve is the NIST P-384 curve.
+	P384Curve SupportedEllipticCurves = "P384"
+)
+
 // CertOptions contains options for generating a new certificate.
 type CertOptions struct {
 	// Comma-separated hostnames and IPs to generate a certificate for.
@@ -64,6 +81,13 @@ type CertOptions struct {
 	// The size of RSA private key to be generated.
 	RSAKeySize int
 
+	// The type of EC signature algorithm of the private key to be generated. An RSA key of RSAKeySize is generated
+	// if empty.
+	ECSigAlg SupportedECSignatureAlgorithms
+
+	// The elliptic curve of the EC private key to be generated, P256Curve if empty.
+	ECCCurve SupportedEllipticCurves
+
 	// Whether this certificate is used as signing cert for CA.
 	IsCA bool
 
@@ -85,24 +109,24 @@ type CertOptions struct {
 
 // GenCertKeyFromOptions generates a X.509 certificate and a private key with the given options.
 func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte, err error) {
-	// Generate a RSA private&public key pair.
+	// Generate a RSA or EC private&public key pair.
 	// The public key will be bound to the certificate generated below. The
 	// private key will be used to sign this certificate in the self-signed
 	// case, otherwise the certificate is signed by the signer private key
 	// as specified in the CertOptions.
-	priv, err := rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	priv, err := generateKey(options)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cert generation fails at RSA key generation (%v)", err)
+		return nil, nil, fmt.Errorf("cert generation fails at key generation (%v)", err)
 	}
 	template, err := genCertTemplateFromOptions(options)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cert generation fails at cert template creation (%v)", err)
 	}
-	signerCert, signerKey := template, crypto.PrivateKey(priv)
+	signerCert, signerKey := template, priv
 	if !options.IsSelfSigned {
 		signerCert, signerKey = options.SignerCert, options.SignerPriv
 	}
-	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, &priv.PublicKey, signerKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, publicKey(priv), signerKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cert generation fails at X509 cert creation (%v)", err)
 	}
@@ -114,6 +138,33 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	return
 }
 
+// generateKey generates the private key of the algorithm selected in the options.
+func generateKey(options CertOptions) (crypto.PrivateKey, error) {
+	switch options.ECSigAlg {
+	case "":
+		return rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	case EcdsaSigAlg:
+		curve, err := ellipticCurve(options.ECCCurve)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported EC signature algorithm: %s", options.ECSigAlg)
+	}
+}
+
+func ellipticCurve(c SupportedEllipticCurves) (elliptic.Curve, error) {
+	switch c {
+	case "", P256Curve:
+		return elliptic.P256(), nil
+	case P384Curve:
+		return elliptic.P384(), nil
+	default:
+		return nil, fmt.Errorf("unsupported elliptic curve: %s", c)
+	}
+}
+
 func publicKey(priv interface{}) interface{} {
 	switch k := priv.(type) {
 	case *rsa.PrivateKey:
@@ -243,8 +294,9 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 		ExtKeyUsage:           extKeyUsages,
 		IsCA:                  isCA,
 		BasicConstraintsValid: true,
-		ExtraExtensions:       exts,
-		SignatureAlgorithm:    csr.SignatureAlgorithm}, nil
+		// The signature algorithm is derived from the signing key, which may be of a different type than the
+		// key of the CSR.
+		ExtraExtensions: exts}, nil
 }
 
 // genCertTemplateFromoptions generates a certificate template with the given options.
@@ -319,7 +371,7 @@ func genSerialNum() (*big.Int, error) {
 	return serialNum, nil
 }
 
-func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey, pkcs8 bool) (
+func encodePem(isCSR bool, csrOrCert []byte, priv crypto.PrivateKey, pkcs8 bool) (
 	csrOrCertPem []byte, privPem []byte, err error) {
 	encodeMsg := "CERTIFICATE"
 	if isCSR {
@@ -334,8 +386,18 @@ func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey, pkcs8 bool) (
 		}
 		privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypePKCS8PrivateKey, Bytes: encodedKey})
 	} else {
-		encodedKey = x509.MarshalPKCS1PrivateKey(priv)
-		privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeRSAPrivateKey, Bytes: encodedKey})
+		switch k := priv.(type) {
+		case *rsa.PrivateKey:
+			encodedKey = x509.MarshalPKCS1PrivateKey(k)
+			privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeRSAPrivateKey, Bytes: encodedKey})
+		case *ecdsa.PrivateKey:
+			if encodedKey, err = x509.MarshalECPrivateKey(k); err != nil {
+				return nil, nil, err
+			}
+			privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeECPrivateKey, Bytes: encodedKey})
+		default:
+			return nil, nil, fmt.Errorf("unsupported private key type: %T", priv)
+		}
 	}
 	err = nil
 	return
@@ -15,6 +15,8 @@
 package util
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/x509"
 	"encoding/pem"
 	"strings"
@@ -54,6 +56,73 @@ func TestGenCSR(t *testing.T) {
 	}
 }
 
+func TestGenCSRWithECDSA(t *testing.T) {
+	cases := map[string]struct {
+		curve         SupportedEllipticCurves
+		expectedCurve elliptic.Curve
+	}{
+		"Default curve": {
+			expectedCurve: elliptic.P256(),
+		},
+		"P256": {
+			curve:         P256Curve,
+			expectedCurve: elliptic.P256(),
+		},
+		"P384": {
+			curve:         P384Curve,
+			expectedCurve: elliptic.P384(),
+		},
+	}
+
+	for id, c := range cases {
+		csrPem, privPem, err := GenCSR(CertOptions{
+			Host:     "test_ca.com",
+			Org:      "MyOrg",
+			ECSigAlg: EcdsaSigAlg,
+			ECCCurve: c.curve,
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to gen CSR: %v", id, err)
+		}
+
+		csr, err := ParsePemEncodedCSR(csrPem)
+		if err != nil {
+			t.Fatalf("%s: failed to parse csr: %v", id, err)
+		}
+		if err = csr.CheckSignature(); err != nil {
+			t.Errorf("%s: csr signature is invalid", id)
+		}
+		pub, ok := csr.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			t.Fatalf("%s: csr public key is not ECDSA: %T", id, csr.PublicKey)
+		}
+		if pub.Curve != c.expectedCurve {
+			t.Errorf("%s: csr curve does not match: %s", id, pub.Curve.Params().Name)
+		}
+
+		priv, err := ParsePemEncodedKey(privPem)
+		if err != nil {
+			t.Fatalf("%s: failed to parse private key: %v", id, err)
+		}
+		if _, ok := priv.(*ecdsa.PrivateKey); !ok {
+			t.Errorf("%s: private key is not ECDSA: %T", id, priv)
+		}
+	}
+}
+
+func TestGenCSRWithInvalidECDSAOptions(t *testing.T) {
+	cases := map[string]CertOptions{
+		"Invalid algorithm": {Host: "test_ca.com", ECSigAlg: "ED25519"},
+		"Invalid curve":     {Host: "test_ca.com", ECSigAlg: EcdsaSigAlg, ECCCurve: "P224"},
+	}
+
+	for id, options := range cases {
+		if csr, priv, err := GenCSR(options); err == nil || csr != nil || priv != nil {
+			t.Errorf("%s: should have failed", id)
+		}
+	}
+}
+
 func TestGenCSRWithInvalidOption(t *testing.T) {
 	// Options with invalid Key size.
 	csrOptions := CertOptions{
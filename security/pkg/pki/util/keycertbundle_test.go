@@ -15,6 +15,9 @@
 package util
 
 import (
+	"crypto"
+	"crypto/x509"
+	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
@@ -167,6 +170,103 @@ func TestCertOptionsAndRetrieveID(t *testing.T) {
 	}
 }
 
+func TestKeyCertBundleWithMixedKeys(t *testing.T) {
+	rsaRootOptions := CertOptions{
+		Host:         "spiffe://cluster.local/ns/istio-system/sa/root",
+		TTL:          time.Hour,
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	}
+	ecdsaRootOptions := rsaRootOptions
+	ecdsaRootOptions.RSAKeySize = 0
+	ecdsaRootOptions.ECSigAlg = EcdsaSigAlg
+	ecdsaRootOptions.ECCCurve = P384Curve
+
+	testCases := map[string]struct {
+		rootOptions CertOptions
+		caOptions   *CertOptions
+	}{
+		"ECDSA CA signed by RSA root": {
+			rootOptions: rsaRootOptions,
+			caOptions: &CertOptions{
+				Host:     "spiffe://cluster.local/ns/istio-system/sa/citadel",
+				TTL:      time.Hour,
+				Org:      "MyOrg",
+				IsCA:     true,
+				ECSigAlg: EcdsaSigAlg,
+				ECCCurve: P256Curve,
+			},
+		},
+		"RSA CA signed by ECDSA root": {
+			rootOptions: ecdsaRootOptions,
+			caOptions: &CertOptions{
+				Host:       "spiffe://cluster.local/ns/istio-system/sa/citadel",
+				TTL:        time.Hour,
+				Org:        "MyOrg",
+				IsCA:       true,
+				RSAKeySize: 1024,
+			},
+		},
+		"ECDSA CA signed by ECDSA root": {
+			rootOptions: ecdsaRootOptions,
+			caOptions: &CertOptions{
+				Host:     "spiffe://cluster.local/ns/istio-system/sa/citadel",
+				TTL:      time.Hour,
+				Org:      "MyOrg",
+				IsCA:     true,
+				ECSigAlg: EcdsaSigAlg,
+				ECCCurve: P384Curve,
+			},
+		},
+	}
+
+	for id, tc := range testCases {
+		rootCertPem, rootKeyPem, err := GenCertKeyFromOptions(tc.rootOptions)
+		if err != nil {
+			t.Fatalf("%s: failed to generate the root cert: %v", id, err)
+		}
+		rootCert, err := ParsePemEncodedCertificate(rootCertPem)
+		if err != nil {
+			t.Fatalf("%s: failed to parse the root cert: %v", id, err)
+		}
+		rootKey, err := ParsePemEncodedKey(rootKeyPem)
+		if err != nil {
+			t.Fatalf("%s: failed to parse the root key: %v", id, err)
+		}
+
+		caOptions := *tc.caOptions
+		caOptions.SignerCert = rootCert
+		caOptions.SignerPriv = rootKey
+		caCertPem, caKeyPem, err := GenCertKeyFromOptions(caOptions)
+		if err != nil {
+			t.Fatalf("%s: failed to generate the CA cert: %v", id, err)
+		}
+
+		k, err := NewVerifiedKeyCertBundleFromPem(caCertPem, caKeyPem, caCertPem, rootCertPem)
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %v", id, err)
+		}
+		opts, err := k.CertOptions()
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %v", id, err)
+		}
+		compareCertOptions(opts, tc.caOptions, t)
+
+		fields := &VerifyFields{
+			TTL:      time.Hour,
+			KeyUsage: x509.KeyUsageCertSign,
+			IsCA:     true,
+			Org:      "MyOrg",
+			Host:     tc.caOptions.Host,
+		}
+		if err := VerifyCertificate(caKeyPem, caCertPem, rootCertPem, fields); err != nil {
+			t.Errorf("%s: failed to verify the CA cert: %v", id, err)
+		}
+	}
+}
+
 func compareCertOptions(actual, expected *CertOptions, t *testing.T) {
 	if actual.Host != expected.Host {
 		t.Errorf("host does not match, %s vs %s", actual.Host, expected.Host)
@@ -183,6 +283,12 @@ func compareCertOptions(actual, expected *CertOptions, t *testing.T) {
 	if actual.RSAKeySize != expected.RSAKeySize {
 		t.Errorf("RSAKeySize does not match")
 	}
+	if actual.ECSigAlg != expected.ECSigAlg {
+		t.Errorf("ECSigAlg does not match")
+	}
+	if actual.ECCCurve != expected.ECCCurve {
+		t.Errorf("ECCCurve does not match")
+	}
 }
 
 // The test of NewVerifiedKeyCertBundleFromPem, VerifyAndSetAll can be covered by this test.
@@ -303,3 +409,75 @@ func TestNewVerifiedKeyCertBundleFromFile(t *testing.T) {
 		}
 	}
 }
+
+func TestNewVerifiedKeyCertBundleFromSigner(t *testing.T) {
+	intKey := loadSigner(t, intKeyFile)
+	testCases := map[string]struct {
+		caCertFile    string
+		signer        crypto.Signer
+		certChainFile string
+		expectedErr   string
+	}{
+		"Success - 2 level CA": {
+			caCertFile:    intCertFile,
+			signer:        intKey,
+			certChainFile: intCertChainFile,
+		},
+		"Failure - cert and signer do not match": {
+			caCertFile:    int2CertFile,
+			signer:        intKey,
+			certChainFile: int2CertChainFile,
+			expectedErr:   "the cert does not match the public key of the signer",
+		},
+		"Failure - 3 level CA without cert chain": {
+			caCertFile: int2CertFile,
+			signer:     loadSigner(t, int2KeyFile),
+			expectedErr: "cannot verify the cert with the provided root chain and " +
+				"cert pool with error: x509: certificate signed by unknown authority",
+		},
+	}
+	for id, tc := range testCases {
+		certBytes := readFile(t, tc.caCertFile)
+		var certChainBytes []byte
+		if tc.certChainFile != "" {
+			certChainBytes = readFile(t, tc.certChainFile)
+		}
+		bundle, err := NewVerifiedKeyCertBundleFromSigner(certBytes, tc.signer, certChainBytes,
+			readFile(t, rootCertFile))
+		if err != nil {
+			if tc.expectedErr == "" {
+				t.Errorf("%s: Unexpected error: %v", id, err)
+			} else if err.Error() != tc.expectedErr {
+				t.Errorf("%s: Unexpected error: %v VS (expected) %s", id, err, tc.expectedErr)
+			}
+			continue
+		} else if tc.expectedErr != "" {
+			t.Errorf("%s: Expected error %s but succeeded", id, tc.expectedErr)
+			continue
+		}
+
+		cert, privKey, _, _ := bundle.GetAll()
+		if cert == nil || *privKey != crypto.PrivateKey(tc.signer) {
+			t.Errorf("%s: the bundle does not hold the cert and the signer", id)
+		}
+		if _, keyBytes, _, _ := bundle.GetAllPem(); len(keyBytes) != 0 {
+			t.Errorf("%s: expected no private key PEM, got %s", id, keyBytes)
+		}
+	}
+}
+
+func loadSigner(t *testing.T, keyFile string) crypto.Signer {
+	key, err := ParsePemEncodedKey(readFile(t, keyFile))
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", keyFile, err)
+	}
+	return key.(crypto.Signer)
+}
+
+func readFile(t *testing.T, file string) []byte {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", file, err)
+	}
+	return b
+}
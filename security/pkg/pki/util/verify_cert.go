@@ -15,7 +15,6 @@
 package util
 
 import (
-	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
 	"reflect"
@@ -81,7 +80,7 @@ func VerifyCertificate(privPem []byte, certChainPem []byte, rootCertPem []byte,
 		return err
 	}
 
-	if !reflect.DeepEqual(priv.(*rsa.PrivateKey).PublicKey, *cert.PublicKey.(*rsa.PublicKey)) {
+	if !reflect.DeepEqual(publicKey(priv), cert.PublicKey) {
 		return fmt.Errorf("the generated private key and cert doesn't match")
 	}
 
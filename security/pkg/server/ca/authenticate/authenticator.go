@@ -42,12 +42,16 @@ type AuthSource int
 const (
 	AuthSourceClientCertificate AuthSource = iota
 	AuthSourceIDToken
+	// AuthSourceOIDC is a JWT of a configured OIDC issuer, its identities are mapped from the token claims.
+	AuthSourceOIDC
 )
 
 // Caller carries the identity and authentication source of a caller.
 type Caller struct {
 	AuthSource AuthSource
 	Identities []string
+	// Node is the authenticated name of the node the caller runs on, or empty if unknown.
+	Node string
 }
 
 // ClientCertAuthenticator extracts identities from client certificate.
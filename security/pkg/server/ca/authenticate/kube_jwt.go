@@ -39,8 +39,11 @@ type KubeJWTAuthenticator struct {
 	trustDomain string
 }
 
-// NewKubeJWTAuthenticator creates a new kubeJWTAuthenticator.
-func NewKubeJWTAuthenticator(k8sAPIServerURL, caCertPath, jwtPath, trustDomain string) (*KubeJWTAuthenticator, error) {
+// NewKubeJWTAuthenticator creates a new kubeJWTAuthenticator. The node of the callers is resolved from the pod
+// their token is bound to when the API server doesn't report it and needNode returns true, e.g. if a decision
+// depends on the node. The node is left empty otherwise. A nil needNode never resolves it.
+func NewKubeJWTAuthenticator(k8sAPIServerURL, caCertPath, jwtPath, trustDomain string,
+	needNode func() bool) (*KubeJWTAuthenticator, error) {
 	// Read the CA certificate of the k8s apiserver
 	caCert, err := ioutil.ReadFile(caCertPath)
 	if err != nil {
@@ -50,8 +53,12 @@ func NewKubeJWTAuthenticator(k8sAPIServerURL, caCertPath, jwtPath, trustDomain s
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Citadel JWT: %v", err)
 	}
+	client := tokenreview.NewK8sSvcAcctAuthn(k8sAPIServerURL, caCert, string(reviewerJWT))
+	if needNode != nil {
+		client.ResolveBoundPodNodes(needNode)
+	}
 	return &KubeJWTAuthenticator{
-		client:      tokenreview.NewK8sSvcAcctAuthn(k8sAPIServerURL, caCert, string(reviewerJWT)),
+		client:      client,
 		trustDomain: trustDomain,
 	}, nil
 }
@@ -71,13 +78,14 @@ func (a *KubeJWTAuthenticator) Authenticate(ctx context.Context) (*Caller, error
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate the JWT: %v", err)
 	}
-	if len(id) != 2 {
-		return nil, fmt.Errorf("failed to parse the JWT. Validation result length is not 2, but %d", len(id))
+	if len(id) != 3 {
+		return nil, fmt.Errorf("failed to parse the JWT. Validation result length is not 3, but %d", len(id))
 	}
 	callerNamespace := id[0]
 	callerServiceAccount := id[1]
 	return &Caller{
 		AuthSource: AuthSourceIDToken,
 		Identities: []string{fmt.Sprintf(identityTemplate, a.trustDomain, callerNamespace, callerServiceAccount)},
+		Node:       id[2],
 	}, nil
 }
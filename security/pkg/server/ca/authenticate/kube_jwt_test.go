@@ -77,7 +77,7 @@ func TestNewKubeJWTAuthenticator(t *testing.T) {
 	}
 
 	for id, tc := range testCases {
-		authenticator, err := NewKubeJWTAuthenticator(url, tc.caCertPath, tc.jwtPath, trustDomain)
+		authenticator, err := NewKubeJWTAuthenticator(url, tc.caCertPath, tc.jwtPath, trustDomain, nil)
 		if len(tc.expectedErrMsg) > 0 {
 			if err == nil {
 				t.Errorf("Case %s: Succeeded. Error expected: %v", id, err)
@@ -105,6 +105,7 @@ func TestAuthenticate(t *testing.T) {
 		metadata       metadata.MD
 		client         tokenReviewClient
 		expectedID     string
+		expectedNode   string
 		expectedErrMsg string
 	}{
 		"No bearer token": {
@@ -136,7 +137,7 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			client:         &mockTokenReviewClient{id: []string{"foo"}, err: nil},
-			expectedErrMsg: "failed to parse the JWT. Validation result length is not 2, but 1",
+			expectedErrMsg: "failed to parse the JWT. Validation result length is not 3, but 1",
 		},
 		"Successful": {
 			metadata: metadata.MD{
@@ -146,8 +147,9 @@ func TestAuthenticate(t *testing.T) {
 					"Bearer bearer-token",
 				},
 			},
-			client:         &mockTokenReviewClient{id: []string{"foo", "bar"}, err: nil},
+			client:         &mockTokenReviewClient{id: []string{"foo", "bar", "node-1"}, err: nil},
 			expectedID:     "spiffe://example.com/ns/foo/sa/bar",
+			expectedNode:   "node-1",
 			expectedErrMsg: "",
 		},
 	}
@@ -177,6 +179,7 @@ func TestAuthenticate(t *testing.T) {
 		expectedCaller := &Caller{
 			AuthSource: AuthSourceIDToken,
 			Identities: []string{tc.expectedID},
+			Node:       tc.expectedNode,
 		}
 
 		if !reflect.DeepEqual(actualCaller, expectedCaller) {
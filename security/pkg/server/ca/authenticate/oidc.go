@@ -0,0 +1,318 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/ghodss/yaml"
+	"golang.org/x/net/context"
+	"gopkg.in/square/go-jose.v2"
+
+	"istio.io/istio/pkg/spiffe"
+)
+
+const (
+	OIDCAuthenticatorType = "OIDCAuthenticator"
+)
+
+// OIDCConfig is the configuration of the OIDC authenticator.
+type OIDCConfig struct {
+	// Issuers are the trusted JWT issuers.
+	Issuers []*OIDCIssuer `json:"issuers"`
+}
+
+// OIDCIssuer describes a trusted JWT issuer and how the claims of its tokens are mapped to SPIFFE identities.
+type OIDCIssuer struct {
+	// Issuer is the value of the "iss" claim of the tokens, e.g. "https://accounts.example.com".
+	Issuer string `json:"issuer"`
+
+	// Audiences are the accepted values of the "aud" claim. At least one audience must be set, so that the tokens
+	// the issuer grants to other relying parties can't be replayed to the CA.
+	Audiences []string `json:"audiences"`
+
+	// JwksURI is the URL of the JSON Web Key Set of the issuer. Exactly one of JwksURI and JwksFile must be set.
+	JwksURI string `json:"jwksUri,omitempty"`
+
+	// JwksFile is the path of a file holding the JSON Web Key Set of the issuer.
+	JwksFile string `json:"jwksFile,omitempty"`
+
+	// SigningAlgorithms are the accepted signing algorithms, e.g. "ES256". Defaults to "RS256".
+	SigningAlgorithms []string `json:"signingAlgorithms,omitempty"`
+
+	// IdentityTemplates are the Go templates mapping the claims of a token to the SPIFFE identities of the caller.
+	// The templates are executed with the TrustDomain and the Claims of the token, for instance
+	// "spiffe://{{.TrustDomain}}/ns/{{.Claims.namespace}}/sa/{{.Claims.sub}}". Referencing a missing claim
+	// fails the authentication, as well as referencing a claim whose value is not a single path segment, e.g.
+	// "foo/sa/admin" or "..", which could otherwise impersonate another identity.
+	IdentityTemplates []string `json:"identityTemplates"`
+}
+
+// LoadOIDCConfig returns a new OIDCConfig decoded and validated from the input YAML.
+func LoadOIDCConfig(in []byte) (*OIDCConfig, error) {
+	out := &OIDCConfig{}
+	if err := yaml.Unmarshal(in, out); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the OIDC configuration: %v", err)
+	}
+	if len(out.Issuers) == 0 {
+		return nil, fmt.Errorf("no issuer in the OIDC configuration")
+	}
+
+	issuers := map[string]bool{}
+	for _, i := range out.Issuers {
+		if i.Issuer == "" {
+			return nil, fmt.Errorf("issuer must be set")
+		}
+		if issuers[i.Issuer] {
+			return nil, fmt.Errorf("duplicate issuer %q", i.Issuer)
+		}
+		issuers[i.Issuer] = true
+		if (i.JwksURI == "") == (i.JwksFile == "") {
+			return nil, fmt.Errorf("issuer %q: exactly one of jwksUri and jwksFile must be set", i.Issuer)
+		}
+		if len(i.Audiences) == 0 {
+			return nil, fmt.Errorf("issuer %q: at least one audience must be set", i.Issuer)
+		}
+		if len(i.IdentityTemplates) == 0 {
+			return nil, fmt.Errorf("issuer %q: at least one identity template must be set", i.Issuer)
+		}
+		for _, t := range i.IdentityTemplates {
+			if _, err := template.New("identity").Parse(t); err != nil {
+				return nil, fmt.Errorf("issuer %q: invalid identity template %q: %v", i.Issuer, t, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// oidcIssuerVerifier verifies the tokens of an issuer and maps their claims to SPIFFE identities.
+type oidcIssuerVerifier struct {
+	audiences  []string
+	verifier   *oidc.IDTokenVerifier
+	identities []*template.Template
+}
+
+// OIDCAuthenticator authenticates the JWTs of the configured OIDC issuers.
+type OIDCAuthenticator struct {
+	trustDomain string
+	issuers     map[string]*oidcIssuerVerifier
+}
+
+// NewOIDCAuthenticator creates a new OIDCAuthenticator from the configuration in the given file.
+func NewOIDCAuthenticator(configFile, trustDomain string) (*OIDCAuthenticator, error) {
+	in, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OIDC configuration: %v", err)
+	}
+	config, err := LoadOIDCConfig(in)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &OIDCAuthenticator{
+		trustDomain: trustDomain,
+		issuers:     make(map[string]*oidcIssuerVerifier, len(config.Issuers)),
+	}
+	for _, i := range config.Issuers {
+		var keySet oidc.KeySet
+		if i.JwksURI != "" {
+			keySet = oidc.NewRemoteKeySet(context.Background(), i.JwksURI)
+		} else if keySet, err = newStaticKeySet(i.JwksFile); err != nil {
+			return nil, fmt.Errorf("issuer %q: %v", i.Issuer, err)
+		}
+
+		v := &oidcIssuerVerifier{
+			audiences: i.Audiences,
+			verifier: oidc.NewVerifier(i.Issuer, keySet, &oidc.Config{
+				// The audiences are checked after the verification, as several audiences may be accepted.
+				SkipClientIDCheck:    true,
+				SupportedSigningAlgs: i.SigningAlgorithms,
+			}),
+		}
+		for _, t := range i.IdentityTemplates {
+			v.identities = append(v.identities, template.Must(template.New("identity").Option("missingkey=error").Parse(t)))
+		}
+		a.issuers[i.Issuer] = v
+	}
+	return a, nil
+}
+
+func (a *OIDCAuthenticator) AuthenticatorType() string {
+	return OIDCAuthenticatorType
+}
+
+// Authenticate authenticates the call using the JWT from the context. The returned Caller.Identities are the
+// SPIFFE identities mapped from the claims of the token.
+func (a *OIDCAuthenticator) Authenticate(ctx context.Context) (*Caller, error) {
+	bearerToken, err := extractBearerToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ID token extraction error: %v", err)
+	}
+
+	issuer, err := unverifiedIssuer(bearerToken)
+	if err != nil {
+		return nil, err
+	}
+	v, found := a.issuers[issuer]
+	if !found {
+		return nil, fmt.Errorf("untrusted issuer %q", issuer)
+	}
+
+	idToken, err := v.verifier.Verify(context.Background(), bearerToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the ID token (error %v)", err)
+	}
+	if !containsAny(idToken.Audience, v.audiences) {
+		return nil, fmt.Errorf("the ID token audiences %v are not accepted", idToken.Audience)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to extract the claims from the ID token: %v", err)
+	}
+	data := struct {
+		TrustDomain string
+		Claims      map[string]interface{}
+	}{
+		TrustDomain: a.trustDomain,
+		Claims:      pathSegmentClaims(claims),
+	}
+	ids := make([]string, 0, len(v.identities))
+	for _, t := range v.identities {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to map the ID token claims to an identity: %v", err)
+		}
+		id := buf.String()
+		if !strings.HasPrefix(id, spiffe.URIPrefix) || strings.Contains(strings.TrimPrefix(id, spiffe.URIPrefix), "//") ||
+			strings.HasSuffix(id, "/") {
+			return nil, fmt.Errorf("the ID token claims are mapped to an invalid identity %q", id)
+		}
+		ids = append(ids, id)
+	}
+
+	return &Caller{
+		AuthSource: AuthSourceOIDC,
+		Identities: ids,
+	}, nil
+}
+
+// pathSegmentClaims returns a copy of the claims without the string values that are not a single path segment, so
+// that the identity templates referencing them fail instead of mapping the token to another path.
+func pathSegmentClaims(claims map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(claims))
+	for k, v := range claims {
+		if v, ok := pathSegmentClaim(v); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func pathSegmentClaim(v interface{}) (interface{}, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, !strings.Contains(t, "/") && t != "." && t != ".."
+	case map[string]interface{}:
+		return pathSegmentClaims(t), true
+	case []interface{}:
+		for _, e := range t {
+			if _, ok := pathSegmentClaim(e); !ok {
+				return nil, false
+			}
+		}
+		return t, true
+	default:
+		return v, true
+	}
+}
+
+// unverifiedIssuer returns the issuer of the JWT, before its signature is verified.
+func unverifiedIssuer(jwt string) (string, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed ID token, expected 3 parts but got %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed ID token payload: %v", err)
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("malformed ID token claims: %v", err)
+	}
+	return claims.Issuer, nil
+}
+
+func containsAny(values, accepted []string) bool {
+	for _, v := range values {
+		for _, a := range accepted {
+			if v == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// staticKeySet verifies the JWT signatures with the keys of a JSON Web Key Set loaded from a file.
+type staticKeySet struct {
+	keys []jose.JSONWebKey
+}
+
+func newStaticKeySet(jwksFile string) (*staticKeySet, error) {
+	in, err := ioutil.ReadFile(jwksFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the JSON Web Key Set: %v", err)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(in, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the JSON Web Key Set: %v", err)
+	}
+	if len(jwks.Keys) == 0 {
+		return nil, fmt.Errorf("no key in the JSON Web Key Set %s", jwksFile)
+	}
+	return &staticKeySet{keys: jwks.Keys}, nil
+}
+
+// VerifySignature implements oidc.KeySet
+func (s *staticKeySet) VerifySignature(_ context.Context, jwt string) ([]byte, error) {
+	jws, err := jose.ParseSigned(jwt)
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt: %v", err)
+	}
+	keyID := ""
+	if len(jws.Signatures) > 0 {
+		keyID = jws.Signatures[0].Header.KeyID
+	}
+	for i := range s.keys {
+		if keyID != "" && s.keys[i].KeyID != keyID {
+			continue
+		}
+		if payload, err := jws.Verify(&s.keys[i]); err == nil {
+			return payload, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to verify the jwt signature")
+}
@@ -0,0 +1,336 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type testIssuer struct {
+	key  *rsa.PrivateKey
+	jwks []byte
+}
+
+func newTestIssuer(t *testing.T, keyID string) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: key.Public(), KeyID: keyID, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal the JWKS: %v", err)
+	}
+	return &testIssuer{key: key, jwks: jwks}
+}
+
+func (i *testIssuer) token(t *testing.T, keyID string, claims map[string]interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: i.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyID))
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func withoutClaim(claims map[string]interface{}, name string) map[string]interface{} {
+	delete(claims, name)
+	return claims
+}
+
+func TestLoadOIDCConfig(t *testing.T) {
+	testCases := map[string]struct {
+		in     string
+		errMsg string
+	}{
+		"valid": {
+			in: `
+issuers:
+- issuer: https://a.example.com
+  audiences: [istio-ca]
+  jwksUri: https://a.example.com/jwks
+  identityTemplates: ["spiffe://{{.TrustDomain}}/sa/{{.Claims.sub}}"]
+- issuer: https://b.example.com
+  audiences: [istio-ca]
+  jwksFile: /etc/jwks.json
+  identityTemplates: ["spiffe://{{.TrustDomain}}/sa/{{.Claims.sub}}"]
+`,
+		},
+		"no issuer": {
+			in:     "issuers: []",
+			errMsg: "no issuer in the OIDC configuration",
+		},
+		"empty issuer": {
+			in:     "issuers: [{jwksUri: https://a.example.com/jwks, identityTemplates: [spiffe://a/b]}]",
+			errMsg: "issuer must be set",
+		},
+		"duplicate issuer": {
+			in: `
+issuers:
+- {issuer: https://a.example.com, audiences: [istio-ca], jwksUri: https://a.example.com/jwks, identityTemplates: [spiffe://a/b]}
+- {issuer: https://a.example.com, audiences: [istio-ca], jwksFile: /etc/jwks.json, identityTemplates: [spiffe://a/b]}
+`,
+			errMsg: `duplicate issuer "https://a.example.com"`,
+		},
+		"no JWKS": {
+			in:     "issuers: [{issuer: https://a.example.com, audiences: [istio-ca], identityTemplates: [spiffe://a/b]}]",
+			errMsg: "exactly one of jwksUri and jwksFile must be set",
+		},
+		"both JWKS": {
+			in: "issuers: [{issuer: https://a.example.com, audiences: [istio-ca], jwksUri: https://a.example.com/jwks, " +
+				"jwksFile: /etc/jwks.json, identityTemplates: [spiffe://a/b]}]",
+			errMsg: "exactly one of jwksUri and jwksFile must be set",
+		},
+		"no audience": {
+			in:     "issuers: [{issuer: https://a.example.com, jwksUri: https://a.example.com/jwks, identityTemplates: [spiffe://a/b]}]",
+			errMsg: "at least one audience must be set",
+		},
+		"no identity template": {
+			in:     "issuers: [{issuer: https://a.example.com, audiences: [istio-ca], jwksUri: https://a.example.com/jwks}]",
+			errMsg: "at least one identity template must be set",
+		},
+		"invalid identity template": {
+			in:     `issuers: [{issuer: https://a.example.com, audiences: [istio-ca], jwksUri: https://a.example.com/jwks, identityTemplates: ["{{.Claims"]}]`,
+			errMsg: "invalid identity template",
+		},
+	}
+
+	for id, tc := range testCases {
+		_, err := LoadOIDCConfig([]byte(tc.in))
+		if tc.errMsg == "" && err != nil {
+			t.Errorf("Case %s: unexpected error: %v", id, err)
+		} else if tc.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tc.errMsg)) {
+			t.Errorf("Case %s: expected error containing %q, got %v", id, tc.errMsg, err)
+		}
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oidc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The file issuer loads its keys from a local JWKS file, the remote issuer from a JWKS server.
+	fileIssuer := newTestIssuer(t, "file-key")
+	jwksFile := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(jwksFile, fileIssuer.jwks, 0644); err != nil {
+		t.Fatal(err)
+	}
+	remoteIssuer := newTestIssuer(t, "remote-key")
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(remoteIssuer.jwks)
+	}))
+	defer jwksServer.Close()
+
+	config := fmt.Sprintf(`
+issuers:
+- issuer: https://file.example.com
+  audiences: [istio-ca, citadel]
+  jwksFile: %s
+  identityTemplates:
+  - "spiffe://{{.TrustDomain}}/ns/{{.Claims.namespace}}/sa/{{.Claims.sub}}"
+- issuer: https://remote.example.com
+  audiences: [istio-ca]
+  jwksUri: %s
+  identityTemplates:
+  - "spiffe://{{.TrustDomain}}/vm/{{index .Claims \"vm.example.com/name\"}}"
+  - "spiffe://{{.TrustDomain}}/sa/{{.Claims.sub}}"
+`, jwksFile, jwksServer.URL)
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	authn, err := NewOIDCAuthenticator(configFile, "cluster.local")
+	if err != nil {
+		t.Fatalf("failed to create the OIDC authenticator: %v", err)
+	}
+	if authn.AuthenticatorType() != OIDCAuthenticatorType {
+		t.Errorf("got authenticator type %q", authn.AuthenticatorType())
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	fileClaims := func(overrides map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{
+			"iss":       "https://file.example.com",
+			"aud":       "istio-ca",
+			"exp":       exp,
+			"sub":       "bar",
+			"namespace": "foo",
+		}
+		for k, v := range overrides {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	testCases := map[string]struct {
+		token          string
+		expectedErrMsg string
+		expectedCaller *Caller
+	}{
+		"No token": {
+			expectedErrMsg: "ID token extraction error",
+		},
+		"Malformed token": {
+			token:          "not-a-jwt",
+			expectedErrMsg: "malformed ID token",
+		},
+		"Untrusted issuer": {
+			token:          fileIssuer.token(t, "file-key", fileClaims(map[string]interface{}{"iss": "https://evil.example.com"})),
+			expectedErrMsg: `untrusted issuer "https://evil.example.com"`,
+		},
+		"Signed by another issuer": {
+			token:          remoteIssuer.token(t, "file-key", fileClaims(nil)),
+			expectedErrMsg: "failed to verify the ID token",
+		},
+		"Expired token": {
+			token:          fileIssuer.token(t, "file-key", fileClaims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			expectedErrMsg: "token is expired",
+		},
+		"Unaccepted audience": {
+			token:          fileIssuer.token(t, "file-key", fileClaims(map[string]interface{}{"aud": "other"})),
+			expectedErrMsg: "the ID token audiences [other] are not accepted",
+		},
+		"Token of another relying party": {
+			token: remoteIssuer.token(t, "remote-key", map[string]interface{}{
+				"iss":                 "https://remote.example.com",
+				"aud":                 "https://app.example.com",
+				"exp":                 exp,
+				"sub":                 "vm-reader",
+				"vm.example.com/name": "vm-1",
+			}),
+			expectedErrMsg: "the ID token audiences [https://app.example.com] are not accepted",
+		},
+		"Token without audience": {
+			token: remoteIssuer.token(t, "remote-key", map[string]interface{}{
+				"iss":                 "https://remote.example.com",
+				"exp":                 exp,
+				"sub":                 "vm-reader",
+				"vm.example.com/name": "vm-1",
+			}),
+			expectedErrMsg: "the ID token audiences [] are not accepted",
+		},
+		"Missing claim": {
+			token:          fileIssuer.token(t, "file-key", withoutClaim(fileClaims(nil), "namespace")),
+			expectedErrMsg: "failed to map the ID token claims to an identity",
+		},
+		"Empty claim": {
+			token:          fileIssuer.token(t, "file-key", fileClaims(map[string]interface{}{"namespace": ""})),
+			expectedErrMsg: `mapped to an invalid identity "spiffe://cluster.local/ns//sa/bar"`,
+		},
+		"Claim with path segments": {
+			token:          fileIssuer.token(t, "file-key", fileClaims(map[string]interface{}{"namespace": "foo/sa/admin"})),
+			expectedErrMsg: "failed to map the ID token claims to an identity",
+		},
+		"Claim with parent path segment": {
+			token:          fileIssuer.token(t, "file-key", fileClaims(map[string]interface{}{"sub": ".."})),
+			expectedErrMsg: "failed to map the ID token claims to an identity",
+		},
+		"File issuer": {
+			token: fileIssuer.token(t, "file-key", fileClaims(nil)),
+			expectedCaller: &Caller{
+				AuthSource: AuthSourceOIDC,
+				Identities: []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+			},
+		},
+		"Remote issuer": {
+			token: remoteIssuer.token(t, "remote-key", map[string]interface{}{
+				"iss":                 "https://remote.example.com",
+				"aud":                 "istio-ca",
+				"exp":                 exp,
+				"sub":                 "vm-reader",
+				"vm.example.com/name": "vm-1",
+			}),
+			expectedCaller: &Caller{
+				AuthSource: AuthSourceOIDC,
+				Identities: []string{"spiffe://cluster.local/vm/vm-1", "spiffe://cluster.local/sa/vm-reader"},
+			},
+		},
+	}
+
+	for id, tc := range testCases {
+		ctx := context.Background()
+		if tc.token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.MD{"authorization": []string{bearerTokenPrefix + tc.token}})
+		}
+
+		actual, err := authn.Authenticate(ctx)
+		if tc.expectedErrMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErrMsg) {
+				t.Errorf("Case %s: expected error containing %q, got %v", id, tc.expectedErrMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Case %s: unexpected error: %v", id, err)
+			continue
+		}
+		if !reflect.DeepEqual(tc.expectedCaller, actual) {
+			t.Errorf("Case %s: unexpected caller: want %v but got %v", id, tc.expectedCaller, actual)
+		}
+	}
+}
+
+func TestNewOIDCAuthenticator_InvalidJWKSFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oidc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	jwksFile := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(jwksFile, []byte(`{"keys": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(dir, "config.yaml")
+	config := fmt.Sprintf("issuers: [{issuer: https://a.example.com, audiences: [istio-ca], jwksFile: %s, identityTemplates: [spiffe://a/b]}]", jwksFile)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewOIDCAuthenticator(configFile, "cluster.local"); err == nil || !strings.Contains(err.Error(), "no key") {
+		t.Errorf("expected an error for an empty JWKS, got %v", err)
+	}
+	if _, err := NewOIDCAuthenticator(filepath.Join(dir, "missing.yaml"), "cluster.local"); err == nil {
+		t.Errorf("expected an error for a missing config file")
+	}
+}
@@ -27,7 +27,6 @@ type authorizer interface {
 
 // sameIDAuthorizer approves a request if the requested identities matches the
 // identities of the requester.
-// nolint
 type sameIDAuthorizer struct{}
 
 func (authZ *sameIDAuthorizer) authorize(requester *authenticate.Caller, requestedIDs []string) error {
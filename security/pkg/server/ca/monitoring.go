@@ -19,11 +19,20 @@ import (
 )
 
 const (
-	errorlabel = "error"
+	errorlabel  = "error"
+	reasonLabel = "reason"
+	limitLabel  = "limit"
+
+	// The rate limits of the CA server.
+	rateLimitIdentity = "identity"
+	rateLimitNode     = "node"
+	rateLimitPeer     = "peer"
 )
 
 var (
-	errorTag = monitoring.MustCreateLabel(errorlabel)
+	errorTag  = monitoring.MustCreateLabel(errorlabel)
+	reasonTag = monitoring.MustCreateLabel(reasonLabel)
+	limitTag  = monitoring.MustCreateLabel(limitLabel)
 
 	csrCounts = monitoring.NewSum(
 		"citadel_server_csr_count",
@@ -45,6 +54,28 @@ var (
 		"The number of errors occurred when extracting the ID from CSR.",
 	)
 
+	revokedErrorCounts = monitoring.NewSum(
+		"citadel_server_revoked_err_count",
+		"The number of requests rejected because the caller is in the deny list.",
+	)
+
+	authzErrorCounts = monitoring.NewSum(
+		"citadel_server_authorization_failure_count",
+		"The number of requests rejected because the requested identities are not authorized for the caller.",
+	)
+
+	policyViolationCounts = monitoring.NewSum(
+		"citadel_server_csr_policy_violation_count",
+		"The number of CSRs rejected because they violate the CSR policy.",
+		monitoring.WithLabels(reasonTag),
+	)
+
+	rateLimitedCounts = monitoring.NewSum(
+		"citadel_server_rate_limited_count",
+		"The number of requests rejected because the caller exceeded its rate limit.",
+		monitoring.WithLabels(limitTag),
+	)
+
 	certSignErrorCounts = monitoring.NewSum(
 		"citadel_server_csr_sign_err_count",
 		"The number of errors occurred when signing the CSR.",
@@ -69,6 +100,10 @@ func init() {
 		authnErrorCounts,
 		csrParsingErrorCounts,
 		idExtractionErrorCounts,
+		revokedErrorCounts,
+		authzErrorCounts,
+		policyViolationCounts,
+		rateLimitedCounts,
 		certSignErrorCounts,
 		successCounts,
 		rootCertExpiryTimestamp,
@@ -82,6 +117,10 @@ type monitoringMetrics struct {
 	Success           monitoring.Metric
 	CSRError          monitoring.Metric
 	IDExtractionError monitoring.Metric
+	RevokedError      monitoring.Metric
+	AuthzError        monitoring.Metric
+	policyViolations  monitoring.Metric
+	rateLimited       monitoring.Metric
 	certSignErrors    monitoring.Metric
 }
 
@@ -93,6 +132,10 @@ func newMonitoringMetrics() monitoringMetrics {
 		Success:           successCounts,
 		CSRError:          csrParsingErrorCounts,
 		IDExtractionError: idExtractionErrorCounts,
+		RevokedError:      revokedErrorCounts,
+		AuthzError:        authzErrorCounts,
+		policyViolations:  policyViolationCounts,
+		rateLimited:       rateLimitedCounts,
 		certSignErrors:    certSignErrorCounts,
 	}
 }
@@ -100,3 +143,11 @@ func newMonitoringMetrics() monitoringMetrics {
 func (m *monitoringMetrics) GetCertSignError(err string) monitoring.Metric {
 	return m.certSignErrors.With(errorTag.Value(err))
 }
+
+func (m *monitoringMetrics) GetPolicyViolation(reason string) monitoring.Metric {
+	return m.policyViolations.With(reasonTag.Value(reason))
+}
+
+func (m *monitoringMetrics) GetRateLimited(limit string) monitoring.Metric {
+	return m.rateLimited.With(limitTag.Value(limit))
+}
@@ -0,0 +1,485 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// The parts of the policy a CSR may violate, used as the reason of a Violation.
+const (
+	ReasonKey       = "key"
+	ReasonSAN       = "san"
+	ReasonTTL       = "ttl"
+	ReasonExtension = "extension"
+)
+
+// The types of subject alternative names.
+const (
+	SANTypeURI   = "URI"
+	SANTypeDNS   = "DNS"
+	SANTypeIP    = "IP"
+	SANTypeEmail = "EMAIL"
+)
+
+// The public key algorithms.
+const (
+	KeyAlgorithmRSA   = "RSA"
+	KeyAlgorithmECDSA = "ECDSA"
+)
+
+var (
+	// oidSubjectAltName is the OID of the subject alternative name extension, always allowed.
+	oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+	supportedCurves = map[string]bool{"P224": true, "P256": true, "P384": true, "P521": true}
+)
+
+// Config is the policy the CSRs must comply with, and the rate limits of the CA server. Any part left empty
+// restricts nothing.
+type Config struct {
+	// Keys restricts the public keys of the CSRs.
+	Keys KeyConfig `json:"keys"`
+
+	// SubjectAltNames restricts the subject alternative names, requested in the CSRs or issued to the callers.
+	SubjectAltNames SANConfig `json:"subjectAltNames"`
+
+	// TTL restricts the TTL of the certificates.
+	TTL TTLConfig `json:"ttl"`
+
+	// Extensions restricts the extensions requested in the CSRs.
+	Extensions ExtensionConfig `json:"extensions"`
+
+	// RateLimits limits the rate of the requests.
+	RateLimits RateLimitConfig `json:"rateLimits"`
+}
+
+// KeyConfig restricts the public keys of the CSRs.
+type KeyConfig struct {
+	// Algorithms are the allowed public key algorithms, "RSA" or "ECDSA". Any algorithm is allowed if empty.
+	Algorithms []string `json:"algorithms,omitempty"`
+
+	// MinRSAKeySize is the minimum size, in bits, of the RSA keys.
+	MinRSAKeySize int `json:"minRSAKeySize,omitempty"`
+
+	// ECDSACurves are the allowed curves of the ECDSA keys, e.g. "P256". Any curve is allowed if empty.
+	ECDSACurves []string `json:"ecdsaCurves,omitempty"`
+}
+
+// SANConfig restricts the subject alternative names.
+type SANConfig struct {
+	// Types are the allowed types of SAN, "URI", "DNS", "IP" or "EMAIL". Any type is allowed if empty.
+	Types []string `json:"types,omitempty"`
+
+	// URIPrefixes are the allowed prefixes of the URI SANs, e.g. "spiffe://cluster.local/ns/". Any URI is allowed
+	// if empty.
+	URIPrefixes []string `json:"uriPrefixes,omitempty"`
+}
+
+// TTLConfig restricts the TTL of the certificates. A request without TTL is issued with the default TTL of the CA,
+// capped to the maximum TTL of its namespace.
+type TTLConfig struct {
+	// Max is the maximum TTL of the certificates, if positive.
+	Max Duration `json:"max,omitempty"`
+
+	// Namespaces overrides the maximum TTL for the SPIFFE identities of the given namespaces.
+	Namespaces map[string]Duration `json:"namespaces,omitempty"`
+}
+
+// ExtensionConfig restricts the extensions requested in the CSRs.
+type ExtensionConfig struct {
+	// Allowed are the OIDs of the extensions allowed besides the subject alternative names, e.g. "2.5.29.15". Any
+	// extension is allowed if nil.
+	Allowed []string `json:"allowed"`
+}
+
+// RateLimitConfig limits the rate of the requests. Requests over the limits are rejected.
+type RateLimitConfig struct {
+	// Identity limits the requests of each caller identity.
+	Identity *Limit `json:"identity,omitempty"`
+
+	// Node limits the requests from each node, identified by the authenticated node of the caller, i.e. the node
+	// running the pod its Kubernetes token is bound to. The callers of unknown nodes, authenticated by a client
+	// certificate or a token not bound to a pod, are only limited by their identity.
+	Node *Limit `json:"node,omitempty"`
+
+	// Peer limits the requests from each peer IP address. Unlike the other limits it is checked before the caller
+	// is authenticated, so that unauthenticated requests can't overload the authenticators, e.g. the Kubernetes
+	// token reviews. The callers sharing an address, e.g. the host network pods of a node, share its limit.
+	Peer *Limit `json:"peer,omitempty"`
+}
+
+// Limit is a token bucket rate limit.
+type Limit struct {
+	// QPS is the sustained rate of requests per second.
+	QPS float64 `json:"qps"`
+
+	// Burst is the maximum number of requests at once.
+	Burst int `json:"burst"`
+}
+
+// Duration is a time.Duration encoded as a string, e.g. "24h".
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s, expecting a string such as \"24h\"", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Violation is the error of a request violating the policy.
+type Violation struct {
+	// Reason is the violated part of the policy, e.g. ReasonKey.
+	Reason string
+	err    error
+}
+
+func (v *Violation) Error() string {
+	return v.err.Error()
+}
+
+func violation(reason, format string, args ...interface{}) *Violation {
+	return &Violation{Reason: reason, err: fmt.Errorf(format, args...)}
+}
+
+// LoadFile returns a new Config decoded and validated from the given YAML file.
+func LoadFile(path string) (*Config, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSR policy: %v", err)
+	}
+	return Load(in)
+}
+
+// Load returns a new Config decoded and validated from the input YAML.
+func Load(in []byte) (*Config, error) {
+	out := &Config{}
+	if err := yaml.Unmarshal(in, out); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the CSR policy: %v", err)
+	}
+
+	for _, a := range out.Keys.Algorithms {
+		if a != KeyAlgorithmRSA && a != KeyAlgorithmECDSA {
+			return nil, fmt.Errorf("unsupported key algorithm %q", a)
+		}
+	}
+	if out.Keys.MinRSAKeySize < 0 {
+		return nil, fmt.Errorf("invalid minimum RSA key size %d", out.Keys.MinRSAKeySize)
+	}
+	for _, c := range out.Keys.ECDSACurves {
+		if !supportedCurves[c] {
+			return nil, fmt.Errorf("unsupported ECDSA curve %q", c)
+		}
+	}
+	for _, t := range out.SubjectAltNames.Types {
+		if t != SANTypeURI && t != SANTypeDNS && t != SANTypeIP && t != SANTypeEmail {
+			return nil, fmt.Errorf("unsupported SAN type %q", t)
+		}
+	}
+	if out.TTL.Max < 0 {
+		return nil, fmt.Errorf("invalid maximum TTL %v", time.Duration(out.TTL.Max))
+	}
+	for ns, ttl := range out.TTL.Namespaces {
+		if ttl <= 0 {
+			return nil, fmt.Errorf("invalid maximum TTL %v for namespace %s", time.Duration(ttl), ns)
+		}
+	}
+	for _, oid := range out.Extensions.Allowed {
+		if _, err := parseOID(oid); err != nil {
+			return nil, err
+		}
+	}
+	for name, l := range map[string]*Limit{
+		"identity": out.RateLimits.Identity, "node": out.RateLimits.Node, "peer": out.RateLimits.Peer} {
+		if l != nil && (l.QPS <= 0 || l.Burst <= 0) {
+			return nil, fmt.Errorf("invalid %s rate limit, qps and burst must be positive", name)
+		}
+	}
+	return out, nil
+}
+
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid extension OID %q", s)
+		}
+		oid = append(oid, n)
+	}
+	if len(oid) < 2 {
+		return nil, fmt.Errorf("invalid extension OID %q", s)
+	}
+	return oid, nil
+}
+
+// Policy enforces a Config on the requests of the CA server. A nil Policy allows everything.
+type Policy struct {
+	config            *Config
+	defaultTTL        time.Duration
+	allowedExtensions map[string]bool
+	identityLimiter   *RateLimiter
+	nodeLimiter       *RateLimiter
+	peerLimiter       *RateLimiter
+}
+
+// New creates a Policy enforcing the given configuration. The defaultTTL is the TTL the CA issues the
+// certificates with when the request has none.
+func New(config *Config, defaultTTL time.Duration) *Policy {
+	p := &Policy{config: config, defaultTTL: defaultTTL}
+	if config.Extensions.Allowed != nil {
+		p.allowedExtensions = map[string]bool{oidSubjectAltName.String(): true}
+		for _, oid := range config.Extensions.Allowed {
+			// The OIDs are validated when the configuration is loaded.
+			parsed, _ := parseOID(oid)
+			p.allowedExtensions[parsed.String()] = true
+		}
+	}
+	if l := config.RateLimits.Identity; l != nil {
+		p.identityLimiter = NewRateLimiter(l.QPS, l.Burst)
+	}
+	if l := config.RateLimits.Node; l != nil {
+		p.nodeLimiter = NewRateLimiter(l.QPS, l.Burst)
+	}
+	if l := config.RateLimits.Peer; l != nil {
+		p.peerLimiter = NewRateLimiter(l.QPS, l.Burst)
+	}
+	return p
+}
+
+// LimitsNodes returns whether the requests are rate limited per node.
+func (p *Policy) LimitsNodes() bool {
+	return p != nil && p.nodeLimiter != nil
+}
+
+// AllowNode returns whether a request from the given node is within the node rate limit. The requests of an
+// unknown node, i.e. an empty node name, are allowed.
+func (p *Policy) AllowNode(node string) bool {
+	if p == nil || p.nodeLimiter == nil || node == "" {
+		return true
+	}
+	return p.nodeLimiter.Allow(node)
+}
+
+// AllowPeer returns whether a request from the given peer IP address is within the peer rate limit. The requests
+// of an unknown address are allowed.
+func (p *Policy) AllowPeer(addr string) bool {
+	if p == nil || p.peerLimiter == nil || addr == "" {
+		return true
+	}
+	return p.peerLimiter.Allow(addr)
+}
+
+// AllowIdentity returns whether a request of the caller with the given identities is within the identity rate
+// limit.
+func (p *Policy) AllowIdentity(ids []string) bool {
+	if p == nil || p.identityLimiter == nil {
+		return true
+	}
+	return p.identityLimiter.Allow(strings.Join(ids, ","))
+}
+
+// Check returns a Violation if the CSR, the identities to issue the certificate to, or the requested TTL violate
+// the policy. Otherwise, it returns the TTL to issue the certificate with, zero meaning the default TTL of the CA.
+func (p *Policy) Check(csr *x509.CertificateRequest, ids []string, ttl time.Duration) (time.Duration, error) {
+	if p == nil {
+		return ttl, nil
+	}
+	if err := p.checkKey(csr); err != nil {
+		return 0, err
+	}
+	if err := p.checkSANs(csr, ids); err != nil {
+		return 0, err
+	}
+	if err := p.checkExtensions(csr); err != nil {
+		return 0, err
+	}
+	return p.checkTTL(ids, ttl)
+}
+
+func (p *Policy) checkKey(csr *x509.CertificateRequest) error {
+	keys := p.config.Keys
+	var algorithm string
+	switch csr.PublicKeyAlgorithm {
+	case x509.RSA:
+		algorithm = KeyAlgorithmRSA
+	case x509.ECDSA:
+		algorithm = KeyAlgorithmECDSA
+	default:
+		algorithm = csr.PublicKeyAlgorithm.String()
+	}
+	if len(keys.Algorithms) > 0 && !contains(keys.Algorithms, algorithm) {
+		return violation(ReasonKey, "key algorithm %s is not allowed", algorithm)
+	}
+
+	switch pub := csr.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if size := pub.N.BitLen(); size < keys.MinRSAKeySize {
+			return violation(ReasonKey, "RSA key size %d is smaller than the minimum %d", size, keys.MinRSAKeySize)
+		}
+	case *ecdsa.PublicKey:
+		curve := strings.Replace(pub.Curve.Params().Name, "-", "", -1)
+		if len(keys.ECDSACurves) > 0 && !contains(keys.ECDSACurves, curve) {
+			return violation(ReasonKey, "ECDSA curve %s is not allowed", curve)
+		}
+	}
+	return nil
+}
+
+func (p *Policy) checkSANs(csr *x509.CertificateRequest, ids []string) error {
+	sans := p.config.SubjectAltNames
+	check := func(sanType, san string) error {
+		if len(sans.Types) > 0 && !contains(sans.Types, sanType) {
+			return violation(ReasonSAN, "%s SAN %q is not allowed", sanType, san)
+		}
+		if sanType == SANTypeURI && len(sans.URIPrefixes) > 0 && !hasAnyPrefix(san, sans.URIPrefixes) {
+			return violation(ReasonSAN, "URI SAN %q does not match the allowed prefixes", san)
+		}
+		return nil
+	}
+
+	// The identities are issued as SANs of the type inferred by the CA.
+	for _, id := range ids {
+		sanType := SANTypeDNS
+		if net.ParseIP(id) != nil {
+			sanType = SANTypeIP
+		} else if strings.Contains(id, "://") {
+			sanType = SANTypeURI
+		}
+		if err := check(sanType, id); err != nil {
+			return err
+		}
+	}
+	for _, u := range csr.URIs {
+		if err := check(SANTypeURI, u.String()); err != nil {
+			return err
+		}
+	}
+	for _, name := range csr.DNSNames {
+		if err := check(SANTypeDNS, name); err != nil {
+			return err
+		}
+	}
+	for _, ip := range csr.IPAddresses {
+		if err := check(SANTypeIP, ip.String()); err != nil {
+			return err
+		}
+	}
+	for _, email := range csr.EmailAddresses {
+		if err := check(SANTypeEmail, email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Policy) checkExtensions(csr *x509.CertificateRequest) error {
+	if p.allowedExtensions == nil {
+		return nil
+	}
+	for _, ext := range csr.Extensions {
+		if !p.allowedExtensions[ext.Id.String()] {
+			return violation(ReasonExtension, "extension %s is not allowed", ext.Id)
+		}
+	}
+	return nil
+}
+
+func (p *Policy) checkTTL(ids []string, ttl time.Duration) (time.Duration, error) {
+	var max time.Duration
+	if len(ids) == 0 {
+		max = time.Duration(p.config.TTL.Max)
+	}
+	// The most restrictive identity applies to the identities of several namespaces.
+	for _, id := range ids {
+		idMax := time.Duration(p.config.TTL.Max)
+		if nsMax, ok := p.config.TTL.Namespaces[namespaceOf(id)]; ok {
+			idMax = time.Duration(nsMax)
+		}
+		if idMax > 0 && (max <= 0 || idMax < max) {
+			max = idMax
+		}
+	}
+	if max <= 0 {
+		return ttl, nil
+	}
+	if ttl <= 0 {
+		// Leave the TTL to the CA unless its default TTL is over the max.
+		if p.defaultTTL > max {
+			return max, nil
+		}
+		return 0, nil
+	}
+	if ttl > max {
+		return 0, violation(ReasonTTL, "requested TTL %v is greater than the max allowed TTL %v", ttl, max)
+	}
+	return ttl, nil
+}
+
+// namespaceOf returns the namespace of a SPIFFE identity such as "spiffe://cluster.local/ns/default/sa/default",
+// or an empty string for other identities.
+func namespaceOf(id string) string {
+	if !strings.HasPrefix(id, "spiffe://") {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(id, "spiffe://"), "/")
+	if len(parts) < 3 || parts[1] != "ns" {
+		return ""
+	}
+	return parts[2]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const testPolicy = `
+keys:
+  algorithms: [RSA, ECDSA]
+  minRSAKeySize: 2048
+  ecdsaCurves: [P256]
+subjectAltNames:
+  types: [URI]
+  uriPrefixes: ["spiffe://cluster.local/ns/"]
+ttl:
+  max: 24h
+  namespaces:
+    restricted: 1h
+extensions:
+  allowed: ["2.5.29.15"]
+rateLimits:
+  identity:
+    qps: 1
+    burst: 2
+  node:
+    qps: 10
+    burst: 20
+  peer:
+    qps: 50
+    burst: 100
+`
+
+func genCSR(t *testing.T, options util.CertOptions) *x509.CertificateRequest {
+	csrPEM, _, err := util.GenCSR(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func TestLoad(t *testing.T) {
+	config, err := Load([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Duration(config.TTL.Max) != 24*time.Hour || time.Duration(config.TTL.Namespaces["restricted"]) != time.Hour {
+		t.Errorf("unexpected TTL configuration %+v", config.TTL)
+	}
+	if config.RateLimits.Identity.QPS != 1 || config.RateLimits.Node.Burst != 20 ||
+		config.RateLimits.Peer.QPS != 50 {
+		t.Errorf("unexpected rate limits %+v", config.RateLimits)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{name: "key algorithm", policy: "keys: {algorithms: [DSA]}", err: "unsupported key algorithm"},
+		{name: "curve", policy: "keys: {ecdsaCurves: [P128]}", err: "unsupported ECDSA curve"},
+		{name: "SAN type", policy: "subjectAltNames: {types: [X400]}", err: "unsupported SAN type"},
+		{name: "duration", policy: "ttl: {max: 24}", err: "invalid duration"},
+		{name: "namespace TTL", policy: "ttl: {namespaces: {foo: 0s}}", err: "invalid maximum TTL"},
+		{name: "OID", policy: "extensions: {allowed: [2.5.x]}", err: "invalid extension OID"},
+		{name: "rate limit", policy: "rateLimits: {node: {qps: 0, burst: 1}}", err: "invalid node rate limit"},
+		{name: "peer rate limit", policy: "rateLimits: {peer: {qps: 1, burst: 0}}", err: "invalid peer rate limit"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Load([]byte(tc.policy)); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("got error %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	config, err := Load([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(config, 12*time.Hour)
+
+	id := "spiffe://cluster.local/ns/default/sa/default"
+	rsaCSR := genCSR(t, util.CertOptions{Host: id, RSAKeySize: 2048})
+	smallRSACSR := genCSR(t, util.CertOptions{Host: id, RSAKeySize: 1024})
+	ecCSR := genCSR(t, util.CertOptions{Host: id, ECSigAlg: util.EcdsaSigAlg, ECCCurve: util.P256Curve})
+	p384CSR := genCSR(t, util.CertOptions{Host: id, ECSigAlg: util.EcdsaSigAlg, ECCCurve: util.P384Curve})
+	dnsCSR := genCSR(t, util.CertOptions{Host: "foo.example.com", RSAKeySize: 2048})
+	ipCSR := &x509.CertificateRequest{PublicKeyAlgorithm: x509.RSA, PublicKey: rsaCSR.PublicKey,
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}}
+	extCSR := &x509.CertificateRequest{PublicKeyAlgorithm: x509.RSA, PublicKey: rsaCSR.PublicKey,
+		Extensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 19}}}}
+	allowedExtCSR := &x509.CertificateRequest{PublicKeyAlgorithm: x509.RSA, PublicKey: rsaCSR.PublicKey,
+		Extensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 15}}}}
+
+	testCases := []struct {
+		name    string
+		csr     *x509.CertificateRequest
+		ids     []string
+		ttl     time.Duration
+		wantTTL time.Duration
+		reason  string
+	}{
+		{name: "RSA", csr: rsaCSR, ids: []string{id}, ttl: time.Hour, wantTTL: time.Hour},
+		{name: "ECDSA", csr: ecCSR, ids: []string{id}, ttl: time.Hour, wantTTL: time.Hour},
+		{name: "small RSA key", csr: smallRSACSR, ids: []string{id}, ttl: time.Hour, reason: ReasonKey},
+		{name: "disallowed curve", csr: p384CSR, ids: []string{id}, ttl: time.Hour, reason: ReasonKey},
+		{name: "DNS SAN in CSR", csr: dnsCSR, ids: []string{id}, ttl: time.Hour, reason: ReasonSAN},
+		{name: "IP SAN in CSR", csr: ipCSR, ids: []string{id}, ttl: time.Hour, reason: ReasonSAN},
+		{name: "DNS identity", csr: allowedExtCSR, ids: []string{"foo.example.com"}, ttl: time.Hour,
+			reason: ReasonSAN},
+		{name: "URI identity of another trust domain", csr: allowedExtCSR,
+			ids: []string{"spiffe://other.local/ns/default/sa/default"}, ttl: time.Hour, reason: ReasonSAN},
+		{name: "disallowed extension", csr: extCSR, ids: []string{id}, ttl: time.Hour, reason: ReasonExtension},
+		{name: "allowed extension", csr: allowedExtCSR, ids: []string{id}, ttl: time.Hour, wantTTL: time.Hour},
+		{name: "TTL over the max", csr: rsaCSR, ids: []string{id}, ttl: 48 * time.Hour, reason: ReasonTTL},
+		{name: "TTL over the namespace max", csr: allowedExtCSR,
+			ids: []string{"spiffe://cluster.local/ns/restricted/sa/default"}, ttl: 2 * time.Hour, reason: ReasonTTL},
+		{name: "default TTL", csr: rsaCSR, ids: []string{id}, wantTTL: 0},
+		{name: "default namespace TTL", csr: allowedExtCSR,
+			ids: []string{id, "spiffe://cluster.local/ns/restricted/sa/default"}, wantTTL: time.Hour},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ttl, err := p.Check(tc.csr, tc.ids, tc.ttl)
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if ttl != tc.wantTTL {
+					t.Errorf("got TTL %v, want %v", ttl, tc.wantTTL)
+				}
+				return
+			}
+			v, ok := err.(*Violation)
+			if !ok || v.Reason != tc.reason {
+				t.Errorf("got error %v, want a %s violation", err, tc.reason)
+			}
+		})
+	}
+}
+
+func TestNilPolicy(t *testing.T) {
+	var p *Policy
+	if ttl, err := p.Check(&x509.CertificateRequest{}, nil, time.Hour); err != nil || ttl != time.Hour {
+		t.Errorf("expected a nil policy to allow everything, got TTL %v (error %v)", ttl, err)
+	}
+	if !p.AllowNode("node-1") || !p.AllowIdentity([]string{"foo"}) || !p.AllowPeer("10.0.0.1") {
+		t.Errorf("expected a nil policy not to rate limit")
+	}
+}
+
+func TestAllow(t *testing.T) {
+	config, err := Load([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(config, time.Hour)
+	ids := []string{"spiffe://cluster.local/ns/default/sa/default"}
+	for i := 0; i < 2; i++ {
+		if !p.AllowIdentity(ids) {
+			t.Fatalf("expected request %d to be allowed within the burst", i)
+		}
+	}
+	if p.AllowIdentity(ids) {
+		t.Errorf("expected the request over the burst to be rate limited")
+	}
+	if !p.AllowIdentity([]string{"spiffe://cluster.local/ns/default/sa/other"}) {
+		t.Errorf("expected another identity not to be rate limited")
+	}
+	for i := 0; i < 20; i++ {
+		if !p.AllowNode("node-1") {
+			t.Fatalf("expected request %d of the node to be allowed within the burst", i)
+		}
+	}
+	if p.AllowNode("node-1") {
+		t.Errorf("expected the node request over the burst to be rate limited")
+	}
+	if !p.AllowNode("node-2") || !p.AllowNode("") {
+		t.Errorf("expected another node and an unknown node not to be rate limited")
+	}
+	for i := 0; i < 100; i++ {
+		if !p.AllowPeer("10.0.0.1") {
+			t.Fatalf("expected request %d of the peer to be allowed within the burst", i)
+		}
+	}
+	if p.AllowPeer("10.0.0.1") {
+		t.Errorf("expected the peer request over the burst to be rate limited")
+	}
+	if !p.AllowPeer("10.0.0.2") || !p.AllowPeer("") {
+		t.Errorf("expected another peer and an unknown peer not to be rate limited")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter applies a token bucket rate limit to each key independently, so that a key exhausting its bucket
+// does not affect the others.
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+	// idle is the time a bucket takes to fill up again. The buckets idle for longer are evicted, since a new bucket
+	// is equivalent.
+	idle time.Duration
+	now  func() time.Time
+
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing qps requests per second per key, with bursts of burst requests.
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		limit:   rate.Limit(qps),
+		burst:   burst,
+		idle:    time.Duration(float64(burst) / qps * float64(time.Second)),
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow consumes a token of the bucket of the key, and returns false if it is empty.
+func (r *RateLimiter) Allow(key string) bool {
+	now := r.now()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if now.Sub(r.lastSweep) > r.idle {
+		for k, b := range r.buckets {
+			if now.Sub(b.lastSeen) > r.idle {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(r.limit, r.burst)}
+		r.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1)
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	r := NewRateLimiter(1, 3)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !r.Allow("a") {
+			t.Fatalf("expected request %d to be allowed within the burst", i)
+		}
+	}
+	if r.Allow("a") {
+		t.Errorf("expected the request over the burst to be rejected")
+	}
+	if !r.Allow("b") {
+		t.Errorf("expected another key to have its own bucket")
+	}
+
+	// One token is added per second.
+	now = now.Add(time.Second)
+	if !r.Allow("a") {
+		t.Errorf("expected a request to be allowed after a second")
+	}
+	if r.Allow("a") {
+		t.Errorf("expected the bucket to be empty again")
+	}
+
+	// The buckets idle long enough to be full again are evicted.
+	now = now.Add(4 * time.Second)
+	if !r.Allow("c") {
+		t.Errorf("expected a request of a new key to be allowed")
+	}
+	if _, ok := r.buckets["b"]; ok {
+		t.Errorf("expected the idle bucket to be evicted")
+	}
+	if len(r.buckets) != 1 {
+		t.Errorf("got %d buckets, want 1", len(r.buckets))
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -26,12 +27,16 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
+	"istio.io/istio/security/pkg/pki/audit"
 	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/revocation"
 	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/registry"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
+	"istio.io/istio/security/pkg/server/ca/policy"
 	pb "istio.io/istio/security/proto"
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
@@ -70,11 +75,19 @@ type Server struct {
 	authenticators []authenticator
 	hostnames      []string
 	authorizer     authorizer
+	// oidcAuthorizer restricts the identities requested by the callers authenticated with an OIDC token to
+	// the identities mapped from the token claims.
+	oidcAuthorizer authorizer
+	denyList       *revocation.Store
 	ca             CertificateAuthority
 	serverCertTTL  time.Duration
 	certificate    *tls.Certificate
 	port           int
 	forCA          bool
+	// policy restricts the CSRs and rate limits the callers. A nil policy allows everything.
+	policy *policy.Policy
+	// auditLog records the issued certificates, if not nil.
+	auditLog *audit.Logger
 }
 
 // CreateCertificate handles an incoming certificate signing request (CSR). It does
@@ -85,23 +98,69 @@ type Server struct {
 // it is signed by the CA signing key.
 func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertificateRequest) (
 	*pb.IstioCertificateResponse, error) {
+	if err := s.checkPeerRateLimit(ctx); err != nil {
+		return nil, err
+	}
 	caller := s.authenticate(ctx)
 	if caller == nil {
 		serverCaLog.Warn("request authentication failure")
 		s.monitoring.AuthnError.Increment()
 		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
 	}
+	if err := s.checkNodeRateLimit(caller.Node); err != nil {
+		return nil, err
+	}
+	if err := s.checkIdentityRateLimit(caller.Identities); err != nil {
+		return nil, err
+	}
 
 	// TODO: Call authorizer.
 
+	var csr *x509.CertificateRequest
+	if caller.AuthSource == authenticate.AuthSourceOIDC || s.policy != nil {
+		var err error
+		if csr, err = util.ParsePemEncodedCSR([]byte(request.Csr)); err != nil {
+			serverCaLog.Warnf("CSR Pem parsing error (error %v)", err)
+			s.monitoring.CSRError.Increment()
+			return nil, status.Errorf(codes.InvalidArgument, "CSR parsing error (%v)", err)
+		}
+	}
+
+	if caller.AuthSource == authenticate.AuthSourceOIDC {
+		requestedIDs, err := util.ExtractIDs(csr.Extensions)
+		if err != nil {
+			serverCaLog.Warnf("CSR identity extraction error (%v)", err)
+			s.monitoring.IDExtractionError.Increment()
+			return nil, status.Errorf(codes.InvalidArgument, "CSR identity extraction error (%v)", err)
+		}
+		if err := s.oidcAuthorizer.authorize(caller, requestedIDs); err != nil {
+			serverCaLog.Warnf("request authorization failure (%v)", err)
+			s.monitoring.AuthzError.Increment()
+			return nil, status.Errorf(codes.PermissionDenied, "request authorization failure (%v)", err)
+		}
+	}
+
+	if err := s.checkRevocation(ctx, caller.Identities, caller.Node); err != nil {
+		serverCaLog.Warnf("request authorization failure (%v)", err)
+		s.monitoring.RevokedError.Increment()
+		return nil, status.Errorf(codes.PermissionDenied, "request authorization failure (%v)", err)
+	}
+
+	ttl, err := s.checkPolicy(csr, caller.Identities, time.Duration(request.ValidityDuration)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
 	_, _, certChainBytes, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
-	cert, signErr := s.ca.Sign(
-		[]byte(request.Csr), caller.Identities, time.Duration(request.ValidityDuration)*time.Second, false)
+	cert, signErr := s.ca.Sign([]byte(request.Csr), caller.Identities, ttl, false)
 	if signErr != nil {
 		serverCaLog.Errorf("CSR signing error (%v)", signErr.Error())
 		s.monitoring.GetCertSignError(signErr.(*caerror.Error).ErrorType()).Increment()
 		return nil, status.Errorf(signErr.(*caerror.Error).HTTPErrorCode(), "CSR signing error (%v)", signErr.(*caerror.Error))
 	}
+	if err := s.recordIssuance(cert, caller); err != nil {
+		return nil, err
+	}
 	respCertChain := []string{string(cert)}
 	if len(certChainBytes) != 0 {
 		respCertChain = append(respCertChain, string(certChainBytes))
@@ -137,12 +196,21 @@ func extractRootCertExpiryTimestamp(ca CertificateAuthority) float64 {
 // [TODO](myidpt): Deprecate this function.
 func (s *Server) HandleCSR(ctx context.Context, request *pb.CsrRequest) (*pb.CsrResponse, error) {
 	s.monitoring.CSR.Increment()
+	if err := s.checkPeerRateLimit(ctx); err != nil {
+		return nil, err
+	}
 	caller := s.authenticate(ctx)
 	if caller == nil || len(caller.Identities) == 0 {
 		serverCaLog.Warn("request authentication failure, no caller identity")
 		s.monitoring.AuthnError.Increment()
 		return nil, status.Error(codes.Unauthenticated, "request authenticate failure, no caller identity")
 	}
+	if err := s.checkNodeRateLimit(caller.Node); err != nil {
+		return nil, err
+	}
+	if err := s.checkIdentityRateLimit(caller.Identities); err != nil {
+		return nil, err
+	}
 
 	csr, err := util.ParsePemEncodedCSR(request.CsrPem)
 	if err != nil {
@@ -151,7 +219,7 @@ func (s *Server) HandleCSR(ctx context.Context, request *pb.CsrRequest) (*pb.Csr
 		return nil, status.Errorf(codes.InvalidArgument, "CSR parsing error (%v)", err)
 	}
 
-	_, err = util.ExtractIDs(csr.Extensions)
+	requestedIDs, err := util.ExtractIDs(csr.Extensions)
 	if err != nil {
 		serverCaLog.Warnf("CSR identity extraction error (%v)", err)
 		s.monitoring.IDExtractionError.Increment()
@@ -160,14 +228,35 @@ func (s *Server) HandleCSR(ctx context.Context, request *pb.CsrRequest) (*pb.Csr
 
 	// TODO: Call authorizer.
 
+	if caller.AuthSource == authenticate.AuthSourceOIDC {
+		if err := s.oidcAuthorizer.authorize(caller, requestedIDs); err != nil {
+			serverCaLog.Warnf("request authorization failure (%v)", err)
+			s.monitoring.AuthzError.Increment()
+			return nil, status.Errorf(codes.PermissionDenied, "request authorization failure (%v)", err)
+		}
+	}
+
+	if err := s.checkRevocation(ctx, append(requestedIDs, caller.Identities...), caller.Node); err != nil {
+		serverCaLog.Warnf("request authorization failure (%v)", err)
+		s.monitoring.RevokedError.Increment()
+		return nil, status.Errorf(codes.PermissionDenied, "request authorization failure (%v)", err)
+	}
+
+	ttl, err := s.checkPolicy(csr, caller.Identities, time.Duration(request.RequestedTtlMinutes)*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
 	_, _, certChainBytes, _ := s.ca.GetCAKeyCertBundle().GetAll()
-	cert, signErr := s.ca.Sign(
-		request.CsrPem, caller.Identities, time.Duration(request.RequestedTtlMinutes)*time.Minute, s.forCA)
+	cert, signErr := s.ca.Sign(request.CsrPem, caller.Identities, ttl, s.forCA)
 	if signErr != nil {
 		serverCaLog.Errorf("CSR signing error (%v)", signErr.Error())
 		s.monitoring.GetCertSignError(signErr.(*caerror.Error).ErrorType()).Increment()
 		return nil, status.Errorf(codes.Internal, "CSR signing error (%v)", signErr.(*caerror.Error))
 	}
+	if err := s.recordIssuance(cert, caller); err != nil {
+		return nil, err
+	}
 
 	response := &pb.CsrResponse{
 		IsApproved: true,
@@ -180,6 +269,112 @@ func (s *Server) HandleCSR(ctx context.Context, request *pb.CsrRequest) (*pb.Csr
 	return response, nil
 }
 
+// checkRevocation returns an error if any of the given identities, the client certificate presented by the
+// caller, or the authenticated node of the caller is in the deny list.
+func (s *Server) checkRevocation(ctx context.Context, ids []string, node string) error {
+	denyList := s.denyList.DenyList()
+	if denyList.IsEmpty() {
+		return nil
+	}
+	if err := denyList.CheckIdentities(ids); err != nil {
+		return err
+	}
+	if denyList.IsNodeDenied(node) {
+		return fmt.Errorf("node %s is revoked", node)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		for _, cert := range tlsInfo.State.PeerCertificates {
+			if err := denyList.CheckCertificate(cert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkPeerRateLimit returns a ResourceExhausted error if the peer address of the request exceeded its rate limit.
+// It is checked before authenticating the caller, to bound the cost of the unauthenticated requests.
+func (s *Server) checkPeerRateLimit(ctx context.Context) error {
+	addr := peerIP(ctx)
+	if !s.policy.AllowPeer(addr) {
+		serverCaLog.Warnf("request rate limited for peer %s", addr)
+		s.monitoring.GetRateLimited(rateLimitPeer).Increment()
+		return status.Errorf(codes.ResourceExhausted, "request rate limit exceeded for peer %s", addr)
+	}
+	return nil
+}
+
+// peerIP returns the IP address of the peer of the request, or an empty string if unknown.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// checkNodeRateLimit returns a ResourceExhausted error if the authenticated node of the caller exceeded its rate
+// limit. The node is taken from the credential of the caller rather than the address of the request, which is
+// the one of the pod rather than the node.
+func (s *Server) checkNodeRateLimit(node string) error {
+	if !s.policy.AllowNode(node) {
+		serverCaLog.Warnf("request rate limited for node %s", node)
+		s.monitoring.GetRateLimited(rateLimitNode).Increment()
+		return status.Errorf(codes.ResourceExhausted, "request rate limit exceeded for node %s", node)
+	}
+	return nil
+}
+
+// checkIdentityRateLimit returns a ResourceExhausted error if the caller exceeded its rate limit.
+func (s *Server) checkIdentityRateLimit(ids []string) error {
+	if !s.policy.AllowIdentity(ids) {
+		serverCaLog.Warnf("request rate limited for identities %v", ids)
+		s.monitoring.GetRateLimited(rateLimitIdentity).Increment()
+		return status.Errorf(codes.ResourceExhausted, "request rate limit exceeded for identities %v", ids)
+	}
+	return nil
+}
+
+// checkPolicy returns an InvalidArgument error if the CSR violates the policy, and the TTL to sign it with
+// otherwise.
+func (s *Server) checkPolicy(csr *x509.CertificateRequest, ids []string, ttl time.Duration) (time.Duration, error) {
+	ttl, err := s.policy.Check(csr, ids, ttl)
+	if err != nil {
+		serverCaLog.Warnf("CSR policy violation (%v)", err)
+		reason := "unknown"
+		if v, ok := err.(*policy.Violation); ok {
+			reason = v.Reason
+		}
+		s.monitoring.GetPolicyViolation(reason).Increment()
+		return 0, status.Errorf(codes.InvalidArgument, "CSR policy violation (%v)", err)
+	}
+	return ttl, nil
+}
+
+// recordIssuance records the certificate issued to the caller in the audit log. The requester is the identities
+// of the caller, and the node its authenticated node, if known. An Internal error is returned if the certificate
+// can't be recorded, the certificate must then not be returned.
+func (s *Server) recordIssuance(cert []byte, caller *authenticate.Caller) error {
+	if err := s.auditLog.RecordCert(cert, strings.Join(caller.Identities, ","), caller.Node); err != nil {
+		return status.Errorf(codes.Internal, "certificate issuance refused (%v)", err)
+	}
+	return nil
+}
+
+// SetAuditLog sets the audit log recording the issued certificates.
+func (s *Server) SetAuditLog(l *audit.Logger) {
+	s.auditLog = l
+}
+
 // Run starts a GRPC server on the specified port.
 func (s *Server) Run() error {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
@@ -210,9 +405,12 @@ func (s *Server) Run() error {
 	return nil
 }
 
-// New creates a new instance of `IstioCAServiceServer`.
-func New(ca CertificateAuthority, ttl time.Duration, forCA bool,
-	hostlist []string, port int, trustDomain string, sdsEnabled bool) (*Server, error) {
+// New creates a new instance of `IstioCAServiceServer`. The requests of the identities, certificates and nodes
+// in the deny list of the given store are rejected, and a nil store revokes nothing. If oidcConfigFile is set,
+// the callers presenting a JWT of one of the configured OIDC issuers are authenticated as well. The CSRs must
+// comply with the given policy, if not nil.
+func New(ca CertificateAuthority, ttl time.Duration, forCA bool, hostlist []string, port int, trustDomain string,
+	sdsEnabled bool, denyList *revocation.Store, oidcConfigFile string, csrPolicy *policy.Policy) (*Server, error) {
 
 	if len(hostlist) == 0 {
 		return nil, fmt.Errorf("failed to create grpc server hostlist empty")
@@ -225,8 +423,12 @@ func New(ca CertificateAuthority, ttl time.Duration, forCA bool,
 
 	// Only add k8s jwt authenticator if SDS is enabled.
 	if sdsEnabled {
+		// Resolving the node of a caller costs a GET of its pod, only do it when a decision depends on the node.
+		needNode := func() bool {
+			return csrPolicy.LimitsNodes() || denyList.DenyList().HasNodes()
+		}
 		authenticator, err := authenticate.NewKubeJWTAuthenticator(k8sAPIServerURL, caCertPath, jwtPath,
-			trustDomain)
+			trustDomain, needNode)
 		if err == nil {
 			authenticators = append(authenticators, authenticator)
 			serverCaLog.Info("added K8s JWT authenticator")
@@ -235,6 +437,15 @@ func New(ca CertificateAuthority, ttl time.Duration, forCA bool,
 		}
 	}
 
+	if oidcConfigFile != "" {
+		authenticator, err := authenticate.NewOIDCAuthenticator(oidcConfigFile, trustDomain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the OIDC authenticator: %v", err)
+		}
+		authenticators = append(authenticators, authenticator)
+		serverCaLog.Info("added OIDC authenticator")
+	}
+
 	// Temporarily disable ID token authenticator by resetting the hostlist.
 	// [TODO](myidpt): enable ID token authenticator when the CSR API authz can work correctly.
 	hostlistForJwtAuth := make([]string, 0)
@@ -254,6 +465,9 @@ func New(ca CertificateAuthority, ttl time.Duration, forCA bool,
 	server := &Server{
 		authenticators: authenticators,
 		authorizer:     &registryAuthorizor{registry.GetIdentityRegistry()},
+		oidcAuthorizer: &sameIDAuthorizer{},
+		denyList:       denyList,
+		policy:         csrPolicy,
 		serverCertTTL:  ttl,
 		ca:             ca,
 		hostnames:      hostlist,
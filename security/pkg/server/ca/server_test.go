@@ -16,25 +16,38 @@ package ca
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"k8s.io/client-go/kubernetes/fake"
 
+	"istio.io/istio/security/pkg/pki/audit"
 	"istio.io/istio/security/pkg/pki/ca"
 	mockca "istio.io/istio/security/pkg/pki/ca/mock"
 
 	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/revocation"
 	pkiutil "istio.io/istio/security/pkg/pki/util"
 	mockutil "istio.io/istio/security/pkg/pki/util/mock"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
+	"istio.io/istio/security/pkg/server/ca/policy"
 	pb "istio.io/istio/security/proto"
 )
 
@@ -71,7 +84,10 @@ gCojNs0xyJ77JA80HLY7iR4J6BRYsZQ/5UB/pYR55e4TGFDbI+C/6NBqLkzEfyX0
 type mockAuthenticator struct {
 	authSource authenticate.AuthSource
 	identities []string
+	node       string
 	errMsg     string
+	// calls is the number of requests authenticated.
+	calls int
 }
 
 func (authn *mockAuthenticator) AuthenticatorType() string {
@@ -79,6 +95,7 @@ func (authn *mockAuthenticator) AuthenticatorType() string {
 }
 
 func (authn *mockAuthenticator) Authenticate(ctx context.Context) (*authenticate.Caller, error) {
+	authn.calls++
 	if len(authn.errMsg) > 0 {
 		return nil, fmt.Errorf("%v", authn.errMsg)
 	}
@@ -86,6 +103,7 @@ func (authn *mockAuthenticator) Authenticate(ctx context.Context) (*authenticate
 	return &authenticate.Caller{
 		AuthSource: authn.authSource,
 		Identities: authn.identities,
+		Node:       authn.node,
 	}, nil
 }
 
@@ -101,6 +119,30 @@ func (authz *mockAuthorizer) authorize(requester *authenticate.Caller, requested
 	return nil
 }
 
+func newTestDenyListStore(t *testing.T, denyList string) *revocation.Store {
+	if denyList == "" {
+		return nil
+	}
+	d, err := revocation.Parse([]byte(denyList))
+	if err != nil {
+		t.Fatalf("failed to parse the deny list: %v", err)
+	}
+	store := revocation.NewStore()
+	store.Set(d)
+	return store
+}
+
+func newTestPolicy(t *testing.T, csrPolicy string) *policy.Policy {
+	if csrPolicy == "" {
+		return nil
+	}
+	config, err := policy.Load([]byte(csrPolicy))
+	if err != nil {
+		t.Fatalf("failed to load the CSR policy: %v", err)
+	}
+	return policy.New(config, time.Hour)
+}
+
 // Test the root cert expiry timestamp can be extracted correctly.
 func TestExtractRootCertExpiryTimestamp(t *testing.T) {
 	cert, key, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
@@ -154,6 +196,9 @@ func TestCreateCertificate(t *testing.T) {
 	testCases := map[string]struct {
 		authenticators []authenticator
 		authorizer     *mockAuthorizer
+		denyList       string
+		policy         string
+		csr            string
 		ca             CertificateAuthority
 		certChain      []string
 		code           codes.Code
@@ -172,6 +217,75 @@ func TestCreateCertificate(t *testing.T) {
 			authorizer: &mockAuthorizer{},
 			ca:         &mockca.FakeCA{},
 		},
+		"Revoked caller": {
+			authorizer: &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{
+				identities: []string{"spiffe://test.com/namespace/ns/serviceaccount/revoked"},
+			}},
+			denyList: "identities: [spiffe://test.com/namespace/ns/serviceaccount/revoked]",
+			ca:       &mockca.FakeCA{},
+			code:     codes.PermissionDenied,
+		},
+		"OIDC caller with corrupted CSR": {
+			authorizer: &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{
+				authSource: authenticate.AuthSourceOIDC,
+				identities: []string{"spiffe://test.com/namespace/ns/serviceaccount/sa"},
+			}},
+			ca:   &mockca.FakeCA{},
+			code: codes.InvalidArgument,
+		},
+		"OIDC caller requesting another identity": {
+			authorizer: &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{
+				authSource: authenticate.AuthSourceOIDC,
+				identities: []string{"spiffe://test.com/namespace/ns/serviceaccount/other"},
+			}},
+			csr:  csr,
+			ca:   &mockca.FakeCA{},
+			code: codes.PermissionDenied,
+		},
+		"OIDC caller requesting its identity": {
+			authorizer: &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{
+				authSource: authenticate.AuthSourceOIDC,
+				identities: []string{"spiffe://test.com/namespace/ns/serviceaccount/sa"},
+			}},
+			csr: csr,
+			ca: &mockca.FakeCA{
+				SignedCert:    []byte("cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{RootCertBytes: []byte("root_cert")},
+			},
+			certChain: []string{"cert", "root_cert"},
+			code:      codes.OK,
+		},
+		"Corrupted CSR with a CSR policy": {
+			authorizer:     &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{}},
+			policy:         "keys: {minRSAKeySize: 1024}",
+			ca:             &mockca.FakeCA{},
+			code:           codes.InvalidArgument,
+		},
+		"CSR policy violation": {
+			authorizer:     &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{}},
+			policy:         "keys: {minRSAKeySize: 2048}",
+			csr:            csr,
+			ca:             &mockca.FakeCA{},
+			code:           codes.InvalidArgument,
+		},
+		"CSR policy compliance": {
+			authorizer:     &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{}},
+			policy:         "keys: {minRSAKeySize: 1024}",
+			csr:            csr,
+			ca: &mockca.FakeCA{
+				SignedCert:    []byte("cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{RootCertBytes: []byte("root_cert")},
+			},
+			certChain: []string{"cert", "root_cert"},
+			code:      codes.OK,
+		},
 		"CA not ready": {
 			authorizer:     &mockAuthorizer{},
 			authenticators: []authenticator{&mockAuthenticator{}},
@@ -217,10 +331,16 @@ func TestCreateCertificate(t *testing.T) {
 			hostnames:      []string{"hostname"},
 			port:           8080,
 			authorizer:     c.authorizer,
+			oidcAuthorizer: &sameIDAuthorizer{},
+			denyList:       newTestDenyListStore(t, c.denyList),
+			policy:         newTestPolicy(t, c.policy),
 			authenticators: c.authenticators,
 			monitoring:     newMonitoringMetrics(),
 		}
 		request := &pb.IstioCertificateRequest{Csr: "dumb CSR"}
+		if c.csr != "" {
+			request.Csr = c.csr
+		}
 
 		response, err := server.CreateCertificate(context.Background(), request)
 		s, _ := status.FromError(err)
@@ -247,6 +367,8 @@ func TestHandleCSR(t *testing.T) {
 	testCases := map[string]struct {
 		authenticators []authenticator
 		authorizer     *mockAuthorizer
+		denyList       string
+		policy         string
 		ca             *mockca.FakeCA
 		csr            string
 		cert           string
@@ -285,6 +407,38 @@ func TestHandleCSR(t *testing.T) {
 			csr:            badSanCsr,
 			code:           codes.InvalidArgument,
 		},
+		"Revoked requested identity": {
+			authorizer:     &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"test"}}},
+			denyList:       "identities: [spiffe://test.com/namespace/ns/serviceaccount/sa]",
+			csr:            csr,
+			code:           codes.PermissionDenied,
+		},
+		"OIDC caller requesting another identity": {
+			authorizer: &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{
+				authSource: authenticate.AuthSourceOIDC,
+				identities: []string{"spiffe://test.com/namespace/ns/serviceaccount/other"},
+			}},
+			csr:  csr,
+			code: codes.PermissionDenied,
+		},
+		"OIDC caller requesting its identity": {
+			authorizer: &mockAuthorizer{},
+			authenticators: []authenticator{&mockAuthenticator{
+				authSource: authenticate.AuthSourceOIDC,
+				identities: []string{"spiffe://test.com/namespace/ns/serviceaccount/sa"},
+			}},
+			ca: &mockca.FakeCA{
+				SignedCert:    []byte("generated cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{CertChainBytes: []byte("cert chain")},
+			},
+			csr:         csr,
+			cert:        "generated cert",
+			certChain:   "cert chain",
+			expectedIDs: []string{"spiffe://test.com/namespace/ns/serviceaccount/sa"},
+			code:        codes.OK,
+		},
 		"Failed to sign": {
 			authorizer:     &mockAuthorizer{},
 			authenticators: []authenticator{&mockAuthenticator{identities: []string{"test"}}},
@@ -305,6 +459,14 @@ func TestHandleCSR(t *testing.T) {
 			expectedIDs: []string{"test"},
 			code:        codes.OK,
 		},
+		"CSR policy violation": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"test"}}},
+			authorizer:     &mockAuthorizer{},
+			policy:         "subjectAltNames: {types: [URI]}",
+			ca:             &mockca.FakeCA{},
+			csr:            csr,
+			code:           codes.InvalidArgument,
+		},
 		"Multiple identities received by CA signer": {
 			authenticators: []authenticator{&mockAuthenticator{identities: []string{"test1", "test2"}}},
 			authorizer:     &mockAuthorizer{},
@@ -326,6 +488,9 @@ func TestHandleCSR(t *testing.T) {
 			hostnames:      []string{"hostname"},
 			port:           8080,
 			authorizer:     c.authorizer,
+			oidcAuthorizer: &sameIDAuthorizer{},
+			denyList:       newTestDenyListStore(t, c.denyList),
+			policy:         newTestPolicy(t, c.policy),
 			authenticators: c.authenticators,
 			monitoring:     newMonitoringMetrics(),
 		}
@@ -360,6 +525,232 @@ func TestHandleCSR(t *testing.T) {
 	}
 }
 
+func TestRateLimit(t *testing.T) {
+	server := &Server{
+		ca: &mockca.FakeCA{
+			SignedCert:    []byte("cert"),
+			KeyCertBundle: &mockutil.FakeKeyCertBundle{RootCertBytes: []byte("root_cert")},
+		},
+		authorizer:     &mockAuthorizer{},
+		oidcAuthorizer: &sameIDAuthorizer{},
+		policy: newTestPolicy(t, `
+rateLimits:
+  identity: {qps: 0.001, burst: 1}
+  node: {qps: 0.001, burst: 2}
+`),
+		authenticators: []authenticator{&mockAuthenticator{identities: []string{"spiffe://test.com/ns/ns/sa/sa"},
+			node: "node-1"}},
+		monitoring: newMonitoringMetrics(),
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1")}})
+	request := &pb.IstioCertificateRequest{Csr: csr}
+
+	if _, err := server.CreateCertificate(ctx, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := server.CreateCertificate(ctx, request)
+	if s, _ := status.FromError(err); s.Code() != codes.ResourceExhausted || !strings.Contains(s.Message(), "identities") {
+		t.Errorf("expected the identity to be rate limited, got %v", err)
+	}
+
+	// The node limit applies to all the identities of the node, whatever the address of their pods.
+	server.authenticators = []authenticator{&mockAuthenticator{identities: []string{"spiffe://test.com/ns/ns/sa/other"},
+		node: "node-1"}}
+	otherPod := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.2")}})
+	_, err = server.CreateCertificate(otherPod, request)
+	if s, _ := status.FromError(err); s.Code() != codes.ResourceExhausted || !strings.Contains(s.Message(), "node") {
+		t.Errorf("expected the node to be rate limited, got %v", err)
+	}
+	server.authenticators = []authenticator{&mockAuthenticator{identities: []string{"spiffe://test.com/ns/ns/sa/other"},
+		node: "node-2"}}
+	if _, err := server.CreateCertificate(ctx, request); err != nil {
+		t.Errorf("expected another node not to be rate limited, got %v", err)
+	}
+}
+
+func TestPeerRateLimit(t *testing.T) {
+	authn := &mockAuthenticator{errMsg: "invalid token"}
+	server := &Server{
+		ca: &mockca.FakeCA{
+			SignedCert:    []byte("cert"),
+			KeyCertBundle: &mockutil.FakeKeyCertBundle{RootCertBytes: []byte("root_cert")},
+		},
+		authorizer:     &mockAuthorizer{},
+		oidcAuthorizer: &sameIDAuthorizer{},
+		policy: newTestPolicy(t, `
+rateLimits:
+  peer: {qps: 0.001, burst: 2}
+`),
+		authenticators: []authenticator{authn},
+		monitoring:     newMonitoringMetrics(),
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	samePeer := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5678}})
+
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: csr}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected an authentication failure, got %v", err)
+	}
+	if _, err := server.HandleCSR(samePeer, &pb.CsrRequest{CsrPem: []byte(csr)}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected an authentication failure, got %v", err)
+	}
+
+	// The requests over the limit are rejected without being authenticated, whatever the port of the peer.
+	_, err := server.CreateCertificate(samePeer, &pb.IstioCertificateRequest{Csr: csr})
+	if s, _ := status.FromError(err); s.Code() != codes.ResourceExhausted || !strings.Contains(s.Message(), "peer 10.0.0.1") {
+		t.Errorf("expected the peer to be rate limited, got %v", err)
+	}
+	_, err = server.HandleCSR(ctx, &pb.CsrRequest{CsrPem: []byte(csr)})
+	if s, _ := status.FromError(err); s.Code() != codes.ResourceExhausted {
+		t.Errorf("expected the peer to be rate limited, got %v", err)
+	}
+	if authn.calls != 2 {
+		t.Errorf("expected the authenticator to be called 2 times, got %d", authn.calls)
+	}
+
+	otherPeer := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.2")}})
+	if _, err := server.CreateCertificate(otherPeer, &pb.IstioCertificateRequest{Csr: csr}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected another peer not to be rate limited, got %v", err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sink, err := audit.NewFileSink(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.NewLogger(sink, signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := "spiffe://test.com/ns/ns/sa/sa"
+	cert, _, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Host:         id,
+		TTL:          time.Hour,
+		RSAKeySize:   2048,
+		IsSelfSigned: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{
+		ca: &mockca.FakeCA{
+			SignedCert:    cert,
+			KeyCertBundle: &mockutil.FakeKeyCertBundle{RootCertBytes: []byte("root_cert")},
+		},
+		authorizer:     &mockAuthorizer{},
+		oidcAuthorizer: &sameIDAuthorizer{},
+		authenticators: []authenticator{&mockAuthenticator{identities: []string{"spiffe://test.com/ns/ns/sa/caller"},
+			node: "node-1"}},
+		monitoring: newMonitoringMetrics(),
+	}
+	server.SetAuditLog(auditLog)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1")}})
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: csr}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := server.HandleCSR(context.Background(), &pb.CsrRequest{CsrPem: []byte(csr)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last, err := sink.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.Sequence != 2 || len(last.IDs) != 1 || last.IDs[0] != id ||
+		last.Requester != "spiffe://test.com/ns/ns/sa/caller" || last.Node != "node-1" {
+		t.Errorf("unexpected last entry %+v", last)
+	}
+
+	// No certificate is issued when it can't be recorded.
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: csr}); status.Code(err) != codes.Internal {
+		t.Errorf("expected an Internal error when the audit log fails, got %v", err)
+	}
+	if _, err := server.HandleCSR(context.Background(), &pb.CsrRequest{CsrPem: []byte(csr)}); status.Code(err) != codes.Internal {
+		t.Errorf("expected an Internal error when the audit log fails, got %v", err)
+	}
+}
+
+func TestCheckRevocation(t *testing.T) {
+	store := newTestDenyListStore(t, `
+identities: [spiffe://test.com/namespace/ns/serviceaccount/revoked]
+serialNumbers: ["ff00"]
+nodes: [revoked-node]
+`)
+	newPeerContext := func(ip string, serial int64) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 15000},
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{SerialNumber: big.NewInt(serial)}},
+			}},
+		})
+	}
+
+	testCases := map[string]struct {
+		store  *revocation.Store
+		ctx    context.Context
+		ids    []string
+		node   string
+		errMsg string
+	}{
+		"No deny list": {
+			ctx: newPeerContext("10.1.2.3", 0xff00),
+			ids: []string{"spiffe://test.com/namespace/ns/serviceaccount/revoked"},
+		},
+		"Allowed": {
+			store: store,
+			ctx:   newPeerContext("10.2.2.3", 0xff),
+			ids:   []string{"spiffe://test.com/namespace/ns/serviceaccount/sa"},
+			node:  "node",
+		},
+		"No peer": {
+			store: store,
+			ctx:   context.Background(),
+			ids:   []string{"spiffe://test.com/namespace/ns/serviceaccount/sa"},
+		},
+		"Revoked identity": {
+			store:  store,
+			ctx:    context.Background(),
+			ids:    []string{"spiffe://test.com/namespace/ns/serviceaccount/revoked"},
+			errMsg: `identity "spiffe://test.com/namespace/ns/serviceaccount/revoked" is revoked`,
+		},
+		"Revoked client certificate": {
+			store:  store,
+			ctx:    newPeerContext("10.2.2.3", 0xff00),
+			errMsg: "certificate with serial number ff00 is revoked",
+		},
+		"Revoked node": {
+			store:  store,
+			ctx:    newPeerContext("10.1.2.3", 0xff),
+			node:   "revoked-node",
+			errMsg: "node revoked-node is revoked",
+		},
+	}
+
+	for id, tc := range testCases {
+		server := &Server{denyList: tc.store}
+		err := server.checkRevocation(tc.ctx, tc.ids, tc.node)
+		if tc.errMsg == "" && err != nil {
+			t.Errorf("Case %s: unexpected error: %v", id, err)
+		} else if tc.errMsg != "" && (err == nil || err.Error() != tc.errMsg) {
+			t.Errorf("Case %s: expecting error (%s) but got (%v)", id, tc.errMsg, err)
+		}
+	}
+}
+
 func TestShouldRefresh(t *testing.T) {
 	now := time.Now()
 	testCases := map[string]struct {
@@ -456,7 +847,7 @@ func TestRun(t *testing.T) {
 			// K8s JWT authenticator is added in k8s env.
 			tc.expectedAuthenticatorsLen++
 		}
-		server, err := New(tc.ca, time.Hour, false, tc.hostname, tc.port, "testdomain.com", true)
+		server, err := New(tc.ca, time.Hour, false, tc.hostname, tc.port, "testdomain.com", true, nil, "", nil)
 		if err == nil {
 			err = server.Run()
 		}
@@ -528,7 +919,7 @@ func TestGetServerCertificate(t *testing.T) {
 	}
 
 	server, err := New(ca, time.Hour, false, []string{"localhost"}, 0,
-		"testdomain.com", true)
+		"testdomain.com", true, nil, "", nil)
 	if err != nil {
 		t.Errorf("Cannot crete server: %v", err)
 	}
@@ -15,5 +15,6 @@
 //go:generate $REPO_ROOT/bin/mixer_codegen.sh -f security/proto/ca_service.proto
 //go:generate $REPO_ROOT/bin/mixer_codegen.sh -f security/proto/workload_service.proto
 //go:generate $REPO_ROOT/bin/mixer_codegen.sh -f security/proto/istioca.proto
+//go:generate $REPO_ROOT/bin/mixer_codegen.sh -f security/proto/kms/kms.proto
 // nolint
 package istio_v1_auth